@@ -5,12 +5,15 @@ import (
 	"os"
 	"path"
 
+	"github.com/firecracker-microvm/firecracker-go-sdk"
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/apis/ignite/scheme"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/container"
 	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/events"
+	"github.com/weaveworks/ignite/pkg/network"
 	"github.com/weaveworks/ignite/pkg/prometheus"
 	"github.com/weaveworks/ignite/pkg/util"
 	patchutil "github.com/weaveworks/libgitops/pkg/util/patch"
@@ -40,17 +43,21 @@ func decodeVM(vmID string) (*api.VM, error) {
 
 func StartVM(vm *api.VM) (err error) {
 
-	// Setup networking inside of the container, return the available interfaces
-	fcIfaces, dhcpIfaces, err := container.SetupContainerNetworking(vm)
-	if err != nil {
-		return fmt.Errorf("network setup failed: %v", err)
-	}
+	var fcIfaces firecracker.NetworkInterfaces
+	if networkPlugin != network.PluginNone {
+		// Setup networking inside of the container, return the available interfaces
+		var dhcpIfaces []container.DHCPInterface
+		fcIfaces, dhcpIfaces, err = container.SetupContainerNetworking(vm)
+		if err != nil {
+			return fmt.Errorf("network setup failed: %v", err)
+		}
 
-	// Serve DHCP requests for those interfaces
-	// This function returns the available IP addresses that are being
-	// served over DHCP now
-	if err = container.StartDHCPServers(vm, dhcpIfaces); err != nil {
-		return
+		// Serve DHCP requests for those interfaces
+		// This function returns the available IP addresses that are being
+		// served over DHCP now
+		if err = container.StartDHCPServers(vm, dhcpIfaces, embeddedDNS); err != nil {
+			return
+		}
 	}
 
 	// Serve metrics over an unix socket in the VM's own directory
@@ -60,24 +67,35 @@ func StartVM(vm *api.VM) (err error) {
 	// Patches the VM object to set state to stopped, and clear IP addresses
 	defer util.DeferErr(&err, func() error { return patchStopped(vm) })
 
-	// Remove the snapshot overlay post-run, which also removes the detached backing loop devices
-	defer util.DeferErr(&err, func() error { return dmlegacy.DeactivateSnapshot(vm) })
+	// Disk files need no teardown, only the snapshot device is set up for the VM
+	drivePath := diskFile
+	if len(drivePath) == 0 {
+		drivePath = vm.SnapshotDev()
+
+		// Remove the snapshot overlay post-run, which also removes the detached backing loop devices
+		defer util.DeferErr(&err, func() error { return dmlegacy.DeactivateSnapshot(vm) })
+	}
 
 	// Remove the Prometheus socket post-run
 	defer util.DeferErr(&err, func() error { return os.Remove(metricsSocket) })
 
 	// Execute Firecracker
-	if err = container.ExecuteFirecracker(vm, fcIfaces); err != nil {
+	if err = container.ExecuteFirecracker(vm, drivePath, fcIfaces); err != nil {
+		events.Record(vm, events.TypeDie, err.Error())
 		return fmt.Errorf("runtime error for VM %q: %v", vm.GetUID(), err)
 	}
 
+	events.Record(vm, events.TypeDie, "")
+
 	return
 }
 
 func serveMetrics(metricsSocket string) {
 	go func() {
-		// Create a new registry and http.Server. Don't register custom metrics to the registry quite yet.
-		_, server := prometheus.New()
+		// Create a new registry and http.Server, and register the Firecracker and network policy metrics to it
+		reg, server := prometheus.New()
+		container.RegisterFirecrackerMetrics(reg)
+		container.RegisterNetworkPolicyMetrics(reg)
 		if err := prometheus.ServeOnSocket(server, metricsSocket); err != nil {
 			log.Errorf("prometheus server was stopped with error: %v", err)
 		}
@@ -6,13 +6,26 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/logs"
 	logflag "github.com/weaveworks/ignite/pkg/logs/flag"
+	"github.com/weaveworks/ignite/pkg/network"
+	networkflag "github.com/weaveworks/ignite/pkg/network/flag"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
 var logLevel = logrus.InfoLevel
 
+// networkPlugin is the network plugin that set up the container, the VM gets no interfaces with the none plugin
+var networkPlugin network.PluginName
+
+// embeddedDNS hands out the bridges of the host as DNS servers, where the embedded DNS resolver of ignited serves
+var embeddedDNS bool
+
+// diskFile is the file the VM boots from, the snapshot device of the VM is used if it's empty
+var diskFile string
+
 // RunIgniteSpawn runs the root command for ignite-spawn
 func RunIgniteSpawn() {
 	fs := &pflag.FlagSet{
@@ -23,6 +36,9 @@ func RunIgniteSpawn() {
 	util.GenericCheckErr(fs.Parse(os.Args[1:]))
 	logs.Logger.SetLevel(logLevel)
 
+	// The event log is mounted to a well-known place in the container
+	events.LogPath = constants.IGNITE_SPAWN_EVENTS_FILE_PATH
+
 	if len(fs.Args()) != 1 {
 		usage()
 	}
@@ -38,10 +54,13 @@ func RunIgniteSpawn() {
 }
 
 func usage() {
-	util.GenericCheckErr(fmt.Errorf("usage: ignite-spawn [--log-level <level>] <vm>"))
+	util.GenericCheckErr(fmt.Errorf("usage: ignite-spawn [--log-level <level>] [--network-plugin <plugin>] [--embedded-dns] [--disk-file <file>] <vm>"))
 }
 
 func addGlobalFlags(fs *pflag.FlagSet) {
 	// TODO: Add a version flag
 	logflag.LogLevelFlagVar(fs, &logLevel)
+	networkflag.NetworkPluginVar(fs, &networkPlugin)
+	fs.BoolVar(&embeddedDNS, "embedded-dns", embeddedDNS, "Hand out the host bridges as the first DNS servers to the VM")
+	fs.StringVar(&diskFile, "disk-file", diskFile, "Boot the VM from the given disk file instead of its snapshot device")
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdEvents shows the lifecycle events of VMs, images and kernels
+func NewCmdEvents(out io.Writer) *cobra.Command {
+	ef := &run.EventsFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show the lifecycle events of VMs, images and kernels",
+		Long: dedent.Dedent(`
+			Show the events recorded when VMs are created, started, become ready,
+			are stopped, die or are removed, and when images and kernels are imported.
+			Events are recorded by ignite, ignited and ignite-spawn alike.
+
+			The --since and --until flags take either a timestamp (e.g. 2020-06-01T15:04:05Z
+			or 2020-06-01) or a duration relative to now (e.g. 10m). Using the --follow flag,
+			new events are streamed as they are recorded.
+
+			Using the -f (--filter) flag, you can give conditions events should fulfill
+			to be displayed, using the same syntax as "ignite ps". The fields of an event
+			are .Time, .Type, .Kind, .UID, .Name and .Message.
+
+			Example usage:
+				$ ignite events --since 1h -f "{{.Type}}=die"
+
+				$ ignite events --follow --format json -f "{{.Name}}=my-vm"
+		`),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				eo, err := ef.NewEventsOptions()
+				if err != nil {
+					return err
+				}
+
+				return run.Events(eo)
+			}())
+		},
+	}
+
+	addEventsFlags(cmd.Flags(), ef)
+	return cmd
+}
+
+func addEventsFlags(fs *pflag.FlagSet, ef *run.EventsFlags) {
+	fs.StringVar(&ef.Since, "since", "", "Show events recorded after this timestamp or duration ago")
+	fs.StringVar(&ef.Until, "until", "", "Show events recorded before this timestamp or duration ago")
+	fs.StringVarP(&ef.Filter, "filter", "f", "", "Filter the events")
+	fs.StringVar(&ef.Format, "format", "", "Format the output as \"json\", or using the given Go template")
+	fs.BoolVar(&ef.Follow, "follow", false, "Keep streaming new events as they are recorded")
+}
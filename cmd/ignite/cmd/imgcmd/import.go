@@ -15,6 +15,7 @@ import (
 
 // NewCmdImport imports a new VM image
 func NewCmdImport(out io.Writer) *cobra.Command {
+	f := &run.ImportFlags{}
 	cmd := &cobra.Command{
 		Use:   "import <OCI image>",
 		Short: "Import a new base image for VMs",
@@ -22,21 +23,45 @@ func NewCmdImport(out io.Writer) *cobra.Command {
 			Import an OCI image as a base image for VMs, takes in a Docker image identifier.
 			This importing is done automatically when the "run" or "create" commands are run.
 			The import step is essentially a cache for images to be used later when running VMs.
+
+			With --from-tar or --from-dir, the root filesystem is imported from a tarball or
+			a directory of the host instead, without using the container runtime. The argument
+			names the image, VMs refer to it by that name. The content ID of the image is the
+			digest of the tarball, or of the directory archived in lexical order. Importing the
+			same content under the same name again is a no-op.
+
+			With --from-oci-layout or --from-docker-archive, the image is read from an OCI image
+			layout directory or a "docker save" archive, and its layers are applied in order
+			without using the container runtime. If the layout or archive holds multiple images,
+			the argument selects one of them by its name or tag. The content ID of the image is
+			the digest of its manifest, or of its configuration for docker archives.
+
+			Example usage:
+				$ ignite image import --from-tar rootfs.tar.gz my-rootfs:v1
+				$ tar -C rootfs -c . | ignite image import --from-tar - my-rootfs:v1
+				$ ignite image import --from-dir ./rootfs my-rootfs:v1
+				$ ignite image import --from-oci-layout ./oci my-rootfs:v1
+				$ ignite image import --from-docker-archive my-rootfs.tar my-rootfs:v1
+				$ ignite run my-rootfs:v1
 		`),
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(func() error {
-				_, err := run.ImportImage(args[0])
+				_, err := run.ImportImage(args[0], f)
 				return err
 			}())
 		},
 	}
 
-	addImportFlags(cmd.Flags())
+	addImportFlags(cmd.Flags(), f)
 	return cmd
 }
 
-func addImportFlags(fs *pflag.FlagSet) {
+func addImportFlags(fs *pflag.FlagSet, f *run.ImportFlags) {
 	runtimeflag.RuntimeVar(fs, &providers.RuntimeName)
 	cmdutil.AddRegistryConfigDirFlag(fs, &providers.RegistryConfigDir)
+	fs.StringVar(&f.FromTar, "from-tar", f.FromTar, "Import the image from a tarball of its root filesystem, optionally gzip compressed, or from stdin with \"-\"")
+	fs.StringVar(&f.FromDir, "from-dir", f.FromDir, "Import the image from a directory holding its root filesystem")
+	fs.StringVar(&f.FromOCILayout, "from-oci-layout", f.FromOCILayout, "Import the image from an OCI image layout directory, as written by e.g. buildah, kaniko or skopeo")
+	fs.StringVar(&f.FromDockerArchive, "from-docker-archive", f.FromDockerArchive, "Import the image from an uncompressed archive written by \"docker save\"")
 }
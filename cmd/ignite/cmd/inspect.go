@@ -19,9 +19,9 @@ func NewCmdInspect(out io.Writer) *cobra.Command {
 		Short: "Inspect an Ignite Object",
 		Long: dedent.Dedent(`
 			Retrieve information about the given object of the given kind.
-			The kind can be "image", "kernel" or "vm". The object is matched
-			by prefix based on its ID and name. Outputs JSON by default, can
-			be overridden with the output flag (-o, --output).
+			The kind can be "image", "kernel", "network" or "vm". The object is
+			matched by prefix based on its ID and name. Outputs JSON by default,
+			can be overridden with the output flag (-o, --output).
 
 			Example usage:
 				$ ignite inspect vm my-vm
@@ -31,6 +31,8 @@ func NewCmdInspect(out io.Writer) *cobra.Command {
 				$ ignite inspect vm my-vm -t {{.ObjectMeta.Name}}
 
 				$ ignite inspect vm my-vm -t {{.Spec.Image.OCI}}
+
+				$ ignite inspect vm my-vm -t "{{range .Spec.Network.Interfaces}}{{.Name}}={{.Mode}} {{end}}"
 		`),
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -15,6 +15,7 @@ import (
 
 // NewCmdImport imports a new kernel image
 func NewCmdImport(out io.Writer) *cobra.Command {
+	f := &run.ImportFlags{}
 	cmd := &cobra.Command{
 		Use:   "import <OCI image>",
 		Short: "Import a kernel image from an OCI image",
@@ -22,21 +23,41 @@ func NewCmdImport(out io.Writer) *cobra.Command {
 			Import an OCI image as a kernel image for VMs, takes in a Docker image identifier.
 			This importing is done automatically when the "run" or "create" commands are run.
 			The import step is essentially a cache for images to be used later when running VMs.
+
+			With --from-tar or --from-dir, the kernel is imported from a tarball or a directory
+			of the host instead, without using the container runtime. They need to hold the
+			kernel at /boot/vmlinux and its modules in /lib/modules. The argument names the
+			kernel, VMs refer to it by that name. The content ID of the kernel is the digest of
+			the tarball, or of the directory archived in lexical order.
+
+			With --from-oci-layout or --from-docker-archive, the kernel is read from an OCI image
+			layout directory or a "docker save" archive, and its layers are applied in order
+			without using the container runtime. The content ID of the kernel is the digest of
+			its manifest, or of its configuration for docker archives.
+
+			Example usage:
+				$ ignite kernel import --from-tar kernel.tar my-kernel:5.10
+				$ ignite kernel import --from-oci-layout ./oci my-kernel:5.10
+				$ ignite run my-rootfs:v1 --kernel-image my-kernel:5.10
 		`),
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(func() error {
-				_, err := run.ImportKernel(args[0])
+				_, err := run.ImportKernel(args[0], f)
 				return err
 			}())
 		},
 	}
 
-	addImportFlags(cmd.Flags())
+	addImportFlags(cmd.Flags(), f)
 	return cmd
 }
 
-func addImportFlags(fs *pflag.FlagSet) {
+func addImportFlags(fs *pflag.FlagSet, f *run.ImportFlags) {
 	runtimeflag.RuntimeVar(fs, &providers.RuntimeName)
 	cmdutil.AddRegistryConfigDirFlag(fs, &providers.RegistryConfigDir)
+	fs.StringVar(&f.FromTar, "from-tar", f.FromTar, "Import the kernel from a tarball of its root filesystem, optionally gzip compressed, or from stdin with \"-\"")
+	fs.StringVar(&f.FromDir, "from-dir", f.FromDir, "Import the kernel from a directory holding its root filesystem")
+	fs.StringVar(&f.FromOCILayout, "from-oci-layout", f.FromOCILayout, "Import the kernel from an OCI image layout directory, as written by e.g. buildah, kaniko or skopeo")
+	fs.StringVar(&f.FromDockerArchive, "from-docker-archive", f.FromDockerArchive, "Import the kernel from an uncompressed archive written by \"docker save\"")
 }
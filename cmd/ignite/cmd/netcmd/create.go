@@ -0,0 +1,56 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdCreate creates a new network
+func NewCmdCreate(out io.Writer) *cobra.Command {
+	nf := run.NewNetworkCreateFlags()
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a new VM network",
+		Long: dedent.Dedent(`
+			Create a new network VMs can be attached to with the network flag
+			(--network) of "ignite create" and "ignite run". Each network gets
+			its own bridge on the host, the VMs get their addresses from the
+			given subnet. An isolated network (--isolated) drops all traffic
+			forwarded between it and the other networks.
+
+			Example usage:
+				$ ignite network create team-a --subnet 10.70.0.0/24 --isolated
+				$ ignite run weaveworks/ignite-ubuntu --network team-a
+		`),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				no, err := nf.NewNetworkCreateOptions(args[0])
+				if err != nil {
+					return err
+				}
+
+				return run.NetworkCreate(no)
+			}())
+		},
+	}
+
+	addNetworkCreateFlags(cmd.Flags(), nf)
+	return cmd
+}
+
+func addNetworkCreateFlags(fs *pflag.FlagSet, nf *run.NetworkCreateFlags) {
+	spec := &nf.Network.Spec
+	fs.StringVar(&spec.Subnet, "subnet", spec.Subnet, "Subnet to allocate the VM addresses from, e.g. \"10.70.0.0/24\"")
+	fs.IPVar(&spec.Gateway, "gateway", spec.Gateway, "Address of the bridge in the subnet, defaults to the first address of the subnet")
+	fs.StringVar(&spec.BridgeName, "bridge-name", spec.BridgeName, "Name of the bridge on the host, needs to start with \"ignite-\", defaults to \"ignite-\" and the first characters of the network ID")
+	fs.BoolVar(&spec.Masquerade, "masquerade", spec.Masquerade, "Masquerade the traffic leaving the network, giving the VMs outbound connectivity")
+	fs.BoolVar(&spec.Isolated, "isolated", spec.Isolated, "Drop all traffic forwarded between this network and the other networks")
+	fs.StringArrayVarP(&nf.Labels, "label", "l", nf.Labels, "Set a label (foo=bar)")
+}
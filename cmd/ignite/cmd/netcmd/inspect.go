@@ -0,0 +1,49 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+)
+
+// NewCmdInspect inspects a network
+func NewCmdInspect(out io.Writer) *cobra.Command {
+	i := &run.InspectFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "inspect <network>",
+		Short: "Inspect a VM network",
+		Long: dedent.Dedent(`
+			Retrieve information about the given network. The network is matched
+			by prefix based on its ID and name. Outputs JSON by default, can be
+			overridden with the output flag (-o, --output).
+
+			Example usage:
+				$ ignite network inspect team-a -t {{.Spec.Subnet}}
+		`),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				io, err := i.NewInspectOptions(api.KindNetwork.Lower(), args[0])
+				if err != nil {
+					return err
+				}
+
+				return run.Inspect(io)
+			}())
+		},
+	}
+
+	addInspectFlags(cmd.Flags(), i)
+	return cmd
+}
+
+func addInspectFlags(fs *pflag.FlagSet, i *run.InspectFlags) {
+	fs.StringVarP(&i.OutputFormat, "output", "o", "json", "Output the object in the specified format")
+	fs.StringVarP(&i.TemplateFormat, "template", "t", "", "Format the output using the given Go template")
+}
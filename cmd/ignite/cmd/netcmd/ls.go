@@ -0,0 +1,25 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdLs lists available networks
+func NewCmdLs(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List available VM networks",
+		Long: dedent.Dedent(`
+			List all available VM networks. Outputs the same as the parent command.
+		`),
+		Aliases: []string{"list"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Parent().Run(cmd, args) // The parent command does this already, so just call it
+		},
+	}
+
+	return cmd
+}
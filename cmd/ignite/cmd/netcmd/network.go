@@ -0,0 +1,41 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdNetwork handles network-related functionality via its subcommands
+// This command by itself lists available networks
+func NewCmdNetwork(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Manage VM networks",
+		Long: dedent.Dedent(`
+			Groups together functionality for managing user-defined VM networks.
+			Calling this command alone lists all available networks.
+		`),
+		Aliases: []string{"networks"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				no, err := run.NewNetworksOptions()
+				if err != nil {
+					return err
+				}
+
+				return run.Networks(no)
+			}())
+		},
+	}
+
+	cmd.AddCommand(NewCmdCreate(out))
+	cmd.AddCommand(NewCmdInspect(out))
+	cmd.AddCommand(NewCmdLs(out))
+	cmd.AddCommand(NewCmdRepair(out))
+	cmd.AddCommand(NewCmdRm(out))
+	return cmd
+}
@@ -0,0 +1,32 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdRepair repairs the networking state of the host
+func NewCmdRepair(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Release the networking leaked by VMs that are gone",
+		Long: dedent.Dedent(`
+			Bring the networking state of the host in line with the VMs, e.g. after
+			the host rebooted. VMs marked running whose containers are gone are
+			marked stopped. The addresses, port forwards and masquerading rules
+			left behind by their containers are released with CNI DEL, and the
+			bridges of removed networks are deleted. The networking of the running
+			VMs is verified with CNI CHECK. ignited runs this on startup.
+		`),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(run.NetworkRepair())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,45 @@
+package netcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdRm removes networks
+func NewCmdRm(out io.Writer) *cobra.Command {
+	rf := &run.RmnFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "rm <network>...",
+		Short: "Remove networks",
+		Long: dedent.Dedent(`
+			Remove one or multiple VM networks along with their bridges. Networks
+			are matched by prefix based on their ID and name. To remove multiple
+			networks, chain the matches separated by spaces. The force flag
+			(-f, --force) kills and removes any VMs attached to the network.
+		`),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				ro, err := rf.NewRmnOptions(args)
+				if err != nil {
+					return err
+				}
+
+				return run.Rmn(ro)
+			}())
+		},
+	}
+
+	addRmnFlags(cmd.Flags(), rf)
+	return cmd
+}
+
+func addRmnFlags(fs *pflag.FlagSet, rf *run.RmnFlags) {
+	cmdutil.AddForceFlag(fs, &rf.Force)
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/vmcmd"
+)
+
+// NewCmdPort is an alias for vmcmd.NewCmdPort
+func NewCmdPort(out io.Writer) *cobra.Command {
+	return vmcmd.NewCmdPort(out)
+}
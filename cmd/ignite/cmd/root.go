@@ -11,6 +11,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/weaveworks/ignite/cmd/ignite/cmd/imgcmd"
 	"github.com/weaveworks/ignite/cmd/ignite/cmd/kerncmd"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/netcmd"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/systemcmd"
 	"github.com/weaveworks/ignite/cmd/ignite/cmd/vmcmd"
 	"github.com/weaveworks/ignite/pkg/config"
 	"github.com/weaveworks/ignite/pkg/logs"
@@ -30,6 +32,8 @@ var configPath string
 func NewIgniteCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 	imageCmd := imgcmd.NewCmdImage(os.Stdout)
 	kernelCmd := kerncmd.NewCmdKernel(os.Stdout)
+	networkCmd := netcmd.NewCmdNetwork(os.Stdout)
+	systemCmd := systemcmd.NewCmdSystem(os.Stdout)
 	vmCmd := vmcmd.NewCmdVM(os.Stdout)
 
 	root := &cobra.Command{
@@ -66,9 +70,11 @@ func NewIgniteCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 			Ignite is a containerized Firecracker microVM administration tool.
 			It can build VM images, spin VMs up/down and manage multiple VMs efficiently.
 
-			Administration is divided into three subcommands:
+			Administration is divided into five subcommands:
 			  image       %s
 			  kernel      %s
+			  network     %s
+			  system      %s
 			  vm          %s
 
 			Ignite also supports the same commands as the Docker CLI.
@@ -86,28 +92,33 @@ func NewIgniteCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 				$ ignite ps
 				$ ignite logs my-vm
 				$ ignite ssh my-vm
-		`, imageCmd.Short, kernelCmd.Short, vmCmd.Short)),
+		`, imageCmd.Short, kernelCmd.Short, networkCmd.Short, systemCmd.Short, vmCmd.Short)),
 	}
 
 	addGlobalFlags(root.PersistentFlags())
 
 	root.AddCommand(imageCmd)
 	root.AddCommand(kernelCmd)
+	root.AddCommand(networkCmd)
+	root.AddCommand(systemCmd)
 	root.AddCommand(vmCmd)
 
 	root.AddCommand(NewCmdAttach(os.Stdout))
 	root.AddCommand(NewCmdCompletion(os.Stdout, root))
 	root.AddCommand(NewCmdCP(os.Stdout))
 	root.AddCommand(NewCmdCreate(os.Stdout))
+	root.AddCommand(NewCmdEvents(os.Stdout))
 	root.AddCommand(NewCmdKill(os.Stdout))
 	root.AddCommand(NewCmdLogs(os.Stdout))
 	root.AddCommand(NewCmdInspect(os.Stdout))
+	root.AddCommand(NewCmdPort(os.Stdout))
 	root.AddCommand(NewCmdPs(os.Stdout))
 	root.AddCommand(NewCmdRm(os.Stdout))
 	root.AddCommand(NewCmdRmi(os.Stdout))
 	root.AddCommand(NewCmdRmk(os.Stdout))
 	root.AddCommand(NewCmdRun(os.Stdout))
 	root.AddCommand(NewCmdSSH(os.Stdout))
+	root.AddCommand(NewCmdStats(os.Stdout))
 	root.AddCommand(NewCmdExec(os.Stdout, os.Stderr, os.Stdin))
 	root.AddCommand(NewCmdStart(os.Stdout))
 	root.AddCommand(NewCmdStop(os.Stdout))
@@ -121,7 +132,7 @@ func isNonRootCommand(cmd string, parentCmd string) bool {
 	}
 
 	switch cmd {
-	case "version", "help", "image", "kernel", "completion", "inspect", "ps":
+	case "version", "help", "image", "kernel", "network", "completion", "inspect", "ps", "events":
 		return true
 	}
 
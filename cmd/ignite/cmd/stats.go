@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/vmcmd"
+)
+
+// NewCmdStats is an alias for vmcmd.NewCmdStats
+func NewCmdStats(out io.Writer) *cobra.Command {
+	return vmcmd.NewCmdStats(out)
+}
@@ -0,0 +1,57 @@
+package systemcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdDF displays the disk usage of Ignite
+func NewCmdDF(out io.Writer) *cobra.Command {
+	df := &run.DFFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "df",
+		Short: "Display the disk space used by images, kernels and VMs",
+		Long: dedent.Dedent(`
+			Display the disk space used by the filesystems of images, the vmlinux
+			and kernel.tar files of kernels and the overlays of VMs. Images and
+			kernels no VM uses are reclaimable, see "ignite system prune --all".
+
+			The overlays of VMs are sparse files truncated to the disk size of
+			the VM, the space allocated for the blocks written by the VM is
+			smaller. Sizes are the allocated space, the verbose flag (-v,
+			--verbose) lists every image, kernel and VM, including the apparent
+			size of the overlays and how full the snapshots of running VMs are.
+			Use the output flag (-o, --output) to output JSON instead of a table.
+
+			Example usage:
+				$ ignite system df
+				$ ignite system df -v
+				$ ignite system df -o json
+		`),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				do, err := df.NewDFOptions()
+				if err != nil {
+					return err
+				}
+
+				return run.DF(do)
+			}())
+		},
+	}
+
+	addDFFlags(cmd.Flags(), df)
+	return cmd
+}
+
+func addDFFlags(fs *pflag.FlagSet, df *run.DFFlags) {
+	fs.BoolVarP(&df.Verbose, "verbose", "v", false, "List the disk usage of every image, kernel and VM")
+	fs.StringVarP(&df.OutputFormat, "output", "o", "table", "Output the disk usage in the specified format (table or json)")
+}
@@ -0,0 +1,65 @@
+package systemcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdPrune removes leaked resources
+func NewCmdPrune(out io.Writer) *cobra.Command {
+	pf := &run.PruneFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove resources leaked by crashed VMs and operations",
+		Long: dedent.Dedent(`
+			Remove the resources left behind by crashed VMs and operations, found by
+			cross-referencing the VMs, images and kernels with the devices, loop
+			devices, containers and networking of the host:
+
+			  containers    sandbox containers of VMs that don't exist
+			  devices       snapshot devices of VMs that don't exist or aren't running
+			  loop-devices  loop devices of Ignite's files no device uses
+			  vm-dirs       VM directories without metadata
+			  networks      addresses, port forwards and bridges leaked by containers
+			  images        images no VM is created from
+			  kernels       kernels no VM boots
+
+			All classes except vm-dirs, images and kernels are pruned by default, the
+			all flag (-a, --all) prunes them too. Single classes are selected with the
+			class flag (--class). The dry run flag (--dry-run) lists the resources
+			without removing them. The devices of VMs that are being started are
+			skipped, but don't prune while images are imported, as their devices
+			aren't in use yet. Nothing of VMs whose metadata can't be loaded is pruned.
+
+			Example usage:
+				$ ignite system prune --dry-run
+				$ ignite system prune --class devices,loop-devices
+		`),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				po, err := pf.NewPruneOptions()
+				if err != nil {
+					return err
+				}
+
+				return run.Prune(po)
+			}())
+		},
+	}
+
+	addPruneFlags(cmd.Flags(), pf)
+	return cmd
+}
+
+func addPruneFlags(fs *pflag.FlagSet, pf *run.PruneFlags) {
+	fs.BoolVarP(&pf.All, "all", "a", pf.All, "Also prune the images and kernels no VM uses")
+	fs.StringSliceVar(&pf.Classes, "class", pf.Classes, "Only prune the given classes of resources")
+	fs.BoolVar(&pf.DryRun, "dry-run", pf.DryRun, "List the resources that would be pruned without removing them")
+}
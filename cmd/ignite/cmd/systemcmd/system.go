@@ -0,0 +1,24 @@
+package systemcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSystem handles host-wide maintenance via its subcommands
+func NewCmdSystem(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "system",
+		Short: "Manage the resources of Ignite on the host",
+		Long: dedent.Dedent(`
+			Groups together functionality for maintaining the resources Ignite
+			keeps on the host, across all VMs, images and kernels.
+		`),
+	}
+
+	cmd.AddCommand(NewCmdDF(out))
+	cmd.AddCommand(NewCmdPrune(out))
+	return cmd
+}
@@ -0,0 +1,50 @@
+package vmcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdCompact releases the space freed inside VMs from their disks
+func NewCmdCompact(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact <vm>...",
+		Short: "Release the disk space freed inside stopped VMs",
+		Long: dedent.Dedent(`
+			Release the blocks freed inside the filesystem of one or multiple
+			stopped VMs from their overlays, which otherwise only grow. The VMs are
+			matched by prefix based on their ID and name. To compact multiple VMs,
+			chain the matches separated by spaces.
+
+			The filesystem of the VM is trimmed through its snapshot, which zeroes
+			the freed blocks in the overlay, and the zeroed blocks are released
+			from the overlay. Trimming requires discards on snapshots, which are
+			supported since Linux 5.4. On older kernels only the blocks that are
+			zeroed already are released.
+
+			The disks of VMs using the thinpool storage driver are trimmed
+			directly, which releases the freed blocks from the pool. The disk files
+			of VMs using the reflink storage driver get holes punched for them.
+
+			Example usage:
+				$ ignite vm compact my-vm
+		`),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				co, err := run.NewCompactOptions(args)
+				if err != nil {
+					return err
+				}
+
+				return run.Compact(co)
+			}())
+		},
+	}
+
+	return cmd
+}
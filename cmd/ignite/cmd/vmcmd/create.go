@@ -68,14 +68,19 @@ func addCreateFlags(fs *pflag.FlagSet, cf *run.CreateFlags) {
 	cmdutil.AddConfigFlag(fs, &cf.ConfigFile)
 
 	// Register flags bound to temporary holder values
-	fs.StringSliceVarP(&cf.PortMappings, "ports", "p", cf.PortMappings, "Map host ports to VM ports")
+	fs.StringSliceVarP(&cf.PortMappings, "ports", "p", cf.PortMappings, "Map host ports to VM ports, e.g. \"8080:80\", \"8000-8010:8000-8010\" or \"80\" to allocate a free host port")
 	fs.StringSliceVarP(&cf.CopyFiles, "copy-files", "f", cf.CopyFiles, "Copy files/directories from the host to the created VM")
+	fs.StringSliceVar(&cf.VM.Spec.Network.Networks, "network", cf.VM.Spec.Network.Networks, "Attach the VM to the given networks, the first one provides its default route")
+	fs.StringSliceVar(&cf.CNINetworks, "cni-network", cf.CNINetworks, "Attach the VM to the given networks of the CNI configuration directory by name, instead of the first one")
+	fs.StringVar(&cf.RestartPolicy, "restart", cf.RestartPolicy, "Restart policy of the VM when ignited starts, \"no\" or \"always\", by default it's restarted if it's marked running")
+	fs.StringVar(&cf.IP, "ip", cf.IP, "Static IP address for the VM, optionally with a prefix length, e.g. \"10.61.0.10\" or \"10.61.0.10/16\"")
 
 	// Register flags for simple types (int, string, etc.)
 	fs.Uint64Var(&cf.VM.Spec.CPUs, "cpus", cf.VM.Spec.CPUs, "VM vCPU count, 1 or even numbers between 1 and 32")
 	fs.StringVar(&cf.VM.Spec.Kernel.CmdLine, "kernel-args", cf.VM.Spec.Kernel.CmdLine, "Set the command line for the kernel")
 	fs.StringArrayVarP(&cf.Labels, "label", "l", cf.Labels, "Set a label (foo=bar)")
 	fs.BoolVar(&cf.RequireName, "require-name", cf.RequireName, "Require VM name to be passed, no name generation")
+	fs.BoolVar(&cf.VM.Spec.Network.StickyIP, "sticky-ip", cf.VM.Spec.Network.StickyIP, "Keep the IP address allocated on the first start of the VM")
 
 	// Register more complex flags with their own flag types
 	cmdutil.SizeVar(fs, &cf.VM.Spec.Memory, "memory", "Amount of RAM to allocate for the VM")
@@ -0,0 +1,45 @@
+package vmcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdMigrateDisk moves the disks of VMs to the configured storage driver
+func NewCmdMigrateDisk(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-disk <vm>...",
+		Short: "Move the disks of stopped VMs to the configured storage driver",
+		Long: dedent.Dedent(`
+			Move the disks of one or multiple stopped VMs to the storage driver
+			selected with storageDriver in the ignite configuration. The VMs are
+			matched by prefix based on their ID and name. To migrate multiple VMs,
+			chain the matches separated by spaces.
+
+			The filesystem on the disk is copied into a new disk of the storage
+			driver, and the old disk is removed afterwards. Migrated disks don't
+			share any blocks with their image. Only the thinpool and reflink storage
+			drivers can import disks, e.g. the overlays of VMs using the legacy driver.
+
+			Example usage:
+				$ ignite vm migrate-disk my-vm
+		`),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				mo, err := run.NewMigrateDiskOptions(args)
+				if err != nil {
+					return err
+				}
+
+				return run.MigrateDisk(mo)
+			}())
+		},
+	}
+
+	return cmd
+}
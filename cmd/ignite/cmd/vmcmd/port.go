@@ -0,0 +1,49 @@
+package vmcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdPort lists the port mappings of a VM
+func NewCmdPort(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "port <vm> [<vm-port>[/<protocol>]]",
+		Short: "List the port mappings of a running VM",
+		Long: dedent.Dedent(`
+			List the effective port mappings of the given VM, including the host
+			ports that were allocated when the VM was started. If a VM port is given,
+			only the host addresses it is bound to are printed. The VM needs to be
+			running. The VM is matched by prefix based on its ID and name.
+
+			Example usage:
+				$ ignite port my-vm
+				80/tcp -> 0.0.0.0:32768
+
+				$ ignite port my-vm 80/tcp
+				0.0.0.0:32768
+		`),
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				var port string
+				if len(args) == 2 {
+					port = args[1]
+				}
+
+				po, err := run.NewPortOptions(args[0], port)
+				if err != nil {
+					return err
+				}
+
+				return run.Port(po)
+			}())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,39 @@
+package vmcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdResizeDisk grows the disk of a VM
+func NewCmdResizeDisk(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resize-disk <vm> <size>",
+		Short: "Grow the disk of a stopped VM",
+		Long: dedent.Dedent(`
+			Grow the disk of a stopped VM to the given size, for example 10GB or
+			20480MB, and resize its filesystem to fill it. The given VM is matched
+			by prefix based on its ID and name. Disks can't be shrunk.
+
+			Example usage:
+				$ ignite vm resize-disk my-vm 20GB
+		`),
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				ro, err := run.NewResizeDiskOptions(args[0], args[1])
+				if err != nil {
+					return err
+				}
+
+				return run.ResizeDisk(ro)
+			}())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package vmcmd
+
+import (
+	"io"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
+	"github.com/weaveworks/ignite/cmd/ignite/run"
+)
+
+// NewCmdStats displays the resource usage of VMs
+func NewCmdStats(out io.Writer) *cobra.Command {
+	sf := &run.StatsFlags{}
+
+	cmd := &cobra.Command{
+		Use:     "stats [vm]...",
+		Short:   "Display a live stream of VM resource usage",
+		Aliases: []string{"top"},
+		Long: dedent.Dedent(`
+			Display a live stream of the CPU, memory, network and block I/O usage
+			of running VMs. The VMs are matched by prefix based on their ID and name.
+			If no VMs are given, all running VMs are shown.
+
+			CPU and memory usage are read from the cgroup of the VM's sandbox
+			container, network and block I/O from the metrics of Firecracker.
+			The CPU percentage is relative to a single host CPU.
+
+			Use the no-stream flag (--no-stream) to only print the first result,
+			and the output flag (-o, --output) to output JSON instead of a table.
+
+			Example usage:
+				$ ignite stats
+				$ ignite stats my-vm --no-stream -o json
+		`),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(func() error {
+				so, err := sf.NewStatsOptions(args)
+				if err != nil {
+					return err
+				}
+
+				return run.Stats(so)
+			}())
+		},
+	}
+
+	addStatsFlags(cmd.Flags(), sf)
+	return cmd
+}
+
+func addStatsFlags(fs *pflag.FlagSet, sf *run.StatsFlags) {
+	fs.BoolVar(&sf.NoStream, "no-stream", false, "Disable streaming stats and only pull the first result")
+	fs.StringVarP(&sf.OutputFormat, "output", "o", "table", "Output the stats in the specified format (table or json)")
+}
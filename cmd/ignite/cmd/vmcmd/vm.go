@@ -31,14 +31,19 @@ func NewCmdVM(out io.Writer) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewCmdAttach(out))
+	cmd.AddCommand(NewCmdCompact(out))
 	cmd.AddCommand(NewCmdCreate(out))
 	cmd.AddCommand(NewCmdKill(out))
 	cmd.AddCommand(NewCmdLogs(out))
+	cmd.AddCommand(NewCmdMigrateDisk(out))
+	cmd.AddCommand(NewCmdPort(out))
 	cmd.AddCommand(NewCmdPs(out))
+	cmd.AddCommand(NewCmdResizeDisk(out))
 	cmd.AddCommand(NewCmdRm(out))
 	cmd.AddCommand(NewCmdRun(out))
 	cmd.AddCommand(NewCmdSSH(out))
 	cmd.AddCommand(NewCmdStart(out))
+	cmd.AddCommand(NewCmdStats(out))
 	cmd.AddCommand(NewCmdStop(out))
 	return cmd
 }
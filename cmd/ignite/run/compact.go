@@ -0,0 +1,38 @@
+package run
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/operations"
+)
+
+type CompactOptions struct {
+	vms []*api.VM
+}
+
+func NewCompactOptions(vmMatches []string) (co *CompactOptions, err error) {
+	co = &CompactOptions{}
+	co.vms, err = getVMsForMatches(vmMatches)
+	return
+}
+
+func Compact(co *CompactOptions) error {
+	for _, vm := range co.vms {
+		released, err := operations.CompactVM(vm)
+		if err != nil {
+			return err
+		}
+
+		if logs.Quiet {
+			fmt.Println(vm.GetUID())
+		} else {
+			log.Infof("Released %s from the disk of %s %q", meta.NewSizeFromBytes(released), vm.GetKind(), vm.GetUID())
+		}
+	}
+
+	return nil
+}
@@ -12,7 +12,8 @@ import (
 	"github.com/weaveworks/ignite/pkg/apis/ignite/validation"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/config"
-	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/metadata"
 	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/providers"
@@ -30,8 +31,11 @@ func NewCreateFlags() *CreateFlags {
 }
 
 type CreateFlags struct {
-	PortMappings []string
-	CopyFiles    []string
+	PortMappings  []string
+	CopyFiles     []string
+	IP            string
+	CNINetworks   []string
+	RestartPolicy string
 	// This is a placeholder value here for now.
 	// If it was set using flags, it will be copied over to
 	// the API type. TODO: When we later have internal types
@@ -112,6 +116,11 @@ func (cf *CreateFlags) NewCreateOptions(args []string, fs *flag.FlagSet) (*Creat
 		return nil, err
 	}
 
+	// Make sure the networks of the VM exist and the network plugin can attach it to them.
+	if _, err := operations.LookupNetworks(cf.VM); err != nil {
+		return nil, err
+	}
+
 	co := &CreateOptions{CreateFlags: cf}
 
 	// Get the image, or import it if it doesn't exist.
@@ -231,6 +240,27 @@ func applyVMFlagOverrides(baseVM *api.VM, cf *CreateFlags, fs *flag.FlagSet) err
 		}
 	}
 
+	if len(cf.IP) > 0 {
+		// Set the static address of the main interface
+		baseVM.SetInterfaceAddress(constants.IGNITE_SPAWN_MAIN_INTERFACE, &api.InterfaceAddress{IP: cf.IP})
+	}
+	if fs.Changed("sticky-ip") {
+		baseVM.Spec.Network.StickyIP = cf.VM.Spec.Network.StickyIP
+	}
+	if fs.Changed("network") {
+		baseVM.Spec.Network.Networks = cf.VM.Spec.Network.Networks
+	}
+	if fs.Changed("cni-network") {
+		baseVM.Spec.Network.CNINetworks = nil
+		for _, name := range cf.CNINetworks {
+			baseVM.Spec.Network.CNINetworks = append(baseVM.Spec.Network.CNINetworks, api.CNINetworkAttachment{Name: name})
+		}
+	}
+
+	if fs.Changed("restart") {
+		baseVM.Spec.RestartPolicy = api.RestartPolicy(cf.RestartPolicy)
+	}
+
 	// If the SSH flag was set, copy it over to the API type
 	if cf.SSH.Generate || cf.SSH.PublicKey != "" {
 		baseVM.Spec.SSH = &cf.SSH
@@ -254,12 +284,14 @@ func Create(co *CreateOptions) (err error) {
 		return
 	}
 
-	// Allocate and populate the overlay file
-	if err = dmlegacy.AllocateAndPopulateOverlay(co.VM); err != nil {
+	// Allocate and populate the disk with the selected storage driver
+	if err = providers.StorageDriver.CreateDisk(co.VM); err != nil {
 		return
 	}
 
-	err = metadata.Success(co.VM)
+	if err = metadata.Success(co.VM); err == nil {
+		events.Record(co.VM, events.TypeCreate, "")
+	}
 
 	return
 }
@@ -0,0 +1,149 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/weaveworks/ignite/pkg/events"
+	"github.com/weaveworks/ignite/pkg/filter"
+)
+
+// eventsPollInterval is how often the event log is checked for new events when following
+const eventsPollInterval = 500 * time.Millisecond
+
+// EventsFlags contains the flags supported by events.
+type EventsFlags struct {
+	Since  string
+	Until  string
+	Filter string
+	Format string
+	Follow bool
+}
+
+type EventsOptions struct {
+	*EventsFlags
+	since   time.Time
+	until   time.Time
+	filters *filter.MultipleMetaFilter
+	tmpl    *template.Template
+}
+
+// NewEventsOptions constructs and returns EventsOptions.
+func (ef *EventsFlags) NewEventsOptions() (eo *EventsOptions, err error) {
+	eo = &EventsOptions{EventsFlags: ef}
+	now := time.Now()
+
+	if eo.since, err = parseEventTime(ef.Since, now); err != nil {
+		return nil, fmt.Errorf("invalid --since value: %v", err)
+	}
+
+	if eo.until, err = parseEventTime(ef.Until, now); err != nil {
+		return nil, fmt.Errorf("invalid --until value: %v", err)
+	}
+
+	if ef.Filter != "" {
+		if eo.filters, err = filter.GenerateMultipleMetadataFiltering(ef.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	// Any format other than "json" is treated as a Go template
+	if ef.Format != "" && ef.Format != "json" {
+		if eo.tmpl, err = template.New("").Parse(ef.Format); err != nil {
+			return nil, fmt.Errorf("failed to parse template: %v", err)
+		}
+	}
+
+	return
+}
+
+// Events prints the recorded events based on the EventsOptions. When following,
+// it keeps printing new events until interrupted or the until time has passed.
+func Events(eo *EventsOptions) error {
+	r, err := events.NewReader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			if !eo.Follow || (!eo.until.IsZero() && time.Now().After(eo.until)) {
+				return nil
+			}
+
+			time.Sleep(eventsPollInterval)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !eo.since.IsZero() && e.Time.Before(eo.since) {
+			continue
+		}
+
+		// Events are appended in order, nothing after this one can match
+		if !eo.until.IsZero() && e.Time.After(eo.until) {
+			return nil
+		}
+
+		if eo.filters != nil {
+			ok, err := eo.filters.AreExpected(e)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+		}
+
+		if err := eo.render(e); err != nil {
+			return err
+		}
+	}
+}
+
+func (eo *EventsOptions) render(e *events.Event) error {
+	if eo.Format == "json" {
+		return json.NewEncoder(os.Stdout).Encode(e)
+	}
+
+	if eo.tmpl != nil {
+		o := &bytes.Buffer{}
+		if err := eo.tmpl.Execute(o, e); err != nil {
+			return fmt.Errorf("failed rendering template: %v", err)
+		}
+		fmt.Println(o.String())
+		return nil
+	}
+
+	fmt.Println(e)
+	return nil
+}
+
+// parseEventTime parses either a timestamp, or a duration relative to now
+func parseEventTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a timestamp nor a duration", value)
+	}
+
+	return now.Add(-d), nil
+}
@@ -0,0 +1,27 @@
+package run
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseEventTime(t *testing.T) {
+	now := time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tm, err := parseEventTime("", now)
+	assert.NilError(t, err)
+	assert.Assert(t, tm.IsZero())
+
+	tm, err = parseEventTime("10m", now)
+	assert.NilError(t, err)
+	assert.Equal(t, tm, now.Add(-10*time.Minute))
+
+	tm, err = parseEventTime("2000-01-01T11:00:00Z", now)
+	assert.NilError(t, err)
+	assert.Assert(t, tm.Equal(now.Add(-time.Hour)))
+
+	_, err = parseEventTime("yesterday", now)
+	assert.ErrorContains(t, err, "neither a timestamp nor a duration")
+}
@@ -3,6 +3,9 @@ package run
 import (
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/runtime"
+	containerdruntime "github.com/weaveworks/ignite/pkg/runtime/containerd"
+	dockerruntime "github.com/weaveworks/ignite/pkg/runtime/docker"
 	"github.com/weaveworks/libgitops/pkg/filter"
 )
 
@@ -27,3 +30,35 @@ func getVMsForMatches(vmMatches []string) ([]*api.VM, error) {
 func getAllVMs() ([]*api.VM, error) {
 	return providers.Client.VMs().FindAll(filter.NewAllFilter())
 }
+
+// runtimeClients lazily initializes and caches container runtime clients, as
+// VMs may have been started with different runtimes than the configured one.
+type runtimeClients map[runtime.Name]runtime.Interface
+
+// forVM returns the runtime client of the given VM. If the VM has an unknown
+// runtime, a nil client is returned without an error.
+func (rc runtimeClients) forVM(vm *api.VM) (runtime.Interface, error) {
+	name := vm.Status.Runtime.Name
+	if client, ok := rc[name]; ok {
+		return client, nil
+	}
+
+	var client runtime.Interface
+	var err error
+
+	switch name {
+	case runtime.RuntimeContainerd:
+		client, err = containerdruntime.GetContainerdClient()
+	case runtime.RuntimeDocker:
+		client, err = dockerruntime.GetDockerClient()
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rc[name] = client
+	return client, nil
+}
@@ -1,6 +1,8 @@
 package run
 
 import (
+	"fmt"
+
 	"github.com/weaveworks/ignite/cmd/ignite/cmd/cmdutil"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
@@ -8,10 +10,50 @@ import (
 	"github.com/weaveworks/ignite/pkg/metadata"
 	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/source"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
-func ImportImage(source string) (image *api.Image, err error) {
+// ImportFlags select a local source to import from, instead of the OCI image of the container runtime
+type ImportFlags struct {
+	FromTar           string
+	FromDir           string
+	FromOCILayout     string
+	FromDockerArchive string
+}
+
+// localSource returns the source selected by the flags, or nil if the OCI image is imported
+func (f *ImportFlags) localSource() (source.Source, error) {
+	var sources []source.Source
+	if len(f.FromTar) > 0 {
+		sources = append(sources, source.NewTarSource(f.FromTar))
+	}
+	if len(f.FromDir) > 0 {
+		sources = append(sources, source.NewDirSource(f.FromDir))
+	}
+	if len(f.FromOCILayout) > 0 {
+		sources = append(sources, source.NewOCILayoutSource(f.FromOCILayout))
+	}
+	if len(f.FromDockerArchive) > 0 {
+		sources = append(sources, source.NewDockerArchiveSource(f.FromDockerArchive))
+	}
+
+	switch len(sources) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sources[0], nil
+	}
+
+	return nil, fmt.Errorf("only one of --from-tar, --from-dir, --from-oci-layout and --from-docker-archive can be used")
+}
+
+func ImportImage(name string, f *ImportFlags) (image *api.Image, err error) {
+	src, err := f.localSource()
+	if err != nil {
+		return
+	}
+
 	// Populate the runtime provider.
 	if err := config.SetAndPopulateProviders(providers.RuntimeName, providers.NetworkPluginName); err != nil {
 		return nil, err
@@ -19,12 +61,16 @@ func ImportImage(source string) (image *api.Image, err error) {
 
 	cmdutil.ResolveRegistryConfigDir()
 
-	ociRef, err := meta.NewOCIImageRef(source)
+	ociRef, err := meta.NewOCIImageRef(name)
 	if err != nil {
 		return
 	}
 
-	image, err = operations.FindOrImportImage(providers.Client, ociRef)
+	if src != nil {
+		image, err = operations.ImportImageFromSource(providers.Client, ociRef, src)
+	} else {
+		image, err = operations.FindOrImportImage(providers.Client, ociRef)
+	}
 	if err != nil {
 		return
 	}
@@ -35,7 +81,12 @@ func ImportImage(source string) (image *api.Image, err error) {
 	return
 }
 
-func ImportKernel(source string) (kernel *api.Kernel, err error) {
+func ImportKernel(name string, f *ImportFlags) (kernel *api.Kernel, err error) {
+	src, err := f.localSource()
+	if err != nil {
+		return
+	}
+
 	// Populate the runtime provider.
 	if err := config.SetAndPopulateProviders(providers.RuntimeName, providers.NetworkPluginName); err != nil {
 		return nil, err
@@ -43,12 +94,16 @@ func ImportKernel(source string) (kernel *api.Kernel, err error) {
 
 	cmdutil.ResolveRegistryConfigDir()
 
-	ociRef, err := meta.NewOCIImageRef(source)
+	ociRef, err := meta.NewOCIImageRef(name)
 	if err != nil {
 		return
 	}
 
-	kernel, err = operations.FindOrImportKernel(providers.Client, ociRef)
+	if src != nil {
+		kernel, err = operations.ImportKernelFromSource(providers.Client, ociRef, src)
+	} else {
+		kernel, err = operations.FindOrImportKernel(providers.Client, ociRef)
+	}
 	if err != nil {
 		return
 	}
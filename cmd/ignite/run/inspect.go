@@ -36,6 +36,8 @@ func (i *InspectFlags) NewInspectOptions(k, objectMatch string) (*InspectOptions
 		kind = api.KindImage
 	case api.KindKernel.Lower():
 		kind = api.KindKernel
+	case api.KindNetwork.Lower():
+		kind = api.KindNetwork
 	case api.KindVM.Lower():
 		kind = api.KindVM
 	default:
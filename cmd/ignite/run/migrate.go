@@ -0,0 +1,40 @@
+package run
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+type MigrateDiskOptions struct {
+	vms []*api.VM
+}
+
+func NewMigrateDiskOptions(vmMatches []string) (mo *MigrateDiskOptions, err error) {
+	mo = &MigrateDiskOptions{}
+	mo.vms, err = getVMsForMatches(vmMatches)
+	return
+}
+
+func MigrateDisk(mo *MigrateDiskOptions) error {
+	for _, vm := range mo.vms {
+		migrated, err := operations.MigrateVMDisk(vm, providers.StorageDriver)
+		if err != nil {
+			return err
+		}
+
+		if logs.Quiet {
+			fmt.Println(vm.GetUID())
+		} else if migrated {
+			log.Infof("Migrated the disk of %s %q to the %s storage driver", vm.GetKind(), vm.GetUID(), providers.StorageDriver.Name())
+		} else {
+			log.Infof("The disk of %s %q is stored by the %s storage driver already", vm.GetKind(), vm.GetUID(), providers.StorageDriver.Name())
+		}
+	}
+
+	return nil
+}
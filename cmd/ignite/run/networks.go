@@ -0,0 +1,150 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/apis/ignite/validation"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/metadata"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/util"
+	"github.com/weaveworks/libgitops/pkg/filter"
+)
+
+// NetworkCreateFlags contains the flags supported by network create.
+type NetworkCreateFlags struct {
+	Network *api.Network
+	Labels  []string
+}
+
+// NewNetworkCreateFlags constructs and returns NetworkCreateFlags with the
+// defaults of a new network.
+func NewNetworkCreateFlags() *NetworkCreateFlags {
+	return &NetworkCreateFlags{
+		Network: &api.Network{
+			Spec: api.NetworkSpec{
+				Masquerade: true,
+			},
+		},
+	}
+}
+
+type NetworkCreateOptions struct {
+	*NetworkCreateFlags
+}
+
+func (nf *NetworkCreateFlags) NewNetworkCreateOptions(name string) (*NetworkCreateOptions, error) {
+	network := providers.Client.Networks().New()
+	network.SetName(name)
+	network.Spec = nf.Network.Spec
+	nf.Network = network
+
+	return &NetworkCreateOptions{NetworkCreateFlags: nf}, nil
+}
+
+func NetworkCreate(no *NetworkCreateOptions) (err error) {
+	// Generate a UID and verify the name is unique
+	if err = metadata.SetNameAndUID(no.Network, providers.Client); err != nil {
+		return
+	}
+	if err = metadata.SetLabels(no.Network, no.Labels); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, func() error { return metadata.Cleanup(no.Network, false) })
+
+	// Derive the bridge name from the UID, it needs to start with "ignite" for the isolation rules
+	if len(no.Network.Spec.BridgeName) == 0 {
+		no.Network.Spec.BridgeName = constants.NETWORK_NAME_PREFIX + no.Network.GetUID().String()[:8]
+	}
+
+	if err = validation.ValidateNetwork(no.Network).ToAggregate(); err != nil {
+		return
+	}
+
+	if err = operations.CheckNetworkConflicts(no.Network); err != nil {
+		return
+	}
+
+	if err = providers.Client.Networks().Set(no.Network); err != nil {
+		return
+	}
+
+	if err = operations.WriteNetworkConfList(no.Network); err != nil {
+		return
+	}
+
+	return metadata.Success(no.Network)
+}
+
+type NetworksOptions struct {
+	allNetworks []*api.Network
+}
+
+func NewNetworksOptions() (no *NetworksOptions, err error) {
+	no = &NetworksOptions{}
+	no.allNetworks, err = providers.Client.Networks().FindAll(filter.NewAllFilter())
+	// If the storage is uninitialized, avoid failure and continue with empty
+	// network list.
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	return
+}
+
+func Networks(no *NetworksOptions) error {
+	o := util.NewOutput()
+	defer o.Flush()
+
+	o.Write("NETWORK ID", "NAME", "CREATED", "SUBNET", "BRIDGE", "OPTIONS")
+	for _, network := range no.allNetworks {
+		o.Write(network.GetUID(), network.GetName(), network.GetCreated(), network.Spec.Subnet, network.Spec.BridgeName, networkOptions(network))
+	}
+
+	return nil
+}
+
+// networkOptions summarizes the boolean options of a network for the list output
+func networkOptions(network *api.Network) string {
+	var options []string
+	if network.Spec.Masquerade {
+		options = append(options, "masquerade")
+	}
+	if network.Spec.Isolated {
+		options = append(options, "isolated")
+	}
+	if len(options) == 0 {
+		return "-"
+	}
+
+	return strings.Join(options, ",")
+}
+
+// NetworkRepair releases the networking leaked by the containers of VMs
+// that are gone and corrects the status of the VMs, see operations.RepairNetworks
+func NetworkRepair() error {
+	result, err := operations.RepairNetworks(false)
+	if err != nil {
+		return err
+	}
+
+	if logs.Quiet {
+		for _, vm := range result.StoppedVMs {
+			fmt.Println(vm.GetUID())
+		}
+	} else {
+		log.Infof("Marked %d VMs stopped, released the networking of %d containers and removed %d bridges",
+			len(result.StoppedVMs), len(result.ReleasedContainers), len(result.RemovedBridges))
+	}
+
+	if len(result.BrokenVMs) > 0 {
+		return fmt.Errorf("the networking of %d running VMs is broken", len(result.BrokenVMs))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+type PortOptions struct {
+	vm       *api.VM
+	vmPort   uint64
+	protocol meta.Protocol
+}
+
+// NewPortOptions constructs and returns PortOptions. The optional
+// port selects a single VM port, in the <port>[/<protocol>] format.
+func NewPortOptions(vmMatch, port string) (po *PortOptions, err error) {
+	po = &PortOptions{}
+	if po.vm, err = getVMForMatch(vmMatch); err != nil {
+		return
+	}
+
+	if len(port) > 0 {
+		parts := strings.SplitN(port, "/", 2)
+		if po.vmPort, err = strconv.ParseUint(parts[0], 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid VM port: %q", parts[0])
+		}
+
+		po.protocol = meta.ProtocolTCP
+		if len(parts) == 2 {
+			po.protocol = meta.Protocol(parts[1])
+			if po.protocol != meta.ProtocolTCP && po.protocol != meta.ProtocolUDP {
+				return nil, fmt.Errorf("invalid protocol: %q", parts[1])
+			}
+		}
+	}
+
+	return
+}
+
+// Port prints the effective port mappings of a running VM
+func Port(po *PortOptions) error {
+	if !po.vm.Running() {
+		return fmt.Errorf("VM %q is not running", po.vm.GetUID())
+	}
+
+	found := false
+	for _, port := range po.vm.Ports() {
+		protocol := port.Protocol
+		if len(protocol) == 0 {
+			protocol = meta.ProtocolTCP
+		}
+
+		bindAddress := "0.0.0.0"
+		if port.BindAddress != nil {
+			bindAddress = port.BindAddress.String()
+		}
+		hostAddress := net.JoinHostPort(bindAddress, strconv.FormatUint(port.HostPort, 10))
+
+		if po.vmPort == 0 {
+			fmt.Printf("%d/%s -> %s\n", port.VMPort, protocol, hostAddress)
+		} else if port.VMPort == po.vmPort && protocol == po.protocol {
+			fmt.Println(hostAddress)
+			found = true
+		}
+	}
+
+	if po.vmPort != 0 && !found {
+		return fmt.Errorf("no host binding for VM port %d/%s of VM %q", po.vmPort, po.protocol, po.vm.GetUID())
+	}
+
+	return nil
+}
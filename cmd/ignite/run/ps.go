@@ -10,10 +10,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/filter"
+	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/providers"
-	"github.com/weaveworks/ignite/pkg/runtime"
-	containerdruntime "github.com/weaveworks/ignite/pkg/runtime/containerd"
-	dockerruntime "github.com/weaveworks/ignite/pkg/runtime/docker"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
@@ -121,7 +119,7 @@ func Ps(po *PsOptions) error {
 	for _, vm := range filteredVMs {
 		o.Write(vm.GetUID(), vm.Spec.Image.OCI, vm.Spec.Kernel.OCI,
 			vm.Spec.DiskSize, vm.Spec.CPUs, vm.Spec.Memory, formatCreated(vm), formatStatus(vm, outdatedVMs), vm.Status.Network.IPAddresses,
-			vm.Spec.Network.Ports, vm.GetName())
+			vm.Ports(), vm.GetName())
 	}
 
 	return nil
@@ -159,7 +157,7 @@ func fetchLatestStatus(vms []*api.VM) (outdatedVMs map[string]bool, errList []er
 
 	// Container runtime clients. These clients are lazy initialized based on
 	// the VM's runtime.
-	var containerdClient, dockerClient runtime.Interface
+	clients := runtimeClients{}
 
 	// Iterate through the VMs, fetching the actual status from the runtime.
 	for _, vm := range vms {
@@ -170,39 +168,21 @@ func fetchLatestStatus(vms []*api.VM) (outdatedVMs map[string]bool, errList []er
 		containerID := vm.Status.Runtime.ID
 		currentRunning := false
 
-		// Runtime client of the VM.
-		var vmRuntime runtime.Interface
-
-		// Set the appropriate runtime client based on the VM runtime info.
-		switch vm.Status.Runtime.Name {
-		case runtime.RuntimeContainerd:
-			if containerdClient == nil {
-				var err error
-				containerdClient, err = containerdruntime.GetContainerdClient()
-				if err != nil {
-					errList = append(errList, err)
-					return
-				}
-			}
-			vmRuntime = containerdClient
-		case runtime.RuntimeDocker:
-			if dockerClient == nil {
-				var err error
-				dockerClient, err = dockerruntime.GetDockerClient()
-				if err != nil {
-					errList = append(errList, err)
-					return
-				}
-			}
-			vmRuntime = dockerClient
-		default:
-			// Skip VMs with unknown runtime
+		// Get the runtime client based on the VM runtime info.
+		vmRuntime, err := clients.forVM(vm)
+		if err != nil {
+			errList = append(errList, err)
+			return
+		}
+
+		// Skip VMs with unknown runtime
+		if vmRuntime == nil {
 			continue
 		}
 
 		// Inspect the VM container using the runtime client.
 		ir, inspectErr := vmRuntime.InspectContainer(containerID)
-		if inspectErr != nil {
+		if inspectErr != nil && !operations.ContainerNotFound(inspectErr) {
 			// Failed to get the container status. Latest status can't be
 			// confirmed.
 			outdatedVMs[vm.Name] = true
@@ -210,9 +190,12 @@ func fetchLatestStatus(vms []*api.VM) (outdatedVMs map[string]bool, errList []er
 			continue
 		}
 
-		// Set current running based on the container status result.
-		if ir.Status == runtimeRunningStatus {
-			currentRunning = true
+		// Set current running based on the container status result. VMs
+		// whose containers are gone aren't running.
+		dieMessage := "the container is gone"
+		if inspectErr == nil {
+			currentRunning = ir.Status == runtimeRunningStatus
+			dieMessage = fmt.Sprintf("the container is %s", ir.Status)
 		}
 
 		// If current running status and the VM object status don't match, mark
@@ -220,6 +203,11 @@ func fetchLatestStatus(vms []*api.VM) (outdatedVMs map[string]bool, errList []er
 		// NOTE: Avoid updating the VM manifest on disk here. That'll be
 		// indicated in the ps output.
 		if currentRunning != vm.Status.Running {
+			// The VM died without ignite-spawn recording it, e.g. its
+			// container was killed.
+			if vm.Status.Running {
+				operations.RecordDie(vm, dieMessage)
+			}
 			vm.Status.Running = currentRunning
 			outdatedVMs[vm.Name] = true
 		}
@@ -76,7 +76,7 @@ func createTestVM(name, id string) (*api.VM, error) {
 	vm.Spec.Sandbox.OCI = ociRefSandbox
 
 	// Initialize network.
-	vm.Status.Network = &api.Network{}
+	vm.Status.Network = &api.VMNetworkStatus{}
 
 	return vm, nil
 }
@@ -0,0 +1,40 @@
+package run
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/operations"
+)
+
+type ResizeDiskOptions struct {
+	vm   *api.VM
+	size meta.Size
+}
+
+func NewResizeDiskOptions(vmMatch, size string) (ro *ResizeDiskOptions, err error) {
+	ro = &ResizeDiskOptions{}
+	if ro.size, err = meta.NewSizeFromString(size); err != nil {
+		return nil, fmt.Errorf("invalid disk size %q: %v", size, err)
+	}
+
+	ro.vm, err = getVMForMatch(vmMatch)
+	return
+}
+
+func ResizeDisk(ro *ResizeDiskOptions) error {
+	if err := operations.ResizeVMDisk(ro.vm, ro.size); err != nil {
+		return err
+	}
+
+	if logs.Quiet {
+		fmt.Println(ro.vm.GetUID())
+	} else {
+		log.Infof("Resized the disk of %s %q to %s", ro.vm.GetKind(), ro.vm.GetUID(), ro.size)
+	}
+
+	return nil
+}
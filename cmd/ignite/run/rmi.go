@@ -2,10 +2,10 @@ package run
 
 import (
 	"fmt"
-	"os"
 
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/operations/lookup"
 	"github.com/weaveworks/ignite/pkg/providers"
 	"github.com/weaveworks/libgitops/pkg/filter"
@@ -66,8 +66,8 @@ func Rmi(ro *RmiOptions) error {
 			}
 		}
 
-		if err := os.RemoveAll(image.ObjectPath()); err != nil {
-			return fmt.Errorf("unable to remove directory for %s %q: %v", image.GetKind(), image.GetUID(), err)
+		if err := operations.RemoveImage(image); err != nil {
+			return err
 		}
 
 		fmt.Println(image.GetUID())
@@ -2,10 +2,10 @@ package run
 
 import (
 	"fmt"
-	"os"
 
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/operations/lookup"
 	"github.com/weaveworks/ignite/pkg/providers"
 	"github.com/weaveworks/libgitops/pkg/filter"
@@ -66,8 +66,8 @@ func Rmk(ro *RmkOptions) error {
 			}
 		}
 
-		if err := os.RemoveAll(kernel.ObjectPath()); err != nil {
-			return fmt.Errorf("unable to remove directory for %s %q: %v", kernel.GetKind(), kernel.GetUID(), err)
+		if err := operations.RemoveKernel(kernel); err != nil {
+			return err
 		}
 
 		fmt.Println(kernel.GetUID())
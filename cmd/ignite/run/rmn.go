@@ -0,0 +1,82 @@
+package run
+
+import (
+	"fmt"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/libgitops/pkg/filter"
+)
+
+type RmnFlags struct {
+	Force bool
+}
+
+type RmnOptions struct {
+	*RmnFlags
+	networks []*api.Network
+	allVMs   []*api.VM
+}
+
+func (rf *RmnFlags) NewRmnOptions(networkMatches []string) (*RmnOptions, error) {
+	ro := &RmnOptions{RmnFlags: rf}
+
+	for _, match := range networkMatches {
+		if network, err := providers.Client.Networks().Find(filter.NewIDNameFilter(match)); err == nil {
+			ro.networks = append(ro.networks, network)
+		} else {
+			return nil, err
+		}
+	}
+
+	var err error
+	ro.allVMs, err = getAllVMs()
+	if err != nil {
+		return nil, err
+	}
+
+	return ro, nil
+}
+
+func Rmn(ro *RmnOptions) error {
+	for _, network := range ro.networks {
+		for _, vm := range ro.allVMs {
+			// Check if there's any VM attached to this network
+			if !vmUsesNetwork(vm, network) {
+				continue
+			}
+
+			if !ro.Force {
+				return fmt.Errorf("unable to remove, network %q is in use by VM %q", network.GetUID(), vm.GetUID())
+			}
+
+			// Force-kill and remove the VM attached to this network
+			if err := Rm(&RmOptions{
+				&RmFlags{Force: true},
+				[]*api.VM{vm},
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := operations.RemoveNetwork(network); err != nil {
+			return err
+		}
+
+		fmt.Println(network.GetUID())
+	}
+
+	return nil
+}
+
+// vmUsesNetwork returns true if the VM is attached to the given network
+func vmUsesNetwork(vm *api.VM, network *api.Network) bool {
+	for _, name := range vm.Spec.Network.Networks {
+		if name == network.GetName() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -10,8 +10,10 @@ import (
 	flag "github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/apis/ignite/validation"
 	"github.com/weaveworks/ignite/pkg/config"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/operations"
@@ -73,6 +75,11 @@ func Start(so *StartOptions, fs *flag.FlagSet) error {
 		return err
 	}
 
+	// The network plugin may have changed since the VM was created
+	if err := validation.ValidateVMNetworkPlugin(so.vm, field.NewPath(".spec.network")).ToAggregate(); err != nil {
+		return err
+	}
+
 	ignoredPreflightErrors := sets.NewString(util.ToLower(so.StartFlags.IgnoredPreflightErrors)...)
 	if err := checkers.StartCmdChecks(so.vm, ignoredPreflightErrors); err != nil {
 		return err
@@ -97,7 +104,7 @@ func Start(so *StartOptions, fs *flag.FlagSet) error {
 }
 
 func dialSuccess(vm *ignite.VM, seconds int) error {
-	addr := vm.Status.Network.IPAddresses[0].String() + ":22"
+	addr := net.JoinHostPort(vm.Status.Network.IPAddresses[0].String(), "22")
 	perSecond := 10
 	delay := time.Second / time.Duration(perSecond)
 	var err error
@@ -147,7 +154,7 @@ func waitForSSH(vm *ignite.VM, dialSeconds int, sshTimeout time.Duration) error
 		Timeout:         sshTimeout,
 	}
 
-	addr := vm.Status.Network.IPAddresses[0].String() + ":22"
+	addr := net.JoinHostPort(vm.Status.Network.IPAddresses[0].String(), "22")
 	sshConn, err := ssh.Dial("tcp", addr, config)
 	if err != nil {
 		if strings.Contains(err.Error(), "unable to authenticate") {
@@ -0,0 +1,271 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/container"
+	"github.com/weaveworks/ignite/pkg/filter"
+	"github.com/weaveworks/ignite/pkg/prometheus"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/runtime"
+	"github.com/weaveworks/ignite/pkg/util"
+	"golang.org/x/sys/unix"
+)
+
+// statsInterval is the time between two samples of the VM stats
+const statsInterval = 2 * time.Second
+
+// clearScreen moves the cursor to the top left and clears the terminal
+const clearScreen = "\033[2J\033[H"
+
+// StatsFlags contains the flags supported by stats.
+type StatsFlags struct {
+	NoStream     bool
+	OutputFormat string
+}
+
+type StatsOptions struct {
+	*StatsFlags
+	vmMatches []string
+}
+
+// VMStats describes the resource usage of a VM over a sampling interval.
+type VMStats struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	CPUPercentage    float64 `json:"cpuPercentage"`
+	MemoryUsage      uint64  `json:"memoryUsage"`
+	MemoryLimit      uint64  `json:"memoryLimit"`
+	MemoryPercentage float64 `json:"memoryPercentage"`
+	NetworkRx        uint64  `json:"networkRx"`
+	NetworkTx        uint64  `json:"networkTx"`
+	BlockRead        uint64  `json:"blockRead"`
+	BlockWrite       uint64  `json:"blockWrite"`
+	PIDs             uint64  `json:"pids"`
+}
+
+// statsSample is a single reading of the stats of a VM
+type statsSample struct {
+	vm  *api.VM
+	raw *runtime.ContainerStatsResult
+}
+
+// NewStatsOptions constructs and returns StatsOptions. If no VMs are
+// given, the stats of all running VMs are shown.
+func (sf *StatsFlags) NewStatsOptions(vmMatches []string) (*StatsOptions, error) {
+	switch sf.OutputFormat {
+	case "", "table", "json":
+	default:
+		return nil, fmt.Errorf("unrecognized output format: %q", sf.OutputFormat)
+	}
+
+	// Verify that the given VMs exist and are running
+	vms, err := getVMsForMatches(vmMatches)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vm := range vms {
+		if !vm.Running() {
+			return nil, fmt.Errorf("VM %q is not running", vm.GetUID())
+		}
+	}
+
+	return &StatsOptions{StatsFlags: sf, vmMatches: vmMatches}, nil
+}
+
+// Stats samples and renders the resource usage of the VMs based on the
+// StatsOptions. Unless NoStream is set, it keeps sampling until interrupted.
+func Stats(so *StatsOptions) error {
+	clients := runtimeClients{}
+
+	prev, err := so.sample(clients)
+	if err != nil {
+		return err
+	}
+
+	for {
+		time.Sleep(statsInterval)
+
+		cur, err := so.sample(clients)
+		if err != nil {
+			return err
+		}
+
+		if err := so.render(computeStats(prev, cur)); err != nil {
+			return err
+		}
+
+		if so.NoStream {
+			return nil
+		}
+
+		prev = cur
+	}
+}
+
+// sample collects the current stats of the selected VMs
+func (so *StatsOptions) sample(clients runtimeClients) (map[string]*statsSample, error) {
+	var vms []*api.VM
+	var err error
+
+	// Refresh the VM list on every sample to pick up started and stopped VMs
+	if len(so.vmMatches) > 0 {
+		vms, err = getVMsForMatches(so.vmMatches)
+	} else {
+		vms, err = providers.Client.VMs().FindAll(filter.NewVMFilterAll("", false))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(map[string]*statsSample, len(vms))
+	for _, vm := range vms {
+		if !vm.Running() {
+			continue
+		}
+
+		vmRuntime, err := clients.forVM(vm)
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip VMs with unknown runtime
+		if vmRuntime == nil {
+			continue
+		}
+
+		raw, err := vmRuntime.ContainerStats(vm.PrefixedID())
+		if err != nil {
+			log.Warnf("Failed to get stats for VM %q: %v", vm.GetUID(), err)
+			continue
+		}
+
+		// The Firecracker metrics describe the I/O the guest performs more precisely
+		// than the cgroup of the sandbox, so prefer them when they are available
+		socketPath := path.Join(vm.ObjectPath(), constants.PROMETHEUS_SOCKET)
+		if families, err := prometheus.ScrapeSocket(socketPath); err == nil {
+			if v, ok := counterValue(families, container.MetricNetRxBytes); ok {
+				raw.NetworkRx = v
+			}
+			if v, ok := counterValue(families, container.MetricNetTxBytes); ok {
+				raw.NetworkTx = v
+			}
+			if v, ok := counterValue(families, container.MetricBlockReadBytes); ok {
+				raw.BlockRead = v
+			}
+			if v, ok := counterValue(families, container.MetricBlockWriteBytes); ok {
+				raw.BlockWrite = v
+			}
+		} else {
+			log.Debugf("Failed to scrape Firecracker metrics for VM %q: %v", vm.GetUID(), err)
+		}
+
+		samples[vm.GetUID().String()] = &statsSample{vm: vm, raw: raw}
+	}
+
+	return samples, nil
+}
+
+// computeStats derives the VM stats from two consecutive samples
+func computeStats(prev, cur map[string]*statsSample) []*VMStats {
+	hostMemory := hostMemory()
+	stats := make([]*VMStats, 0, len(cur))
+
+	for uid, c := range cur {
+		s := &VMStats{
+			ID:          uid,
+			Name:        c.vm.GetName(),
+			MemoryUsage: c.raw.MemoryUsage,
+			MemoryLimit: c.raw.MemoryLimit,
+			NetworkRx:   c.raw.NetworkRx,
+			NetworkTx:   c.raw.NetworkTx,
+			BlockRead:   c.raw.BlockRead,
+			BlockWrite:  c.raw.BlockWrite,
+			PIDs:        c.raw.PIDs,
+		}
+
+		// An unset cgroup memory limit is reported as a huge number, cap it to the host memory
+		if s.MemoryLimit == 0 || (hostMemory > 0 && s.MemoryLimit > hostMemory) {
+			s.MemoryLimit = hostMemory
+		}
+
+		if s.MemoryLimit > 0 {
+			s.MemoryPercentage = float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+		}
+
+		// The CPU usage is relative to a single host CPU, like in top
+		if p, ok := prev[uid]; ok {
+			cpuDelta := float64(c.raw.CPUUsage) - float64(p.raw.CPUUsage)
+			timeDelta := float64(c.raw.Read.Sub(p.raw.Read).Nanoseconds())
+			if cpuDelta > 0 && timeDelta > 0 {
+				s.CPUPercentage = cpuDelta / timeDelta * 100
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	// Keep the rows in a stable order between samples
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats
+}
+
+func (so *StatsOptions) render(stats []*VMStats) error {
+	if so.OutputFormat == "json" {
+		return json.NewEncoder(os.Stdout).Encode(stats)
+	}
+
+	if !so.NoStream {
+		fmt.Print(clearScreen)
+	}
+
+	o := util.NewOutput()
+	defer o.Flush()
+
+	o.Write("VM ID", "NAME", "CPU %", "MEM USAGE / LIMIT", "MEM %", "NET I/O", "BLOCK I/O", "PIDS")
+	for _, s := range stats {
+		o.Write(s.ID, s.Name,
+			fmt.Sprintf("%.2f%%", s.CPUPercentage),
+			fmt.Sprintf("%s / %s", meta.NewSizeFromBytes(s.MemoryUsage), meta.NewSizeFromBytes(s.MemoryLimit)),
+			fmt.Sprintf("%.2f%%", s.MemoryPercentage),
+			fmt.Sprintf("%s / %s", meta.NewSizeFromBytes(s.NetworkRx), meta.NewSizeFromBytes(s.NetworkTx)),
+			fmt.Sprintf("%s / %s", meta.NewSizeFromBytes(s.BlockRead), meta.NewSizeFromBytes(s.BlockWrite)),
+			s.PIDs)
+	}
+
+	return nil
+}
+
+// counterValue returns the value of the first counter in the given metric family
+func counterValue(families map[string]*dto.MetricFamily, name string) (uint64, bool) {
+	family, ok := families[name]
+	if !ok || len(family.GetMetric()) == 0 {
+		return 0, false
+	}
+
+	return uint64(family.GetMetric()[0].GetCounter().GetValue()), true
+}
+
+// hostMemory returns the total amount of memory of the host in bytes
+func hostMemory() uint64 {
+	var info unix.Sysinfo_t
+	if err := unix.Sysinfo(&info); err != nil {
+		return 0
+	}
+
+	return uint64(info.Totalram) * uint64(info.Unit)
+}
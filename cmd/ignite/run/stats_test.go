@@ -0,0 +1,59 @@
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/weaveworks/ignite/pkg/runtime"
+	"gotest.tools/assert"
+)
+
+func TestComputeStats(t *testing.T) {
+	vm1, err := createTestVM("vm1", "20e1d566ce318ada")
+	assert.NilError(t, err)
+	vm2, err := createTestVM("vm2", "bfc80c948b1e2419")
+	assert.NilError(t, err)
+
+	readTime := time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC)
+
+	prev := map[string]*statsSample{
+		"20e1d566ce318ada": {vm: vm1, raw: &runtime.ContainerStatsResult{
+			CPUUsage: uint64(time.Second),
+			Read:     readTime,
+		}},
+	}
+
+	cur := map[string]*statsSample{
+		// Used half a CPU over a two second interval
+		"20e1d566ce318ada": {vm: vm1, raw: &runtime.ContainerStatsResult{
+			CPUUsage:    uint64(2 * time.Second),
+			MemoryUsage: 256,
+			MemoryLimit: 1024,
+			NetworkRx:   10,
+			NetworkTx:   20,
+			PIDs:        5,
+			Read:        readTime.Add(2 * time.Second),
+		}},
+		// Started between the samples, no CPU usage can be computed yet
+		"bfc80c948b1e2419": {vm: vm2, raw: &runtime.ContainerStatsResult{
+			CPUUsage:    uint64(time.Second),
+			MemoryUsage: 512,
+			MemoryLimit: 1024,
+			Read:        readTime.Add(2 * time.Second),
+		}},
+	}
+
+	stats := computeStats(prev, cur)
+	assert.Equal(t, len(stats), 2)
+
+	assert.Equal(t, stats[0].Name, "vm1")
+	assert.Equal(t, stats[0].CPUPercentage, 50.0)
+	assert.Equal(t, stats[0].MemoryPercentage, 25.0)
+	assert.Equal(t, stats[0].NetworkRx, uint64(10))
+	assert.Equal(t, stats[0].NetworkTx, uint64(20))
+	assert.Equal(t, stats[0].PIDs, uint64(5))
+
+	assert.Equal(t, stats[1].Name, "vm2")
+	assert.Equal(t, stats[1].CPUPercentage, 0.0)
+	assert.Equal(t, stats[1].MemoryPercentage, 50.0)
+}
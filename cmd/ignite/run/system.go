@@ -0,0 +1,253 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+type PruneFlags struct {
+	All     bool
+	Classes []string
+	DryRun  bool
+}
+
+type PruneOptions struct {
+	*PruneFlags
+	classes []operations.PruneClass
+}
+
+func (pf *PruneFlags) NewPruneOptions() (*PruneOptions, error) {
+	po := &PruneOptions{PruneFlags: pf}
+
+	if len(pf.Classes) == 0 {
+		po.classes = operations.LeakedPruneClasses()
+		if pf.All {
+			po.classes = operations.PruneClasses()
+		}
+
+		return po, nil
+	}
+
+	if pf.All {
+		return nil, fmt.Errorf("the all flag can't be combined with the class flag")
+	}
+
+	valid := map[operations.PruneClass]bool{}
+	for _, class := range operations.PruneClasses() {
+		valid[class] = true
+	}
+
+	for _, class := range pf.Classes {
+		if !valid[operations.PruneClass(class)] {
+			return nil, fmt.Errorf("unknown class of resources %q, supported classes are %v", class, operations.PruneClasses())
+		}
+
+		po.classes = append(po.classes, operations.PruneClass(class))
+	}
+
+	return po, nil
+}
+
+func Prune(po *PruneOptions) error {
+	pruned, err := operations.Prune(po.classes, po.DryRun)
+
+	if logs.Quiet {
+		for _, r := range pruned {
+			fmt.Println(r.Name)
+		}
+	} else if len(pruned) > 0 {
+		o := util.NewOutput()
+		o.Write("CLASS", "RESOURCE")
+		for _, r := range pruned {
+			o.Write(r.Class, r.Name)
+		}
+		o.Flush()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !logs.Quiet {
+		if po.DryRun {
+			log.Infof("Would prune %d resources", len(pruned))
+		} else {
+			log.Infof("Pruned %d resources", len(pruned))
+		}
+	}
+
+	return nil
+}
+
+// DFFlags contains the flags supported by system df.
+type DFFlags struct {
+	Verbose      bool
+	OutputFormat string
+}
+
+type DFOptions struct {
+	*DFFlags
+}
+
+// diskUsageSummary sums up the disk usage of a type of resources
+type diskUsageSummary struct {
+	kind        string
+	total       int
+	active      int
+	size        uint64
+	reclaimable uint64
+}
+
+func (df *DFFlags) NewDFOptions() (*DFOptions, error) {
+	switch df.OutputFormat {
+	case "", "table", "json":
+	default:
+		return nil, fmt.Errorf("unrecognized output format: %q", df.OutputFormat)
+	}
+
+	return &DFOptions{DFFlags: df}, nil
+}
+
+func DF(do *DFOptions) error {
+	du, err := operations.GetDiskUsage()
+	if err != nil {
+		return err
+	}
+
+	if do.OutputFormat == "json" {
+		return json.NewEncoder(os.Stdout).Encode(du)
+	}
+
+	o := util.NewOutput()
+	o.Write("TYPE", "TOTAL", "ACTIVE", "SIZE", "RECLAIMABLE")
+	for _, s := range summarizeDiskUsage(du) {
+		reclaimable := "-"
+		if s.kind != "VMs" && s.kind != "Pool" {
+			reclaimable = fmt.Sprintf("%s (%d%%)", meta.NewSizeFromBytes(s.reclaimable), percentage(s.reclaimable, s.size))
+		}
+
+		o.Write(s.kind, s.total, s.active, meta.NewSizeFromBytes(s.size), reclaimable)
+	}
+	o.Flush()
+
+	if !do.Verbose {
+		return nil
+	}
+
+	fmt.Println("\nImages space usage:")
+	o = util.NewOutput()
+	o.Write("IMAGE ID", "NAME", "SIZE", "ALLOCATED", "VMS", "RECLAIMABLE")
+	for _, u := range du.Images {
+		o.Write(u.ID, u.Name, meta.NewSizeFromBytes(u.Size), meta.NewSizeFromBytes(u.Allocated), u.VMs, u.Reclaimable)
+	}
+	o.Flush()
+
+	fmt.Println("\nKernels space usage:")
+	o = util.NewOutput()
+	o.Write("KERNEL ID", "NAME", "VMLINUX", "KERNEL.TAR", "VMS", "RECLAIMABLE")
+	for _, u := range du.Kernels {
+		o.Write(u.ID, u.Name, meta.NewSizeFromBytes(u.KernelSize), meta.NewSizeFromBytes(u.TarSize), u.VMs, u.Reclaimable)
+	}
+	o.Flush()
+
+	fmt.Println("\nVMs space usage:")
+	o = util.NewOutput()
+	o.Write("VM ID", "NAME", "RUNNING", "STORAGE DRIVER", "ALLOCATED", "SIZE", "SNAPSHOT FILL")
+	for _, u := range du.VMs {
+		fill := "-"
+		if u.SnapshotInvalid {
+			fill = "invalid"
+		} else if u.SnapshotFillPercentage != nil {
+			fill = fmt.Sprintf("%.2f%%", *u.SnapshotFillPercentage)
+		}
+
+		o.Write(u.ID, u.Name, u.Running, u.StorageDriver, meta.NewSizeFromBytes(u.Allocated), meta.NewSizeFromBytes(u.Size), fill)
+	}
+	o.Flush()
+
+	if du.Pool == nil {
+		return nil
+	}
+
+	fmt.Println("\nPool space usage:")
+	o = util.NewOutput()
+	o.Write("DEVICES", "DATA", "METADATA", "ALLOCATED", "MODE")
+	data, metadata, mode := "-", "-", "inactive"
+	if du.Pool.Active {
+		data = fmt.Sprintf("%s/%s (%d%%)", meta.NewSizeFromBytes(du.Pool.UsedData), meta.NewSizeFromBytes(du.Pool.DataSize), percentage(du.Pool.UsedData, du.Pool.DataSize))
+		metadata = fmt.Sprintf("%s/%s (%d%%)", meta.NewSizeFromBytes(du.Pool.UsedMetadata), meta.NewSizeFromBytes(du.Pool.MetadataSize), percentage(du.Pool.UsedMetadata, du.Pool.MetadataSize))
+		mode = du.Pool.Mode
+	}
+	o.Write(du.Pool.Devices, data, metadata, meta.NewSizeFromBytes(du.Pool.Allocated), mode)
+	o.Flush()
+
+	return nil
+}
+
+// summarizeDiskUsage sums up the disk usage per type of resources. The sizes are the space allocated on disk,
+// the apparent size of the overlays of VMs is their disk size. Images and kernels no VM uses are reclaimable.
+// The disks of VMs in the pool of the thinpool storage driver count towards the pool, its active devices are
+// the disks of running VMs.
+func summarizeDiskUsage(du *operations.DiskUsage) []*diskUsageSummary {
+	images := &diskUsageSummary{kind: "Images", total: len(du.Images)}
+	for _, u := range du.Images {
+		images.size += u.Allocated
+		if u.Reclaimable {
+			images.reclaimable += u.Allocated
+		} else {
+			images.active++
+		}
+	}
+
+	kernels := &diskUsageSummary{kind: "Kernels", total: len(du.Kernels)}
+	for _, u := range du.Kernels {
+		kernels.size += u.KernelSize + u.TarSize
+		if u.Reclaimable {
+			kernels.reclaimable += u.KernelSize + u.TarSize
+		} else {
+			kernels.active++
+		}
+	}
+
+	vms := &diskUsageSummary{kind: "VMs", total: len(du.VMs)}
+	pool := &diskUsageSummary{kind: "Pool"}
+	for _, u := range du.VMs {
+		// The disks of thin devices are stored in the pool
+		if u.StorageDriver == api.StorageDriverThinPool {
+			if u.Running {
+				pool.active++
+			}
+		} else {
+			vms.size += u.Allocated
+		}
+
+		if u.Running {
+			vms.active++
+		}
+	}
+
+	if du.Pool == nil {
+		return []*diskUsageSummary{images, kernels, vms}
+	}
+
+	pool.total = du.Pool.Devices
+	pool.size = du.Pool.Allocated
+	return []*diskUsageSummary{images, kernels, vms, pool}
+}
+
+// percentage returns the rounded down percentage of part in total
+func percentage(part, total uint64) uint64 {
+	if total == 0 {
+		return 0
+	}
+
+	return part * 100 / total
+}
@@ -0,0 +1,97 @@
+package run
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"gotest.tools/assert"
+)
+
+func TestNewPruneOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    PruneFlags
+		expected []operations.PruneClass
+		err      bool
+	}{
+		{
+			name:     "leaked resources by default",
+			expected: operations.LeakedPruneClasses(),
+		},
+		{
+			name:     "all",
+			flags:    PruneFlags{All: true},
+			expected: operations.PruneClasses(),
+		},
+		{
+			name:     "selected classes",
+			flags:    PruneFlags{Classes: []string{"images", "devices"}},
+			expected: []operations.PruneClass{operations.PruneImages, operations.PruneDevices},
+		},
+		{
+			name:  "unknown class",
+			flags: PruneFlags{Classes: []string{"volumes"}},
+			err:   true,
+		},
+		{
+			name:  "all with classes",
+			flags: PruneFlags{All: true, Classes: []string{"images"}},
+			err:   true,
+		},
+	}
+
+	for _, rt := range tests {
+		t.Run(rt.name, func(t *testing.T) {
+			po, err := rt.flags.NewPruneOptions()
+			if rt.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, po.classes, rt.expected)
+		})
+	}
+}
+
+func TestSummarizeDiskUsage(t *testing.T) {
+	du := &operations.DiskUsage{
+		Images: []*operations.ImageDiskUsage{
+			{Name: "weaveworks/ignite-ubuntu", Size: 4096, Allocated: 3000, VMs: 2},
+			{Name: "weaveworks/ignite-centos", Size: 4096, Allocated: 1000, Reclaimable: true},
+		},
+		Kernels: []*operations.KernelDiskUsage{
+			{Name: "weaveworks/ignite-kernel", KernelSize: 300, TarSize: 700, VMs: 2},
+		},
+		VMs: []*operations.VMDiskUsage{
+			{Name: "running", Size: 4096, Allocated: 512, Running: true},
+			{Name: "stopped", Size: 4096, Allocated: 256},
+		},
+	}
+
+	expected := []*diskUsageSummary{
+		{kind: "Images", total: 2, active: 1, size: 4000, reclaimable: 1000},
+		{kind: "Kernels", total: 1, active: 1, size: 1000},
+		{kind: "VMs", total: 2, active: 1, size: 768},
+	}
+
+	actual := summarizeDiskUsage(du)
+	assert.Assert(t, reflect.DeepEqual(actual, expected), "expected %+v, got %+v", expected, actual)
+	assert.Equal(t, percentage(1000, 4000), uint64(25))
+
+	// The disks of VMs stored in the pool count towards the pool
+	du.VMs = append(du.VMs,
+		&operations.VMDiskUsage{Name: "thin-running", Size: 4096, Allocated: 2048, Running: true, StorageDriver: api.StorageDriverThinPool},
+		&operations.VMDiskUsage{Name: "thin-stopped", Size: 4096, Allocated: 1024, StorageDriver: api.StorageDriverThinPool},
+	)
+	du.Pool = &operations.PoolDiskUsage{Devices: 4, Allocated: 8192}
+
+	expected[2] = &diskUsageSummary{kind: "VMs", total: 4, active: 2, size: 768}
+	expected = append(expected, &diskUsageSummary{kind: "Pool", total: 4, active: 1, size: 8192})
+
+	actual = summarizeDiskUsage(du)
+	assert.Assert(t, reflect.DeepEqual(actual, expected), "expected %+v, got %+v", expected, actual)
+	assert.Equal(t, percentage(0, 0), uint64(0))
+}
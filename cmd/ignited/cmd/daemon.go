@@ -6,14 +6,28 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/weaveworks/ignite/pkg/operations/reconcile"
+	"github.com/weaveworks/ignite/pkg/providers"
 	"github.com/weaveworks/ignite/pkg/providers/manifeststorage"
+	"github.com/weaveworks/ignite/pkg/resolver"
 )
 
+type daemonFlags struct {
+	recovery reconcile.RecoveryOptions
+}
+
 func NewCmdDaemon(out io.Writer) *cobra.Command {
+	f := &daemonFlags{
+		recovery: reconcile.RecoveryOptions{
+			Parallelism: 4,
+			Interval:    time.Second,
+		},
+	}
 	cmd := &cobra.Command{
 		Use:   "daemon",
 		Short: "Operates in daemon mode and watches /etc/firecracker/manifests for VM specifications to run.", // TODO: Parameterize
@@ -28,10 +42,23 @@ func NewCmdDaemon(out io.Writer) *cobra.Command {
 			ms := manifeststorage.ManifestStorage
 
 			go func() {
+				// Bring the VMs back to their desired state, e.g. after a host reboot, before reconciling any changes
+				log.Infof("Recovering VMs...")
+				reconcile.RecoverVMs(f.recovery)
+
 				log.Infof("Starting reconciliation loop...")
 				reconcile.ReconcileManifests(ms)
 			}()
 
+			// Serve the embedded DNS resolver on the bridges of the VMs, if enabled
+			stopDNS := make(chan struct{})
+			if providers.EmbeddedDNS() {
+				go func() {
+					log.Infof("Starting embedded DNS resolver...")
+					resolver.New(providers.Client).Run(stopDNS)
+				}()
+			}
+
 			go func() {
 				<-signalChannel
 				endWaiter.Done()
@@ -41,9 +68,16 @@ func NewCmdDaemon(out io.Writer) *cobra.Command {
 
 			// Close the Storage's watcher threads
 			fmt.Println("Closing...")
+			close(stopDNS)
 			ms.Close()
 		},
 	}
 
+	addDaemonFlags(cmd.Flags(), f)
 	return cmd
 }
+
+func addDaemonFlags(fs *pflag.FlagSet, f *daemonFlags) {
+	fs.IntVar(&f.recovery.Parallelism, "recovery-parallelism", f.recovery.Parallelism, "How many VMs to restart at once on startup, 0 disables restarting VMs")
+	fs.DurationVar(&f.recovery.Interval, "recovery-interval", f.recovery.Interval, "Minimum time between restarting two VMs on startup")
+}
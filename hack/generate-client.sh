@@ -3,7 +3,7 @@
 SCRIPT_DIR=$( dirname "${BASH_SOURCE[0]}" )
 cd ${SCRIPT_DIR}/..
 
-Resources="VM Image Kernel"
+Resources="VM Image Kernel Network"
 for Resource in ${Resources}; do
     resource=$(echo "${Resource}" | awk '{print tolower($0)}')
     sed -e "s|Resource|${Resource}|g;s|resource|${resource}|g;/build ignore/d" \
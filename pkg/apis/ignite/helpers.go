@@ -1,8 +1,14 @@
 package ignite
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"net"
 	"path"
+	"sort"
+	"strings"
 
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/util"
 )
@@ -45,12 +51,181 @@ func (vm *VM) Running() bool {
 	return vm.Status.Running
 }
 
+// Ports returns the port mappings of the VM. For a running VM, these
+// include the host ports that have been allocated when it was started.
+func (vm *VM) Ports() meta.PortMappings {
+	if vm.Status.Network != nil && len(vm.Status.Network.Ports) > 0 {
+		return vm.Status.Network.Ports
+	}
+	return vm.Spec.Network.Ports
+}
+
+// Interface returns the spec of the sandbox interface with the given name, or nil if it isn't defined
+func (vm *VM) Interface(name string) *NetworkInterface {
+	for i := range vm.Spec.Network.Interfaces {
+		if vm.Spec.Network.Interfaces[i].Name == name {
+			return &vm.Spec.Network.Interfaces[i]
+		}
+	}
+
+	return nil
+}
+
+// SetInterfaceAddress sets the static address of the sandbox interface with the given name
+func (vm *VM) SetInterfaceAddress(name string, address *InterfaceAddress) {
+	if intf := vm.Interface(name); intf != nil {
+		intf.Address = address
+		return
+	}
+
+	vm.Spec.Network.Interfaces = append(vm.Spec.Network.Interfaces, NetworkInterface{
+		Name:    name,
+		Address: address,
+	})
+}
+
+// MACAddress returns the MAC address of the VM for the sandbox interface with the given name. Unless it's set
+// in the spec, it's derived from the UID of the VM and the interface name, so the guest sees the same MAC
+// address on every start and e.g. its udev interface naming stays stable.
+func (vm *VM) MACAddress(name string) string {
+	if intf := vm.Interface(name); intf != nil && len(intf.MACAddress) > 0 {
+		return intf.MACAddress
+	}
+
+	sum := sha256.Sum256([]byte(vm.GetUID().String() + "/" + name))
+	// Set local bit, ensure unicast address
+	sum[0] = (sum[0] | 2) & 0xfe
+
+	return net.HardwareAddr(sum[:6]).String()
+}
+
+// StaticIP returns the static IP address of the main interface of the VM, or nil if it isn't set
+func (vm *VM) StaticIP() net.IP {
+	if intf := vm.Interface(constants.IGNITE_SPAWN_MAIN_INTERFACE); intf != nil && intf.Address != nil {
+		if ip, _, err := intf.Address.Parse(); err == nil {
+			return ip
+		}
+	}
+
+	return nil
+}
+
+// MigrateInterfaceAnnotations converts the legacy interface annotations (ignite.weave.works/interface/<name>: <mode>)
+// of VMs stored by earlier versions to interfaces in the spec. Unrecognized modes are converted as well, for validation
+// to report them. Interfaces defined in the spec take precedence. The annotations and interfaces are replaced instead of
+// modified, as the conversion functions calling this share them with the object they convert from.
+func (vm *VM) MigrateInterfaceAnnotations() {
+	var names []string
+	annotations := make(map[string]string, len(vm.Annotations))
+	for key, value := range vm.Annotations {
+		if strings.HasPrefix(key, constants.IGNITE_INTERFACE_ANNOTATION) {
+			names = append(names, key)
+		} else {
+			annotations[key] = value
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	interfaces := append([]NetworkInterface(nil), vm.Spec.Network.Interfaces...)
+	for _, key := range names {
+		name := strings.TrimPrefix(key, constants.IGNITE_INTERFACE_ANNOTATION)
+		if name == "" || vm.Interface(name) != nil {
+			continue
+		}
+
+		interfaces = append(interfaces, NetworkInterface{
+			Name: name,
+			Mode: InterfaceMode(vm.Annotations[key]),
+		})
+	}
+
+	vm.Spec.Network.Interfaces = interfaces
+	vm.Annotations = annotations
+}
+
+// Parse returns the IP address, and its prefix length as a mask if given in CIDR notation
+func (a *InterfaceAddress) Parse() (net.IP, net.IPMask, error) {
+	if strings.Contains(a.IP, "/") {
+		ip, ipNet, err := net.ParseCIDR(a.IP)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+
+		return ip, ipNet.Mask, nil
+	}
+
+	ip := net.ParseIP(a.IP)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("invalid IP address: %s", a.IP)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	return ip, nil, nil
+}
+
+// IsPassthrough returns true if the interface is created on a host device instead of the sandbox network
+func (m InterfaceMode) IsPassthrough() bool {
+	return m == InterfaceModeMacvlan || m == InterfaceModeIPVLAN
+}
+
+// AttachedNetworks returns the amount of networks the sandbox of the VM is attached to by the network plugin,
+// the sandbox interfaces eth0, eth1 etc. are attached to them in order
+func (vm *VM) AttachedNetworks() int {
+	if n := len(vm.Spec.Network.Networks) + len(vm.Spec.Network.CNINetworks); n > 0 {
+		return n
+	}
+
+	return 1
+}
+
+// InterfaceNetwork returns the name of the network the given interface of the VM is attached to. The sandbox
+// interfaces eth0, eth1 etc. are attached to the user-defined networks of the VM in order, without user-defined
+// networks eth0 is attached to the default network. An empty string is returned for all other interfaces,
+// and for the networks of the CNI configuration directory.
+func (vm *VM) InterfaceNetwork(name string) string {
+	if len(vm.Spec.Network.CNINetworks) > 0 {
+		return ""
+	}
+
+	if len(vm.Spec.Network.Networks) == 0 {
+		if name == "eth0" {
+			return constants.DEFAULT_NETWORK_NAME
+		}
+		return ""
+	}
+
+	for i, network := range vm.Spec.Network.Networks {
+		if name == fmt.Sprintf("eth%d", i) {
+			return network
+		}
+	}
+
+	return ""
+}
+
 // OverlayFile returns the path to the overlay.dm file for the VM.
 // TODO: This will be removed once we have the new snapshotter in place.
 func (vm *VM) OverlayFile() string {
 	return path.Join(vm.ObjectPath(), constants.OVERLAY_FILE)
 }
 
+// DiskFile returns the path to the disk.ext4 file of the VM, which is
+// a clone of the image file made by the reflink storage driver
+func (vm *VM) DiskFile() string {
+	return path.Join(vm.ObjectPath(), constants.DISK_FILE)
+}
+
 // ObjectPath returns the directory where this VM's data is stored
 func (vm *VM) ObjectPath() string {
 	// TODO: Move this into storage
@@ -68,3 +243,19 @@ func (k *Kernel) ObjectPath() string {
 	// TODO: Move this into storage
 	return path.Join(constants.DATA_DIR, k.GetKind().Lower(), k.GetUID().String())
 }
+
+// ObjectPath returns the directory where this Network's data is stored
+func (n *Network) ObjectPath() string {
+	// TODO: Move this into storage
+	return path.Join(constants.DATA_DIR, n.GetKind().Lower(), n.GetUID().String())
+}
+
+// ConfListPath returns the path of the CNI configuration list generated for this Network
+func (n *Network) ConfListPath() string {
+	return path.Join(n.ObjectPath(), constants.NETWORK_CONFLIST)
+}
+
+// CNIName returns the name of the CNI network of this Network, it shows up in e.g. iptables comments
+func (n *Network) CNIName() string {
+	return constants.NETWORK_NAME_PREFIX + n.GetName()
+}
@@ -31,6 +31,7 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&VM{},
 		&Kernel{},
+		&Network{},
 		&Pool{},
 		&Image{},
 		&Configuration{},
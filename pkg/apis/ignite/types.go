@@ -1,16 +1,20 @@
 package ignite
 
 import (
+	"net"
+
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	igniteNetwork "github.com/weaveworks/ignite/pkg/network"
 	igniteRuntime "github.com/weaveworks/ignite/pkg/runtime"
 	"github.com/weaveworks/libgitops/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	KindImage  runtime.Kind = "Image"
-	KindKernel runtime.Kind = "Kernel"
-	KindVM     runtime.Kind = "VM"
+	KindImage   runtime.Kind = "Image"
+	KindKernel  runtime.Kind = "Kernel"
+	KindNetwork runtime.Kind = "Network"
+	KindVM      runtime.Kind = "VM"
 )
 
 // Image represents a cached OCI image ready to be used with Ignite
@@ -129,6 +133,35 @@ type KernelStatus struct {
 	OCISource OCIImageSource `json:"ociSource"`
 }
 
+// Network is a user-defined network VMs can be attached to, it's set up by
+// the CNI network plugin using a CNI configuration generated from its spec
+// These files are stored in /var/lib/firecracker/network/{network-id}/metadata.json
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type Network struct {
+	runtime.TypeMeta `json:",inline"`
+	// runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID
+	// Name is available at the .metadata.name JSON path
+	// ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)
+	runtime.ObjectMeta `json:"metadata"`
+
+	Spec NetworkSpec `json:"spec"`
+}
+
+// NetworkSpec describes the configuration of a network
+type NetworkSpec struct {
+	// Subnet is the IPv4 or IPv6 subnet the addresses of the VMs are allocated from, in CIDR notation
+	Subnet string `json:"subnet"`
+	// Gateway is the address of the bridge in the subnet, defaults to the first address of the subnet
+	Gateway net.IP `json:"gateway,omitempty"`
+	// BridgeName is the name of the bridge device on the host, it needs to start with "ignite-". Defaults to "ignite-"
+	// and the first characters of the ID
+	BridgeName string `json:"bridgeName,omitempty"`
+	// Masquerade masquerades the traffic leaving the network, which gives the VMs outbound connectivity
+	Masquerade bool `json:"masquerade,omitempty"`
+	// Isolated drops all traffic forwarded between the network and the other networks of ignite
+	Isolated bool `json:"isolated,omitempty"`
+}
+
 // VM represents a virtual machine run by Firecracker
 // These files are stored in /var/lib/firecracker/vm/{vm-id}/metadata.json
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -167,8 +200,21 @@ type VMSpec struct {
 	// If SSH.PublicKey is set, this struct will marshal as a string using that path
 	// If SSH.Generate is set, this struct will marshal as a bool => true
 	SSH *SSH `json:"ssh,omitempty"`
+	// RestartPolicy decides if ignited restarts the VM when it starts, e.g. after a host reboot.
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
 }
 
+// RestartPolicy decides if ignited restarts a VM when it starts. VMs without a restart policy
+// are restarted if they're marked running, which is their desired state in ignited's manifests.
+type RestartPolicy string
+
+const (
+	// RestartPolicyNo never restarts the VM, it's marked stopped
+	RestartPolicyNo RestartPolicy = "no"
+	// RestartPolicyAlways restarts the VM even if it's stopped
+	RestartPolicyAlways RestartPolicy = "always"
+)
+
 type VMImageSpec struct {
 	OCI meta.OCIImageRef `json:"oci"`
 }
@@ -185,6 +231,149 @@ type VMSandboxSpec struct {
 
 type VMNetworkSpec struct {
 	Ports meta.PortMappings `json:"ports,omitempty"`
+	// Interfaces configures the interfaces of the sandbox that are passed to the VM
+	Interfaces []NetworkInterface `json:"interfaces,omitempty"`
+	// StickyIP keeps the IP address allocated for the main interface on the first
+	// start of the VM, by recording it as the static address of the interface
+	StickyIP bool `json:"stickyIP,omitempty"`
+	// Networks are the names of the user-defined networks the VM is attached to. The VM gets an
+	// interface for each of them, the first one is the main interface providing the default route.
+	// The default network of the network plugin is used if unset.
+	Networks []string `json:"networks,omitempty"`
+	// CNINetworks are the networks of the CNI configuration directory the VM is attached to, with the cni
+	// network plugin. Like with Networks, the VM gets an interface for each of them, the first one is the
+	// main interface. The first network of the directory is used if unset. Can't be combined with Networks.
+	CNINetworks []CNINetworkAttachment `json:"cniNetworks,omitempty"`
+	// DHCP overrides the options the DHCP server of ignite-spawn hands out to the VM
+	DHCP *DHCPOptions `json:"dhcp,omitempty"`
+	// Policy restricts the traffic of the VM, all traffic is allowed if unset
+	Policy *NetworkPolicy `json:"policy,omitempty"`
+}
+
+// CNINetworkAttachment attaches a VM to a network of the CNI configuration directory
+type CNINetworkAttachment struct {
+	// Name is the name of the network, as given in its configuration
+	Name string `json:"name"`
+	// Args are passed to the plugins of the network as CNI_ARGS
+	Args map[string]string `json:"args,omitempty"`
+	// Bandwidth limits the traffic of the interface, for plugins supporting the "bandwidth" capability
+	Bandwidth *CNIBandwidth `json:"bandwidth,omitempty"`
+	// IPs are the addresses in CIDR notation requested for the interface, for plugins supporting
+	// the "ips" capability. The static IP of the VM is requested for the main interface if unset.
+	IPs []string `json:"ips,omitempty"`
+	// MAC is the MAC address requested for the sandbox interface, for plugins supporting the "mac" capability
+	MAC string `json:"mac,omitempty"`
+}
+
+// CNIBandwidth configures the "bandwidth" capability of CNI, the rates are in bits per second and the bursts in bits
+type CNIBandwidth struct {
+	IngressRate  uint64 `json:"ingressRate,omitempty"`
+	IngressBurst uint64 `json:"ingressBurst,omitempty"`
+	EgressRate   uint64 `json:"egressRate,omitempty"`
+	EgressBurst  uint64 `json:"egressBurst,omitempty"`
+}
+
+// DHCPOptions configures the options handed out to the VM for its dhcp-bridge interfaces
+type DHCPOptions struct {
+	// NameServers replace the DNS servers of the sandbox, and the embedded DNS resolver
+	NameServers meta.IPAddresses `json:"nameServers,omitempty"`
+	// SearchDomains are the DNS search domains of the VM (option 119)
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	// NTPServers are the IPv4 addresses of the NTP servers of the VM (option 42)
+	NTPServers meta.IPAddresses `json:"ntpServers,omitempty"`
+	// DomainName is the domain name of the VM (option 15), it overrides the domain of the network of the embedded DNS
+	DomainName string `json:"domainName,omitempty"`
+	// MTU is the MTU of the interfaces that don't set their own, defaults to the MTU of the sandbox interfaces
+	MTU uint32 `json:"mtu,omitempty"`
+	// Routes are classless static routes (option 121), handed out for the interface their gateway is reachable on
+	Routes []DHCPRoute `json:"routes,omitempty"`
+	// LeaseDuration is the duration of the leases, they're infinite by default
+	LeaseDuration *metav1.Duration `json:"leaseDuration,omitempty"`
+}
+
+// DHCPRoute is a classless static IPv4 route handed out to the VM
+type DHCPRoute struct {
+	// Destination is the destination subnet of the route in CIDR notation
+	Destination string `json:"destination"`
+	// Gateway is the next hop of the route, it needs to be in the subnet of an interface of the VM
+	Gateway net.IP `json:"gateway"`
+}
+
+// NetworkPolicy restricts the traffic of the dhcp-bridge interfaces of a VM to allow lists, it's enforced
+// in the sandbox of the VM. Replies to allowed connections, ARP and IPv6 neighbor discovery are always allowed.
+type NetworkPolicy struct {
+	// Ingress restricts the connections to the VM, they're all allowed if unset
+	Ingress *NetworkPolicyRules `json:"ingress,omitempty"`
+	// Egress restricts the connections from the VM, they're all allowed if unset
+	Egress *NetworkPolicyRules `json:"egress,omitempty"`
+}
+
+// NetworkPolicyRules lists the allowed connections of one direction
+type NetworkPolicyRules struct {
+	// Allow lists the allowed connections, all other connections are dropped
+	Allow []NetworkPolicyRule `json:"allow,omitempty"`
+}
+
+// NetworkPolicyRule allows the connections matching both its peers and its ports
+type NetworkPolicyRule struct {
+	// CIDRs are the subnets of the peers, the sources for ingress and the destinations
+	// for egress. All peers are allowed if unset.
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Ports are the destination ports, all ports and protocols are allowed if unset
+	Ports []NetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// NetworkPolicyPort is a destination port, or a range of ports, of a NetworkPolicyRule
+type NetworkPolicyPort struct {
+	// Protocol is the protocol of the port, tcp or udp. Defaults to tcp.
+	Protocol meta.Protocol `json:"protocol,omitempty"`
+	// Port is the destination port, or the first port of the range
+	Port uint16 `json:"port"`
+	// EndPort is the last port of the range, if any
+	EndPort uint16 `json:"endPort,omitempty"`
+}
+
+// NetworkInterface defines an interface of the sandbox that is passed to the VM
+type NetworkInterface struct {
+	// Name is the name of the interface in the sandbox, e.g. eth0
+	Name string `json:"name"`
+	// Mode defines how the interface is passed to the VM, defaults to dhcp-bridge
+	Mode InterfaceMode `json:"mode,omitempty"`
+	// MACAddress is the MAC address of the interface in the VM. For dhcp-bridge interfaces it's derived from
+	// the VM UID and the interface name by default, tc-redirect interfaces use the sandbox interface's one.
+	MACAddress string `json:"macAddress,omitempty"`
+	// MTU is the MTU of the interface, defaults to the MTU of the sandbox interface
+	MTU uint32 `json:"mtu,omitempty"`
+	// GuestName is the name of the interface in the VM, it's kept by the kernel if unset
+	GuestName string `json:"guestName,omitempty"`
+	// Address is a static IP address given to the VM for the interface
+	Address *InterfaceAddress `json:"address,omitempty"`
+	// Parent is the host device the interface is created on for the macvlan and ipvlan modes.
+	// If unset, the device is expected to be created in the sandbox by another system.
+	Parent string `json:"parent,omitempty"`
+}
+
+// InterfaceMode defines how a sandbox interface is passed to the VM
+type InterfaceMode string
+
+const (
+	// InterfaceModeDHCPBridge bridges the sandbox interface to the VM, and hands its address to the VM using DHCP
+	InterfaceModeDHCPBridge InterfaceMode = "dhcp-bridge"
+	// InterfaceModeTCRedirect redirects all traffic between the sandbox interface and the VM using tc
+	InterfaceModeTCRedirect InterfaceMode = "tc-redirect"
+	// InterfaceModeMacvlan creates a macvlan device with its own MAC address on the parent host device for the VM
+	InterfaceModeMacvlan InterfaceMode = "macvlan"
+	// InterfaceModeIPVLAN creates an ipvlan device sharing the MAC address of the parent host device for the VM
+	InterfaceModeIPVLAN InterfaceMode = "ipvlan"
+)
+
+// InterfaceAddress defines a static IP address of an interface
+type InterfaceAddress struct {
+	// IP is the IP address, optionally with a prefix length in CIDR notation (e.g. 10.61.0.10/16).
+	// Without a prefix length, the prefix length of the address given to the sandbox is used.
+	IP string `json:"ip"`
+	// Gateway overrides the gateway given to the sandbox by the network plugin
+	Gateway net.IP `json:"gateway,omitempty"`
 }
 
 // VMStorageSpec defines the VM's Volumes and VolumeMounts
@@ -231,21 +420,37 @@ type Runtime struct {
 	Name igniteRuntime.Name `json:"name"`
 }
 
-// Network specifies the VM's network information.
-type Network struct {
+// VMNetworkStatus specifies the VM's network information.
+type VMNetworkStatus struct {
 	Plugin      igniteNetwork.PluginName `json:"plugin"`
 	IPAddresses meta.IPAddresses         `json:"ipAddresses"`
+	// Ports are the port mappings of the running VM, with the allocated host ports
+	Ports meta.PortMappings `json:"ports,omitempty"`
+	// Networks are the results of attaching the sandbox of the VM to its networks, in the order of its interfaces
+	Networks []NetworkAttachmentStatus `json:"networks,omitempty"`
+}
+
+// NetworkAttachmentStatus is the result of attaching the sandbox of a VM to a network
+type NetworkAttachmentStatus struct {
+	// Name is the name of the network, as given in its CNI configuration
+	Name string `json:"name"`
+	// Interface is the name of the sandbox interface attached to the network
+	Interface string `json:"interface"`
+	// MAC is the MAC address of the sandbox interface
+	MAC string `json:"mac,omitempty"`
+	// IPAddresses are the addresses allocated for the interface
+	IPAddresses meta.IPAddresses `json:"ipAddresses,omitempty"`
 }
 
 // VMStatus defines the status of a VM
 type VMStatus struct {
-	Running   bool           `json:"running"`
-	Runtime   *Runtime       `json:"runtime,omitempty"`
-	StartTime *runtime.Time  `json:"startTime,omitempty"`
-	Network   *Network       `json:"network,omitempty"`
-	Image     OCIImageSource `json:"image"`
-	Kernel    OCIImageSource `json:"kernel"`
-	IDPrefix  string         `json:"idPrefix"`
+	Running   bool             `json:"running"`
+	Runtime   *Runtime         `json:"runtime,omitempty"`
+	StartTime *runtime.Time    `json:"startTime,omitempty"`
+	Network   *VMNetworkStatus `json:"network,omitempty"`
+	Image     OCIImageSource   `json:"image"`
+	Kernel    OCIImageSource   `json:"kernel"`
+	IDPrefix  string           `json:"idPrefix"`
 }
 
 // Configuration represents the ignite runtime configuration.
@@ -264,4 +469,29 @@ type ConfigurationSpec struct {
 	VMDefaults        VMSpec                   `json:"vmDefaults,omitempty"`
 	IDPrefix          string                   `json:"idPrefix,omitempty"`
 	RegistryConfigDir string                   `json:"registryConfigDir,omitempty"`
+	// EmbeddedDNS hands out the host bridge as the first DNS server to the VMs, ignited answers
+	// <vm-name>.<network>.ignite on it and forwards all other queries to the host's DNS servers
+	EmbeddedDNS bool `json:"embeddedDNS,omitempty"`
+	// CNIBinDirs are the directories of the CNI plugin binaries, defaults to /opt/cni/bin
+	CNIBinDirs []string `json:"cniBinDirs,omitempty"`
+	// CNIConfDir is the directory of the CNI network configurations, defaults to /etc/cni/net.d
+	CNIConfDir string `json:"cniConfDir,omitempty"`
+	// StorageDriver stores the disks of new VMs, defaults to legacy. VMs keep the driver they were created with.
+	StorageDriver StorageDriver `json:"storageDriver,omitempty"`
+	// ThinPool configures the pool of the thinpool storage driver when it's created
+	ThinPool *PoolSpec `json:"thinPool,omitempty"`
 }
+
+// StorageDriver stores the images, kernels and disks of VMs
+type StorageDriver string
+
+const (
+	// StorageDriverLegacy stores the disk of every VM in a sparse overlay file,
+	// on top of the image file with a device mapper snapshot
+	StorageDriverLegacy StorageDriver = "legacy"
+	// StorageDriverThinPool stores images, kernels and the disks of VMs as thin devices in a device mapper thin pool
+	StorageDriverThinPool StorageDriver = "thinpool"
+	// StorageDriverReflink stores the disk of every VM in a file cloned from the image file, which requires a
+	// data directory on a filesystem supporting reflinks like XFS or btrfs. The VM boots from the file directly.
+	StorageDriverReflink StorageDriver = "reflink"
+)
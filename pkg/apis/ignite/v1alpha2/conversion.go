@@ -17,7 +17,7 @@ func Convert_v1alpha2_VMStatus_To_ignite_VMStatus(in *VMStatus, out *ignite.VMSt
 	}
 
 	if out.Network == nil {
-		out.Network = &ignite.Network{}
+		out.Network = &ignite.VMNetworkStatus{}
 	}
 
 	// Set IPAddresses to the new position, under Network block.
@@ -37,3 +37,26 @@ func Convert_ignite_VMStatus_To_v1alpha2_VMStatus(in *ignite.VMStatus, out *VMSt
 
 	return nil
 }
+
+// Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec calls the autogenerated conversion function along with custom conversion logic
+func Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
+	// The interfaces, sticky IPs, networks, CNI networks, DHCP options and policies aren't part of v1alpha2, they're dropped
+	return autoConvert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(in, out, s)
+}
+
+// Convert_ignite_VMSpec_To_v1alpha2_VMSpec calls the autogenerated conversion function along with custom conversion logic
+func Convert_ignite_VMSpec_To_v1alpha2_VMSpec(in *ignite.VMSpec, out *VMSpec, s conversion.Scope) error {
+	// The restart policy isn't part of v1alpha2, it's dropped
+	return autoConvert_ignite_VMSpec_To_v1alpha2_VMSpec(in, out, s)
+}
+
+// Convert_v1alpha2_VM_To_ignite_VM calls the autogenerated conversion function along with custom conversion logic
+func Convert_v1alpha2_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
+	if err := autoConvert_v1alpha2_VM_To_ignite_VM(in, out, s); err != nil {
+		return err
+	}
+
+	// VMs may still carry the legacy interface annotations, they're converted to interfaces in the spec
+	out.MigrateInterfaceAnnotations()
+	return nil
+}
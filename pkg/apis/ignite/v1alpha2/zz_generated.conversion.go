@@ -167,11 +167,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*ignite.VM)(nil), (*VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_ignite_VM_To_v1alpha2_VM(a.(*ignite.VM), b.(*VM), scope)
 	}); err != nil {
@@ -202,11 +197,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*ignite.VMNetworkSpec)(nil), (*VMNetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(a.(*ignite.VMNetworkSpec), b.(*VMNetworkSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VMSandboxSpec)(nil), (*ignite.VMSandboxSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_VMSandboxSpec_To_ignite_VMSandboxSpec(a.(*VMSandboxSpec), b.(*ignite.VMSandboxSpec), scope)
 	}); err != nil {
@@ -222,11 +212,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*ignite.VMSpec)(nil), (*VMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_ignite_VMSpec_To_v1alpha2_VMSpec(a.(*ignite.VMSpec), b.(*VMSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VMStorageSpec)(nil), (*ignite.VMStorageSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_VMStorageSpec_To_ignite_VMStorageSpec(a.(*VMStorageSpec), b.(*ignite.VMStorageSpec), scope)
 	}); err != nil {
@@ -262,11 +247,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*ignite.VMNetworkSpec)(nil), (*VMNetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(a.(*ignite.VMNetworkSpec), b.(*VMNetworkSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ignite.VMSpec)(nil), (*VMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMSpec_To_v1alpha2_VMSpec(a.(*ignite.VMSpec), b.(*VMSpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*ignite.VMStatus)(nil), (*VMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_ignite_VMStatus_To_v1alpha2_VMStatus(a.(*ignite.VMStatus), b.(*VMStatus), scope)
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*VMStatus)(nil), (*ignite.VMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_VMStatus_To_ignite_VMStatus(a.(*VMStatus), b.(*ignite.VMStatus), scope)
 	}); err != nil {
@@ -653,11 +653,6 @@ func autoConvert_v1alpha2_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.S
 	return nil
 }
 
-// Convert_v1alpha2_VM_To_ignite_VM is an autogenerated conversion function.
-func Convert_v1alpha2_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
-	return autoConvert_v1alpha2_VM_To_ignite_VM(in, out, s)
-}
-
 func autoConvert_ignite_VM_To_v1alpha2_VM(in *ignite.VM, out *VM, s conversion.Scope) error {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
@@ -729,14 +724,15 @@ func Convert_v1alpha2_VMNetworkSpec_To_ignite_VMNetworkSpec(in *VMNetworkSpec, o
 
 func autoConvert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
 	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	// WARNING: in.Interfaces requires manual conversion: does not exist in peer-type
+	// WARNING: in.StickyIP requires manual conversion: does not exist in peer-type
+	// WARNING: in.Networks requires manual conversion: does not exist in peer-type
+	// WARNING: in.CNINetworks requires manual conversion: does not exist in peer-type
+	// WARNING: in.DHCP requires manual conversion: does not exist in peer-type
+	// WARNING: in.Policy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec is an autogenerated conversion function.
-func Convert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
-	return autoConvert_ignite_VMNetworkSpec_To_v1alpha2_VMNetworkSpec(in, out, s)
-}
-
 func autoConvert_v1alpha2_VMSandboxSpec_To_ignite_VMSandboxSpec(in *VMSandboxSpec, out *ignite.VMSandboxSpec, s conversion.Scope) error {
 	out.OCI = in.OCI
 	return nil
@@ -807,14 +803,10 @@ func autoConvert_ignite_VMSpec_To_v1alpha2_VMSpec(in *ignite.VMSpec, out *VMSpec
 	}
 	out.CopyFiles = *(*[]FileMapping)(unsafe.Pointer(&in.CopyFiles))
 	out.SSH = (*SSH)(unsafe.Pointer(in.SSH))
+	// WARNING: in.RestartPolicy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_ignite_VMSpec_To_v1alpha2_VMSpec is an autogenerated conversion function.
-func Convert_ignite_VMSpec_To_v1alpha2_VMSpec(in *ignite.VMSpec, out *VMSpec, s conversion.Scope) error {
-	return autoConvert_ignite_VMSpec_To_v1alpha2_VMSpec(in, out, s)
-}
-
 func autoConvert_v1alpha2_VMStatus_To_ignite_VMStatus(in *VMStatus, out *ignite.VMStatus, s conversion.Scope) error {
 	out.Running = in.Running
 	if in.Runtime != nil {
@@ -7,5 +7,45 @@ import (
 
 // Convert_ignite_ConfigurationSpec_To_v1alpha3_ConfigurationSpec calls the autogenerated conversion function along with custom conversion logic
 func Convert_ignite_ConfigurationSpec_To_v1alpha3_ConfigurationSpec(in *ignite.ConfigurationSpec, out *ConfigurationSpec, s conversion.Scope) error {
+	// The registry config dir, the embedded DNS, the CNI directories and the storage driver aren't part of v1alpha3, they're dropped
 	return autoConvert_ignite_ConfigurationSpec_To_v1alpha3_ConfigurationSpec(in, out, s)
 }
+
+// Convert_v1alpha3_Network_To_ignite_VMNetworkStatus converts the network status of v1alpha3, which is named
+// Network there. The internal Network type is the network kind, so the types aren't paired automatically.
+func Convert_v1alpha3_Network_To_ignite_VMNetworkStatus(in *Network, out *ignite.VMNetworkStatus, s conversion.Scope) error {
+	out.Plugin = in.Plugin
+	out.IPAddresses = in.IPAddresses
+	return nil
+}
+
+// Convert_ignite_VMNetworkStatus_To_v1alpha3_Network converts the network status to its v1alpha3 type
+func Convert_ignite_VMNetworkStatus_To_v1alpha3_Network(in *ignite.VMNetworkStatus, out *Network, s conversion.Scope) error {
+	// The allocated ports and the network results aren't part of v1alpha3, they're dropped
+	out.Plugin = in.Plugin
+	out.IPAddresses = in.IPAddresses
+	return nil
+}
+
+// Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec calls the autogenerated conversion function along with custom conversion logic
+func Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
+	// The interfaces, sticky IPs, networks, CNI networks, DHCP options and policies aren't part of v1alpha3, they're dropped
+	return autoConvert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(in, out, s)
+}
+
+// Convert_ignite_VMSpec_To_v1alpha3_VMSpec calls the autogenerated conversion function along with custom conversion logic
+func Convert_ignite_VMSpec_To_v1alpha3_VMSpec(in *ignite.VMSpec, out *VMSpec, s conversion.Scope) error {
+	// The restart policy isn't part of v1alpha3, it's dropped
+	return autoConvert_ignite_VMSpec_To_v1alpha3_VMSpec(in, out, s)
+}
+
+// Convert_v1alpha3_VM_To_ignite_VM calls the autogenerated conversion function along with custom conversion logic
+func Convert_v1alpha3_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
+	if err := autoConvert_v1alpha3_VM_To_ignite_VM(in, out, s); err != nil {
+		return err
+	}
+
+	// VMs may still carry the legacy interface annotations, they're converted to interfaces in the spec
+	out.MigrateInterfaceAnnotations()
+	return nil
+}
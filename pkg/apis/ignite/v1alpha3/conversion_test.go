@@ -0,0 +1,50 @@
+package v1alpha3
+
+import (
+	"net"
+	"testing"
+
+	"github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+
+	"gotest.tools/assert"
+)
+
+func TestConvertVMInterfaceAnnotations(t *testing.T) {
+	vm := &VM{}
+	vm.SetAnnotation("foo", "bar")
+	vm.SetAnnotation("ignite.weave.works/interface/eth0", "dhcp-bridge")
+	vm.SetAnnotation("ignite.weave.works/interface/eth1", "tc-redirect")
+
+	out := &ignite.VM{}
+	assert.NilError(t, Convert_v1alpha3_VM_To_ignite_VM(vm, out, nil))
+
+	// VMs stored as v1alpha3 get the interfaces of their annotations, without defaulting
+	assert.DeepEqual(t, out.Spec.Network.Interfaces, []ignite.NetworkInterface{
+		{Name: "eth0", Mode: ignite.InterfaceModeDHCPBridge},
+		{Name: "eth1", Mode: ignite.InterfaceModeTCRedirect},
+	})
+	assert.DeepEqual(t, out.Annotations, map[string]string{"foo": "bar"})
+	assert.Equal(t, len(vm.Annotations), 3)
+}
+
+func TestConvertVMNetworkStatus(t *testing.T) {
+	vm := &VM{}
+	vm.Status.Network = &Network{
+		Plugin:      "cni",
+		IPAddresses: meta.IPAddresses{net.ParseIP("10.61.0.2")},
+	}
+
+	out := &ignite.VM{}
+	assert.NilError(t, Convert_v1alpha3_VM_To_ignite_VM(vm, out, nil))
+	assert.DeepEqual(t, out.Status.Network, &ignite.VMNetworkStatus{
+		Plugin:      "cni",
+		IPAddresses: meta.IPAddresses{net.ParseIP("10.61.0.2")},
+	})
+
+	// The allocated ports are dropped when converting back
+	out.Status.Network.Ports = meta.PortMappings{{HostPort: 8080, VMPort: 80}}
+	back := &VM{}
+	assert.NilError(t, Convert_ignite_VM_To_v1alpha3_VM(out, back, nil))
+	assert.DeepEqual(t, back.Status.Network, vm.Status.Network)
+}
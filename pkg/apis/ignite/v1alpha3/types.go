@@ -232,6 +232,7 @@ type Runtime struct {
 }
 
 // Network specifies the VM's network information.
+// +k8s:conversion-gen=false
 type Network struct {
 	Plugin      igniteNetwork.PluginName `json:"plugin"`
 	IPAddresses meta.IPAddresses         `json:"ipAddresses"`
@@ -119,16 +119,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*Network)(nil), (*ignite.Network)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha3_Network_To_ignite_Network(a.(*Network), b.(*ignite.Network), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*ignite.Network)(nil), (*Network)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_ignite_Network_To_v1alpha3_Network(a.(*ignite.Network), b.(*Network), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*OCIImageSource)(nil), (*ignite.OCIImageSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_OCIImageSource_To_ignite_OCIImageSource(a.(*OCIImageSource), b.(*ignite.OCIImageSource), scope)
 	}); err != nil {
@@ -199,11 +189,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha3_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*ignite.VM)(nil), (*VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_ignite_VM_To_v1alpha3_VM(a.(*ignite.VM), b.(*VM), scope)
 	}); err != nil {
@@ -234,11 +219,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*ignite.VMNetworkSpec)(nil), (*VMNetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(a.(*ignite.VMNetworkSpec), b.(*VMNetworkSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VMSandboxSpec)(nil), (*ignite.VMSandboxSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VMSandboxSpec_To_ignite_VMSandboxSpec(a.(*VMSandboxSpec), b.(*ignite.VMSandboxSpec), scope)
 	}); err != nil {
@@ -254,11 +234,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*ignite.VMSpec)(nil), (*VMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_ignite_VMSpec_To_v1alpha3_VMSpec(a.(*ignite.VMSpec), b.(*VMSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*VMStatus)(nil), (*ignite.VMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_VMStatus_To_ignite_VMStatus(a.(*VMStatus), b.(*ignite.VMStatus), scope)
 	}); err != nil {
@@ -304,6 +279,31 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*ignite.VMNetworkSpec)(nil), (*VMNetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(a.(*ignite.VMNetworkSpec), b.(*VMNetworkSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ignite.VMNetworkStatus)(nil), (*Network)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMNetworkStatus_To_v1alpha3_Network(a.(*ignite.VMNetworkStatus), b.(*Network), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ignite.VMSpec)(nil), (*VMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMSpec_To_v1alpha3_VMSpec(a.(*ignite.VMSpec), b.(*VMSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*Network)(nil), (*ignite.VMNetworkStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_Network_To_ignite_VMNetworkStatus(a.(*Network), b.(*ignite.VMNetworkStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -378,6 +378,11 @@ func autoConvert_ignite_ConfigurationSpec_To_v1alpha3_ConfigurationSpec(in *igni
 	}
 	out.IDPrefix = in.IDPrefix
 	// WARNING: in.RegistryConfigDir requires manual conversion: does not exist in peer-type
+	// WARNING: in.EmbeddedDNS requires manual conversion: does not exist in peer-type
+	// WARNING: in.CNIBinDirs requires manual conversion: does not exist in peer-type
+	// WARNING: in.CNIConfDir requires manual conversion: does not exist in peer-type
+	// WARNING: in.StorageDriver requires manual conversion: does not exist in peer-type
+	// WARNING: in.ThinPool requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -561,28 +566,6 @@ func Convert_ignite_KernelStatus_To_v1alpha3_KernelStatus(in *ignite.KernelStatu
 	return autoConvert_ignite_KernelStatus_To_v1alpha3_KernelStatus(in, out, s)
 }
 
-func autoConvert_v1alpha3_Network_To_ignite_Network(in *Network, out *ignite.Network, s conversion.Scope) error {
-	out.Plugin = network.PluginName(in.Plugin)
-	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
-	return nil
-}
-
-// Convert_v1alpha3_Network_To_ignite_Network is an autogenerated conversion function.
-func Convert_v1alpha3_Network_To_ignite_Network(in *Network, out *ignite.Network, s conversion.Scope) error {
-	return autoConvert_v1alpha3_Network_To_ignite_Network(in, out, s)
-}
-
-func autoConvert_ignite_Network_To_v1alpha3_Network(in *ignite.Network, out *Network, s conversion.Scope) error {
-	out.Plugin = network.PluginName(in.Plugin)
-	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
-	return nil
-}
-
-// Convert_ignite_Network_To_v1alpha3_Network is an autogenerated conversion function.
-func Convert_ignite_Network_To_v1alpha3_Network(in *ignite.Network, out *Network, s conversion.Scope) error {
-	return autoConvert_ignite_Network_To_v1alpha3_Network(in, out, s)
-}
-
 func autoConvert_v1alpha3_OCIImageSource_To_ignite_OCIImageSource(in *OCIImageSource, out *ignite.OCIImageSource, s conversion.Scope) error {
 	out.ID = (*v1alpha1.OCIContentID)(unsafe.Pointer(in.ID))
 	out.Size = in.Size
@@ -767,11 +750,6 @@ func autoConvert_v1alpha3_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.S
 	return nil
 }
 
-// Convert_v1alpha3_VM_To_ignite_VM is an autogenerated conversion function.
-func Convert_v1alpha3_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
-	return autoConvert_v1alpha3_VM_To_ignite_VM(in, out, s)
-}
-
 func autoConvert_ignite_VM_To_v1alpha3_VM(in *ignite.VM, out *VM, s conversion.Scope) error {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
@@ -843,14 +821,15 @@ func Convert_v1alpha3_VMNetworkSpec_To_ignite_VMNetworkSpec(in *VMNetworkSpec, o
 
 func autoConvert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
 	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	// WARNING: in.Interfaces requires manual conversion: does not exist in peer-type
+	// WARNING: in.StickyIP requires manual conversion: does not exist in peer-type
+	// WARNING: in.Networks requires manual conversion: does not exist in peer-type
+	// WARNING: in.CNINetworks requires manual conversion: does not exist in peer-type
+	// WARNING: in.DHCP requires manual conversion: does not exist in peer-type
+	// WARNING: in.Policy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec is an autogenerated conversion function.
-func Convert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
-	return autoConvert_ignite_VMNetworkSpec_To_v1alpha3_VMNetworkSpec(in, out, s)
-}
-
 func autoConvert_v1alpha3_VMSandboxSpec_To_ignite_VMSandboxSpec(in *VMSandboxSpec, out *ignite.VMSandboxSpec, s conversion.Scope) error {
 	out.OCI = in.OCI
 	return nil
@@ -921,19 +900,23 @@ func autoConvert_ignite_VMSpec_To_v1alpha3_VMSpec(in *ignite.VMSpec, out *VMSpec
 	}
 	out.CopyFiles = *(*[]FileMapping)(unsafe.Pointer(&in.CopyFiles))
 	out.SSH = (*SSH)(unsafe.Pointer(in.SSH))
+	// WARNING: in.RestartPolicy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_ignite_VMSpec_To_v1alpha3_VMSpec is an autogenerated conversion function.
-func Convert_ignite_VMSpec_To_v1alpha3_VMSpec(in *ignite.VMSpec, out *VMSpec, s conversion.Scope) error {
-	return autoConvert_ignite_VMSpec_To_v1alpha3_VMSpec(in, out, s)
-}
-
 func autoConvert_v1alpha3_VMStatus_To_ignite_VMStatus(in *VMStatus, out *ignite.VMStatus, s conversion.Scope) error {
 	out.Running = in.Running
 	out.Runtime = (*ignite.Runtime)(unsafe.Pointer(in.Runtime))
 	out.StartTime = (*libgitopspkgruntime.Time)(unsafe.Pointer(in.StartTime))
-	out.Network = (*ignite.Network)(unsafe.Pointer(in.Network))
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(ignite.VMNetworkStatus)
+		if err := Convert_v1alpha3_Network_To_ignite_VMNetworkStatus(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Network = nil
+	}
 	if err := Convert_v1alpha3_OCIImageSource_To_ignite_OCIImageSource(&in.Image, &out.Image, s); err != nil {
 		return err
 	}
@@ -953,7 +936,15 @@ func autoConvert_ignite_VMStatus_To_v1alpha3_VMStatus(in *ignite.VMStatus, out *
 	out.Running = in.Running
 	out.Runtime = (*Runtime)(unsafe.Pointer(in.Runtime))
 	out.StartTime = (*libgitopspkgruntime.Time)(unsafe.Pointer(in.StartTime))
-	out.Network = (*Network)(unsafe.Pointer(in.Network))
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(Network)
+		if err := Convert_ignite_VMNetworkStatus_To_v1alpha3_Network(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Network = nil
+	}
 	if err := Convert_ignite_OCIImageSource_To_v1alpha3_OCIImageSource(&in.Image, &out.Image, s); err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+package v1alpha4
+
+import (
+	"github.com/weaveworks/ignite/pkg/apis/ignite"
+	"k8s.io/apimachinery/pkg/conversion"
+)
+
+// Convert_v1alpha4_VM_To_ignite_VM calls the autogenerated conversion function along with custom conversion logic
+func Convert_v1alpha4_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
+	if err := autoConvert_v1alpha4_VM_To_ignite_VM(in, out, s); err != nil {
+		return err
+	}
+
+	// VMs may still carry the legacy interface annotations, they're converted to interfaces in the spec
+	out.MigrateInterfaceAnnotations()
+	return nil
+}
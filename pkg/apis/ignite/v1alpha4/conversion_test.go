@@ -0,0 +1,101 @@
+package v1alpha4
+
+import (
+	"testing"
+
+	"github.com/weaveworks/ignite/pkg/apis/ignite"
+
+	"gotest.tools/assert"
+)
+
+func TestConvertVMInterfaceAnnotations(t *testing.T) {
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		intfs           []NetworkInterface
+		wantIntfs       []ignite.NetworkInterface
+		wantAnnotations map[string]string
+	}{
+		{
+			name: "empty object",
+		},
+		{
+			name: "wrong annotations",
+			annotations: map[string]string{
+				"foo":                                 "bar",
+				"ignite.weave.works/interface/":       "dhcp-bridge",
+				"ignite.weave.works/interface/eth123": "foo",
+			},
+			// Unrecognized modes are converted for validation to reject them
+			wantIntfs: []ignite.NetworkInterface{
+				{Name: "eth123", Mode: "foo"},
+			},
+			wantAnnotations: map[string]string{
+				"foo": "bar",
+			},
+		},
+		{
+			name: "many interfaces",
+			annotations: map[string]string{
+				"foo":                                 "bar",
+				"ignite.weave.works/interface/eth123": "tc-redirect",
+				"ignite.weave.works/interface/eth0":   "dhcp-bridge",
+			},
+			wantIntfs: []ignite.NetworkInterface{
+				{Name: "eth0", Mode: ignite.InterfaceModeDHCPBridge},
+				{Name: "eth123", Mode: ignite.InterfaceModeTCRedirect},
+			},
+			wantAnnotations: map[string]string{
+				"foo": "bar",
+			},
+		},
+		{
+			name: "interface defined in the spec",
+			annotations: map[string]string{
+				"ignite.weave.works/interface/eth1": "tc-redirect",
+			},
+			intfs: []NetworkInterface{
+				{Name: "eth1", MTU: 9000},
+			},
+			wantIntfs: []ignite.NetworkInterface{
+				{Name: "eth1", MTU: 9000},
+			},
+			wantAnnotations: map[string]string{},
+		},
+		{
+			name: "no interface annotations",
+			annotations: map[string]string{
+				"foo": "bar",
+			},
+			intfs: []NetworkInterface{
+				{Name: "eth1", MTU: 9000},
+			},
+			wantIntfs: []ignite.NetworkInterface{
+				{Name: "eth1", MTU: 9000},
+			},
+			wantAnnotations: map[string]string{
+				"foo": "bar",
+			},
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			vm := &VM{}
+			for k, v := range rt.annotations {
+				vm.SetAnnotation(k, v)
+			}
+			vm.Spec.Network.Interfaces = rt.intfs
+
+			in := vm.DeepCopy()
+			out := &ignite.VM{}
+			assert.NilError(t, Convert_v1alpha4_VM_To_ignite_VM(vm, out, nil))
+
+			assert.DeepEqual(t, out.Spec.Network.Interfaces, rt.wantIntfs)
+			assert.DeepEqual(t, out.Annotations, rt.wantAnnotations)
+			// The object converted from is left as is
+			assert.DeepEqual(t, vm.Annotations, in.Annotations)
+			assert.DeepEqual(t, vm.Spec.Network.Interfaces, in.Spec.Network.Interfaces)
+		})
+	}
+}
@@ -20,11 +20,11 @@ func SetDefaults_PoolSpec(obj *PoolSpec) {
 	}
 
 	if obj.DataSize == meta.EmptySize {
-		obj.AllocationSize = meta.NewSizeFromBytes(constants.POOL_DATA_SIZE_BYTES)
+		obj.DataSize = meta.NewSizeFromBytes(constants.POOL_DATA_SIZE_BYTES)
 	}
 
 	if obj.MetadataSize == meta.EmptySize {
-		obj.AllocationSize = calcMetadataDevSize(obj)
+		obj.MetadataSize = calcMetadataDevSize(obj)
 	}
 
 	if len(obj.MetadataPath) == 0 {
@@ -91,6 +91,6 @@ func SetDefaults_VMStatus(obj *VMStatus) {
 		obj.Runtime = &Runtime{}
 	}
 	if obj.Network == nil {
-		obj.Network = &Network{}
+		obj.Network = &VMNetworkStatus{}
 	}
 }
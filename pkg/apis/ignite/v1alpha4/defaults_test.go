@@ -0,0 +1,21 @@
+package v1alpha4
+
+import (
+	"testing"
+
+	"github.com/weaveworks/ignite/pkg/constants"
+
+	"gotest.tools/assert"
+)
+
+func TestSetDefaultsPoolSpec(t *testing.T) {
+	obj := &PoolSpec{}
+	SetDefaults_PoolSpec(obj)
+
+	assert.Equal(t, obj.AllocationSize.Bytes(), uint64(constants.POOL_ALLOCATION_SIZE_SECTORS*512))
+	assert.Equal(t, obj.DataSize.Bytes(), uint64(constants.POOL_DATA_SIZE_BYTES))
+	// 48 bytes of metadata per allocated block
+	assert.Equal(t, obj.MetadataSize.Bytes(), uint64(48*constants.POOL_DATA_SIZE_BYTES/(constants.POOL_ALLOCATION_SIZE_SECTORS*512)))
+	assert.Equal(t, obj.MetadataPath, constants.SNAPSHOTTER_METADATA_PATH)
+	assert.Equal(t, obj.DataPath, constants.SNAPSHOTTER_DATA_PATH)
+}
@@ -6,6 +6,7 @@
 package v1alpha4
 
 import (
+	net "net"
 	unsafe "unsafe"
 
 	ignite "github.com/weaveworks/ignite/pkg/apis/ignite"
@@ -13,6 +14,7 @@ import (
 	network "github.com/weaveworks/ignite/pkg/network"
 	pkgruntime "github.com/weaveworks/ignite/pkg/runtime"
 	libgitopspkgruntime "github.com/weaveworks/libgitops/pkg/runtime"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -34,6 +36,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*CNIBandwidth)(nil), (*ignite.CNIBandwidth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_CNIBandwidth_To_ignite_CNIBandwidth(a.(*CNIBandwidth), b.(*ignite.CNIBandwidth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.CNIBandwidth)(nil), (*CNIBandwidth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_CNIBandwidth_To_v1alpha4_CNIBandwidth(a.(*ignite.CNIBandwidth), b.(*CNIBandwidth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CNINetworkAttachment)(nil), (*ignite.CNINetworkAttachment)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_CNINetworkAttachment_To_ignite_CNINetworkAttachment(a.(*CNINetworkAttachment), b.(*ignite.CNINetworkAttachment), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.CNINetworkAttachment)(nil), (*CNINetworkAttachment)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_CNINetworkAttachment_To_v1alpha4_CNINetworkAttachment(a.(*ignite.CNINetworkAttachment), b.(*CNINetworkAttachment), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Configuration)(nil), (*ignite.Configuration)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_Configuration_To_ignite_Configuration(a.(*Configuration), b.(*ignite.Configuration), scope)
 	}); err != nil {
@@ -54,6 +76,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*DHCPOptions)(nil), (*ignite.DHCPOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_DHCPOptions_To_ignite_DHCPOptions(a.(*DHCPOptions), b.(*ignite.DHCPOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.DHCPOptions)(nil), (*DHCPOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_DHCPOptions_To_v1alpha4_DHCPOptions(a.(*ignite.DHCPOptions), b.(*DHCPOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*DHCPRoute)(nil), (*ignite.DHCPRoute)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_DHCPRoute_To_ignite_DHCPRoute(a.(*DHCPRoute), b.(*ignite.DHCPRoute), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.DHCPRoute)(nil), (*DHCPRoute)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_DHCPRoute_To_v1alpha4_DHCPRoute(a.(*ignite.DHCPRoute), b.(*DHCPRoute), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FileMapping)(nil), (*ignite.FileMapping)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_FileMapping_To_ignite_FileMapping(a.(*FileMapping), b.(*ignite.FileMapping), scope)
 	}); err != nil {
@@ -94,6 +136,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*InterfaceAddress)(nil), (*ignite.InterfaceAddress)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_InterfaceAddress_To_ignite_InterfaceAddress(a.(*InterfaceAddress), b.(*ignite.InterfaceAddress), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.InterfaceAddress)(nil), (*InterfaceAddress)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_InterfaceAddress_To_v1alpha4_InterfaceAddress(a.(*ignite.InterfaceAddress), b.(*InterfaceAddress), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Kernel)(nil), (*ignite.Kernel)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_Kernel_To_ignite_Kernel(a.(*Kernel), b.(*ignite.Kernel), scope)
 	}); err != nil {
@@ -134,6 +186,76 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*NetworkAttachmentStatus)(nil), (*ignite.NetworkAttachmentStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkAttachmentStatus_To_ignite_NetworkAttachmentStatus(a.(*NetworkAttachmentStatus), b.(*ignite.NetworkAttachmentStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkAttachmentStatus)(nil), (*NetworkAttachmentStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkAttachmentStatus_To_v1alpha4_NetworkAttachmentStatus(a.(*ignite.NetworkAttachmentStatus), b.(*NetworkAttachmentStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkInterface)(nil), (*ignite.NetworkInterface)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkInterface_To_ignite_NetworkInterface(a.(*NetworkInterface), b.(*ignite.NetworkInterface), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkInterface)(nil), (*NetworkInterface)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkInterface_To_v1alpha4_NetworkInterface(a.(*ignite.NetworkInterface), b.(*NetworkInterface), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkPolicy)(nil), (*ignite.NetworkPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkPolicy_To_ignite_NetworkPolicy(a.(*NetworkPolicy), b.(*ignite.NetworkPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkPolicy)(nil), (*NetworkPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkPolicy_To_v1alpha4_NetworkPolicy(a.(*ignite.NetworkPolicy), b.(*NetworkPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkPolicyPort)(nil), (*ignite.NetworkPolicyPort)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkPolicyPort_To_ignite_NetworkPolicyPort(a.(*NetworkPolicyPort), b.(*ignite.NetworkPolicyPort), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkPolicyPort)(nil), (*NetworkPolicyPort)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkPolicyPort_To_v1alpha4_NetworkPolicyPort(a.(*ignite.NetworkPolicyPort), b.(*NetworkPolicyPort), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkPolicyRule)(nil), (*ignite.NetworkPolicyRule)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkPolicyRule_To_ignite_NetworkPolicyRule(a.(*NetworkPolicyRule), b.(*ignite.NetworkPolicyRule), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkPolicyRule)(nil), (*NetworkPolicyRule)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkPolicyRule_To_v1alpha4_NetworkPolicyRule(a.(*ignite.NetworkPolicyRule), b.(*NetworkPolicyRule), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkPolicyRules)(nil), (*ignite.NetworkPolicyRules)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkPolicyRules_To_ignite_NetworkPolicyRules(a.(*NetworkPolicyRules), b.(*ignite.NetworkPolicyRules), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkPolicyRules)(nil), (*NetworkPolicyRules)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkPolicyRules_To_v1alpha4_NetworkPolicyRules(a.(*ignite.NetworkPolicyRules), b.(*NetworkPolicyRules), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkSpec)(nil), (*ignite.NetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec(a.(*NetworkSpec), b.(*ignite.NetworkSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.NetworkSpec)(nil), (*NetworkSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec(a.(*ignite.NetworkSpec), b.(*NetworkSpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*OCIImageSource)(nil), (*ignite.OCIImageSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_OCIImageSource_To_ignite_OCIImageSource(a.(*OCIImageSource), b.(*ignite.OCIImageSource), scope)
 	}); err != nil {
@@ -204,11 +326,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*ignite.VM)(nil), (*VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_ignite_VM_To_v1alpha4_VM(a.(*ignite.VM), b.(*VM), scope)
 	}); err != nil {
@@ -244,6 +361,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*VMNetworkStatus)(nil), (*ignite.VMNetworkStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_VMNetworkStatus_To_ignite_VMNetworkStatus(a.(*VMNetworkStatus), b.(*ignite.VMNetworkStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ignite.VMNetworkStatus)(nil), (*VMNetworkStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_ignite_VMNetworkStatus_To_v1alpha4_VMNetworkStatus(a.(*ignite.VMNetworkStatus), b.(*VMNetworkStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*VMSandboxSpec)(nil), (*ignite.VMSandboxSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_VMSandboxSpec_To_ignite_VMSandboxSpec(a.(*VMSandboxSpec), b.(*ignite.VMSandboxSpec), scope)
 	}); err != nil {
@@ -304,6 +431,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*VM)(nil), (*ignite.VM)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_VM_To_ignite_VM(a.(*VM), b.(*ignite.VM), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -327,6 +459,60 @@ func Convert_ignite_BlockDeviceVolume_To_v1alpha4_BlockDeviceVolume(in *ignite.B
 	return autoConvert_ignite_BlockDeviceVolume_To_v1alpha4_BlockDeviceVolume(in, out, s)
 }
 
+func autoConvert_v1alpha4_CNIBandwidth_To_ignite_CNIBandwidth(in *CNIBandwidth, out *ignite.CNIBandwidth, s conversion.Scope) error {
+	out.IngressRate = in.IngressRate
+	out.IngressBurst = in.IngressBurst
+	out.EgressRate = in.EgressRate
+	out.EgressBurst = in.EgressBurst
+	return nil
+}
+
+// Convert_v1alpha4_CNIBandwidth_To_ignite_CNIBandwidth is an autogenerated conversion function.
+func Convert_v1alpha4_CNIBandwidth_To_ignite_CNIBandwidth(in *CNIBandwidth, out *ignite.CNIBandwidth, s conversion.Scope) error {
+	return autoConvert_v1alpha4_CNIBandwidth_To_ignite_CNIBandwidth(in, out, s)
+}
+
+func autoConvert_ignite_CNIBandwidth_To_v1alpha4_CNIBandwidth(in *ignite.CNIBandwidth, out *CNIBandwidth, s conversion.Scope) error {
+	out.IngressRate = in.IngressRate
+	out.IngressBurst = in.IngressBurst
+	out.EgressRate = in.EgressRate
+	out.EgressBurst = in.EgressBurst
+	return nil
+}
+
+// Convert_ignite_CNIBandwidth_To_v1alpha4_CNIBandwidth is an autogenerated conversion function.
+func Convert_ignite_CNIBandwidth_To_v1alpha4_CNIBandwidth(in *ignite.CNIBandwidth, out *CNIBandwidth, s conversion.Scope) error {
+	return autoConvert_ignite_CNIBandwidth_To_v1alpha4_CNIBandwidth(in, out, s)
+}
+
+func autoConvert_v1alpha4_CNINetworkAttachment_To_ignite_CNINetworkAttachment(in *CNINetworkAttachment, out *ignite.CNINetworkAttachment, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Args = *(*map[string]string)(unsafe.Pointer(&in.Args))
+	out.Bandwidth = (*ignite.CNIBandwidth)(unsafe.Pointer(in.Bandwidth))
+	out.IPs = *(*[]string)(unsafe.Pointer(&in.IPs))
+	out.MAC = in.MAC
+	return nil
+}
+
+// Convert_v1alpha4_CNINetworkAttachment_To_ignite_CNINetworkAttachment is an autogenerated conversion function.
+func Convert_v1alpha4_CNINetworkAttachment_To_ignite_CNINetworkAttachment(in *CNINetworkAttachment, out *ignite.CNINetworkAttachment, s conversion.Scope) error {
+	return autoConvert_v1alpha4_CNINetworkAttachment_To_ignite_CNINetworkAttachment(in, out, s)
+}
+
+func autoConvert_ignite_CNINetworkAttachment_To_v1alpha4_CNINetworkAttachment(in *ignite.CNINetworkAttachment, out *CNINetworkAttachment, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Args = *(*map[string]string)(unsafe.Pointer(&in.Args))
+	out.Bandwidth = (*CNIBandwidth)(unsafe.Pointer(in.Bandwidth))
+	out.IPs = *(*[]string)(unsafe.Pointer(&in.IPs))
+	out.MAC = in.MAC
+	return nil
+}
+
+// Convert_ignite_CNINetworkAttachment_To_v1alpha4_CNINetworkAttachment is an autogenerated conversion function.
+func Convert_ignite_CNINetworkAttachment_To_v1alpha4_CNINetworkAttachment(in *ignite.CNINetworkAttachment, out *CNINetworkAttachment, s conversion.Scope) error {
+	return autoConvert_ignite_CNINetworkAttachment_To_v1alpha4_CNINetworkAttachment(in, out, s)
+}
+
 func autoConvert_v1alpha4_Configuration_To_ignite_Configuration(in *Configuration, out *ignite.Configuration, s conversion.Scope) error {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
@@ -363,6 +549,11 @@ func autoConvert_v1alpha4_ConfigurationSpec_To_ignite_ConfigurationSpec(in *Conf
 	}
 	out.IDPrefix = in.IDPrefix
 	out.RegistryConfigDir = in.RegistryConfigDir
+	out.EmbeddedDNS = in.EmbeddedDNS
+	out.CNIBinDirs = *(*[]string)(unsafe.Pointer(&in.CNIBinDirs))
+	out.CNIConfDir = in.CNIConfDir
+	out.StorageDriver = ignite.StorageDriver(in.StorageDriver)
+	out.ThinPool = (*ignite.PoolSpec)(unsafe.Pointer(in.ThinPool))
 	return nil
 }
 
@@ -379,6 +570,11 @@ func autoConvert_ignite_ConfigurationSpec_To_v1alpha4_ConfigurationSpec(in *igni
 	}
 	out.IDPrefix = in.IDPrefix
 	out.RegistryConfigDir = in.RegistryConfigDir
+	out.EmbeddedDNS = in.EmbeddedDNS
+	out.CNIBinDirs = *(*[]string)(unsafe.Pointer(&in.CNIBinDirs))
+	out.CNIConfDir = in.CNIConfDir
+	out.StorageDriver = StorageDriver(in.StorageDriver)
+	out.ThinPool = (*PoolSpec)(unsafe.Pointer(in.ThinPool))
 	return nil
 }
 
@@ -387,6 +583,60 @@ func Convert_ignite_ConfigurationSpec_To_v1alpha4_ConfigurationSpec(in *ignite.C
 	return autoConvert_ignite_ConfigurationSpec_To_v1alpha4_ConfigurationSpec(in, out, s)
 }
 
+func autoConvert_v1alpha4_DHCPOptions_To_ignite_DHCPOptions(in *DHCPOptions, out *ignite.DHCPOptions, s conversion.Scope) error {
+	out.NameServers = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.NameServers))
+	out.SearchDomains = *(*[]string)(unsafe.Pointer(&in.SearchDomains))
+	out.NTPServers = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.NTPServers))
+	out.DomainName = in.DomainName
+	out.MTU = in.MTU
+	out.Routes = *(*[]ignite.DHCPRoute)(unsafe.Pointer(&in.Routes))
+	out.LeaseDuration = (*v1.Duration)(unsafe.Pointer(in.LeaseDuration))
+	return nil
+}
+
+// Convert_v1alpha4_DHCPOptions_To_ignite_DHCPOptions is an autogenerated conversion function.
+func Convert_v1alpha4_DHCPOptions_To_ignite_DHCPOptions(in *DHCPOptions, out *ignite.DHCPOptions, s conversion.Scope) error {
+	return autoConvert_v1alpha4_DHCPOptions_To_ignite_DHCPOptions(in, out, s)
+}
+
+func autoConvert_ignite_DHCPOptions_To_v1alpha4_DHCPOptions(in *ignite.DHCPOptions, out *DHCPOptions, s conversion.Scope) error {
+	out.NameServers = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.NameServers))
+	out.SearchDomains = *(*[]string)(unsafe.Pointer(&in.SearchDomains))
+	out.NTPServers = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.NTPServers))
+	out.DomainName = in.DomainName
+	out.MTU = in.MTU
+	out.Routes = *(*[]DHCPRoute)(unsafe.Pointer(&in.Routes))
+	out.LeaseDuration = (*v1.Duration)(unsafe.Pointer(in.LeaseDuration))
+	return nil
+}
+
+// Convert_ignite_DHCPOptions_To_v1alpha4_DHCPOptions is an autogenerated conversion function.
+func Convert_ignite_DHCPOptions_To_v1alpha4_DHCPOptions(in *ignite.DHCPOptions, out *DHCPOptions, s conversion.Scope) error {
+	return autoConvert_ignite_DHCPOptions_To_v1alpha4_DHCPOptions(in, out, s)
+}
+
+func autoConvert_v1alpha4_DHCPRoute_To_ignite_DHCPRoute(in *DHCPRoute, out *ignite.DHCPRoute, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	return nil
+}
+
+// Convert_v1alpha4_DHCPRoute_To_ignite_DHCPRoute is an autogenerated conversion function.
+func Convert_v1alpha4_DHCPRoute_To_ignite_DHCPRoute(in *DHCPRoute, out *ignite.DHCPRoute, s conversion.Scope) error {
+	return autoConvert_v1alpha4_DHCPRoute_To_ignite_DHCPRoute(in, out, s)
+}
+
+func autoConvert_ignite_DHCPRoute_To_v1alpha4_DHCPRoute(in *ignite.DHCPRoute, out *DHCPRoute, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	return nil
+}
+
+// Convert_ignite_DHCPRoute_To_v1alpha4_DHCPRoute is an autogenerated conversion function.
+func Convert_ignite_DHCPRoute_To_v1alpha4_DHCPRoute(in *ignite.DHCPRoute, out *DHCPRoute, s conversion.Scope) error {
+	return autoConvert_ignite_DHCPRoute_To_v1alpha4_DHCPRoute(in, out, s)
+}
+
 func autoConvert_v1alpha4_FileMapping_To_ignite_FileMapping(in *FileMapping, out *ignite.FileMapping, s conversion.Scope) error {
 	out.HostPath = in.HostPath
 	out.VMPath = in.VMPath
@@ -487,6 +737,28 @@ func Convert_ignite_ImageStatus_To_v1alpha4_ImageStatus(in *ignite.ImageStatus,
 	return autoConvert_ignite_ImageStatus_To_v1alpha4_ImageStatus(in, out, s)
 }
 
+func autoConvert_v1alpha4_InterfaceAddress_To_ignite_InterfaceAddress(in *InterfaceAddress, out *ignite.InterfaceAddress, s conversion.Scope) error {
+	out.IP = in.IP
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	return nil
+}
+
+// Convert_v1alpha4_InterfaceAddress_To_ignite_InterfaceAddress is an autogenerated conversion function.
+func Convert_v1alpha4_InterfaceAddress_To_ignite_InterfaceAddress(in *InterfaceAddress, out *ignite.InterfaceAddress, s conversion.Scope) error {
+	return autoConvert_v1alpha4_InterfaceAddress_To_ignite_InterfaceAddress(in, out, s)
+}
+
+func autoConvert_ignite_InterfaceAddress_To_v1alpha4_InterfaceAddress(in *ignite.InterfaceAddress, out *InterfaceAddress, s conversion.Scope) error {
+	out.IP = in.IP
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	return nil
+}
+
+// Convert_ignite_InterfaceAddress_To_v1alpha4_InterfaceAddress is an autogenerated conversion function.
+func Convert_ignite_InterfaceAddress_To_v1alpha4_InterfaceAddress(in *ignite.InterfaceAddress, out *InterfaceAddress, s conversion.Scope) error {
+	return autoConvert_ignite_InterfaceAddress_To_v1alpha4_InterfaceAddress(in, out, s)
+}
+
 func autoConvert_v1alpha4_Kernel_To_ignite_Kernel(in *Kernel, out *ignite.Kernel, s conversion.Scope) error {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
@@ -568,8 +840,11 @@ func Convert_ignite_KernelStatus_To_v1alpha4_KernelStatus(in *ignite.KernelStatu
 }
 
 func autoConvert_v1alpha4_Network_To_ignite_Network(in *Network, out *ignite.Network, s conversion.Scope) error {
-	out.Plugin = network.PluginName(in.Plugin)
-	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	out.TypeMeta = in.TypeMeta
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -579,8 +854,11 @@ func Convert_v1alpha4_Network_To_ignite_Network(in *Network, out *ignite.Network
 }
 
 func autoConvert_ignite_Network_To_v1alpha4_Network(in *ignite.Network, out *Network, s conversion.Scope) error {
-	out.Plugin = network.PluginName(in.Plugin)
-	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	out.TypeMeta = in.TypeMeta
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -589,6 +867,180 @@ func Convert_ignite_Network_To_v1alpha4_Network(in *ignite.Network, out *Network
 	return autoConvert_ignite_Network_To_v1alpha4_Network(in, out, s)
 }
 
+func autoConvert_v1alpha4_NetworkAttachmentStatus_To_ignite_NetworkAttachmentStatus(in *NetworkAttachmentStatus, out *ignite.NetworkAttachmentStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Interface = in.Interface
+	out.MAC = in.MAC
+	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	return nil
+}
+
+// Convert_v1alpha4_NetworkAttachmentStatus_To_ignite_NetworkAttachmentStatus is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkAttachmentStatus_To_ignite_NetworkAttachmentStatus(in *NetworkAttachmentStatus, out *ignite.NetworkAttachmentStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkAttachmentStatus_To_ignite_NetworkAttachmentStatus(in, out, s)
+}
+
+func autoConvert_ignite_NetworkAttachmentStatus_To_v1alpha4_NetworkAttachmentStatus(in *ignite.NetworkAttachmentStatus, out *NetworkAttachmentStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Interface = in.Interface
+	out.MAC = in.MAC
+	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	return nil
+}
+
+// Convert_ignite_NetworkAttachmentStatus_To_v1alpha4_NetworkAttachmentStatus is an autogenerated conversion function.
+func Convert_ignite_NetworkAttachmentStatus_To_v1alpha4_NetworkAttachmentStatus(in *ignite.NetworkAttachmentStatus, out *NetworkAttachmentStatus, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkAttachmentStatus_To_v1alpha4_NetworkAttachmentStatus(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkInterface_To_ignite_NetworkInterface(in *NetworkInterface, out *ignite.NetworkInterface, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Mode = ignite.InterfaceMode(in.Mode)
+	out.MACAddress = in.MACAddress
+	out.MTU = in.MTU
+	out.GuestName = in.GuestName
+	out.Address = (*ignite.InterfaceAddress)(unsafe.Pointer(in.Address))
+	out.Parent = in.Parent
+	return nil
+}
+
+// Convert_v1alpha4_NetworkInterface_To_ignite_NetworkInterface is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkInterface_To_ignite_NetworkInterface(in *NetworkInterface, out *ignite.NetworkInterface, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkInterface_To_ignite_NetworkInterface(in, out, s)
+}
+
+func autoConvert_ignite_NetworkInterface_To_v1alpha4_NetworkInterface(in *ignite.NetworkInterface, out *NetworkInterface, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Mode = InterfaceMode(in.Mode)
+	out.MACAddress = in.MACAddress
+	out.MTU = in.MTU
+	out.GuestName = in.GuestName
+	out.Address = (*InterfaceAddress)(unsafe.Pointer(in.Address))
+	out.Parent = in.Parent
+	return nil
+}
+
+// Convert_ignite_NetworkInterface_To_v1alpha4_NetworkInterface is an autogenerated conversion function.
+func Convert_ignite_NetworkInterface_To_v1alpha4_NetworkInterface(in *ignite.NetworkInterface, out *NetworkInterface, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkInterface_To_v1alpha4_NetworkInterface(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkPolicy_To_ignite_NetworkPolicy(in *NetworkPolicy, out *ignite.NetworkPolicy, s conversion.Scope) error {
+	out.Ingress = (*ignite.NetworkPolicyRules)(unsafe.Pointer(in.Ingress))
+	out.Egress = (*ignite.NetworkPolicyRules)(unsafe.Pointer(in.Egress))
+	return nil
+}
+
+// Convert_v1alpha4_NetworkPolicy_To_ignite_NetworkPolicy is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkPolicy_To_ignite_NetworkPolicy(in *NetworkPolicy, out *ignite.NetworkPolicy, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkPolicy_To_ignite_NetworkPolicy(in, out, s)
+}
+
+func autoConvert_ignite_NetworkPolicy_To_v1alpha4_NetworkPolicy(in *ignite.NetworkPolicy, out *NetworkPolicy, s conversion.Scope) error {
+	out.Ingress = (*NetworkPolicyRules)(unsafe.Pointer(in.Ingress))
+	out.Egress = (*NetworkPolicyRules)(unsafe.Pointer(in.Egress))
+	return nil
+}
+
+// Convert_ignite_NetworkPolicy_To_v1alpha4_NetworkPolicy is an autogenerated conversion function.
+func Convert_ignite_NetworkPolicy_To_v1alpha4_NetworkPolicy(in *ignite.NetworkPolicy, out *NetworkPolicy, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkPolicy_To_v1alpha4_NetworkPolicy(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkPolicyPort_To_ignite_NetworkPolicyPort(in *NetworkPolicyPort, out *ignite.NetworkPolicyPort, s conversion.Scope) error {
+	out.Protocol = v1alpha1.Protocol(in.Protocol)
+	out.Port = in.Port
+	out.EndPort = in.EndPort
+	return nil
+}
+
+// Convert_v1alpha4_NetworkPolicyPort_To_ignite_NetworkPolicyPort is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkPolicyPort_To_ignite_NetworkPolicyPort(in *NetworkPolicyPort, out *ignite.NetworkPolicyPort, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkPolicyPort_To_ignite_NetworkPolicyPort(in, out, s)
+}
+
+func autoConvert_ignite_NetworkPolicyPort_To_v1alpha4_NetworkPolicyPort(in *ignite.NetworkPolicyPort, out *NetworkPolicyPort, s conversion.Scope) error {
+	out.Protocol = v1alpha1.Protocol(in.Protocol)
+	out.Port = in.Port
+	out.EndPort = in.EndPort
+	return nil
+}
+
+// Convert_ignite_NetworkPolicyPort_To_v1alpha4_NetworkPolicyPort is an autogenerated conversion function.
+func Convert_ignite_NetworkPolicyPort_To_v1alpha4_NetworkPolicyPort(in *ignite.NetworkPolicyPort, out *NetworkPolicyPort, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkPolicyPort_To_v1alpha4_NetworkPolicyPort(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkPolicyRule_To_ignite_NetworkPolicyRule(in *NetworkPolicyRule, out *ignite.NetworkPolicyRule, s conversion.Scope) error {
+	out.CIDRs = *(*[]string)(unsafe.Pointer(&in.CIDRs))
+	out.Ports = *(*[]ignite.NetworkPolicyPort)(unsafe.Pointer(&in.Ports))
+	return nil
+}
+
+// Convert_v1alpha4_NetworkPolicyRule_To_ignite_NetworkPolicyRule is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkPolicyRule_To_ignite_NetworkPolicyRule(in *NetworkPolicyRule, out *ignite.NetworkPolicyRule, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkPolicyRule_To_ignite_NetworkPolicyRule(in, out, s)
+}
+
+func autoConvert_ignite_NetworkPolicyRule_To_v1alpha4_NetworkPolicyRule(in *ignite.NetworkPolicyRule, out *NetworkPolicyRule, s conversion.Scope) error {
+	out.CIDRs = *(*[]string)(unsafe.Pointer(&in.CIDRs))
+	out.Ports = *(*[]NetworkPolicyPort)(unsafe.Pointer(&in.Ports))
+	return nil
+}
+
+// Convert_ignite_NetworkPolicyRule_To_v1alpha4_NetworkPolicyRule is an autogenerated conversion function.
+func Convert_ignite_NetworkPolicyRule_To_v1alpha4_NetworkPolicyRule(in *ignite.NetworkPolicyRule, out *NetworkPolicyRule, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkPolicyRule_To_v1alpha4_NetworkPolicyRule(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkPolicyRules_To_ignite_NetworkPolicyRules(in *NetworkPolicyRules, out *ignite.NetworkPolicyRules, s conversion.Scope) error {
+	out.Allow = *(*[]ignite.NetworkPolicyRule)(unsafe.Pointer(&in.Allow))
+	return nil
+}
+
+// Convert_v1alpha4_NetworkPolicyRules_To_ignite_NetworkPolicyRules is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkPolicyRules_To_ignite_NetworkPolicyRules(in *NetworkPolicyRules, out *ignite.NetworkPolicyRules, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkPolicyRules_To_ignite_NetworkPolicyRules(in, out, s)
+}
+
+func autoConvert_ignite_NetworkPolicyRules_To_v1alpha4_NetworkPolicyRules(in *ignite.NetworkPolicyRules, out *NetworkPolicyRules, s conversion.Scope) error {
+	out.Allow = *(*[]NetworkPolicyRule)(unsafe.Pointer(&in.Allow))
+	return nil
+}
+
+// Convert_ignite_NetworkPolicyRules_To_v1alpha4_NetworkPolicyRules is an autogenerated conversion function.
+func Convert_ignite_NetworkPolicyRules_To_v1alpha4_NetworkPolicyRules(in *ignite.NetworkPolicyRules, out *NetworkPolicyRules, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkPolicyRules_To_v1alpha4_NetworkPolicyRules(in, out, s)
+}
+
+func autoConvert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec(in *NetworkSpec, out *ignite.NetworkSpec, s conversion.Scope) error {
+	out.Subnet = in.Subnet
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	out.BridgeName = in.BridgeName
+	out.Masquerade = in.Masquerade
+	out.Isolated = in.Isolated
+	return nil
+}
+
+// Convert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec is an autogenerated conversion function.
+func Convert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec(in *NetworkSpec, out *ignite.NetworkSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha4_NetworkSpec_To_ignite_NetworkSpec(in, out, s)
+}
+
+func autoConvert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec(in *ignite.NetworkSpec, out *NetworkSpec, s conversion.Scope) error {
+	out.Subnet = in.Subnet
+	out.Gateway = *(*net.IP)(unsafe.Pointer(&in.Gateway))
+	out.BridgeName = in.BridgeName
+	out.Masquerade = in.Masquerade
+	out.Isolated = in.Isolated
+	return nil
+}
+
+// Convert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec is an autogenerated conversion function.
+func Convert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec(in *ignite.NetworkSpec, out *NetworkSpec, s conversion.Scope) error {
+	return autoConvert_ignite_NetworkSpec_To_v1alpha4_NetworkSpec(in, out, s)
+}
+
 func autoConvert_v1alpha4_OCIImageSource_To_ignite_OCIImageSource(in *OCIImageSource, out *ignite.OCIImageSource, s conversion.Scope) error {
 	out.ID = (*v1alpha1.OCIContentID)(unsafe.Pointer(in.ID))
 	out.Size = in.Size
@@ -773,11 +1225,6 @@ func autoConvert_v1alpha4_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.S
 	return nil
 }
 
-// Convert_v1alpha4_VM_To_ignite_VM is an autogenerated conversion function.
-func Convert_v1alpha4_VM_To_ignite_VM(in *VM, out *ignite.VM, s conversion.Scope) error {
-	return autoConvert_v1alpha4_VM_To_ignite_VM(in, out, s)
-}
-
 func autoConvert_ignite_VM_To_v1alpha4_VM(in *ignite.VM, out *VM, s conversion.Scope) error {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
@@ -839,6 +1286,12 @@ func Convert_ignite_VMKernelSpec_To_v1alpha4_VMKernelSpec(in *ignite.VMKernelSpe
 
 func autoConvert_v1alpha4_VMNetworkSpec_To_ignite_VMNetworkSpec(in *VMNetworkSpec, out *ignite.VMNetworkSpec, s conversion.Scope) error {
 	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	out.Interfaces = *(*[]ignite.NetworkInterface)(unsafe.Pointer(&in.Interfaces))
+	out.StickyIP = in.StickyIP
+	out.Networks = *(*[]string)(unsafe.Pointer(&in.Networks))
+	out.CNINetworks = *(*[]ignite.CNINetworkAttachment)(unsafe.Pointer(&in.CNINetworks))
+	out.DHCP = (*ignite.DHCPOptions)(unsafe.Pointer(in.DHCP))
+	out.Policy = (*ignite.NetworkPolicy)(unsafe.Pointer(in.Policy))
 	return nil
 }
 
@@ -849,6 +1302,12 @@ func Convert_v1alpha4_VMNetworkSpec_To_ignite_VMNetworkSpec(in *VMNetworkSpec, o
 
 func autoConvert_ignite_VMNetworkSpec_To_v1alpha4_VMNetworkSpec(in *ignite.VMNetworkSpec, out *VMNetworkSpec, s conversion.Scope) error {
 	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	out.Interfaces = *(*[]NetworkInterface)(unsafe.Pointer(&in.Interfaces))
+	out.StickyIP = in.StickyIP
+	out.Networks = *(*[]string)(unsafe.Pointer(&in.Networks))
+	out.CNINetworks = *(*[]CNINetworkAttachment)(unsafe.Pointer(&in.CNINetworks))
+	out.DHCP = (*DHCPOptions)(unsafe.Pointer(in.DHCP))
+	out.Policy = (*NetworkPolicy)(unsafe.Pointer(in.Policy))
 	return nil
 }
 
@@ -857,6 +1316,32 @@ func Convert_ignite_VMNetworkSpec_To_v1alpha4_VMNetworkSpec(in *ignite.VMNetwork
 	return autoConvert_ignite_VMNetworkSpec_To_v1alpha4_VMNetworkSpec(in, out, s)
 }
 
+func autoConvert_v1alpha4_VMNetworkStatus_To_ignite_VMNetworkStatus(in *VMNetworkStatus, out *ignite.VMNetworkStatus, s conversion.Scope) error {
+	out.Plugin = network.PluginName(in.Plugin)
+	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	out.Networks = *(*[]ignite.NetworkAttachmentStatus)(unsafe.Pointer(&in.Networks))
+	return nil
+}
+
+// Convert_v1alpha4_VMNetworkStatus_To_ignite_VMNetworkStatus is an autogenerated conversion function.
+func Convert_v1alpha4_VMNetworkStatus_To_ignite_VMNetworkStatus(in *VMNetworkStatus, out *ignite.VMNetworkStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha4_VMNetworkStatus_To_ignite_VMNetworkStatus(in, out, s)
+}
+
+func autoConvert_ignite_VMNetworkStatus_To_v1alpha4_VMNetworkStatus(in *ignite.VMNetworkStatus, out *VMNetworkStatus, s conversion.Scope) error {
+	out.Plugin = network.PluginName(in.Plugin)
+	out.IPAddresses = *(*v1alpha1.IPAddresses)(unsafe.Pointer(&in.IPAddresses))
+	out.Ports = *(*v1alpha1.PortMappings)(unsafe.Pointer(&in.Ports))
+	out.Networks = *(*[]NetworkAttachmentStatus)(unsafe.Pointer(&in.Networks))
+	return nil
+}
+
+// Convert_ignite_VMNetworkStatus_To_v1alpha4_VMNetworkStatus is an autogenerated conversion function.
+func Convert_ignite_VMNetworkStatus_To_v1alpha4_VMNetworkStatus(in *ignite.VMNetworkStatus, out *VMNetworkStatus, s conversion.Scope) error {
+	return autoConvert_ignite_VMNetworkStatus_To_v1alpha4_VMNetworkStatus(in, out, s)
+}
+
 func autoConvert_v1alpha4_VMSandboxSpec_To_ignite_VMSandboxSpec(in *VMSandboxSpec, out *ignite.VMSandboxSpec, s conversion.Scope) error {
 	out.OCI = in.OCI
 	return nil
@@ -898,6 +1383,7 @@ func autoConvert_v1alpha4_VMSpec_To_ignite_VMSpec(in *VMSpec, out *ignite.VMSpec
 	}
 	out.CopyFiles = *(*[]ignite.FileMapping)(unsafe.Pointer(&in.CopyFiles))
 	out.SSH = (*ignite.SSH)(unsafe.Pointer(in.SSH))
+	out.RestartPolicy = ignite.RestartPolicy(in.RestartPolicy)
 	return nil
 }
 
@@ -927,6 +1413,7 @@ func autoConvert_ignite_VMSpec_To_v1alpha4_VMSpec(in *ignite.VMSpec, out *VMSpec
 	}
 	out.CopyFiles = *(*[]FileMapping)(unsafe.Pointer(&in.CopyFiles))
 	out.SSH = (*SSH)(unsafe.Pointer(in.SSH))
+	out.RestartPolicy = RestartPolicy(in.RestartPolicy)
 	return nil
 }
 
@@ -939,7 +1426,7 @@ func autoConvert_v1alpha4_VMStatus_To_ignite_VMStatus(in *VMStatus, out *ignite.
 	out.Running = in.Running
 	out.Runtime = (*ignite.Runtime)(unsafe.Pointer(in.Runtime))
 	out.StartTime = (*libgitopspkgruntime.Time)(unsafe.Pointer(in.StartTime))
-	out.Network = (*ignite.Network)(unsafe.Pointer(in.Network))
+	out.Network = (*ignite.VMNetworkStatus)(unsafe.Pointer(in.Network))
 	if err := Convert_v1alpha4_OCIImageSource_To_ignite_OCIImageSource(&in.Image, &out.Image, s); err != nil {
 		return err
 	}
@@ -959,7 +1446,7 @@ func autoConvert_ignite_VMStatus_To_v1alpha4_VMStatus(in *ignite.VMStatus, out *
 	out.Running = in.Running
 	out.Runtime = (*Runtime)(unsafe.Pointer(in.Runtime))
 	out.StartTime = (*libgitopspkgruntime.Time)(unsafe.Pointer(in.StartTime))
-	out.Network = (*Network)(unsafe.Pointer(in.Network))
+	out.Network = (*VMNetworkStatus)(unsafe.Pointer(in.Network))
 	if err := Convert_ignite_OCIImageSource_To_v1alpha4_OCIImageSource(&in.Image, &out.Image, s); err != nil {
 		return err
 	}
@@ -24,6 +24,9 @@ func SetObjectDefaults_Configuration(in *Configuration) {
 	SetDefaults_VMSpec(&in.Spec.VMDefaults)
 	SetDefaults_VMSandboxSpec(&in.Spec.VMDefaults.Sandbox)
 	SetDefaults_VMKernelSpec(&in.Spec.VMDefaults.Kernel)
+	if in.Spec.ThinPool != nil {
+		SetDefaults_PoolSpec(in.Spec.ThinPool)
+	}
 }
 
 func SetObjectDefaults_Pool(in *Pool) {
@@ -2,10 +2,15 @@ package validation
 
 import (
 	"fmt"
+	"net"
 	"path"
+	"strings"
+	"time"
 
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/network"
 	"github.com/weaveworks/ignite/pkg/util"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -18,11 +23,80 @@ func ValidateVM(obj *api.VM) (allErrs field.ErrorList) {
 	allErrs = append(allErrs, RequireOCIImageRef(&obj.Spec.Kernel.OCI, field.NewPath(".spec.kernel.oci"))...)
 	allErrs = append(allErrs, ValidateFileMappings(&obj.Spec.CopyFiles, field.NewPath(".spec.copyFiles"))...)
 	allErrs = append(allErrs, ValidateVMStorage(&obj.Spec.Storage, field.NewPath(".spec.storage"))...)
+	allErrs = append(allErrs, ValidateVMNetwork(&obj.Spec.Network, field.NewPath(".spec.network"))...)
+	allErrs = append(allErrs, ValidateVMNetworkPlugin(obj, field.NewPath(".spec.network"))...)
+	allErrs = append(allErrs, ValidateRestartPolicy(obj.Spec.RestartPolicy, field.NewPath(".spec.restartPolicy"))...)
 	// TODO: Add vCPU, memory, disk max and min sizes
 	// TODO: Add port mapping validation
 	return
 }
 
+// ValidateRestartPolicy validates the restart policy of a VM
+func ValidateRestartPolicy(policy api.RestartPolicy, fldPath *field.Path) (allErrs field.ErrorList) {
+	switch policy {
+	case "", api.RestartPolicyNo, api.RestartPolicyAlways:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, policy, []string{
+			string(api.RestartPolicyNo),
+			string(api.RestartPolicyAlways),
+		}))
+	}
+
+	return
+}
+
+// ValidateNetwork validates a Network object and collects all encountered errors
+func ValidateNetwork(obj *api.Network) (allErrs field.ErrorList) {
+	allErrs = append(allErrs, ValidateNetworkName(obj.GetName(), field.NewPath("metadata.name"))...)
+	allErrs = append(allErrs, ValidateNetworkSpec(&obj.Spec, field.NewPath(".spec"))...)
+	return
+}
+
+// ValidateNetworkName validates the network name, it needs to be usable as a DNS label.
+// The name of the default network is reserved for the VMs without user-defined networks.
+func ValidateNetworkName(name string, fldPath *field.Path) (allErrs field.ErrorList) {
+	errs := validation.IsDNS1123Label(name)
+	for _, e := range errs {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, e))
+	}
+
+	if name == constants.DEFAULT_NETWORK_NAME {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "the name is reserved for the default network"))
+	}
+
+	return
+}
+
+// ValidateNetworkSpec validates the subnet, gateway and bridge name of a network
+func ValidateNetworkSpec(s *api.NetworkSpec, fldPath *field.Path) (allErrs field.ErrorList) {
+	ip, subnet, err := net.ParseCIDR(s.Subnet)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("subnet"), s.Subnet, "must be a subnet in CIDR notation, e.g. 10.70.0.0/16"))
+	} else if !ip.Equal(subnet.IP) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("subnet"), s.Subnet, fmt.Sprintf("must be the address of the subnet, i.e. %s", subnet)))
+	} else if ones, bits := subnet.Mask.Size(); bits-ones < 2 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("subnet"), s.Subnet, "must have room for the gateway and at least one VM"))
+	}
+
+	if gw := s.Gateway; gw != nil && subnet != nil {
+		if !subnet.Contains(gw) || gw.Equal(subnet.IP) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("gateway"), gw.String(), fmt.Sprintf("must be a host address in the subnet %s", subnet)))
+		}
+	}
+
+	if len(s.BridgeName) > 0 {
+		allErrs = append(allErrs, ValidateInterfaceName(s.BridgeName, fldPath.Child("bridgeName"))...)
+
+		// The isolation rules and network repair recognize the bridges of the networks by the prefix
+		if !strings.HasPrefix(s.BridgeName, constants.NETWORK_NAME_PREFIX) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("bridgeName"), s.BridgeName,
+				fmt.Sprintf("must start with %q", constants.NETWORK_NAME_PREFIX)))
+		}
+	}
+
+	return
+}
+
 // RequireOCIImageRef validates that the OCIImageRef is set
 func RequireOCIImageRef(ref *meta.OCIImageRef, fldPath *field.Path) (allErrs field.ErrorList) {
 	if ref.IsUnset() {
@@ -53,6 +127,313 @@ func ValidateFileMappings(mappings *[]api.FileMapping, fldPath *field.Path) (all
 	return
 }
 
+// ValidateVMNetwork validates the interfaces of the VM, their static addresses and the networks of the VM
+func ValidateVMNetwork(n *api.VMNetworkSpec, fldPath *field.Path) (allErrs field.ErrorList) {
+	names := map[string]bool{}
+	guestNames := map[string]bool{}
+	for i, intf := range n.Interfaces {
+		intfPath := fldPath.Child("interfaces").Index(i)
+		allErrs = append(allErrs, ValidateNonemptyName(intf.Name, intfPath.Child("name"))...)
+		allErrs = append(allErrs, ValidateNetworkInterface(&intf, intfPath)...)
+
+		if names[intf.Name] {
+			allErrs = append(allErrs, field.Duplicate(intfPath.Child("name"), intf.Name))
+		}
+		names[intf.Name] = true
+
+		if len(intf.GuestName) > 0 {
+			if guestNames[intf.GuestName] {
+				allErrs = append(allErrs, field.Duplicate(intfPath.Child("guestName"), intf.GuestName))
+			}
+			guestNames[intf.GuestName] = true
+		}
+
+		if intf.Address == nil {
+			continue
+		}
+
+		ip, _, err := intf.Address.Parse()
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(intfPath.Child("address", "ip"), intf.Address.IP, err.Error()))
+			continue
+		}
+
+		if gw := intf.Address.Gateway; gw != nil && (gw.To4() == nil) != (ip.To4() == nil) {
+			allErrs = append(allErrs, field.Invalid(intfPath.Child("address", "gateway"), gw.String(), "gateway must be of the same IP family as the address"))
+		}
+	}
+
+	networks := map[string]bool{}
+	for i, name := range n.Networks {
+		networkPath := fldPath.Child("networks").Index(i)
+		allErrs = append(allErrs, ValidateNonemptyName(name, networkPath)...)
+
+		if networks[name] {
+			allErrs = append(allErrs, field.Duplicate(networkPath, name))
+		}
+		networks[name] = true
+	}
+
+	if len(n.CNINetworks) > 0 {
+		if len(n.Networks) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cniNetworks"), n.CNINetworks, "can't be combined with networks"))
+		}
+
+		allErrs = append(allErrs, ValidateCNINetworks(n.CNINetworks, fldPath.Child("cniNetworks"))...)
+	}
+
+	if n.DHCP != nil {
+		allErrs = append(allErrs, ValidateDHCPOptions(n.DHCP, fldPath.Child("dhcp"))...)
+	}
+
+	if n.Policy != nil {
+		allErrs = append(allErrs, ValidateNetworkPolicy(n.Policy, fldPath.Child("policy"))...)
+
+		// The policy is enforced on the bridges between the sandbox interfaces and the TAPs of the VM
+		for i, intf := range n.Interfaces {
+			if intf.Mode != "" && intf.Mode != api.InterfaceModeDHCPBridge {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("interfaces").Index(i).Child("mode"), intf.Mode,
+					fmt.Sprintf("must be %q for the network policy to be enforced", api.InterfaceModeDHCPBridge)))
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateVMNetworkPlugin validates that the network plugin in the status of the VM supports its network spec
+func ValidateVMNetworkPlugin(vm *api.VM, fldPath *field.Path) (allErrs field.ErrorList) {
+	if vm.Status.Network.Plugin != network.PluginDockerBridge {
+		return
+	}
+
+	// Docker allocates the address of the container and forwards the ports to it, so
+	// a different address of the VM wouldn't be reachable through the forwarded ports
+	for i, intf := range vm.Spec.Network.Interfaces {
+		if intf.Name == constants.IGNITE_SPAWN_MAIN_INTERFACE && intf.Address != nil && !intf.Mode.IsPassthrough() {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("interfaces").Index(i).Child("address"),
+				fmt.Sprintf("static IPs are not supported by the %q network plugin", network.PluginDockerBridge)))
+		}
+	}
+
+	if vm.Spec.Network.StickyIP {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("stickyIP"),
+			fmt.Sprintf("sticky IPs are not supported by the %q network plugin", network.PluginDockerBridge)))
+	}
+
+	return
+}
+
+// ValidateNetworkPolicy validates the peers and ports of the rules of a network policy
+func ValidateNetworkPolicy(p *api.NetworkPolicy, fldPath *field.Path) (allErrs field.ErrorList) {
+	for i, rules := range []*api.NetworkPolicyRules{p.Ingress, p.Egress} {
+		if rules == nil {
+			continue
+		}
+
+		name := []string{"ingress", "egress"}[i]
+
+		for i, rule := range rules.Allow {
+			rulePath := fldPath.Child(name, "allow").Index(i)
+			for j, cidr := range rule.CIDRs {
+				if _, _, err := net.ParseCIDR(cidr); err != nil {
+					allErrs = append(allErrs, field.Invalid(rulePath.Child("cidrs").Index(j), cidr, "must be a subnet in CIDR notation, e.g. 10.70.0.0/16"))
+				}
+			}
+
+			for j, port := range rule.Ports {
+				portPath := rulePath.Child("ports").Index(j)
+				switch port.Protocol {
+				case "", meta.ProtocolTCP, meta.ProtocolUDP:
+				default:
+					allErrs = append(allErrs, field.NotSupported(portPath.Child("protocol"), port.Protocol, []string{
+						string(meta.ProtocolTCP),
+						string(meta.ProtocolUDP),
+					}))
+				}
+
+				if port.Port == 0 {
+					allErrs = append(allErrs, field.Invalid(portPath.Child("port"), port.Port, "must be between 1 and 65535"))
+				}
+
+				if port.EndPort != 0 && port.EndPort < port.Port {
+					allErrs = append(allErrs, field.Invalid(portPath.Child("endPort"), port.EndPort, "must not be lower than the port"))
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateCNINetworks validates the names and the capabilities of the CNI network attachments of a VM
+func ValidateCNINetworks(attachments []api.CNINetworkAttachment, fldPath *field.Path) (allErrs field.ErrorList) {
+	names := make(map[string]bool, len(attachments))
+	for i, a := range attachments {
+		attachmentPath := fldPath.Index(i)
+		if len(a.Name) == 0 {
+			allErrs = append(allErrs, field.Required(attachmentPath.Child("name"), "the network name is required"))
+		} else if names[a.Name] {
+			allErrs = append(allErrs, field.Duplicate(attachmentPath.Child("name"), a.Name))
+		}
+		names[a.Name] = true
+
+		for j, cidr := range a.IPs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				allErrs = append(allErrs, field.Invalid(attachmentPath.Child("ips").Index(j), cidr, "must be an address in CIDR notation, e.g. 10.61.0.10/16"))
+			}
+		}
+
+		if len(a.MAC) > 0 {
+			if _, err := net.ParseMAC(a.MAC); err != nil {
+				allErrs = append(allErrs, field.Invalid(attachmentPath.Child("mac"), a.MAC, err.Error()))
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateDHCPOptions validates the addresses, domains, MTU, routes and lease duration handed out over DHCP
+func ValidateDHCPOptions(o *api.DHCPOptions, fldPath *field.Path) (allErrs field.ErrorList) {
+	for i, ip := range o.NameServers {
+		if ip == nil || ip.IsUnspecified() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nameServers").Index(i), ip.String(), "must be an IP address"))
+		}
+	}
+
+	for i, domain := range o.SearchDomains {
+		for _, e := range validation.IsDNS1123Subdomain(domain) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("searchDomains").Index(i), domain, e))
+		}
+	}
+
+	for i, ip := range o.NTPServers {
+		if ip.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ntpServers").Index(i), ip.String(), "must be an IPv4 address"))
+		}
+	}
+
+	if len(o.DomainName) > 0 {
+		for _, e := range validation.IsDNS1123Subdomain(o.DomainName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("domainName"), o.DomainName, e))
+		}
+	}
+
+	if o.MTU != 0 && (o.MTU < 68 || o.MTU > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mtu"), o.MTU, "must be between 68 and 65535"))
+	}
+
+	for i, route := range o.Routes {
+		routePath := fldPath.Child("routes").Index(i)
+		if ip, _, err := net.ParseCIDR(route.Destination); err != nil || ip.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(routePath.Child("destination"), route.Destination, "must be an IPv4 subnet in CIDR notation, e.g. 10.70.0.0/16"))
+		}
+
+		if route.Gateway.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(routePath.Child("gateway"), route.Gateway.String(), "must be an IPv4 address"))
+		}
+	}
+
+	if o.LeaseDuration != nil && o.LeaseDuration.Duration < time.Minute {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("leaseDuration"), o.LeaseDuration.Duration.String(), "must be at least a minute"))
+	}
+
+	return
+}
+
+// ValidateNetworkInterface validates the mode, MAC address, MTU and guest name of an interface
+func ValidateNetworkInterface(intf *api.NetworkInterface, fldPath *field.Path) (allErrs field.ErrorList) {
+	switch intf.Mode {
+	case "", api.InterfaceModeDHCPBridge, api.InterfaceModeTCRedirect, api.InterfaceModeMacvlan, api.InterfaceModeIPVLAN:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), intf.Mode, []string{
+			string(api.InterfaceModeDHCPBridge),
+			string(api.InterfaceModeTCRedirect),
+			string(api.InterfaceModeMacvlan),
+			string(api.InterfaceModeIPVLAN),
+		}))
+	}
+
+	if intf.Mode.IsPassthrough() {
+		allErrs = append(allErrs, ValidatePassthroughInterface(intf, fldPath)...)
+	} else if len(intf.Parent) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("parent"), "a parent device is only supported for the macvlan and ipvlan modes"))
+	}
+
+	if len(intf.MACAddress) > 0 {
+		if mac, err := net.ParseMAC(intf.MACAddress); err != nil || len(mac) != 6 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("macAddress"), intf.MACAddress, "must be a 48-bit MAC address"))
+		} else if mac[0]&0x01 != 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("macAddress"), intf.MACAddress, "must be a unicast MAC address"))
+		}
+	}
+
+	if intf.MTU != 0 && (intf.MTU < 68 || intf.MTU > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mtu"), intf.MTU, "must be between 68 and 65535"))
+	}
+
+	if len(intf.GuestName) > 0 {
+		allErrs = append(allErrs, ValidateInterfaceName(intf.GuestName, fldPath.Child("guestName"))...)
+
+		// The guest matches the interface by MAC address to rename it, tc-redirect
+		// interfaces keep the MAC of the container interface unless one is given
+		if intf.Mode == api.InterfaceModeTCRedirect && len(intf.MACAddress) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("macAddress"), "a MAC address is required to rename tc-redirect interfaces"))
+		}
+	}
+
+	return
+}
+
+// ValidatePassthroughInterface validates the static address of a macvlan or ipvlan interface
+func ValidatePassthroughInterface(intf *api.NetworkInterface, fldPath *field.Path) (allErrs field.ErrorList) {
+	// ipvlan devices share the MAC address of their parent, the host's DHCP server can't tell the VM apart
+	if intf.Mode == api.InterfaceModeIPVLAN {
+		if len(intf.MACAddress) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("macAddress"), "ipvlan interfaces use the MAC address of their parent device"))
+		}
+
+		if intf.Address == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("address"), "ipvlan interfaces require a static address"))
+		}
+
+		if len(intf.GuestName) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("guestName"), "ipvlan interfaces can't be renamed, as their MAC address isn't known in advance"))
+		}
+	}
+
+	if intf.Address == nil {
+		return
+	}
+
+	// There's no sandbox address to inherit the prefix length and gateway from, ignite-spawn
+	// serves the static address over DHCP using the gateway as the server address
+	addrPath := fldPath.Child("address")
+	if ip, mask, err := intf.Address.Parse(); err == nil {
+		if ip.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(addrPath.Child("ip"), intf.Address.IP, "only IPv4 static addresses are supported for macvlan and ipvlan interfaces"))
+		} else if mask == nil {
+			allErrs = append(allErrs, field.Invalid(addrPath.Child("ip"), intf.Address.IP, "the prefix length is required for macvlan and ipvlan interfaces"))
+		}
+	}
+
+	if intf.Address.Gateway == nil {
+		allErrs = append(allErrs, field.Required(addrPath.Child("gateway"), "the gateway is required for macvlan and ipvlan interfaces"))
+	}
+
+	return
+}
+
+// ValidateInterfaceName validates that the given name can be used as the name of a network interface
+func ValidateInterfaceName(name string, fldPath *field.Path) (allErrs field.ErrorList) {
+	if len(name) > 15 || strings.ContainsAny(name, "/ \t\n") {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "must be at most 15 characters and not contain slashes or whitespace"))
+	}
+
+	return
+}
+
 // ValidateNonemptyName validated that the given name is nonempty
 func ValidateNonemptyName(name string, fldPath *field.Path) (allErrs field.ErrorList) {
 	if util.IsEmptyString(name) {
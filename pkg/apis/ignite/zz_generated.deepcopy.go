@@ -10,6 +10,7 @@ import (
 
 	v1alpha1 "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	pkgruntime "github.com/weaveworks/libgitops/pkg/runtime"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -29,6 +30,55 @@ func (in *BlockDeviceVolume) DeepCopy() *BlockDeviceVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNIBandwidth) DeepCopyInto(out *CNIBandwidth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNIBandwidth.
+func (in *CNIBandwidth) DeepCopy() *CNIBandwidth {
+	if in == nil {
+		return nil
+	}
+	out := new(CNIBandwidth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNINetworkAttachment) DeepCopyInto(out *CNINetworkAttachment) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Bandwidth != nil {
+		in, out := &in.Bandwidth, &out.Bandwidth
+		*out = new(CNIBandwidth)
+		**out = **in
+	}
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNINetworkAttachment.
+func (in *CNINetworkAttachment) DeepCopy() *CNINetworkAttachment {
+	if in == nil {
+		return nil
+	}
+	out := new(CNINetworkAttachment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Configuration) DeepCopyInto(out *Configuration) {
 	*out = *in
@@ -60,6 +110,16 @@ func (in *Configuration) DeepCopyObject() runtime.Object {
 func (in *ConfigurationSpec) DeepCopyInto(out *ConfigurationSpec) {
 	*out = *in
 	in.VMDefaults.DeepCopyInto(&out.VMDefaults)
+	if in.CNIBinDirs != nil {
+		in, out := &in.CNIBinDirs, &out.CNIBinDirs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ThinPool != nil {
+		in, out := &in.ThinPool, &out.ThinPool
+		*out = new(PoolSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -73,6 +133,82 @@ func (in *ConfigurationSpec) DeepCopy() *ConfigurationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPOptions) DeepCopyInto(out *DHCPOptions) {
+	*out = *in
+	if in.NameServers != nil {
+		in, out := &in.NameServers, &out.NameServers
+		*out = make(v1alpha1.IPAddresses, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(net.IP, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make(v1alpha1.IPAddresses, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(net.IP, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]DHCPRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LeaseDuration != nil {
+		in, out := &in.LeaseDuration, &out.LeaseDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPOptions.
+func (in *DHCPOptions) DeepCopy() *DHCPOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPRoute) DeepCopyInto(out *DHCPRoute) {
+	*out = *in
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = make(net.IP, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPRoute.
+func (in *DHCPRoute) DeepCopy() *DHCPRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FileMapping) DeepCopyInto(out *FileMapping) {
 	*out = *in
@@ -151,6 +287,27 @@ func (in *ImageStatus) DeepCopy() *ImageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterfaceAddress) DeepCopyInto(out *InterfaceAddress) {
+	*out = *in
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = make(net.IP, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterfaceAddress.
+func (in *InterfaceAddress) DeepCopy() *InterfaceAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(InterfaceAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Kernel) DeepCopyInto(out *Kernel) {
 	*out = *in
@@ -215,6 +372,33 @@ func (in *KernelStatus) DeepCopy() *KernelStatus {
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Network) DeepCopyInto(out *Network) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
+func (in *Network) DeepCopy() *Network {
+	if in == nil {
+		return nil
+	}
+	out := new(Network)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Network) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAttachmentStatus) DeepCopyInto(out *NetworkAttachmentStatus) {
 	*out = *in
 	if in.IPAddresses != nil {
 		in, out := &in.IPAddresses, &out.IPAddresses
@@ -230,12 +414,145 @@ func (in *Network) DeepCopyInto(out *Network) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
-func (in *Network) DeepCopy() *Network {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAttachmentStatus.
+func (in *NetworkAttachmentStatus) DeepCopy() *NetworkAttachmentStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Network)
+	out := new(NetworkAttachmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterface) DeepCopyInto(out *NetworkInterface) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(InterfaceAddress)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterface.
+func (in *NetworkInterface) DeepCopy() *NetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicy) DeepCopyInto(out *NetworkPolicy) {
+	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(NetworkPolicyRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = new(NetworkPolicyRules)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicy.
+func (in *NetworkPolicy) DeepCopy() *NetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyPort) DeepCopyInto(out *NetworkPolicyPort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyPort.
+func (in *NetworkPolicyPort) DeepCopy() *NetworkPolicyPort {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyRule) DeepCopyInto(out *NetworkPolicyRule) {
+	*out = *in
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]NetworkPolicyPort, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyRule.
+func (in *NetworkPolicyRule) DeepCopy() *NetworkPolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyRules) DeepCopyInto(out *NetworkPolicyRules) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]NetworkPolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyRules.
+func (in *NetworkPolicyRules) DeepCopy() *NetworkPolicyRules {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = make(net.IP, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -457,6 +774,35 @@ func (in *VMNetworkSpec) DeepCopyInto(out *VMNetworkSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]NetworkInterface, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CNINetworks != nil {
+		in, out := &in.CNINetworks, &out.CNINetworks
+		*out = make([]CNINetworkAttachment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DHCP != nil {
+		in, out := &in.DHCP, &out.DHCP
+		*out = new(DHCPOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(NetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -470,6 +816,47 @@ func (in *VMNetworkSpec) DeepCopy() *VMNetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMNetworkStatus) DeepCopyInto(out *VMNetworkStatus) {
+	*out = *in
+	if in.IPAddresses != nil {
+		in, out := &in.IPAddresses, &out.IPAddresses
+		*out = make(v1alpha1.IPAddresses, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(net.IP, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make(v1alpha1.PortMappings, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]NetworkAttachmentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMNetworkStatus.
+func (in *VMNetworkStatus) DeepCopy() *VMNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VMSandboxSpec) DeepCopyInto(out *VMSandboxSpec) {
 	*out = *in
@@ -535,7 +922,7 @@ func (in *VMStatus) DeepCopyInto(out *VMStatus) {
 	}
 	if in.Network != nil {
 		in, out := &in.Network, &out.Network
-		*out = new(Network)
+		*out = new(VMNetworkStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	in.Image.DeepCopyInto(&out.Image)
@@ -1,6 +1,9 @@
 package v1alpha1
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // DMID specifies the format for device mapper IDs
 type DMID struct {
@@ -9,6 +12,9 @@ type DMID struct {
 
 var _ fmt.Stringer = DMID{}
 
+var _ json.Marshaler = DMID{}
+var _ json.Unmarshaler = &DMID{}
+
 func NewDMID(i int) DMID {
 	// device mapper IDs are unsigned 24-bit integers
 	if i < 0 || i >= 1<<24 {
@@ -27,7 +33,7 @@ func NewPoolDMID() DMID {
 	}
 }
 
-func (d *DMID) Pool() bool {
+func (d DMID) Pool() bool {
 	return d.index < 0
 }
 
@@ -46,3 +52,37 @@ func (d DMID) String() string {
 
 	return "pool"
 }
+
+// MarshalJSON encodes the ID of a device as its index, and the pool's ID as "pool"
+func (d DMID) MarshalJSON() ([]byte, error) {
+	if d.Pool() {
+		return json.Marshal(d.String())
+	}
+
+	return json.Marshal(d.index)
+}
+
+// UnmarshalJSON decodes an index or "pool", null also decodes to the pool's ID
+func (d *DMID) UnmarshalJSON(b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+
+	if value == nil || value == "pool" {
+		*d = NewPoolDMID()
+		return nil
+	}
+
+	var index int
+	if err := json.Unmarshal(b, &index); err != nil {
+		return fmt.Errorf("invalid device mapper ID: %s", b)
+	}
+
+	if index < 0 || index >= 1<<24 {
+		return fmt.Errorf("device mapper ID out of range: %d", index)
+	}
+
+	*d = NewDMID(index)
+	return nil
+}
@@ -0,0 +1,50 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDMIDJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		id   DMID
+		out  string
+		fail bool
+	}{
+		{in: `0`, id: NewDMID(0), out: `0`},
+		{in: `42`, id: NewDMID(42), out: `42`},
+		{in: `"pool"`, id: NewPoolDMID(), out: `"pool"`},
+		{in: `null`, id: NewPoolDMID(), out: `"pool"`},
+		{in: `-1`, fail: true},
+		{in: `16777216`, fail: true},
+		{in: `"device"`, fail: true},
+	}
+
+	for _, rt := range tests {
+		t.Run(rt.in, func(t *testing.T) {
+			var id DMID
+			err := json.Unmarshal([]byte(rt.in), &id)
+			if rt.fail {
+				if err == nil {
+					t.Errorf("expected an error decoding %s, got %s", rt.in, id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != rt.id {
+				t.Errorf("expected %s, got %s", rt.id, id)
+			}
+
+			out, err := json.Marshal(id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != rt.out {
+				t.Errorf("expected %s, got %s", rt.out, out)
+			}
+		})
+	}
+}
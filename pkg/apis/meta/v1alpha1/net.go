@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/docker/go-connections/nat"
 )
 
-// PortMapping defines a port mapping between the VM and the host
+// PortMapping defines a port mapping between the VM and the host.
+// A HostPort of zero requests a free host port to be allocated when
+// the VM is started, the allocated port is recorded in the VM status.
 type PortMapping struct {
 	BindAddress net.IP   `json:"bindAddress,omitempty"`
 	HostPort    uint64   `json:"hostPort"`
@@ -23,13 +26,21 @@ var _ fmt.Stringer = PortMapping{}
 func (p PortMapping) String() string {
 	var sb strings.Builder
 
-	if p.BindAddress != nil {
+	if p.BindAddress != nil && p.BindAddress.To4() == nil {
+		// Enclose IPv6 addresses in brackets to separate them from the port
+		sb.WriteString(fmt.Sprintf("[%s]", p.BindAddress))
+	} else if p.BindAddress != nil {
 		sb.WriteString(p.BindAddress.String())
 	} else {
 		sb.WriteString("0.0.0.0")
 	}
 
-	sb.WriteString(fmt.Sprintf(":%d->%d", p.HostPort, p.VMPort))
+	if p.HostPort == 0 {
+		// The host port is allocated when the VM is started
+		sb.WriteString(fmt.Sprintf(":*->%d", p.VMPort))
+	} else {
+		sb.WriteString(fmt.Sprintf(":%d->%d", p.HostPort, p.VMPort))
+	}
 
 	if len(p.Protocol) > 0 {
 		sb.WriteString(fmt.Sprintf("/%s", p.Protocol))
@@ -43,6 +54,10 @@ type PortMappings []PortMapping
 
 var _ fmt.Stringer = PortMappings{}
 
+// ParsePortMappings parses port mappings in the Docker "-p" format, e.g.
+// "80:80", "127.0.0.1:8080:80/udp", "8000-8010:8000-8010" or just "80".
+// If no host port is given, a free host port is allocated on VM start.
+// A VM port can be bound to multiple host addresses/ports.
 func ParsePortMappings(input []string) (PortMappings, error) {
 	result := make(PortMappings, 0, len(input))
 
@@ -52,51 +67,63 @@ func ParsePortMappings(input []string) (PortMappings, error) {
 	}
 
 	for port, bindings := range bindings {
-		if len(bindings) > 1 {
-			// TODO: For now only support mapping a VM port to a single host IP/port
-			return nil, fmt.Errorf("only one host binding per VM binding supported for now, received %d", len(bindings))
-		}
+		for _, binding := range bindings {
+			var err error
+			var bindAddress net.IP
+			var hostPort uint64
+			var vmPort uint64
+			var protocol Protocol
+
+			if len(binding.HostIP) > 0 {
+				if bindAddress = net.ParseIP(binding.HostIP); bindAddress == nil {
+					return nil, fmt.Errorf("invalid bind address: %q", binding.HostIP)
+				}
+			}
 
-		binding := bindings[0]
-		var err error
-		var bindAddress net.IP
-		var hostPort uint64
-		var vmPort uint64
-		var protocol Protocol
+			// An empty host port requests dynamic allocation
+			if len(binding.HostPort) > 0 {
+				if hostPort, err = strconv.ParseUint(binding.HostPort, 10, 16); err != nil {
+					return nil, fmt.Errorf("invalid host port: %q", binding.HostPort)
+				}
+			}
 
-		if len(binding.HostIP) > 0 {
-			if bindAddress = net.ParseIP(binding.HostIP); bindAddress == nil {
-				return nil, fmt.Errorf("invalid bind address: %q", binding.HostIP)
+			if vmPort, err = strconv.ParseUint(port.Port(), 10, 16); err != nil {
+				return nil, fmt.Errorf("invalid VM port: %q", port.Port())
 			}
-		}
 
-		if hostPort, err = strconv.ParseUint(binding.HostPort, 10, 64); err != nil {
-			return nil, fmt.Errorf("invalid host port: %q", binding.HostPort)
-		}
+			if protocol, err = protocolFromString(port.Proto()); err != nil {
+				return nil, err
+			}
 
-		if vmPort, err = strconv.ParseUint(port.Port(), 10, 64); err != nil {
-			return nil, fmt.Errorf("invalid VM port: %q", port.Port())
-		}
+			mapping := PortMapping{
+				BindAddress: bindAddress,
+				HostPort:    hostPort,
+				VMPort:      vmPort,
+				Protocol:    protocol,
+			}
 
-		if protocol, err = protocolFromString(port.Proto()); err != nil {
-			return nil, err
-		}
+			if hostPort != 0 {
+				for _, portMapping := range result {
+					if portMapping.HostPort == mapping.HostPort && portMapping.Protocol == mapping.Protocol {
+						return nil, fmt.Errorf("cannot use a port/protocol combination on the host twice")
+					}
+				}
+			}
 
-		mapping := PortMapping{
-			BindAddress: bindAddress,
-			HostPort:    hostPort,
-			VMPort:      vmPort,
-			Protocol:    protocol,
+			result = append(result, mapping)
 		}
+	}
 
-		for _, portMapping := range result {
-			if portMapping.HostPort == mapping.HostPort && portMapping.Protocol == mapping.Protocol {
-				return nil, fmt.Errorf("cannot use a port/protocol combination on the host twice")
-			}
+	// The bindings are parsed into a map, keep the result in a stable order
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].VMPort != result[j].VMPort {
+			return result[i].VMPort < result[j].VMPort
 		}
-
-		result = append(result, mapping)
-	}
+		if result[i].Protocol != result[j].Protocol {
+			return result[i].Protocol < result[j].Protocol
+		}
+		return result[i].HostPort < result[j].HostPort
+	})
 
 	return result, nil
 }
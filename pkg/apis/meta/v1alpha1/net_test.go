@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestParsePortMappings(t *testing.T) {
+	tests := []struct {
+		in  []string
+		out string
+		err bool
+	}{
+		{
+			in:  []string{"80:80"},
+			out: "0.0.0.0:80->80/tcp",
+		},
+		{
+			in:  []string{"80"},
+			out: "0.0.0.0:*->80/tcp",
+		},
+		{
+			in:  []string{"8000-8002:9000-9002/udp"},
+			out: "0.0.0.0:8000->9000/udp, 0.0.0.0:8001->9001/udp, 0.0.0.0:8002->9002/udp",
+		},
+		{
+			in:  []string{"127.0.0.1:8080:80", "8081:80", "80"},
+			out: "0.0.0.0:*->80/tcp, 127.0.0.1:8080->80/tcp, 0.0.0.0:8081->80/tcp",
+		},
+		{
+			in:  []string{"[::1]:8080:80"},
+			out: "[::1]:8080->80/tcp",
+		},
+		{
+			in:  []string{"8080:80", "8080:81"},
+			err: true,
+		},
+		{
+			in:  []string{"8000-8002:9000-9001"},
+			err: true,
+		},
+	}
+
+	for _, rt := range tests {
+		actual, err := ParsePortMappings(rt.in)
+		if (err != nil) != rt.err {
+			t.Fatalf("expected error %t, actual: %v", rt.err, err)
+		}
+		// Check actual string when there's no error.
+		if err == nil {
+			if actual.String() != rt.out {
+				t.Errorf("expected %q, actual: %q", rt.out, actual.String())
+			}
+		}
+	}
+}
@@ -82,5 +82,6 @@ type IgniteInternalClient struct {
 	vmClient       VMClient
 	kernelClient   KernelClient
 	imageClient    ImageClient
+	networkClient  NetworkClient
 	dynamicClients map[schema.GroupVersionKind]DynamicClient
 }
@@ -0,0 +1,149 @@
+/*
+	Note: This file is autogenerated! Do not edit it manually!
+	Edit client_network_template.go instead, and run
+	hack/generate-client.sh afterwards.
+*/
+
+package client
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/libgitops/pkg/runtime"
+	"github.com/weaveworks/libgitops/pkg/storage"
+	"github.com/weaveworks/libgitops/pkg/storage/filterer"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NetworkClient is an interface for accessing Network-specific API objects
+type NetworkClient interface {
+	// New returns a new Network
+	New() *api.Network
+	// Get returns the Network matching given UID from the storage
+	Get(runtime.UID) (*api.Network, error)
+	// Set saves the given Network into persistent storage
+	Set(*api.Network) error
+	// Patch performs a strategic merge patch on the object with
+	// the given UID, using the byte-encoded patch given
+	Patch(runtime.UID, []byte) error
+	// Find returns the Network matching the given filter, filters can
+	// match e.g. the Object's Name, UID or a specific property
+	Find(filter filterer.BaseFilter) (*api.Network, error)
+	// FindAll returns multiple Networks matching the given filter, filters can
+	// match e.g. the Object's Name, UID or a specific property
+	FindAll(filter filterer.BaseFilter) ([]*api.Network, error)
+	// Delete deletes the Network with the given UID from the storage
+	Delete(uid runtime.UID) error
+	// List returns a list of all Networks available
+	List() ([]*api.Network, error)
+}
+
+// Networks returns the NetworkClient for the IgniteInternalClient instance
+func (c *IgniteInternalClient) Networks() NetworkClient {
+	if c.networkClient == nil {
+		c.networkClient = newNetworkClient(c.storage, c.gv)
+	}
+
+	return c.networkClient
+}
+
+// networkClient is a struct implementing the NetworkClient interface
+// It uses a shared storage instance passed from the Client together with its own Filterer
+type networkClient struct {
+	storage  storage.Storage
+	filterer *filterer.Filterer
+	gvk      schema.GroupVersionKind
+}
+
+// newNetworkClient builds the networkClient struct using the storage implementation and a new Filterer
+func newNetworkClient(s storage.Storage, gv schema.GroupVersion) NetworkClient {
+	return &networkClient{
+		storage:  s,
+		filterer: filterer.NewFilterer(s),
+		gvk:      gv.WithKind(api.KindNetwork.Title()),
+	}
+}
+
+// New returns a new Object of its kind
+func (c *networkClient) New() *api.Network {
+	log.Tracef("Client.New; GVK: %v", c.gvk)
+	obj, err := c.storage.New(c.gvk)
+	if err != nil {
+		panic(fmt.Sprintf("Client.New must not return an error: %v", err))
+	}
+	return obj.(*api.Network)
+}
+
+// Find returns a single Network based on the given Filter
+func (c *networkClient) Find(filter filterer.BaseFilter) (*api.Network, error) {
+	log.Tracef("Client.Find; GVK: %v", c.gvk)
+	object, err := c.filterer.Find(c.gvk, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.(*api.Network), nil
+}
+
+// FindAll returns multiple Networks based on the given Filter
+func (c *networkClient) FindAll(filter filterer.BaseFilter) ([]*api.Network, error) {
+	log.Tracef("Client.FindAll; GVK: %v", c.gvk)
+	matches, err := c.filterer.FindAll(c.gvk, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*api.Network, 0, len(matches))
+	for _, item := range matches {
+		results = append(results, item.(*api.Network))
+	}
+
+	return results, nil
+}
+
+// Get returns the Network matching given UID from the storage
+func (c *networkClient) Get(uid runtime.UID) (*api.Network, error) {
+	log.Tracef("Client.Get; UID: %q, GVK: %v", uid, c.gvk)
+	object, err := c.storage.Get(c.gvk, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.(*api.Network), nil
+}
+
+// Set saves the given Network into the persistent storage
+func (c *networkClient) Set(network *api.Network) error {
+	log.Tracef("Client.Set; UID: %q, GVK: %v", network.GetUID(), c.gvk)
+	return c.storage.Set(c.gvk, network)
+}
+
+// Patch performs a strategic merge patch on the object with
+// the given UID, using the byte-encoded patch given
+func (c *networkClient) Patch(uid runtime.UID, patch []byte) error {
+	return c.storage.Patch(c.gvk, uid, patch)
+}
+
+// Delete deletes the Network from the storage
+func (c *networkClient) Delete(uid runtime.UID) error {
+	log.Tracef("Client.Delete; UID: %q, GVK: %v", uid, c.gvk)
+	return c.storage.Delete(c.gvk, uid)
+}
+
+// List returns a list of all Networks available
+func (c *networkClient) List() ([]*api.Network, error) {
+	log.Tracef("Client.List; GVK: %v", c.gvk)
+	list, err := c.storage.List(c.gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*api.Network, 0, len(list))
+	for _, item := range list {
+		results = append(results, item.(*api.Network))
+	}
+
+	return results, nil
+}
@@ -49,6 +49,9 @@ func ApplyConfiguration(configPath string) error {
 		if providers.ComponentConfig.Spec.IDPrefix != "" && providers.IDPrefix == "" {
 			providers.IDPrefix = providers.ComponentConfig.Spec.IDPrefix
 		}
+		if providers.ComponentConfig.Spec.StorageDriver != "" && providers.StorageDriverName == "" {
+			providers.StorageDriverName = providers.ComponentConfig.Spec.StorageDriver
+		}
 	} else {
 		log.Debugln("Using ignite default configurations")
 	}
@@ -64,6 +67,9 @@ func ApplyConfiguration(configPath string) error {
 	if providers.IDPrefix == "" {
 		providers.IDPrefix = constants.IGNITE_PREFIX
 	}
+	if providers.StorageDriverName == "" {
+		providers.StorageDriverName = api.StorageDriverLegacy
+	}
 
 	return nil
 }
@@ -1,25 +1,33 @@
 package constants
 
 var BinaryDependencies = [...]string{
-	"mount",
-	"umount",
 	"tar",
 	"mkfs.ext4",
 	"e2fsck",
 	"resize2fs",
+	"debugfs",
 	"strings",
-	"dmsetup",
 	"ssh",
 	"git",
 }
 
 var PathDependencies = [...]string{
-	"/dev/mapper/control",
 	"/dev/net/tun",
 	"/dev/kvm",
 }
 
-var CNIDependencies = [...]string{
-	"/opt/cni/bin/loopback",
-	"/opt/cni/bin/bridge",
+var BridgeDependencies = [...]string{
+	"iptables",
+}
+
+// DMBinaryDependencies are needed by the device mapper storage drivers
+var DMBinaryDependencies = [...]string{
+	"mount",
+	"umount",
+	"dmsetup",
+}
+
+// DMPathDependencies are needed by the device mapper storage drivers
+var DMPathDependencies = [...]string{
+	"/dev/mapper/control",
 }
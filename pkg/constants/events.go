@@ -0,0 +1,6 @@
+package constants
+
+const (
+	// Path to the append-only log of object lifecycle events
+	EVENTS_FILE = DATA_DIR + "/events.log"
+)
@@ -0,0 +1,27 @@
+package constants
+
+const (
+	// Path to directory containing a subdirectory for each user-defined network
+	NETWORK_DIR = DATA_DIR + "/network"
+
+	// Filename of the CNI configuration list generated for a user-defined network
+	NETWORK_CONFLIST = "cni.conflist"
+
+	// Prefix of the names of the bridges and CNI networks created for user-defined networks
+	NETWORK_NAME_PREFIX = "ignite-"
+
+	// Path to the directory containing the state of ignite's own network plugins
+	IPAM_DIR = DATA_DIR + "/ipam"
+
+	// Path to the file recording the addresses and port forwards handed out by the bridge network plugin
+	BRIDGE_IPAM_FILE = IPAM_DIR + "/bridge.json"
+
+	// Path to the file recording the addresses handed out by the isolated network plugin
+	ISOLATED_IPAM_FILE = IPAM_DIR + "/isolated.json"
+
+	// Name of the network of VMs that aren't attached to user-defined networks, in DNS names
+	DEFAULT_NETWORK_NAME = "default"
+
+	// Domain of the DNS names of the VMs answered by the embedded DNS resolver, <vm-name>.<network>.ignite
+	DNS_DOMAIN = "ignite"
+)
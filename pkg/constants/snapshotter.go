@@ -18,4 +18,7 @@ const (
 	// Paths to the default data and metadata backing files
 	SNAPSHOTTER_METADATA_PATH = SNAPSHOTTER_DIR + "/metadata.dm"
 	SNAPSHOTTER_DATA_PATH     = SNAPSHOTTER_DIR + "/data.dm"
+
+	// Path to the database of the devices in the pool
+	SNAPSHOTTER_POOL_FILE = SNAPSHOTTER_DIR + "/pool.json"
 )
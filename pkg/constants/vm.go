@@ -21,6 +21,12 @@ const (
 	// TODO: remove this when the old dm code is removed
 	OVERLAY_FILE = "overlay.dm"
 
+	// Filename of the disk of VMs stored by the reflink storage driver
+	DISK_FILE = "disk.ext4"
+
+	// Filename of the lock held while a VM is started, until its container runs
+	VM_START_LOCK = "start.lock"
+
 	// Prometheus socket filename
 	PROMETHEUS_SOCKET = "prometheus.sock"
 
@@ -30,6 +36,9 @@ const (
 	// Where the vmlinux kernel is located inside of the container
 	IGNITE_SPAWN_VMLINUX_FILE_PATH = "/vmlinux"
 
+	// Where the event log is located inside of the container
+	IGNITE_SPAWN_EVENTS_FILE_PATH = "/events.log"
+
 	// Subdirectory for volumes to be forwarded into the VM
 	IGNITE_SPAWN_VOLUME_DIR = "/volumes"
 
@@ -44,6 +53,9 @@ const (
 	// IGNITE_INTERFACE_ANNOTATION is the annotation prefix to store a list of extra interfaces
 	IGNITE_INTERFACE_ANNOTATION = "ignite.weave.works/interface/"
 
+	// IGNITE_SPAWN_MAIN_INTERFACE is the sandbox interface set up by the network plugin, it's always passed to the VM
+	IGNITE_SPAWN_MAIN_INTERFACE = "eth0"
+
 	// IGNITE_SANDBOX_ENV_VAR is the annotation prefix to store a list of env variables
 	IGNITE_SANDBOX_ENV_VAR = "ignite.weave.works/sandbox-env/"
 
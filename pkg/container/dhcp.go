@@ -1,6 +1,8 @@
 package container
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"time"
@@ -11,13 +13,15 @@ import (
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/constants"
+	"golang.org/x/sys/unix"
 )
 
 var leaseDuration, _ = time.ParseDuration(constants.DHCP_INFINITE_LEASE) // Infinite lease time
 
 // StartDHCPServers starts multiple DHCP servers for the VM, one per interface
 // It returns the IP addresses that the API object may post in .status, and a potential error
-func StartDHCPServers(vm *api.VM, dhcpIfaces []DHCPInterface) error {
+// With embeddedDNS, the host bridges are handed out as the first DNS servers, where the VMs resolve each other.
+func StartDHCPServers(vm *api.VM, dhcpIfaces []DHCPInterface, embeddedDNS bool) error {
 
 	// Fetch the DNS servers given to the container
 	clientConfig, err := dns.ClientConfigFromFile("/etc/resolv.conf")
@@ -25,37 +29,98 @@ func StartDHCPServers(vm *api.VM, dhcpIfaces []DHCPInterface) error {
 		return fmt.Errorf("failed to get DNS configuration: %v", err)
 	}
 
+	options := vm.Spec.Network.DHCP
+	if options == nil {
+		options = &api.DHCPOptions{}
+	}
+
 	for i := range dhcpIfaces {
 		dhcpIface := &dhcpIfaces[i]
 		// Set the VM hostname to the VM ID
 		dhcpIface.Hostname = vm.GetUID().String()
 
 		// Add the DNS servers from the container
-		dhcpIface.SetDNSServers(clientConfig.Servers)
+		servers := clientConfig.Servers
+		if embeddedDNS && len(dhcpIface.Network) > 0 && dhcpIface.VMIPNet != nil {
+			// The embedded DNS resolver serves on the address of the bridge, the VM looks up the other
+			// VMs of its network by their name with the domain of the network as the search domain
+			servers = append([]string{dhcpIface.serverIP().String()}, servers...)
+			dhcpIface.domainName = dhcpIface.Network + "." + constants.DNS_DOMAIN
+		}
 
-		go func() {
-			log.Infof("Starting DHCP server for interface %q (%s)\n", dhcpIface.Bridge, dhcpIface.VMIPNet.IP)
-			if err := dhcpIface.StartBlockingServer(); err != nil {
-				log.Errorf("%q DHCP server error: %v\n", dhcpIface.Bridge, err)
+		// The DNS servers configured for the VM replace all others
+		if len(options.NameServers) > 0 {
+			servers = make([]string, 0, len(options.NameServers))
+			for _, ip := range options.NameServers {
+				servers = append(servers, ip.String())
 			}
-		}()
+		}
+		dhcpIface.SetDNSServers(servers)
+
+		if err := dhcpIface.SetOptions(options); err != nil {
+			return fmt.Errorf("invalid DHCP options for interface %q: %v", dhcpIface.Bridge, err)
+		}
+
+		if dhcpIface.VMIPNet != nil {
+			go func() {
+				log.Infof("Starting DHCP server for interface %q (%s)\n", dhcpIface.Bridge, dhcpIface.VMIPNet.IP)
+				if err := dhcpIface.StartBlockingServer(); err != nil {
+					log.Errorf("%q DHCP server error: %v\n", dhcpIface.Bridge, err)
+				}
+			}()
+		}
+
+		if dhcpIface.VMIPv6Net != nil {
+			go func() {
+				log.Infof("Starting DHCPv6 server for interface %q (%s)\n", dhcpIface.Bridge, dhcpIface.VMIPv6Net.IP)
+				if err := dhcpIface.StartBlockingServer6(); err != nil {
+					log.Errorf("%q DHCPv6 server error: %v\n", dhcpIface.Bridge, err)
+				}
+			}()
+		}
 	}
 
 	return nil
 }
 
 type DHCPInterface struct {
-	VMIPNet    *net.IPNet
-	GatewayIP  *net.IP
-	VMTAP      string
-	Bridge     string
-	Hostname   string
-	MACFilter  string
-	dnsServers []byte
+	VMIPNet   *net.IPNet
+	GatewayIP *net.IP
+	VMIPv6Net *net.IPNet
+	// GatewayIPv6 is the link-local address of the IPv6 router advertised to the VM
+	GatewayIPv6 *net.IP
+	GatewayMAC  net.HardwareAddr
+	VMTAP       string
+	Bridge      string
+	Hostname    string
+	MACFilter   string
+	MTU         uint16
+	// Network is the name of the network the interface is attached to, if any
+	Network     string
+	dnsServers  []byte
+	dnsServers6 []byte
+	domainName  string
+	// domainSearch is the list of search domains in DNS wire format, shared by DHCP and DHCPv6
+	domainSearch  []byte
+	ntpServers    []byte
+	routes        []byte
+	leaseDuration time.Duration
 }
 
-// StartBlockingServer starts a blocking DHCP server on port 67
+// StartBlockingServer starts a blocking DHCP server on port 67. With a finite lease, the VM renews it by
+// unicasting to the server identifier, which is the gateway or the host bridge, not an address of the
+// container. The server then talks Ethernet on the TAP device of the VM, where it sees these requests too.
 func (i *DHCPInterface) StartBlockingServer() error {
+	if i.lease() != leaseDuration {
+		tc, err := i.newTAPConn()
+		if err != nil {
+			return err
+		}
+		defer tc.Close()
+
+		return dhcp.Serve(tc, i)
+	}
+
 	packetConn, err := conn.NewUDP4BoundListener(i.Bridge, ":67")
 	if err != nil {
 		return err
@@ -64,6 +129,187 @@ func (i *DHCPInterface) StartBlockingServer() error {
 	return dhcp.Serve(packetConn, i)
 }
 
+// dhcpRequestFilter only passes the IPv4 UDP datagrams to port 67 that aren't fragments to the
+// packet socket of the TAP device, like the BPF program of tcpdump for "ip and udp dst port 67"
+var dhcpRequestFilter = []unix.SockFilter{
+	// Load the EtherType, it needs to be IPv4
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_ABS, K: 12},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 8, K: unix.ETH_P_IP},
+	// Load the IP protocol, it needs to be UDP
+	{Code: unix.BPF_LD | unix.BPF_B | unix.BPF_ABS, K: ethernetHeaderLen + 9},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 6, K: protocolUDP},
+	// Load the fragment offset, it needs to be the first fragment
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_ABS, K: ethernetHeaderLen + 6},
+	{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: 4, K: 0x1fff},
+	// Load the IP header length into X and the UDP destination port after it, it needs to be 67
+	{Code: unix.BPF_LDX | unix.BPF_B | unix.BPF_MSH, K: ethernetHeaderLen},
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_IND, K: ethernetHeaderLen + 2},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 1, K: dhcpServerPort},
+	// Accept the whole frame, or drop it
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0xffff},
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0},
+}
+
+// tapConn reads the DHCP requests of the VM from its TAP device, both the broadcast ones and the ones
+// unicast to the server identifier, and writes the replies to the VM as Ethernet frames. The frames the
+// VM sends are seen by packet sockets before they're bridged, so the container doesn't need an address.
+type tapConn struct {
+	fd     int
+	tap    *net.Interface
+	buf    []byte
+	vmMAC  net.HardwareAddr
+	srcMAC net.HardwareAddr
+	srcIP  net.IP
+}
+
+// Compile-time assert to verify interface compatibility
+var _ dhcp.ServeConn = &tapConn{}
+
+func (i *DHCPInterface) newTAPConn() (*tapConn, error) {
+	bridge, err := net.InterfaceByName(i.Bridge)
+	if err != nil {
+		return nil, err
+	}
+
+	tap, err := net.InterfaceByName(i.VMTAP)
+	if err != nil {
+		return nil, err
+	}
+
+	vmMAC, err := net.ParseMAC(i.MACFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Frames sent to a bridge port are only seen by packet sockets for all protocols
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open packet socket: %v", err)
+	}
+
+	filter := &unix.SockFprog{Len: uint16(len(dhcpRequestFilter)), Filter: &dhcpRequestFilter[0]}
+	if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, filter); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to filter packet socket: %v", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: tap.Index}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind packet socket to %q: %v", i.VMTAP, err)
+	}
+
+	return &tapConn{
+		fd:     fd,
+		tap:    tap,
+		buf:    make([]byte, 65536),
+		vmMAC:  vmMAC,
+		srcMAC: bridge.HardwareAddr,
+		srcIP:  i.serverIP(),
+	}, nil
+}
+
+// ReadFrom returns the payload of the next DHCP request of the VM and its source address
+func (c *tapConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		n, _, err := unix.Recvfrom(c.fd, c.buf, 0)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if payload, addr := parseDHCPRequest(c.buf[:n], c.vmMAC); payload != nil {
+			return copy(b, payload), addr, nil
+		}
+	}
+}
+
+// WriteTo sends a DHCP reply to the given address of the VM, or broadcasts it to the VM
+func (c *tapConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, fmt.Errorf("unsupported address %v", addr)
+	}
+
+	frame := ethernetFrame(c.vmMAC, c.srcMAC, unix.ETH_P_IP,
+		ipv4Packet(c.srcIP, udpAddr.IP, protocolUDP, udpDatagram4(dhcpServerPort, uint16(udpAddr.Port), b)))
+
+	sa := &unix.SockaddrLinklayer{Ifindex: c.tap.Index, Halen: uint8(len(c.vmMAC))}
+	copy(sa.Addr[:], c.vmMAC)
+	if err := unix.Sendto(c.fd, frame, 0, sa); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// Close closes the packet socket
+func (c *tapConn) Close() error {
+	return unix.Close(c.fd)
+}
+
+// parseDHCPRequest returns the payload and the source address of a DHCP request sent by the VM in
+// the given Ethernet frame, or nil if the frame doesn't contain one
+func parseDHCPRequest(frame []byte, vmMAC net.HardwareAddr) ([]byte, *net.UDPAddr) {
+	if len(frame) < ethernetHeaderLen+ipv4HeaderLen+udpHeaderLen || !bytes.Equal(frame[6:12], vmMAC) ||
+		binary.BigEndian.Uint16(frame[12:14]) != unix.ETH_P_IP {
+		return nil, nil
+	}
+
+	packet := frame[ethernetHeaderLen:]
+	headerLen := int(packet[0]&0x0f) * 4
+	totalLen := int(binary.BigEndian.Uint16(packet[2:4]))
+	if packet[0]>>4 != 4 || headerLen < ipv4HeaderLen || totalLen < headerLen+udpHeaderLen || len(packet) < totalLen ||
+		packet[9] != protocolUDP || binary.BigEndian.Uint16(packet[6:8])&0x3fff != 0 {
+		return nil, nil
+	}
+
+	datagram := packet[headerLen:totalLen]
+	udpLen := int(binary.BigEndian.Uint16(datagram[4:6]))
+	if binary.BigEndian.Uint16(datagram[2:4]) != dhcpServerPort || udpLen < udpHeaderLen || len(datagram) < udpLen {
+		return nil, nil
+	}
+
+	addr := &net.UDPAddr{
+		IP:   net.IP(append([]byte(nil), packet[12:16]...)),
+		Port: int(binary.BigEndian.Uint16(datagram[0:2])),
+	}
+
+	return datagram[udpHeaderLen:udpLen], addr
+}
+
+func ipv4Packet(src, dst net.IP, protocol byte, payload []byte) []byte {
+	packet := make([]byte, ipv4HeaderLen, ipv4HeaderLen+len(payload))
+	packet[0] = 4<<4 | ipv4HeaderLen/4
+	binary.BigEndian.PutUint16(packet[2:4], uint16(ipv4HeaderLen+len(payload)))
+	packet[8] = 64 // TTL
+	packet[9] = protocol
+	copy(packet[12:16], src.To4())
+	copy(packet[16:20], dst.To4())
+	binary.BigEndian.PutUint16(packet[10:12], checksum(packet))
+	return append(packet, payload...)
+}
+
+// udpDatagram4 builds an UDP datagram for IPv4, where the checksum is optional and left out
+func udpDatagram4(srcPort, dstPort uint16, payload []byte) []byte {
+	datagram := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(datagram[0:2], srcPort)
+	binary.BigEndian.PutUint16(datagram[2:4], dstPort)
+	binary.BigEndian.PutUint16(datagram[4:6], uint16(udpHeaderLen+len(payload)))
+	return append(datagram, payload...)
+}
+
+// checksum calculates the checksum of an IPv4 header
+func checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		sum += uint32(header[i])<<8 | uint32(header[i+1])
+	}
+
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
 // ServeDHCP responds to a DHCP request
 func (i *DHCPInterface) ServeDHCP(p dhcp.Packet, msgType dhcp.MessageType, options dhcp.Options) dhcp.Packet {
 	var respMsg dhcp.MessageType
@@ -81,23 +327,173 @@ func (i *DHCPInterface) ServeDHCP(p dhcp.Packet, msgType dhcp.MessageType, optio
 		if requestingMAC == i.MACFilter {
 			opts := dhcp.Options{
 				dhcp.OptionSubnetMask:       []byte(i.VMIPNet.Mask),
-				dhcp.OptionRouter:           []byte(*i.GatewayIP),
 				dhcp.OptionDomainNameServer: i.dnsServers,
 				dhcp.OptionHostName:         []byte(i.Hostname),
 			}
 
+			// Networks without external connectivity have no gateway, the VM gets no default route
+			if i.GatewayIP != nil {
+				opts[dhcp.OptionRouter] = []byte(*i.GatewayIP)
+			}
+
+			if len(i.domainName) > 0 {
+				opts[dhcp.OptionDomainName] = []byte(i.domainName)
+			}
+
+			if len(i.domainSearch) > 0 {
+				opts[dhcp.OptionDomainSearch] = i.domainSearch
+			}
+
+			if len(i.ntpServers) > 0 {
+				opts[dhcp.OptionNetworkTimeProtocolServers] = i.ntpServers
+			}
+
+			// The VM ignores the router option when given classless routes, they include the default route
+			if len(i.routes) > 0 {
+				opts[dhcp.OptionClasslessRouteFormat] = i.routes
+			}
+
+			if i.MTU > 0 {
+				opts[dhcp.OptionInterfaceMTU] = []byte{byte(i.MTU >> 8), byte(i.MTU)}
+			}
+
 			optSlice := opts.SelectOrderOrAll(options[dhcp.OptionParameterRequestList])
 			//fmt.Printf("Response: %s, Source %s, Client: %s, Options: %v, MAC: %s\n", respMsg.String(), i.GatewayIP.String(), i.VMIPNet.IP.String(), optSlice, requestingMAC)
-			return dhcp.ReplyPacket(p, respMsg, *i.GatewayIP, i.VMIPNet.IP, leaseDuration, optSlice)
+			return dhcp.ReplyPacket(p, respMsg, i.serverIP(), i.VMIPNet.IP, i.lease(), optSlice)
 		}
 	}
 
 	return nil
 }
 
-// Parse the DNS servers for the DHCP server
+// serverIP returns the address the DHCP server identifies itself with. This is the gateway, or the first
+// address of the VM's subnet if there's none, which is the address of the host bridge for the isolated network.
+func (i *DHCPInterface) serverIP() net.IP {
+	if i.GatewayIP != nil {
+		return *i.GatewayIP
+	}
+
+	ip := i.VMIPNet.IP.Mask(i.VMIPNet.Mask)
+	ip[len(ip)-1]++
+	return ip
+}
+
+// Parse the DNS servers for the DHCP and DHCPv6 servers
 func (i *DHCPInterface) SetDNSServers(dns []string) {
 	for _, server := range dns {
-		i.dnsServers = append(i.dnsServers, []byte(net.ParseIP(server).To4())...)
+		ip := net.ParseIP(server)
+		if ip == nil {
+			continue
+		}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			i.dnsServers = append(i.dnsServers, []byte(ip4)...)
+		} else {
+			i.dnsServers6 = append(i.dnsServers6, []byte(ip)...)
+		}
+	}
+}
+
+// SetOptions applies the DHCP options configured for the VM to the interface, except for the DNS servers
+func (i *DHCPInterface) SetOptions(o *api.DHCPOptions) (err error) {
+	if len(o.DomainName) > 0 {
+		i.domainName = o.DomainName
+	}
+
+	if i.domainSearch, err = encodeDomainSearch(o.SearchDomains); err != nil {
+		return
+	}
+
+	i.ntpServers = nil
+	for _, ip := range o.NTPServers {
+		if ip4 := ip.To4(); ip4 != nil {
+			i.ntpServers = append(i.ntpServers, ip4...)
+		}
+	}
+
+	if i.VMIPNet != nil {
+		if i.routes, err = encodeClasslessRoutes(o.Routes, i.VMIPNet, i.GatewayIP); err != nil {
+			return
+		}
+	}
+
+	if o.LeaseDuration != nil {
+		i.leaseDuration = o.LeaseDuration.Duration
 	}
+
+	return
+}
+
+// lease returns the lease duration handed out to the VM, the leases are infinite by default
+func (i *DHCPInterface) lease() time.Duration {
+	if i.leaseDuration > 0 && i.leaseDuration < leaseDuration {
+		return i.leaseDuration
+	}
+
+	return leaseDuration
+}
+
+// encodeDomainSearch encodes the search domains in DNS wire format for the domain search option (RFC 3397)
+func encodeDomainSearch(domains []string) ([]byte, error) {
+	var b []byte
+	for _, domain := range domains {
+		buf := make([]byte, 256)
+		n, err := dns.PackDomainName(dns.Fqdn(domain), buf, 0, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search domain %q: %v", domain, err)
+		}
+
+		b = append(b, buf[:n]...)
+	}
+
+	if len(b) > 255 {
+		return nil, fmt.Errorf("the search domains exceed the maximum option length of 255 bytes")
+	}
+
+	return b, nil
+}
+
+// encodeClasslessRoutes encodes the routes with a gateway in the given subnet for the classless static route
+// option (RFC 3442). DHCP clients ignore the router option when given classless routes, so the default route
+// through the given gateway is included. No routes are returned if none of them apply to the subnet.
+func encodeClasslessRoutes(routes []api.DHCPRoute, subnet *net.IPNet, gateway *net.IP) ([]byte, error) {
+	var b []byte
+	for _, route := range routes {
+		gw := route.Gateway.To4()
+		if gw == nil || !subnet.Contains(gw) {
+			continue
+		}
+
+		_, dest, err := net.ParseCIDR(route.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route destination %q: %v", route.Destination, err)
+		}
+
+		destIP := dest.IP.To4()
+		if destIP == nil {
+			return nil, fmt.Errorf("route destination %q is not an IPv4 subnet", route.Destination)
+		}
+
+		// Only the significant octets of the destination are encoded
+		ones, _ := dest.Mask.Size()
+		b = append(b, byte(ones))
+		b = append(b, destIP[:(ones+7)/8]...)
+		b = append(b, gw...)
+	}
+
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	if gateway != nil {
+		if gw := gateway.To4(); gw != nil {
+			b = append([]byte{0, gw[0], gw[1], gw[2], gw[3]}, b...)
+		}
+	}
+
+	if len(b) > 255 {
+		return nil, fmt.Errorf("the routes exceed the maximum option length of 255 bytes")
+	}
+
+	return b, nil
 }
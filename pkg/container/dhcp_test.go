@@ -0,0 +1,196 @@
+package container
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	dhcp "github.com/krolaw/dhcp4"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"golang.org/x/sys/unix"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDHCP(t *testing.T) {
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	ip, ipNet, _ := net.ParseCIDR("10.63.0.5/16")
+	ipNet.IP = ip.To4()
+	gw := net.ParseIP("10.63.0.254").To4()
+
+	cases := []struct {
+		name       string
+		gateway    *net.IP
+		domainName string
+		wantServer string
+		wantRouter bool
+	}{
+		{
+			name:       "gateway",
+			gateway:    &gw,
+			wantServer: "10.63.0.254",
+			wantRouter: true,
+		},
+		{
+			name:       "no gateway",
+			wantServer: "10.63.0.1",
+		},
+		{
+			name:       "domain name",
+			gateway:    &gw,
+			domainName: "team-a.ignite",
+			wantServer: "10.63.0.254",
+			wantRouter: true,
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			iface := &DHCPInterface{VMIPNet: ipNet, GatewayIP: rt.gateway, MACFilter: mac.String(), domainName: rt.domainName}
+			iface.SetDNSServers([]string{"10.0.0.1"})
+
+			request := dhcp.RequestPacket(dhcp.Discover, mac, nil, []byte{1, 2, 3, 4}, false, nil)
+			reply := iface.ServeDHCP(request, dhcp.Discover, request.ParseOptions())
+			assert.Assert(t, reply != nil)
+
+			options := reply.ParseOptions()
+			assert.Equal(t, net.IP(options[dhcp.OptionServerIdentifier]).String(), rt.wantServer)
+			assert.Equal(t, reply.YIAddr().String(), "10.63.0.5")
+
+			_, ok := options[dhcp.OptionRouter]
+			assert.Equal(t, ok, rt.wantRouter)
+			assert.Equal(t, string(options[dhcp.OptionDomainName]), rt.domainName)
+		})
+	}
+}
+
+func TestServeDHCPOptions(t *testing.T) {
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	ip, ipNet, _ := net.ParseCIDR("10.61.0.5/16")
+	ipNet.IP = ip.To4()
+	gw := net.ParseIP("10.61.0.1").To4()
+
+	iface := &DHCPInterface{VMIPNet: ipNet, GatewayIP: &gw, MACFilter: mac.String()}
+	err := iface.SetOptions(&api.DHCPOptions{
+		SearchDomains: []string{"example.com"},
+		NTPServers:    meta.IPAddresses{net.ParseIP("10.0.0.123")},
+		DomainName:    "vms.example.com",
+		Routes: []api.DHCPRoute{
+			{Destination: "192.168.0.0/16", Gateway: net.ParseIP("10.61.0.254")},
+		},
+		LeaseDuration: &metav1.Duration{Duration: time.Hour},
+	})
+	assert.NilError(t, err)
+
+	request := dhcp.RequestPacket(dhcp.Discover, mac, nil, []byte{1, 2, 3, 4}, false, nil)
+	reply := iface.ServeDHCP(request, dhcp.Discover, request.ParseOptions())
+	assert.Assert(t, reply != nil)
+
+	options := reply.ParseOptions()
+	assert.DeepEqual(t, options[dhcp.OptionDomainSearch], []byte("\x07example\x03com\x00"))
+	assert.DeepEqual(t, options[dhcp.OptionNetworkTimeProtocolServers], []byte{10, 0, 0, 123})
+	assert.Equal(t, string(options[dhcp.OptionDomainName]), "vms.example.com")
+	assert.DeepEqual(t, options[dhcp.OptionClasslessRouteFormat], []byte{0, 10, 61, 0, 1, 16, 192, 168, 10, 61, 0, 254})
+	assert.DeepEqual(t, options[dhcp.OptionIPAddressLeaseTime], []byte{0, 0, 0x0e, 0x10})
+}
+
+func TestEncodeClasslessRoutes(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.61.0.0/16")
+	gw := net.ParseIP("10.61.0.1")
+
+	cases := []struct {
+		name    string
+		routes  []api.DHCPRoute
+		gateway *net.IP
+		want    []byte
+	}{
+		{
+			name: "no routes",
+		},
+		{
+			name: "route on another subnet",
+			routes: []api.DHCPRoute{
+				{Destination: "192.168.0.0/16", Gateway: net.ParseIP("10.62.0.1")},
+			},
+			gateway: &gw,
+		},
+		{
+			name: "routes with default route",
+			routes: []api.DHCPRoute{
+				{Destination: "10.0.0.0/8", Gateway: net.ParseIP("10.61.0.2")},
+				{Destination: "172.16.1.0/25", Gateway: net.ParseIP("10.61.0.3")},
+			},
+			gateway: &gw,
+			want:    []byte{0, 10, 61, 0, 1, 8, 10, 10, 61, 0, 2, 25, 172, 16, 1, 0, 10, 61, 0, 3},
+		},
+		{
+			name: "routes without gateway",
+			routes: []api.DHCPRoute{
+				{Destination: "10.62.1.1/32", Gateway: net.ParseIP("10.61.0.2")},
+			},
+			want: []byte{32, 10, 62, 1, 1, 10, 61, 0, 2},
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			b, err := encodeClasslessRoutes(rt.routes, subnet, rt.gateway)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, b, rt.want)
+		})
+	}
+}
+
+func TestLease(t *testing.T) {
+	iface := &DHCPInterface{}
+	assert.Equal(t, iface.lease(), leaseDuration)
+
+	lifetime, t1, t2 := iface.lifetimes6()
+	assert.Equal(t, lifetime, uint32(dhcpv6InfiniteLifetime))
+	assert.Equal(t, t1, uint32(dhcpv6InfiniteLifetime))
+	assert.Equal(t, t2, uint32(dhcpv6InfiniteLifetime))
+
+	iface.leaseDuration = 10 * time.Minute
+	assert.Equal(t, iface.lease(), 10*time.Minute)
+
+	lifetime, t1, t2 = iface.lifetimes6()
+	assert.Equal(t, lifetime, uint32(600))
+	assert.Equal(t, t1, uint32(300))
+	assert.Equal(t, t2, uint32(480))
+}
+
+func TestParseDHCPRequest(t *testing.T) {
+	vmMAC, _ := net.ParseMAC("02:42:ac:11:00:02")
+	gwMAC, _ := net.ParseMAC("02:42:ac:11:00:01")
+	vmIP := net.IP{10, 63, 0, 5}
+	gwIP := net.IP{10, 63, 0, 254}
+	payload := []byte("request")
+
+	request := func(src net.HardwareAddr, dstPort uint16) []byte {
+		return ethernetFrame(gwMAC, src, unix.ETH_P_IP, ipv4Packet(vmIP, gwIP, protocolUDP, udpDatagram4(68, dstPort, payload)))
+	}
+
+	// A renewal unicast to the gateway
+	frame := request(vmMAC, dhcpServerPort)
+	assert.Equal(t, checksum(frame[ethernetHeaderLen:ethernetHeaderLen+ipv4HeaderLen]), uint16(0))
+
+	got, addr := parseDHCPRequest(frame, vmMAC)
+	assert.DeepEqual(t, got, payload)
+	assert.Equal(t, addr.String(), "10.63.0.5:68")
+
+	// Ethernet padding after the datagram is ignored
+	got, _ = parseDHCPRequest(append(frame, 0, 0, 0, 0), vmMAC)
+	assert.DeepEqual(t, got, payload)
+
+	// Frames of others, other ports and fragments aren't requests of the VM
+	got, _ = parseDHCPRequest(request(gwMAC, dhcpServerPort), vmMAC)
+	assert.Assert(t, got == nil)
+
+	got, _ = parseDHCPRequest(request(vmMAC, 53), vmMAC)
+	assert.Assert(t, got == nil)
+
+	frame[ethernetHeaderLen+6] = 0x20 // More fragments
+	got, _ = parseDHCPRequest(frame, vmMAC)
+	assert.Assert(t, got == nil)
+}
@@ -0,0 +1,379 @@
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ethernetHeaderLen = 14
+	ipv4HeaderLen     = 20
+	ipv6HeaderLen     = 40
+	udpHeaderLen      = 8
+
+	protocolICMPv6 = 58
+	protocolUDP    = 17
+
+	icmpv6RouterSolicitation  = 133
+	icmpv6RouterAdvertisement = 134
+
+	dhcpServerPort   = 67
+	dhcpv6ServerPort = 547
+	dhcpv6ClientPort = 546
+
+	// DHCPv6 message types (RFC 8415)
+	dhcpv6Solicit            = 1
+	dhcpv6Advertise          = 2
+	dhcpv6Request            = 3
+	dhcpv6Confirm            = 4
+	dhcpv6Renew              = 5
+	dhcpv6Rebind             = 6
+	dhcpv6Reply              = 7
+	dhcpv6Release            = 8
+	dhcpv6Decline            = 9
+	dhcpv6InformationRequest = 11
+
+	// DHCPv6 option codes (RFC 8415, RFC 3646)
+	dhcpv6OptionClientID    = 1
+	dhcpv6OptionServerID    = 2
+	dhcpv6OptionIANA        = 3
+	dhcpv6OptionIAAddr      = 5
+	dhcpv6OptionStatusCode  = 13
+	dhcpv6OptionRapidCommit = 14
+	dhcpv6OptionDNSServers  = 23
+	dhcpv6OptionDomainList  = 24
+
+	// dhcpv6InfiniteLifetime never expires, just like the DHCP leases
+	dhcpv6InfiniteLifetime = 0xffffffff
+	// routerLifetime is how long the VM uses the advertised router, in seconds
+	routerLifetime = 1800
+	// routerAdvertisementDelay is the time between unsolicited router advertisements
+	routerAdvertisementDelay = 60 * time.Second
+)
+
+// allNodesIP addresses all hosts on the link
+var allNodesIP = net.ParseIP("ff02::1")
+
+// StartBlockingServer6 starts a blocking router advertisement and DHCPv6 server on the bridge.
+// Router advertisements tell the VM which router to use and to request its address using
+// DHCPv6. The server talks Ethernet directly, so the container doesn't need an IPv6 stack.
+// All frames are sent to the MAC address of the VM through its TAP device, so the forged
+// advertisements of the gateway don't reach other hosts on the network of the container.
+func (i *DHCPInterface) StartBlockingServer6() error {
+	bridge, err := net.InterfaceByName(i.Bridge)
+	if err != nil {
+		return err
+	}
+
+	tap, err := net.InterfaceByName(i.VMTAP)
+	if err != nil {
+		return err
+	}
+
+	vmMAC, err := net.ParseMAC(i.MACFilter)
+	if err != nil {
+		return err
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_IPV6)))
+	if err != nil {
+		return fmt.Errorf("failed to open packet socket: %v", err)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_IPV6), Ifindex: bridge.Index}); err != nil {
+		return fmt.Errorf("failed to bind packet socket to %q: %v", i.Bridge, err)
+	}
+
+	send := func(frame []byte) {
+		addr := &unix.SockaddrLinklayer{Ifindex: tap.Index, Halen: uint8(len(vmMAC))}
+		copy(addr.Addr[:], vmMAC)
+		if err := unix.Sendto(fd, frame, 0, addr); err != nil {
+			log.Warnf("%q failed to send IPv6 configuration to the VM: %v", i.Bridge, err)
+		}
+	}
+
+	srcMAC := bridge.HardwareAddr
+	srcIP := linkLocalFromMAC(srcMAC)
+	serverID := append([]byte{0, 3, 0, 1}, srcMAC...) // DUID-LL for Ethernet
+
+	// Advertise the router periodically, in addition to when it is solicited by the VM
+	go func() {
+		for {
+			send(i.routerAdvertisement(srcMAC, vmMAC, allNodesIP))
+			time.Sleep(routerAdvertisementDelay)
+		}
+	}()
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return err
+		}
+		frame := buf[:n]
+
+		// Only answer the VM, this also skips the frames sent by this server
+		if len(frame) < ethernetHeaderLen+ipv6HeaderLen || !bytes.Equal(frame[6:12], vmMAC) {
+			continue
+		}
+
+		packet := frame[ethernetHeaderLen:]
+		payloadLen := int(binary.BigEndian.Uint16(packet[4:6]))
+		if packet[0]>>4 != 6 || len(packet) < ipv6HeaderLen+payloadLen {
+			continue
+		}
+		clientIP := net.IP(packet[8:24])
+		payload := packet[ipv6HeaderLen : ipv6HeaderLen+payloadLen]
+
+		switch packet[6] {
+		case protocolICMPv6:
+			if len(payload) > 0 && payload[0] == icmpv6RouterSolicitation {
+				// Solicited advertisements may be sent to the soliciting address (RFC 4861, section 6.2.6)
+				dstIP := allNodesIP
+				if clientIP.IsLinkLocalUnicast() {
+					dstIP = clientIP
+				}
+				send(i.routerAdvertisement(srcMAC, vmMAC, dstIP))
+			}
+		case protocolUDP:
+			if len(payload) < udpHeaderLen || binary.BigEndian.Uint16(payload[2:4]) != dhcpv6ServerPort {
+				continue
+			}
+
+			if reply := i.serveDHCPv6(payload[udpHeaderLen:], serverID); reply != nil {
+				datagram := udpDatagram(srcIP, clientIP, dhcpv6ServerPort, dhcpv6ClientPort, reply)
+				send(ethernetFrame(vmMAC, srcMAC, unix.ETH_P_IPV6, ipv6Packet(srcIP, clientIP, protocolUDP, 255, datagram)))
+			}
+		}
+	}
+}
+
+// routerAdvertisement builds a router advertisement frame for the VM. The VM is told to
+// get its address using DHCPv6, and that the prefix of the address is on-link. If the
+// container had no IPv6 gateway, the advertisement doesn't set a default router. The frame
+// is addressed to the MAC address of the VM even if dstIP is the all-nodes address, which
+// hosts accept (RFC 6085), so only the VM gets the advertisement.
+func (i *DHCPInterface) routerAdvertisement(srcMAC, dstMAC net.HardwareAddr, dstIP net.IP) []byte {
+	srcIP := linkLocalFromMAC(srcMAC)
+	routerMAC := srcMAC
+	lifetime := uint16(0)
+	if i.GatewayIPv6 != nil && i.GatewayMAC != nil {
+		srcIP = *i.GatewayIPv6
+		routerMAC = i.GatewayMAC
+		lifetime = routerLifetime
+	}
+
+	ra := make([]byte, 16, 64)
+	ra[0] = icmpv6RouterAdvertisement
+	ra[4] = 64   // Current hop limit
+	ra[5] = 0xc0 // Managed and other configuration flags
+	binary.BigEndian.PutUint16(ra[6:8], lifetime)
+
+	// Source link-layer address option
+	ra = append(ra, 1, 1)
+	ra = append(ra, routerMAC...)
+
+	// MTU option
+	if i.MTU > 0 {
+		ra = append(ra, 5, 1, 0, 0, 0, 0, byte(i.MTU>>8), byte(i.MTU))
+	}
+
+	// Prefix information option, with the on-link flag set and autonomous configuration disabled
+	prefixLen, _ := i.VMIPv6Net.Mask.Size()
+	prefix := make([]byte, 32)
+	prefix[0], prefix[1], prefix[2], prefix[3] = 3, 4, byte(prefixLen), 0x80
+	binary.BigEndian.PutUint32(prefix[4:8], dhcpv6InfiniteLifetime)
+	binary.BigEndian.PutUint32(prefix[8:12], dhcpv6InfiniteLifetime)
+	copy(prefix[16:], i.VMIPv6Net.IP.Mask(i.VMIPv6Net.Mask).To16())
+	ra = append(ra, prefix...)
+
+	binary.BigEndian.PutUint16(ra[2:4], checksum6(srcIP, dstIP, protocolICMPv6, ra))
+	return ethernetFrame(dstMAC, srcMAC, unix.ETH_P_IPV6, ipv6Packet(srcIP, dstIP, protocolICMPv6, 255, ra))
+}
+
+// serveDHCPv6 responds to a DHCPv6 message from the VM, returning nil if it should not be answered
+func (i *DHCPInterface) serveDHCPv6(msg, serverID []byte) []byte {
+	if len(msg) < 4 {
+		return nil
+	}
+
+	options, ok := parseDHCPv6Options(msg[4:])
+	if !ok {
+		return nil
+	}
+
+	// Messages directed at another server are not answered
+	if id, ok := options[dhcpv6OptionServerID]; ok && !bytes.Equal(id, serverID) {
+		return nil
+	}
+
+	clientID, hasClientID := options[dhcpv6OptionClientID]
+	if !hasClientID && msg[0] != dhcpv6InformationRequest {
+		return nil
+	}
+
+	_, rapidCommit := options[dhcpv6OptionRapidCommit]
+	respType := byte(dhcpv6Reply)
+	switch msg[0] {
+	case dhcpv6Solicit:
+		if !rapidCommit {
+			respType = dhcpv6Advertise
+		}
+	case dhcpv6Request, dhcpv6Confirm, dhcpv6Renew, dhcpv6Rebind, dhcpv6Release, dhcpv6Decline, dhcpv6InformationRequest:
+	default:
+		return nil
+	}
+
+	resp := append([]byte{respType}, msg[1:4]...) // Echo the transaction ID
+	if hasClientID {
+		resp = appendDHCPv6Option(resp, dhcpv6OptionClientID, clientID)
+	}
+	resp = appendDHCPv6Option(resp, dhcpv6OptionServerID, serverID)
+
+	switch msg[0] {
+	case dhcpv6Solicit, dhcpv6Request, dhcpv6Renew, dhcpv6Rebind:
+		if msg[0] == dhcpv6Solicit && rapidCommit {
+			resp = appendDHCPv6Option(resp, dhcpv6OptionRapidCommit, nil)
+		}
+
+		if iana, ok := options[dhcpv6OptionIANA]; ok && len(iana) >= 4 {
+			lifetime, t1, t2 := i.lifetimes6()
+			addr := make([]byte, 24)
+			copy(addr, i.VMIPv6Net.IP.To16())
+			binary.BigEndian.PutUint32(addr[16:20], lifetime)
+			binary.BigEndian.PutUint32(addr[20:24], lifetime)
+
+			// Echo the IAID, with an infinite lease the address never needs to be renewed
+			ia := make([]byte, 12)
+			copy(ia, iana[:4])
+			binary.BigEndian.PutUint32(ia[4:8], t1)
+			binary.BigEndian.PutUint32(ia[8:12], t2)
+			ia = appendDHCPv6Option(ia, dhcpv6OptionIAAddr, addr)
+
+			resp = appendDHCPv6Option(resp, dhcpv6OptionIANA, ia)
+		}
+	case dhcpv6Confirm, dhcpv6Release, dhcpv6Decline:
+		// The address is static, so these always succeed
+		return appendDHCPv6Option(resp, dhcpv6OptionStatusCode, []byte{0, 0})
+	}
+
+	if len(i.dnsServers6) > 0 {
+		resp = appendDHCPv6Option(resp, dhcpv6OptionDNSServers, i.dnsServers6)
+	}
+
+	if len(i.domainSearch) > 0 {
+		resp = appendDHCPv6Option(resp, dhcpv6OptionDomainList, i.domainSearch)
+	}
+
+	return resp
+}
+
+// lifetimes6 returns the lifetime of the address handed out over DHCPv6, and the times to renew and rebind it
+// at in seconds. The times are set like the defaults of DHCP clients, at half and 80 percent of the lifetime.
+func (i *DHCPInterface) lifetimes6() (uint32, uint32, uint32) {
+	lease := i.lease()
+	if lease == leaseDuration {
+		return dhcpv6InfiniteLifetime, dhcpv6InfiniteLifetime, dhcpv6InfiniteLifetime
+	}
+
+	lifetime := uint32(lease / time.Second)
+	return lifetime, lifetime / 2, lifetime / 5 * 4
+}
+
+// parseDHCPv6Options returns the first occurrence of every option in the given data
+func parseDHCPv6Options(data []byte) (map[uint16][]byte, bool) {
+	options := map[uint16][]byte{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, false
+		}
+
+		code := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			return nil, false
+		}
+
+		if _, ok := options[code]; !ok {
+			options[code] = data[4 : 4+length]
+		}
+		data = data[4+length:]
+	}
+
+	return options, true
+}
+
+func appendDHCPv6Option(b []byte, code uint16, value []byte) []byte {
+	b = append(b, byte(code>>8), byte(code), byte(len(value)>>8), byte(len(value)))
+	return append(b, value...)
+}
+
+func ethernetFrame(dst, src net.HardwareAddr, etherType uint16, payload []byte) []byte {
+	frame := make([]byte, ethernetHeaderLen, ethernetHeaderLen+len(payload))
+	copy(frame[0:6], dst)
+	copy(frame[6:12], src)
+	binary.BigEndian.PutUint16(frame[12:14], etherType)
+	return append(frame, payload...)
+}
+
+func ipv6Packet(src, dst net.IP, nextHeader, hopLimit byte, payload []byte) []byte {
+	packet := make([]byte, ipv6HeaderLen, ipv6HeaderLen+len(payload))
+	packet[0] = 6 << 4
+	binary.BigEndian.PutUint16(packet[4:6], uint16(len(payload)))
+	packet[6] = nextHeader
+	packet[7] = hopLimit
+	copy(packet[8:24], src.To16())
+	copy(packet[24:40], dst.To16())
+	return append(packet, payload...)
+}
+
+func udpDatagram(src, dst net.IP, srcPort, dstPort uint16, payload []byte) []byte {
+	datagram := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(datagram[0:2], srcPort)
+	binary.BigEndian.PutUint16(datagram[2:4], dstPort)
+	binary.BigEndian.PutUint16(datagram[4:6], uint16(udpHeaderLen+len(payload)))
+	datagram = append(datagram, payload...)
+
+	// A zero checksum is not allowed for UDP over IPv6, it's transmitted as all ones
+	sum := checksum6(src, dst, protocolUDP, datagram)
+	if sum == 0 {
+		sum = 0xffff
+	}
+	binary.BigEndian.PutUint16(datagram[6:8], sum)
+	return datagram
+}
+
+// checksum6 calculates the checksum of an upper-layer packet including the IPv6 pseudo-header
+func checksum6(src, dst net.IP, nextHeader byte, payload []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(b[i])<<8 | uint32(b[i+1])
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+
+	add(src.To16())
+	add(dst.To16())
+	sum += uint32(len(payload)) + uint32(nextHeader)
+	add(payload)
+
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// htons converts a short from host to network byte order
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
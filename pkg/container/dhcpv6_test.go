@@ -0,0 +1,117 @@
+package container
+
+import (
+	"net"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestLinkLocalFromMAC(t *testing.T) {
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	assert.Equal(t, linkLocalFromMAC(mac).String(), "fe80::42:acff:fe11:2")
+}
+
+func TestServeDHCPv6(t *testing.T) {
+	ip, ipNet, _ := net.ParseCIDR("fd61:6967:6e69::5/64")
+	ipNet.IP = ip
+	iface := &DHCPInterface{VMIPv6Net: ipNet}
+	iface.SetDNSServers([]string{"10.0.0.1", "2001:4860:4860::8888"})
+
+	serverID := []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 1}
+	clientID := []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 2}
+	iana := []byte{0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	solicit := appendDHCPv6Option([]byte{dhcpv6Solicit, 1, 2, 3}, dhcpv6OptionClientID, clientID)
+	solicit = appendDHCPv6Option(solicit, dhcpv6OptionIANA, iana)
+
+	cases := []struct {
+		name     string
+		msg      []byte
+		wantType byte
+		wantAddr bool
+	}{
+		{
+			name:     "solicit",
+			msg:      solicit,
+			wantType: dhcpv6Advertise,
+			wantAddr: true,
+		},
+		{
+			name:     "solicit with rapid commit",
+			msg:      appendDHCPv6Option(solicit, dhcpv6OptionRapidCommit, nil),
+			wantType: dhcpv6Reply,
+			wantAddr: true,
+		},
+		{
+			name:     "request",
+			msg:      appendDHCPv6Option(append([]byte{dhcpv6Request}, solicit[1:]...), dhcpv6OptionServerID, serverID),
+			wantType: dhcpv6Reply,
+			wantAddr: true,
+		},
+		{
+			name:     "information request",
+			msg:      []byte{dhcpv6InformationRequest, 1, 2, 3},
+			wantType: dhcpv6Reply,
+		},
+		{
+			name: "request for another server",
+			msg:  appendDHCPv6Option(append([]byte{dhcpv6Request}, solicit[1:]...), dhcpv6OptionServerID, clientID),
+		},
+		{
+			name: "solicit without client ID",
+			msg:  []byte{dhcpv6Solicit, 1, 2, 3},
+		},
+		{
+			name: "truncated option",
+			msg:  []byte{dhcpv6Solicit, 1, 2, 3, 0, 1, 0, 10, 0},
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			resp := iface.serveDHCPv6(rt.msg, serverID)
+			if rt.wantType == 0 {
+				assert.Assert(t, resp == nil)
+				return
+			}
+
+			assert.Equal(t, resp[0], rt.wantType)
+			assert.DeepEqual(t, resp[1:4], []byte{1, 2, 3})
+
+			options, ok := parseDHCPv6Options(resp[4:])
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, options[dhcpv6OptionServerID], serverID)
+			assert.DeepEqual(t, options[dhcpv6OptionDNSServers], []byte(net.ParseIP("2001:4860:4860::8888")))
+
+			ia, ok := options[dhcpv6OptionIANA]
+			assert.Equal(t, ok, rt.wantAddr)
+			if rt.wantAddr {
+				assert.DeepEqual(t, options[dhcpv6OptionClientID], clientID)
+				assert.DeepEqual(t, ia[:4], iana[:4])
+
+				iaOptions, ok := parseDHCPv6Options(ia[12:])
+				assert.Assert(t, ok)
+				assert.Assert(t, net.IP(iaOptions[dhcpv6OptionIAAddr][:16]).Equal(ip))
+			}
+		})
+	}
+}
+
+func TestRouterAdvertisement(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("fd61:6967:6e69::5/64")
+	srcMAC, _ := net.ParseMAC("02:42:ac:11:00:01")
+	vmMAC, _ := net.ParseMAC("02:42:ac:11:00:02")
+	iface := &DHCPInterface{VMIPv6Net: ipNet}
+
+	for _, dstIP := range []net.IP{allNodesIP, linkLocalFromMAC(vmMAC)} {
+		frame := iface.routerAdvertisement(srcMAC, vmMAC, dstIP)
+
+		// The frame is unicast to the VM, whatever the destination address
+		assert.DeepEqual(t, net.HardwareAddr(frame[0:6]), vmMAC)
+		packet := frame[ethernetHeaderLen:]
+		assert.Assert(t, net.IP(packet[24:40]).Equal(dstIP))
+		assert.Equal(t, packet[ipv6HeaderLen], byte(icmpv6RouterAdvertisement))
+		assert.Equal(t, checksum6(net.IP(packet[8:24]), dstIP, protocolICMPv6, packet[ipv6HeaderLen:]), uint16(0))
+	}
+}
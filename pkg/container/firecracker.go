@@ -15,14 +15,14 @@ import (
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/logs"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
-// ExecuteFirecracker executes the firecracker process using the Go SDK
-func ExecuteFirecracker(vm *api.VM, fcIfaces firecracker.NetworkInterfaces) (err error) {
-	drivePath := vm.SnapshotDev()
-
+// ExecuteFirecracker executes the firecracker process using the Go SDK,
+// the VM boots from the block device or file at drivePath
+func ExecuteFirecracker(vm *api.VM, drivePath string, fcIfaces firecracker.NetworkInterfaces) (err error) {
 	vCPUCount := int64(vm.Spec.CPUs)
 	memSizeMib := int64(vm.Spec.Memory.MBytes())
 
@@ -124,6 +124,11 @@ func ExecuteFirecracker(vm *api.VM, fcIfaces firecracker.NetworkInterfaces) (err
 	}
 	defer util.DeferErr(&err, m.StopVMM)
 
+	events.Record(vm, events.TypeReady, "")
+
+	// Expose the Firecracker metrics through the Prometheus socket
+	go collectMetrics(ctx, firecrackerSocketPath, metricsSocketPath)
+
 	installSignalHandlers(ctx, m)
 
 	// wait for the VMM to exit
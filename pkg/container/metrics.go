@@ -0,0 +1,133 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/firecracker-microvm/firecracker-go-sdk"
+	models "github.com/firecracker-microvm/firecracker-go-sdk/client/models"
+	go_prom "github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the Firecracker metrics exposed by ignite-spawn
+const (
+	MetricNetRxBytes      = "ignite_firecracker_net_rx_bytes_total"
+	MetricNetTxBytes      = "ignite_firecracker_net_tx_bytes_total"
+	MetricNetRxPackets    = "ignite_firecracker_net_rx_packets_total"
+	MetricNetTxPackets    = "ignite_firecracker_net_tx_packets_total"
+	MetricBlockReadBytes  = "ignite_firecracker_block_read_bytes_total"
+	MetricBlockWriteBytes = "ignite_firecracker_block_write_bytes_total"
+	MetricBlockReads      = "ignite_firecracker_block_reads_total"
+	MetricBlockWrites     = "ignite_firecracker_block_writes_total"
+)
+
+// metricsFlushInterval is how often Firecracker is asked to flush its metrics
+const metricsFlushInterval = 5 * time.Second
+
+var (
+	netRxBytes      = newFirecrackerCounter(MetricNetRxBytes, "Bytes received by the VM's network interfaces")
+	netTxBytes      = newFirecrackerCounter(MetricNetTxBytes, "Bytes sent by the VM's network interfaces")
+	netRxPackets    = newFirecrackerCounter(MetricNetRxPackets, "Packets received by the VM's network interfaces")
+	netTxPackets    = newFirecrackerCounter(MetricNetTxPackets, "Packets sent by the VM's network interfaces")
+	blockReadBytes  = newFirecrackerCounter(MetricBlockReadBytes, "Bytes read by the VM from its block devices")
+	blockWriteBytes = newFirecrackerCounter(MetricBlockWriteBytes, "Bytes written by the VM to its block devices")
+	blockReads      = newFirecrackerCounter(MetricBlockReads, "Read operations performed by the VM on its block devices")
+	blockWrites     = newFirecrackerCounter(MetricBlockWrites, "Write operations performed by the VM on its block devices")
+)
+
+func newFirecrackerCounter(name, help string) go_prom.Counter {
+	return go_prom.NewCounter(go_prom.CounterOpts{
+		Name: name,
+		Help: help,
+	})
+}
+
+// RegisterFirecrackerMetrics registers the metrics collected
+// from the Firecracker metrics FIFO to the given registry
+func RegisterFirecrackerMetrics(reg go_prom.Registerer) {
+	reg.MustRegister(
+		netRxBytes, netTxBytes, netRxPackets, netTxPackets,
+		blockReadBytes, blockWriteBytes, blockReads, blockWrites,
+	)
+}
+
+// firecrackerMetrics is the subset of the metrics Firecracker
+// writes to its metrics FIFO that Ignite is interested in.
+// Firecracker reports these values as deltas since the last flush.
+type firecrackerMetrics struct {
+	Block struct {
+		ReadBytes  uint64 `json:"read_bytes"`
+		WriteBytes uint64 `json:"write_bytes"`
+		ReadCount  uint64 `json:"read_count"`
+		WriteCount uint64 `json:"write_count"`
+	} `json:"block"`
+	Net struct {
+		RxBytesCount   uint64 `json:"rx_bytes_count"`
+		TxBytesCount   uint64 `json:"tx_bytes_count"`
+		RxPacketsCount uint64 `json:"rx_packets_count"`
+		TxPacketsCount uint64 `json:"tx_packets_count"`
+	} `json:"net"`
+}
+
+func (m *firecrackerMetrics) record() {
+	netRxBytes.Add(float64(m.Net.RxBytesCount))
+	netTxBytes.Add(float64(m.Net.TxBytesCount))
+	netRxPackets.Add(float64(m.Net.RxPacketsCount))
+	netTxPackets.Add(float64(m.Net.TxPacketsCount))
+	blockReadBytes.Add(float64(m.Block.ReadBytes))
+	blockWriteBytes.Add(float64(m.Block.WriteBytes))
+	blockReads.Add(float64(m.Block.ReadCount))
+	blockWrites.Add(float64(m.Block.WriteCount))
+}
+
+// collectMetrics reads the metrics Firecracker writes to the given FIFO and records
+// them until the context is cancelled. Firecracker only flushes its metrics once per
+// minute by itself, so an explicit flush is requested through the API socket periodically.
+func collectMetrics(ctx context.Context, apiSocketPath, fifoPath string) {
+	// Opening the FIFO read-write never blocks, even if Firecracker hasn't opened it yet
+	fifo, err := os.OpenFile(fifoPath, os.O_RDWR, 0)
+	if err != nil {
+		log.Errorf("Failed to open Firecracker metrics FIFO: %v", err)
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = fifo.Close()
+	}()
+
+	go func() {
+		client := firecracker.NewClient(apiSocketPath, log.NewEntry(log.StandardLogger()), false)
+		ticker := time.NewTicker(metricsFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := client.CreateSyncAction(ctx, &models.InstanceActionInfo{
+					ActionType: firecracker.String(models.InstanceActionInfoActionTypeFlushMetrics),
+				}); err != nil {
+					log.Debugf("Failed to flush Firecracker metrics: %v", err)
+				}
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(fifo)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // A metrics line can exceed the default 64k limit
+	for scanner.Scan() {
+		var m firecrackerMetrics
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			log.Debugf("Failed to parse Firecracker metrics: %v", err)
+			continue
+		}
+
+		m.record()
+	}
+}
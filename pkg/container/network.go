@@ -5,7 +5,6 @@ import (
 	"net"
 	"os"
 	"sort"
-	"strings"
 	"syscall"
 	"time"
 
@@ -14,7 +13,6 @@ import (
 	"github.com/vishvananda/netlink"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/constants"
-	"github.com/weaveworks/ignite/pkg/util"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -40,34 +38,39 @@ var ignoreInterfaces = map[string]struct{}{
 	"tunl0": {},
 }
 
-const MODE_DHCP = "dhcp-bridge"
-const MODE_TC = "tc-redirect"
+const MODE_DHCP = string(api.InterfaceModeDHCPBridge)
+const MODE_TC = string(api.InterfaceModeTCRedirect)
+const MODE_MACVLAN = string(api.InterfaceModeMacvlan)
+const MODE_IPVLAN = string(api.InterfaceModeIPVLAN)
 
-// supportedModes is a Set of Mode strings
-var supportedModes = map[string]struct{}{
-	MODE_DHCP: {},
-	MODE_TC:   {},
-}
-
-var mainInterface = "eth0"
+var mainInterface = constants.IGNITE_SPAWN_MAIN_INTERFACE
 
 func SetupContainerNetworking(vm *api.VM) (firecracker.NetworkInterfaces, []DHCPInterface, error) {
 	var dhcpIntfs []DHCPInterface
 	var fcIntfs firecracker.NetworkInterfaces
 
-	vmIntfs := parseExtraIntfs(vm)
+	vmIntfs := specIntfs(vm)
 
 	// Setting up mainInterface if not defined
 	if _, ok := vmIntfs[mainInterface]; !ok {
 		vmIntfs[mainInterface] = MODE_DHCP
 	}
 
+	// The network plugin attaches the sandbox to the additional networks of the VM after the main interface,
+	// wait for their interfaces too
+	for i := 1; i < vm.AttachedNetworks(); i++ {
+		intfName := fmt.Sprintf("eth%d", i)
+		if _, ok := vmIntfs[intfName]; !ok {
+			vmIntfs[intfName] = MODE_DHCP
+		}
+	}
+
 	interval := 1 * time.Second
 
 	err := wait.PollImmediate(interval, constants.IGNITE_SPAWN_TIMEOUT, func() (bool, error) {
 
 		// This func returns true if it's done, and optionally an error
-		retry, err := collectInterfaces(vmIntfs)
+		retry, err := collectInterfaces(vm, vmIntfs)
 
 		if err == nil {
 			// We're done here
@@ -86,14 +89,18 @@ func SetupContainerNetworking(vm *api.VM) (firecracker.NetworkInterfaces, []DHCP
 		return nil, nil, err
 	}
 
-	if err := networkSetup(&fcIntfs, &dhcpIntfs, vmIntfs); err != nil {
+	if err := networkSetup(vm, &fcIntfs, &dhcpIntfs, vmIntfs); err != nil {
+		return nil, nil, err
+	}
+
+	if err := ApplyNetworkPolicy(vm.Spec.Network.Policy, dhcpIntfs); err != nil {
 		return nil, nil, err
 	}
 
 	return fcIntfs, dhcpIntfs, nil
 }
 
-func collectInterfaces(vmIntfs map[string]string) (bool, error) {
+func collectInterfaces(vm *api.VM, vmIntfs map[string]string) (bool, error) {
 	allIntfs, err := net.Interfaces()
 	if err != nil || allIntfs == nil || len(allIntfs) == 0 {
 		return false, fmt.Errorf("cannot get local network interfaces: %v", err)
@@ -126,8 +133,9 @@ func collectInterfaces(vmIntfs map[string]string) (bool, error) {
 			return true, fmt.Errorf("interface %q (mode %q) is still not found", intfName, mode)
 		}
 
-		// for DHCP interface, we need to make sure IP and route exist
-		if mode == MODE_DHCP {
+		// for DHCP interface, we need to make sure IP and route exist, unless the VM is given a static
+		// address for it. The main interface is set up by the network plugin, always wait for its IP.
+		if mode == MODE_DHCP && (intfName == mainInterface || staticAddress(vm, intfName) == nil) {
 			intf := foundIntfs[intfName]
 			_, _, _, noIPs, err := getAddress(&intf)
 			if err != nil {
@@ -142,7 +150,7 @@ func collectInterfaces(vmIntfs map[string]string) (bool, error) {
 	return false, nil
 }
 
-func networkSetup(fcIntfs *firecracker.NetworkInterfaces, dhcpIntfs *[]DHCPInterface, vmIntfs map[string]string) error {
+func networkSetup(vm *api.VM, fcIntfs *firecracker.NetworkInterfaces, dhcpIntfs *[]DHCPInterface, vmIntfs map[string]string) error {
 
 	// The order in which interfaces are plugged in is intentionally deterministic
 	// All interfaces are sorted alphabetically and 'eth0' is always first
@@ -164,18 +172,28 @@ func networkSetup(fcIntfs *firecracker.NetworkInterfaces, dhcpIntfs *[]DHCPInter
 
 		switch vmIntfs[intfName] {
 		case MODE_DHCP:
-			ipNet, gw, err := takeAddress(intf)
+			v4, v6, err := takeAddress(intf, staticAddress(vm, intfName))
 			if err != nil {
 				return fmt.Errorf("error parsing interface %q: %s", intfName, err)
 			}
 
-			dhcpIface, err := bridge(intf)
+			dhcpIface, err := bridge(intf, vm.MACAddress(intfName), intfMTU(vm, intf))
 			if err != nil {
 				return fmt.Errorf("bridging interface %q failed: %v", intfName, err)
 			}
 
-			dhcpIface.VMIPNet = ipNet
-			dhcpIface.GatewayIP = gw
+			dhcpIface.Network = vm.InterfaceNetwork(intfName)
+
+			if v4 != nil {
+				dhcpIface.VMIPNet = v4.ipNet
+				dhcpIface.GatewayIP = v4.gateway
+			}
+
+			if v6 != nil {
+				dhcpIface.VMIPv6Net = v6.ipNet
+				dhcpIface.GatewayIPv6 = v6.gateway
+				dhcpIface.GatewayMAC = v6.gatewayMAC
+			}
 
 			*dhcpIntfs = append(*dhcpIntfs, *dhcpIface)
 
@@ -186,13 +204,31 @@ func networkSetup(fcIntfs *firecracker.NetworkInterfaces, dhcpIntfs *[]DHCPInter
 				},
 			})
 		case MODE_TC:
-			tcInterface, err := addTcRedirect(intf)
+			tcInterface, err := addTcRedirect(intf, intfMTU(vm, intf))
 			if err != nil {
 				log.Errorf("Failed to setup tc redirect %v", err)
 				continue
 			}
 
+			// The sandbox interface's MAC address is used, unless one is set
+			if spec := vm.Interface(intfName); spec != nil && len(spec.MACAddress) > 0 {
+				tcInterface.StaticConfiguration.MacAddress = spec.MACAddress
+			}
+
 			*fcIntfs = append(*fcIntfs, *tcInterface)
+		case MODE_MACVLAN, MODE_IPVLAN:
+			fcIntf, dhcpIface, err := passthrough(intf, staticAddress(vm, intfName), intfMTU(vm, intf))
+			if err != nil {
+				return fmt.Errorf("passing through interface %q failed: %v", intfName, err)
+			}
+
+			if dhcpIface != nil {
+				*dhcpIntfs = append(*dhcpIntfs, *dhcpIface)
+			}
+
+			*fcIntfs = append(*fcIntfs, *fcIntf)
+		default:
+			return fmt.Errorf("interface %q has unsupported mode %q", intfName, vmIntfs[intfName])
 		}
 	}
 
@@ -201,7 +237,7 @@ func networkSetup(fcIntfs *firecracker.NetworkInterfaces, dhcpIntfs *[]DHCPInter
 
 // addTcRedirect sets up tc redirect betweeb veth and tap https://github.com/awslabs/tc-redirect-tap/blob/master/internal/netlink.go
 // on WSL2 this requires `CONFIG_NET_CLS_U32=y`
-func addTcRedirect(iface *net.Interface) (*firecracker.NetworkInterface, error) {
+func addTcRedirect(iface *net.Interface, mtu int) (*firecracker.NetworkInterface, error) {
 
 	log.Infof("Adding tc-redirect for %q", iface.Name)
 
@@ -211,7 +247,7 @@ func addTcRedirect(iface *net.Interface) (*firecracker.NetworkInterface, error)
 	}
 
 	tapName := constants.TAP_PREFIX + iface.Name
-	tuntap, err := createTAPAdapter(tapName)
+	tuntap, err := createTAPAdapter(tapName, mtu)
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +279,94 @@ func addTcRedirect(iface *net.Interface) (*firecracker.NetworkInterface, error)
 	}, nil
 }
 
+// passthrough redirects the traffic between the macvlan or ipvlan device the host has moved into the container
+// and the VM. The VM uses the MAC address of the device, so it's reachable on the parent's network. Without a
+// static address, the VM is configured by e.g. the DHCP server of that network. Otherwise, the DHCP requests of
+// the VM are kept in the container, where they're answered with the static address by the returned DHCP server.
+func passthrough(iface *net.Interface, static *api.InterfaceAddress, mtu int) (*firecracker.NetworkInterface, *DHCPInterface, error) {
+	fcIntf, err := addTcRedirect(iface, mtu)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if static == nil {
+		return fcIntf, nil, nil
+	}
+
+	ip, mask, err := static.Parse()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid static address: %v", err)
+	}
+
+	link, err := netlink.LinkByIndex(iface.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// ipvlan devices demultiplex the incoming traffic by the addresses assigned to them. The
+	// container never sees the traffic of the address, as all of it is redirected to the VM.
+	if _, ok := link.(*netlink.IPVlan); ok {
+		if err := netlink.AddrAdd(link, &netlink.Addr{IPNet: &net.IPNet{IP: ip, Mask: mask}}); err != nil {
+			return nil, nil, fmt.Errorf("failed to add address %s to interface %q: %v", ip, iface.Name, err)
+		}
+	}
+
+	tapName := fcIntf.StaticConfiguration.HostDevName
+	tuntap, err := netlink.LinkByName(tapName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := addDHCPServerFilter(tuntap); err != nil {
+		return nil, nil, err
+	}
+
+	gw := static.Gateway.To4()
+	log.Infof("Assigning static IP address %s (%s) with gateway %s to VM", ip.String(), maskString(mask), gw.String())
+
+	return fcIntf, &DHCPInterface{
+		VMIPNet:   &net.IPNet{IP: ip, Mask: mask},
+		GatewayIP: &gw,
+		VMTAP:     tapName,
+		Bridge:    tapName,
+		MACFilter: fcIntf.StaticConfiguration.MacAddress,
+		MTU:       uint16(mtu),
+	}, nil
+}
+
+// addDHCPServerFilter passes the DHCP requests sent by the VM to the container instead of redirecting them.
+// It's added after the redirect filter, so it gets a lower priority number and is evaluated first.
+// tc filter add dev $TAP_IFACE parent ffff:
+// protocol ip
+// u32 match ip protocol 17 0xff match ip dport 67 0xffff
+// action ok
+func addDHCPServerFilter(link netlink.Link) error {
+	filter := &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    netlink.MakeHandle(0xffff, 0),
+			Protocol:  syscall.ETH_P_IP,
+		},
+		Sel: &netlink.TcU32Sel{
+			Flags: netlink.TC_U32_TERMINAL,
+			Keys: []netlink.TcU32Key{
+				// The IP protocol is UDP
+				{Mask: 0x00ff0000, Val: 0x00110000, Off: 8},
+				// The UDP destination port is 67, DHCP clients don't send IP options
+				{Mask: 0x0000ffff, Val: 67, Off: 20},
+			},
+		},
+		Actions: []netlink.Action{
+			&netlink.GenericAction{
+				ActionAttrs: netlink.ActionAttrs{
+					Action: netlink.TC_ACT_OK,
+				},
+			},
+		},
+	}
+	return netlink.FilterAdd(filter)
+}
+
 // tc qdisc add dev $SRC_IFACE ingress
 func addIngressQdisc(link netlink.Link) error {
 	qdisc := &netlink.Ingress{
@@ -285,7 +409,7 @@ func addRedirectFilter(linkSrc, linkDest netlink.Link) error {
 }
 
 // bridge creates the TAP device and performs the bridging, returning the base configuration for a DHCP server
-func bridge(iface *net.Interface) (*DHCPInterface, error) {
+func bridge(iface *net.Interface, macAddress string, mtu int) (*DHCPInterface, error) {
 	tapName := constants.TAP_PREFIX + iface.Name
 	bridgeName := constants.BRIDGE_PREFIX + iface.Name
 
@@ -294,7 +418,7 @@ func bridge(iface *net.Interface) (*DHCPInterface, error) {
 		return nil, err
 	}
 
-	tuntap, err := createTAPAdapter(tapName)
+	tuntap, err := createTAPAdapter(tapName, mtu)
 	if err != nil {
 		return nil, err
 	}
@@ -308,28 +432,32 @@ func bridge(iface *net.Interface) (*DHCPInterface, error) {
 		return nil, err
 	}
 
-	// Generate the MAC addresses for the VM's adapters
-	macAddress := make([]string, 0, 1)
-	if err := util.NewMAC(&macAddress); err != nil {
-		return nil, fmt.Errorf("failed to generate MAC addresses: %v", err)
-	}
-
 	return &DHCPInterface{
 		VMTAP:     tapName,
 		Bridge:    bridgeName,
-		MACFilter: macAddress[0],
+		MACFilter: macAddress,
+		MTU:       uint16(mtu),
 	}, nil
 }
 
-// getAddress collects the first IP and gateway information from an interface
-// in case of multiple routes over an interface, only the first one is considered
-func getAddress(iface *net.Interface) (*net.IPNet, *net.IP, netlink.Link, bool, error) {
+// addressConfig describes an address of one IP family that is moved from the container to the VM
+type addressConfig struct {
+	ipNet   *net.IPNet
+	gateway *net.IP
+	// gatewayMAC is only resolved for IPv6, as the gateway is advertised to the VM as its router
+	gatewayMAC net.HardwareAddr
+}
+
+// getAddress collects the first IPv4 and the first global IPv6 address and their gateway information
+// from an interface. In case of multiple routes over an interface, only the first one per family is considered
+func getAddress(iface *net.Interface) (*addressConfig, *addressConfig, netlink.Link, bool, error) {
 	addrs, err := iface.Addrs()
 	if err != nil || addrs == nil || len(addrs) == 0 {
 		// set the bool to true so the caller knows to retry
 		return nil, nil, nil, true, fmt.Errorf("interface %q has no address", iface.Name)
 	}
 
+	var v4, v6 *addressConfig
 	for _, addr := range addrs {
 		var ip net.IP
 		var mask net.IPMask
@@ -347,68 +475,229 @@ func getAddress(iface *net.Interface) (*net.IPNet, *net.IP, netlink.Link, bool,
 			continue
 		}
 
-		ip = ip.To4()
-		if ip == nil {
-			continue
+		if ip4 := ip.To4(); ip4 != nil {
+			if v4 == nil {
+				// Convert the mask to the 4-byte form to match the address
+				v4 = &addressConfig{ipNet: &net.IPNet{IP: ip4, Mask: mask[len(mask)-net.IPv4len:]}}
+			}
+		} else if v6 == nil && ip.IsGlobalUnicast() {
+			// Link-local addresses stay with the container, only routable addresses are moved
+			v6 = &addressConfig{ipNet: &net.IPNet{IP: ip, Mask: mask}}
 		}
+	}
 
-		link, err := netlink.LinkByName(iface.Name)
-		if err != nil {
-			return nil, nil, nil, false, fmt.Errorf("failed to get interface %q by name: %v", iface.Name, err)
+	if v4 == nil && v6 == nil {
+		return nil, nil, nil, true, fmt.Errorf("interface %q has no valid addresses", iface.Name)
+	}
+
+	link, err := netlink.LinkByName(iface.Name)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("failed to get interface %q by name: %v", iface.Name, err)
+	}
+
+	for _, family := range []struct {
+		config *addressConfig
+		family int
+	}{
+		{v4, netlink.FAMILY_V4},
+		{v6, netlink.FAMILY_V6},
+	} {
+		if family.config == nil {
+			continue
 		}
 
-		var gw *net.IP
-		routes, err := netlink.RouteList(link, netlink.FAMILY_ALL)
+		routes, err := netlink.RouteList(link, family.family)
 		if err != nil {
 			return nil, nil, nil, false, fmt.Errorf("failed to get default gateway for interface %q: %v", iface.Name, err)
 		}
 		for _, rt := range routes {
 			if rt.Gw != nil {
-				gw = &rt.Gw
+				gw := rt.Gw
+				family.config.gateway = &gw
 				break
 			}
 		}
-
-		return &net.IPNet{
-			IP:   ip,
-			Mask: mask,
-		}, gw, link, false, nil
 	}
 
-	return nil, nil, nil, true, fmt.Errorf("interface %q has no valid addresses", iface.Name)
+	return v4, v6, link, false, nil
 }
 
-// takeAddress removes the first address of an interface and returns it and the appropriate gateway
-func takeAddress(iface *net.Interface) (*net.IPNet, *net.IP, error) {
+// takeAddress removes the first IPv4 and IPv6 addresses of an interface and returns them and the appropriate gateways.
+// If a static address is given, it replaces the address of its family, and it doesn't need the interface to have one.
+func takeAddress(iface *net.Interface, static *api.InterfaceAddress) (*addressConfig, *addressConfig, error) {
 
-	ip, gw, link, noIPs, err := getAddress(iface)
-	if err != nil {
+	v4, v6, link, noIPs, err := getAddress(iface)
+	if err != nil && !(noIPs && static != nil) {
 		return nil, nil, err
 	}
-	if noIPs {
+	if noIPs && static == nil {
 		return nil, nil, fmt.Errorf("interface %q expected to have an IP but none found", iface.Name)
 	}
 
-	delAddr := &netlink.Addr{
-		IPNet: &net.IPNet{
-			IP:   ip.IP,
-			Mask: ip.Mask,
-		},
+	if link == nil {
+		if link, err = netlink.LinkByName(iface.Name); err != nil {
+			return nil, nil, fmt.Errorf("failed to get interface %q by name: %v", iface.Name, err)
+		}
+	}
+
+	vm4, vm6 := v4, v6
+	if static != nil {
+		if vm4, vm6, err = staticConfig(static, v4, v6); err != nil {
+			return nil, nil, fmt.Errorf("invalid static address for interface %q: %v", iface.Name, err)
+		}
+	}
+
+	if vm6 != nil && vm6.gateway != nil {
+		// The router needs to be resolved while the container still has the address to reach it from
+		if err := resolveRouter(link, vm6); err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve IPv6 gateway %s of interface %q: %v", vm6.gateway, iface.Name, err)
+		}
+	}
+
+	for _, config := range []*addressConfig{v4, v6} {
+		if config == nil {
+			continue
+		}
+
+		delAddr := &netlink.Addr{
+			IPNet: &net.IPNet{
+				IP:   config.ipNet.IP,
+				Mask: config.ipNet.Mask,
+			},
+		}
+
+		if err = netlink.AddrDel(link, delAddr); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove address %q from interface %q: %v", delAddr, iface.Name, err)
+		}
+	}
+
+	for _, config := range []*addressConfig{vm4, vm6} {
+		if config == nil {
+			continue
+		}
+
+		if config == v4 || config == v6 {
+			log.Infof("Moving IP address %s (%s) with gateway %v from container to VM", config.ipNet.IP.String(), maskString(config.ipNet.Mask), config.gateway)
+		} else {
+			log.Infof("Assigning static IP address %s (%s) with gateway %v to VM", config.ipNet.IP.String(), maskString(config.ipNet.Mask), config.gateway)
+		}
+	}
+
+	return vm4, vm6, nil
+}
+
+// staticConfig replaces the container's address of the same family as the static address.
+// The prefix length and gateway are taken from the container's address, unless specified.
+func staticConfig(static *api.InterfaceAddress, v4, v6 *addressConfig) (*addressConfig, *addressConfig, error) {
+	ip, mask, err := static.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := v6
+	if ip.To4() != nil {
+		base = v4
+	}
+
+	config := &addressConfig{ipNet: &net.IPNet{IP: ip, Mask: mask}}
+	if base != nil {
+		if mask == nil {
+			config.ipNet.Mask = base.ipNet.Mask
+		}
+		config.gateway = base.gateway
+	} else if mask == nil {
+		return nil, nil, fmt.Errorf("the sandbox has no address to take the prefix length of %s from, it needs to be specified", ip)
+	}
+
+	if static.Gateway != nil {
+		gw := static.Gateway
+		if gw4 := gw.To4(); gw4 != nil {
+			gw = gw4
+		}
+		config.gateway = &gw
+	}
+
+	if ip.To4() != nil {
+		return config, v6, nil
+	}
+	return v4, config, nil
+}
+
+// staticAddress returns the static address of the given interface from the VM spec, if any
+func staticAddress(vm *api.VM, intfName string) *api.InterfaceAddress {
+	if intf := vm.Interface(intfName); intf != nil {
+		return intf.Address
+	}
+
+	return nil
+}
+
+// resolveRouter looks up the MAC address and the link-local address of the IPv6 gateway.
+// Router advertisements must be sent from a link-local address, so the VM is told to use
+// the gateway by its link-local address, which is resolved to the gateway's MAC address.
+func resolveRouter(link netlink.Link, config *addressConfig) error {
+	gw := *config.gateway
+
+	err := wait.PollImmediate(100*time.Millisecond, 5*time.Second, func() (bool, error) {
+		neighs, err := netlink.NeighList(link.Attrs().Index, netlink.FAMILY_V6)
+		if err != nil {
+			return false, err
+		}
+
+		for _, neigh := range neighs {
+			if neigh.IP.Equal(gw) && len(neigh.HardwareAddr) > 0 && neigh.State&(netlink.NUD_FAILED|netlink.NUD_INCOMPLETE) == 0 {
+				config.gatewayMAC = neigh.HardwareAddr
+				return true, nil
+			}
+		}
+
+		// Send a datagram to the gateway to trigger neighbor discovery
+		conn, err := net.DialUDP("udp6", nil, &net.UDPAddr{IP: gw, Port: 9, Zone: link.Attrs().Name})
+		if err != nil {
+			return false, err
+		}
+		_, _ = conn.Write([]byte{0})
+		return false, conn.Close()
+	})
+	if err != nil {
+		return err
 	}
 
-	if err = netlink.AddrDel(link, delAddr); err != nil {
-		return nil, nil, fmt.Errorf("failed to remove address %q from interface %q: %v", delAddr, iface.Name, err)
+	if gw.IsLinkLocalUnicast() {
+		return nil
 	}
 
-	log.Infof("Moving IP address %s (%s) with gateway %s from container to VM", ip.String(), maskString(ip.Mask), gw.String())
+	// Prefer a known link-local address of the gateway, otherwise assume it has been derived from its MAC address
+	routerIP := linkLocalFromMAC(config.gatewayMAC)
+	if neighs, err := netlink.NeighList(link.Attrs().Index, netlink.FAMILY_V6); err == nil {
+		for _, neigh := range neighs {
+			if neigh.IP.IsLinkLocalUnicast() && neigh.HardwareAddr.String() == config.gatewayMAC.String() {
+				routerIP = neigh.IP
+				break
+			}
+		}
+	}
+
+	config.gateway = &routerIP
+	return nil
+}
 
-	return ip, gw, nil
+// linkLocalFromMAC derives the EUI-64 based link-local IPv6 address for the given MAC address
+func linkLocalFromMAC(mac net.HardwareAddr) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1] = 0xfe, 0x80
+	ip[8] = mac[0] ^ 0x02
+	ip[9], ip[10] = mac[1], mac[2]
+	ip[11], ip[12] = 0xff, 0xfe
+	ip[13], ip[14], ip[15] = mac[3], mac[4], mac[5]
+	return ip
 }
 
-// createTAPAdapter creates a new TAP device with the given name
-func createTAPAdapter(tapName string) (*netlink.Tuntap, error) {
+// createTAPAdapter creates a new TAP device with the given name and MTU
+func createTAPAdapter(tapName string, mtu int) (*netlink.Tuntap, error) {
 	la := netlink.NewLinkAttrs()
 	la.Name = tapName
+	la.MTU = mtu
 	tuntap := &netlink.Tuntap{
 		LinkAttrs: la,
 		Mode:      netlink.TUNTAP_MODE_TAP,
@@ -427,7 +716,10 @@ func createBridge(bridgeName string) (*netlink.Bridge, error) {
 	// with more than two interfaces attached to the bridge anyways, so we're not
 	// taking any performance hit by disabling it here.
 	ageingTime := uint32(0)
-	bridge := &netlink.Bridge{LinkAttrs: la, AgeingTime: &ageingTime}
+	// Disable multicast snooping, so the neighbor discovery and DHCPv6 multicast
+	// traffic reaches the VM before it has joined the multicast groups.
+	multicastSnooping := false
+	bridge := &netlink.Bridge{LinkAttrs: la, AgeingTime: &ageingTime, MulticastSnooping: &multicastSnooping}
 	return bridge, addLink(bridge)
 }
 
@@ -482,6 +774,11 @@ func getMAC(link netlink.Link) (addr net.HardwareAddr, err error) {
 }
 
 func maskString(mask net.IPMask) string {
+	if len(mask) == net.IPv6len {
+		ones, _ := mask.Size()
+		return fmt.Sprintf("/%d", ones)
+	}
+
 	if len(mask) < 4 {
 		return "<nil>"
 	}
@@ -489,30 +786,31 @@ func maskString(mask net.IPMask) string {
 	return fmt.Sprintf("%d.%d.%d.%d", mask[0], mask[1], mask[2], mask[3])
 }
 
-// this function extracts a list of interfaces from VM's API definition
-// currently it's a comma-separated string stored in annotations
-func parseExtraIntfs(vm *api.VM) map[string]string {
+// specIntfs returns the modes of the interfaces defined in the VM spec
+func specIntfs(vm *api.VM) map[string]string {
 	result := make(map[string]string)
 
-	for intf, mode := range vm.GetObjectMeta().Annotations {
-		if !strings.HasPrefix(intf, constants.IGNITE_INTERFACE_ANNOTATION) {
-			continue
+	for _, intf := range vm.Spec.Network.Interfaces {
+		mode := intf.Mode
+		if len(mode) == 0 {
+			mode = api.InterfaceModeDHCPBridge
 		}
 
-		intf = strings.TrimPrefix(intf, constants.IGNITE_INTERFACE_ANNOTATION)
-		if intf == "" {
-			continue
-		}
+		result[intf.Name] = string(mode)
+	}
 
-		if _, ok := supportedModes[mode]; ok {
-			result[intf] = mode
-		} else {
-			log.Warnf("VM specifies unrecognized mode %q for interface %q", mode, intf)
-			continue
-		}
+	return result
+}
 
+// intfMTU returns the MTU for the VM's interface, which defaults to the MTU of the DHCP options and of the sandbox interface
+func intfMTU(vm *api.VM, iface *net.Interface) int {
+	if spec := vm.Interface(iface.Name); spec != nil && spec.MTU > 0 {
+		return int(spec.MTU)
 	}
 
-	return result
+	if dhcp := vm.Spec.Network.DHCP; dhcp != nil && dhcp.MTU > 0 {
+		return int(dhcp.MTU)
+	}
 
+	return iface.MTU
 }
@@ -1,48 +1,38 @@
 package container
 
 import (
+	"net"
+	"strings"
 	"testing"
 
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"gotest.tools/assert"
 )
 
-func TestParseExtraIntfs(t *testing.T) {
+func TestSpecIntfs(t *testing.T) {
 	cases := []struct {
-		name        string
-		annotations map[string]string
-		wantIntfs   map[string]string
+		name      string
+		intfs     []api.NetworkInterface
+		wantIntfs map[string]string
 	}{
 		{
 			name:      "empty object",
 			wantIntfs: make(map[string]string),
 		},
 		{
-			name: "wrong annotations",
-			annotations: map[string]string{
-				"foo":                                 "bar",
-				"ignite.weave.works/interface/":       "dhcp-bridge",
-				"ignite.weave.works/interface/eth123": "foo",
-			},
-			wantIntfs: make(map[string]string),
-		},
-		{
-			name: "one interface",
-			annotations: map[string]string{
-				"foo":                                 "bar",
-				"ignite.weave.works/interface/":       "dhcp-bridge",
-				"ignite.weave.works/interface/eth123": "tc-redirect",
+			name: "default mode",
+			intfs: []api.NetworkInterface{
+				{Name: "eth123"},
 			},
 			wantIntfs: map[string]string{
-				"eth123": "tc-redirect",
+				"eth123": "dhcp-bridge",
 			},
 		},
 		{
 			name: "many interfaces",
-			annotations: map[string]string{
-				"foo":                                 "bar",
-				"ignite.weave.works/interface/eth0":   "dhcp-bridge",
-				"ignite.weave.works/interface/eth123": "tc-redirect",
+			intfs: []api.NetworkInterface{
+				{Name: "eth0", Mode: api.InterfaceModeDHCPBridge},
+				{Name: "eth123", Mode: api.InterfaceModeTCRedirect},
 			},
 			wantIntfs: map[string]string{
 				"eth0":   "dhcp-bridge",
@@ -54,14 +44,83 @@ func TestParseExtraIntfs(t *testing.T) {
 	for _, rt := range cases {
 		t.Run(rt.name, func(t *testing.T) {
 			vm := &api.VM{}
-			for k, v := range rt.annotations {
-				vm.SetAnnotation(k, v)
-			}
+			vm.Spec.Network.Interfaces = rt.intfs
 
-			parsedIntfs := parseExtraIntfs(vm)
+			assert.DeepEqual(t, specIntfs(vm), rt.wantIntfs)
+		})
+	}
+}
+
+func TestStaticConfig(t *testing.T) {
+	_, net4, _ := net.ParseCIDR("10.61.0.2/16")
+	_, net6, _ := net.ParseCIDR("fd69:676e:6974:6500::2/64")
+	gw4 := net.IP{10, 61, 0, 1}
+	gw6 := net.ParseIP("fe80::1")
+	v4 := &addressConfig{ipNet: net4, gateway: &gw4}
+	v6 := &addressConfig{ipNet: net6, gateway: &gw6}
 
-			assert.DeepEqual(t, parsedIntfs, rt.wantIntfs)
+	cases := []struct {
+		name    string
+		static  *api.InterfaceAddress
+		v4, v6  *addressConfig
+		wantIP  string
+		wantGW  string
+		wantErr bool
+	}{
+		{
+			name:   "address of the sandbox prefix",
+			static: &api.InterfaceAddress{IP: "10.61.0.10"},
+			v4:     v4,
+			v6:     v6,
+			wantIP: "10.61.0.10/16",
+			wantGW: "10.61.0.1",
+		},
+		{
+			name:   "address with prefix and gateway",
+			static: &api.InterfaceAddress{IP: "192.168.1.10/24", Gateway: net.ParseIP("192.168.1.1")},
+			v4:     v4,
+			wantIP: "192.168.1.10/24",
+			wantGW: "192.168.1.1",
+		},
+		{
+			name:   "IPv6 address",
+			static: &api.InterfaceAddress{IP: "fd69:676e:6974:6500::10"},
+			v4:     v4,
+			v6:     v6,
+			wantIP: "fd69:676e:6974:6500::10/64",
+			wantGW: "fe80::1",
+		},
+		{
+			name:    "address without prefix for a sandbox without address",
+			static:  &api.InterfaceAddress{IP: "10.61.0.10"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid address",
+			static:  &api.InterfaceAddress{IP: "10.61.0"},
+			v4:      v4,
+			wantErr: true,
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			vm4, vm6, err := staticConfig(rt.static, rt.v4, rt.v6)
+			if rt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+
+			// The address of the other family is passed on unchanged
+			config, other, otherWant := vm4, vm6, rt.v6
+			if strings.Contains(rt.wantIP, ":") {
+				config, other, otherWant = vm6, vm4, rt.v4
+			}
 
+			assert.Equal(t, config.ipNet.String(), rt.wantIP)
+			assert.Equal(t, config.gateway.String(), rt.wantGW)
+			assert.Equal(t, other, otherWant)
 		})
 	}
 }
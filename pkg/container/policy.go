@@ -0,0 +1,247 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	go_prom "github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// policyTable is the nftables table enforcing the network policy of the VM. The bridge family filters the
+// frames forwarded between the TAPs and the sandbox interfaces without br_netfilter, and tracks their
+// connections on kernels 5.3 and later.
+const policyTable = "ignite_policy"
+
+// Names of the network policy metrics exposed by ignite-spawn
+const (
+	MetricPolicyDroppedPackets = "ignite_network_policy_dropped_packets_total"
+	MetricPolicyDroppedBytes   = "ignite_network_policy_dropped_bytes_total"
+)
+
+var (
+	policyDroppedPackets = go_prom.NewDesc(MetricPolicyDroppedPackets, "Packets dropped by the network policy of the VM", []string{"direction"}, nil)
+	policyDroppedBytes   = go_prom.NewDesc(MetricPolicyDroppedBytes, "Bytes dropped by the network policy of the VM", []string{"direction"}, nil)
+
+	// policyApplied is set once the network policy is enforced, its counters are only collected then
+	policyApplied bool
+)
+
+// ApplyNetworkPolicy enforces the network policy of the VM on the TAPs of its bridged interfaces
+func ApplyNetworkPolicy(policy *api.NetworkPolicy, dhcpIfaces []DHCPInterface) error {
+	if policy == nil {
+		return nil
+	}
+
+	var taps []string
+	for _, dhcpIface := range dhcpIfaces {
+		if len(dhcpIface.Bridge) > 0 {
+			taps = append(taps, dhcpIface.VMTAP)
+		}
+	}
+
+	ruleset, err := policyRuleset(policy, taps)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Applying the network policy to %s", strings.Join(taps, ", "))
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(ruleset)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply the network policy: %q: %v", out, err)
+	}
+
+	policyApplied = true
+	return nil
+}
+
+// policyRuleset generates the nftables ruleset for the policy. Traffic sent out of a TAP is ingress for
+// the VM, traffic received on it is egress. The DHCP server and the router advertisements of ignite-spawn
+// are local to the sandbox, they don't pass the forward hook.
+func policyRuleset(policy *api.NetworkPolicy, taps []string) (string, error) {
+	var b strings.Builder
+
+	// Deleting the table first replaces the ruleset atomically, the empty declaration makes sure it exists
+	fmt.Fprintf(&b, "table bridge %s {}\ndelete table bridge %s\n", policyTable, policyTable)
+	fmt.Fprintf(&b, "table bridge %s {\n", policyTable)
+
+	directions := []struct {
+		name  string
+		rules *api.NetworkPolicyRules
+		iface string
+		peer  string
+	}{
+		{"ingress", policy.Ingress, "oifname", "saddr"},
+		{"egress", policy.Egress, "iifname", "daddr"},
+	}
+
+	for _, d := range directions {
+		if d.rules != nil {
+			fmt.Fprintf(&b, "\tcounter %s_dropped {}\n", d.name)
+		}
+	}
+
+	fmt.Fprintf(&b, "\tchain forward {\n\t\ttype filter hook forward priority 0; policy accept;\n")
+	for _, d := range directions {
+		if d.rules == nil {
+			continue
+		}
+
+		for _, tap := range taps {
+			fmt.Fprintf(&b, "\t\t%s %q jump %s\n", d.iface, tap, d.name)
+		}
+	}
+	fmt.Fprintf(&b, "\t}\n")
+
+	for _, d := range directions {
+		if d.rules == nil {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\tchain %s {\n", d.name)
+		fmt.Fprintf(&b, "\t\tct state established,related accept\n")
+		// ARP and other non-IP frames aren't subject to the policy
+		fmt.Fprintf(&b, "\t\tmeta protocol != { ip, ip6 } accept\n")
+		fmt.Fprintf(&b, "\t\ticmpv6 type { nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept\n")
+
+		for _, rule := range d.rules.Allow {
+			matches, err := policyMatches(rule, d.peer)
+			if err != nil {
+				return "", err
+			}
+
+			for _, match := range matches {
+				fmt.Fprintf(&b, "\t\t%s\n", strings.TrimSpace(match+" accept"))
+			}
+		}
+
+		fmt.Fprintf(&b, "\t\tcounter name %s_dropped drop\n", d.name)
+		fmt.Fprintf(&b, "\t}\n")
+	}
+
+	fmt.Fprintf(&b, "}\n")
+	return b.String(), nil
+}
+
+// policyMatches returns the nftables matches of a rule, one per combination of its peers and ports
+func policyMatches(rule api.NetworkPolicyRule, peer string) ([]string, error) {
+	peers := []string{""}
+	if len(rule.CIDRs) > 0 {
+		peers = peers[:0]
+		for _, cidr := range rule.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network policy CIDR %q: %v", cidr, err)
+			}
+
+			family := "ip"
+			if ipNet.IP.To4() == nil {
+				family = "ip6"
+			}
+
+			peers = append(peers, fmt.Sprintf("%s %s %s", family, peer, ipNet))
+		}
+	}
+
+	ports := []string{""}
+	if len(rule.Ports) > 0 {
+		ports = ports[:0]
+		for _, port := range rule.Ports {
+			protocol := port.Protocol
+			if len(protocol) == 0 {
+				protocol = meta.ProtocolTCP
+			}
+
+			dport := fmt.Sprintf("%d", port.Port)
+			if port.EndPort > port.Port {
+				dport = fmt.Sprintf("%d-%d", port.Port, port.EndPort)
+			}
+
+			ports = append(ports, fmt.Sprintf("%s dport %s", protocol, dport))
+		}
+	}
+
+	var matches []string
+	for _, p := range peers {
+		for _, port := range ports {
+			matches = append(matches, strings.TrimSpace(p+" "+port))
+		}
+	}
+
+	return matches, nil
+}
+
+// policyCollector exposes the counters of the packets dropped by the network policy
+type policyCollector struct{}
+
+var _ go_prom.Collector = policyCollector{}
+
+// RegisterNetworkPolicyMetrics registers the metrics of the packets
+// dropped by the network policy of the VM to the given registry
+func RegisterNetworkPolicyMetrics(reg go_prom.Registerer) {
+	reg.MustRegister(policyCollector{})
+}
+
+func (policyCollector) Describe(ch chan<- *go_prom.Desc) {
+	ch <- policyDroppedPackets
+	ch <- policyDroppedBytes
+}
+
+func (policyCollector) Collect(ch chan<- go_prom.Metric) {
+	if !policyApplied {
+		return
+	}
+
+	out, err := exec.Command("nft", "-j", "list", "table", "bridge", policyTable).Output()
+	if err != nil {
+		log.Errorf("Failed to list the network policy counters: %v", err)
+		return
+	}
+
+	counters, err := parsePolicyCounters(out)
+	if err != nil {
+		log.Errorf("Failed to parse the network policy counters: %v", err)
+		return
+	}
+
+	for _, c := range counters {
+		direction := strings.TrimSuffix(c.Name, "_dropped")
+		ch <- go_prom.MustNewConstMetric(policyDroppedPackets, go_prom.CounterValue, float64(c.Packets), direction)
+		ch <- go_prom.MustNewConstMetric(policyDroppedBytes, go_prom.CounterValue, float64(c.Bytes), direction)
+	}
+}
+
+// policyCounter is a named counter of the network policy table, as listed by nft -j
+type policyCounter struct {
+	Name    string `json:"name"`
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// parsePolicyCounters extracts the named counters from the JSON listing of the network policy table
+func parsePolicyCounters(data []byte) ([]policyCounter, error) {
+	var listing struct {
+		Nftables []struct {
+			Counter *policyCounter `json:"counter"`
+		} `json:"nftables"`
+	}
+
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, err
+	}
+
+	var counters []policyCounter
+	for _, obj := range listing.Nftables {
+		if obj.Counter != nil {
+			counters = append(counters, *obj.Counter)
+		}
+	}
+
+	return counters, nil
+}
@@ -0,0 +1,109 @@
+package container
+
+import (
+	"testing"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"gotest.tools/assert"
+)
+
+func TestPolicyRuleset(t *testing.T) {
+	policy := &api.NetworkPolicy{
+		Ingress: &api.NetworkPolicyRules{
+			Allow: []api.NetworkPolicyRule{
+				{
+					CIDRs: []string{"10.0.0.5/8", "fd00::/64"},
+					Ports: []api.NetworkPolicyPort{{Port: 22}},
+				},
+				{
+					Ports: []api.NetworkPolicyPort{{Protocol: meta.ProtocolUDP, Port: 8000, EndPort: 8080}},
+				},
+			},
+		},
+		Egress: &api.NetworkPolicyRules{
+			Allow: []api.NetworkPolicyRule{
+				{CIDRs: []string{"192.168.0.0/16"}},
+				{},
+			},
+		},
+	}
+
+	expected := `table bridge ignite_policy {}
+delete table bridge ignite_policy
+table bridge ignite_policy {
+	counter ingress_dropped {}
+	counter egress_dropped {}
+	chain forward {
+		type filter hook forward priority 0; policy accept;
+		oifname "vm_eth0" jump ingress
+		oifname "vm_eth1" jump ingress
+		iifname "vm_eth0" jump egress
+		iifname "vm_eth1" jump egress
+	}
+	chain ingress {
+		ct state established,related accept
+		meta protocol != { ip, ip6 } accept
+		icmpv6 type { nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept
+		ip saddr 10.0.0.0/8 tcp dport 22 accept
+		ip6 saddr fd00::/64 tcp dport 22 accept
+		udp dport 8000-8080 accept
+		counter name ingress_dropped drop
+	}
+	chain egress {
+		ct state established,related accept
+		meta protocol != { ip, ip6 } accept
+		icmpv6 type { nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept
+		ip daddr 192.168.0.0/16 accept
+		accept
+		counter name egress_dropped drop
+	}
+}
+`
+
+	ruleset, err := policyRuleset(policy, []string{"vm_eth0", "vm_eth1"})
+	assert.NilError(t, err)
+	assert.Equal(t, ruleset, expected)
+}
+
+func TestPolicyRulesetIngressOnly(t *testing.T) {
+	policy := &api.NetworkPolicy{Ingress: &api.NetworkPolicyRules{}}
+
+	expected := `table bridge ignite_policy {}
+delete table bridge ignite_policy
+table bridge ignite_policy {
+	counter ingress_dropped {}
+	chain forward {
+		type filter hook forward priority 0; policy accept;
+		oifname "vm_eth0" jump ingress
+	}
+	chain ingress {
+		ct state established,related accept
+		meta protocol != { ip, ip6 } accept
+		icmpv6 type { nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept
+		counter name ingress_dropped drop
+	}
+}
+`
+
+	ruleset, err := policyRuleset(policy, []string{"vm_eth0"})
+	assert.NilError(t, err)
+	assert.Equal(t, ruleset, expected)
+}
+
+func TestParsePolicyCounters(t *testing.T) {
+	data := []byte(`{"nftables": [
+		{"metainfo": {"version": "1.0.2", "json_schema_version": 1}},
+		{"table": {"family": "bridge", "name": "ignite_policy", "handle": 1}},
+		{"counter": {"family": "bridge", "name": "ingress_dropped", "table": "ignite_policy", "handle": 2, "packets": 3, "bytes": 180}},
+		{"counter": {"family": "bridge", "name": "egress_dropped", "table": "ignite_policy", "handle": 3, "packets": 0, "bytes": 0}},
+		{"rule": {"family": "bridge", "table": "ignite_policy", "chain": "ingress", "handle": 9, "expr": [{"counter": "ingress_dropped"}, {"drop": null}]}}
+	]}`)
+
+	counters, err := parsePolicyCounters(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, counters, []policyCounter{
+		{Name: "ingress_dropped", Packets: 3, Bytes: 180},
+		{Name: "egress_dropped"},
+	})
+}
@@ -0,0 +1,380 @@
+package debugfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxLinks is the number of symlinks followed when resolving a path, like MAXSYMLINKS of the kernel
+const maxLinks = 40
+
+// Image edits the ext4 filesystem in a disk file with debugfs, which works on the file itself. Unlike
+// mounting the file, this needs neither a loop device nor any privileges besides access to the file.
+type Image struct {
+	file string
+	// dirs caches the modes of the entries of the directories listed so far by their resolved paths
+	dirs map[string]map[string]uint32
+}
+
+// NewImage returns an editor of the ext4 filesystem in the given file
+func NewImage(file string) *Image {
+	return &Image{
+		file: file,
+		dirs: map[string]map[string]uint32{},
+	}
+}
+
+// run runs the given debugfs commands against the filesystem. debugfs exits with zero even if commands
+// fail, so the errors it reports on stderr are returned instead. Commands that modify the filesystem need write.
+func (i *Image) run(write bool, commands ...string) (string, error) {
+	args := []string{i.file}
+	if len(commands) == 1 {
+		args = append([]string{"-R", commands[0]}, args...)
+	} else {
+		// debugfs echoes the commands of a script to stdout, only the output of single commands is returned
+		script, err := ioutil.TempFile("", "ignite-debugfs")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(script.Name())
+
+		_, err = script.WriteString(strings.Join(commands, "\n") + "\n")
+		if closeErr := script.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+
+		args = append([]string{"-f", script.Name()}, args...)
+	}
+
+	if write {
+		args = append([]string{"-w"}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("debugfs", args...)
+	cmd.Env = append(os.Environ(), "DEBUGFS_PAGER=__none__")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("command %q exited with %q: %v", cmd.Args, stderr.String(), err)
+	}
+
+	var errs []string
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		// Skip the version banner
+		if len(line) > 0 && !strings.HasPrefix(line, "debugfs ") {
+			errs = append(errs, line)
+		}
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("debugfs failed on %q: %s", i.file, strings.Join(errs, "; "))
+	}
+
+	if len(commands) > 1 {
+		return "", nil
+	}
+
+	return stdout.String(), nil
+}
+
+// lookup returns the mode of the entry with the given name in the resolved directory, or zero if it doesn't exist
+func (i *Image) lookup(dir, name string) (uint32, error) {
+	entries, ok := i.dirs[dir]
+	if !ok {
+		out, err := i.run(false, "ls -p "+quote(dir))
+		if err != nil {
+			return 0, err
+		}
+
+		// Every entry is printed as /inode/mode/uid/gid/name/size/
+		entries = map[string]uint32{}
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Split(line, "/")
+			if len(fields) != 8 {
+				continue
+			}
+
+			mode, err := strconv.ParseUint(fields[2], 8, 32)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse the mode of %q in %q: %v", fields[5], dir, err)
+			}
+			entries[fields[5]] = uint32(mode)
+		}
+
+		i.dirs[dir] = entries
+	}
+
+	return entries[name], nil
+}
+
+// readlink returns the target of the symlink at the resolved path
+func (i *Image) readlink(p string) (string, error) {
+	out, err := i.run(false, "stat "+quote(p))
+	if err != nil {
+		return "", err
+	}
+
+	// Short targets are stored in the inode, debugfs prints them with stat
+	const fastLink = "Fast link dest: "
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, fastLink) {
+			return strconv.Unquote(strings.TrimPrefix(line, fastLink))
+		}
+	}
+
+	// Longer targets are stored in a data block like the contents of a file
+	return i.run(false, "cat "+quote(p))
+}
+
+// add caches the mode of an entry created at the resolved path
+func (i *Image) add(p string, mode uint32) {
+	dir := path.Dir(p)
+	if _, ok := i.dirs[dir]; !ok {
+		i.dirs[dir] = map[string]uint32{}
+	}
+	i.dirs[dir][path.Base(p)] = mode
+}
+
+// resolve follows the symlinks in the path like the kernel would if the filesystem was mounted. It returns
+// the resolved path and the mode of the entry there. If the entry doesn't exist, the mode is zero and the
+// path is resolved up to the first missing directory.
+func (i *Image) resolve(p string) (string, uint32, error) {
+	resolved, mode := "/", uint32(unix.S_IFDIR)
+	components, links := splitPath(p), 0
+	for len(components) > 0 {
+		name := components[0]
+		components = components[1:]
+
+		switch name {
+		case ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		var err error
+		if mode, err = i.lookup(resolved, name); err != nil {
+			return "", 0, err
+		}
+
+		next := path.Join(resolved, name)
+		if mode == 0 {
+			return path.Join(append([]string{next}, components...)...), 0, nil
+		}
+
+		if mode&unix.S_IFMT == unix.S_IFLNK {
+			if links++; links > maxLinks {
+				return "", 0, fmt.Errorf("too many levels of symbolic links in %q of %q", p, i.file)
+			}
+
+			target, err := i.readlink(next)
+			if err != nil {
+				return "", 0, err
+			}
+
+			if path.IsAbs(target) {
+				resolved = "/"
+			}
+			components = append(splitPath(target), components...)
+			mode = unix.S_IFDIR
+			continue
+		}
+
+		resolved = next
+	}
+
+	return resolved, mode, nil
+}
+
+// Dump copies the file at the given path in the filesystem to the host if it exists
+func (i *Image) Dump(p, hostPath string) error {
+	resolved, mode, err := i.resolve(p)
+	if err != nil || mode&unix.S_IFMT != unix.S_IFREG {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+		return err
+	}
+
+	_, err = i.run(false, fmt.Sprintf("dump -p %s %s", quote(resolved), quote(hostPath)))
+	return err
+}
+
+// CopyIn copies the directory tree on the host into the root of the filesystem, like extracting an
+// archive would. Existing files are replaced, existing directories and their modes are kept.
+func (i *Image) CopyIn(dir string) error {
+	var commands []string
+	err := filepath.Walk(dir, func(hostPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, hostPath)
+		if err != nil {
+			return err
+		}
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return fmt.Errorf("failed to stat %q", hostPath)
+		}
+
+		if rel == "." {
+			// Set the mode of the root directory like it's set for the mountpoint
+			commands = append(commands, fmt.Sprintf("sif / mode 0%o", stat.Mode))
+			return nil
+		}
+
+		p, mode, err := i.resolve("/" + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		if err := checkPath(p); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if mode != 0 {
+				if mode&unix.S_IFMT != unix.S_IFDIR {
+					return fmt.Errorf("%q in %q is not a directory", p, i.file)
+				}
+
+				return nil
+			}
+
+			i.add(p, stat.Mode)
+			i.dirs[p] = map[string]uint32{}
+			commands = append(commands, "mkdir "+quote(p))
+			commands = append(commands, setInode(p, stat)...)
+			return nil
+		}
+
+		if mode != 0 {
+			commands = append(commands, "rm "+quote(p))
+		}
+
+		switch info.Mode() & os.ModeType {
+		case 0:
+			commands = append(commands, fmt.Sprintf("write %s %s", quote(hostPath), quote(p)))
+		case os.ModeSymlink:
+			target, err := os.Readlink(hostPath)
+			if err != nil {
+				return err
+			}
+			commands = append(commands, fmt.Sprintf("symlink %s %s", quote(p), quote(target)))
+		default:
+			return fmt.Errorf("unsupported file type of %q: %v", hostPath, info.Mode())
+		}
+
+		i.add(p, stat.Mode)
+		commands = append(commands, setInode(p, stat)...)
+		return nil
+	})
+	if err != nil || len(commands) == 0 {
+		return err
+	}
+
+	_, err = i.run(true, commands...)
+	return err
+}
+
+// Edit runs the given commands against the filesystem, as returned by SetInode and Mknod
+func (i *Image) Edit(commands ...string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	// The cached directory listings may be outdated afterwards
+	i.dirs = map[string]map[string]uint32{}
+	_, err := i.run(true, commands...)
+	return err
+}
+
+// SetInode returns the commands to set the mode, including the file type, and the owner of the inode at the
+// given path. The path isn't resolved, symlinks in it aren't followed.
+func SetInode(p string, mode, uid, gid uint32) ([]string, error) {
+	if err := checkPath(p); err != nil {
+		return nil, err
+	}
+
+	return []string{
+		fmt.Sprintf("sif %s mode 0%o", quote(p), mode),
+		fmt.Sprintf("sif %s uid %d", quote(p), uid),
+		fmt.Sprintf("sif %s gid %d", quote(p), gid),
+	}, nil
+}
+
+// Mknod returns the commands to create the device node or FIFO of the given mode at the given path, owned
+// by the given user and group. Creating device nodes this way needs no privileges, unlike mknod(2).
+func Mknod(p string, mode, uid, gid uint32, major, minor uint32) ([]string, error) {
+	var node string
+	switch mode & unix.S_IFMT {
+	case unix.S_IFCHR:
+		node = fmt.Sprintf("c %d %d", major, minor)
+	case unix.S_IFBLK:
+		node = fmt.Sprintf("b %d %d", major, minor)
+	case unix.S_IFIFO:
+		node = "p"
+	default:
+		return nil, fmt.Errorf("unsupported file type of %q: %o", p, mode&unix.S_IFMT)
+	}
+
+	commands, err := SetInode(p, mode, uid, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	// mknod only takes a name in the current directory
+	return append([]string{
+		"cd " + quote(path.Dir(p)),
+		fmt.Sprintf("mknod %s %s", quote(path.Base(p)), node),
+		"cd /",
+	}, commands...), nil
+}
+
+// setInode returns the commands to set the mode and the owner of the inode at the given path like on the host
+func setInode(p string, stat *syscall.Stat_t) []string {
+	// The path has been checked already
+	commands, _ := SetInode(p, stat.Mode, stat.Uid, stat.Gid)
+	return commands
+}
+
+// checkPath returns an error if the path can't be passed to debugfs
+func checkPath(p string) error {
+	if strings.ContainsAny(p, "\"\n") {
+		return fmt.Errorf("unsupported path %q, debugfs can't handle quotes or newlines", p)
+	}
+
+	return nil
+}
+
+// quote quotes the path for debugfs, which splits the arguments of commands at whitespace
+func quote(p string) string {
+	return `"` + p + `"`
+}
+
+func splitPath(p string) []string {
+	var components []string
+	for _, c := range strings.Split(p, "/") {
+		if len(c) > 0 {
+			components = append(components, c)
+		}
+	}
+
+	return components
+}
@@ -0,0 +1,122 @@
+package debugfs
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string, links map[string]string) {
+	for name, contents := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImageCopyIn(t *testing.T) {
+	for _, tool := range []string{"mkfs.ext4", "debugfs", "e2fsck"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "ignite-debugfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "image.ext4")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(file, 32*1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("mkfs.ext4", "-q", "-F", file).CombinedOutput(); err != nil {
+		t.Fatalf("mkfs.ext4 failed: %s", out)
+	}
+
+	// Lay out the image like a distribution with /lib linked to /usr/lib
+	base := filepath.Join(dir, "base")
+	writeTree(t, base, map[string]string{
+		"usr/lib/os-release": "ignite",
+		"etc/hosts":          "127.0.0.1\tlocalhost\n",
+	}, map[string]string{
+		"lib": "usr/lib",
+	})
+	if err := NewImage(file).CopyIn(base); err != nil {
+		t.Fatal(err)
+	}
+
+	// The files are added through the symlink and replace existing ones
+	overlay := filepath.Join(dir, "overlay")
+	writeTree(t, overlay, map[string]string{
+		"lib/modules/4.19/modules.dep": "modules",
+		"etc/hosts":                    "10.61.0.2\tvm\n",
+	}, nil)
+
+	img := NewImage(file)
+	if err := img.Dump("/etc/hosts", filepath.Join(dir, "hosts")); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, err := ioutil.ReadFile(filepath.Join(dir, "hosts")); err != nil || string(hosts) != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("unexpected /etc/hosts %q: %v", hosts, err)
+	}
+
+	if err := img.CopyIn(overlay); err != nil {
+		t.Fatal(err)
+	}
+
+	img = NewImage(file)
+	for p, expected := range map[string]string{
+		"/usr/lib/modules/4.19/modules.dep": "modules",
+		"/lib/modules/4.19/modules.dep":     "modules",
+		"/etc/hosts":                        "10.61.0.2\tvm\n",
+	} {
+		resolved, mode, err := img.resolve(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode&unix.S_IFMT != unix.S_IFREG {
+			t.Errorf("expected %q to be a regular file, got mode %o", p, mode)
+			continue
+		}
+
+		contents, err := img.run(false, "cat "+quote(resolved))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contents != expected {
+			t.Errorf("expected %q to contain %q, got %q", p, expected, contents)
+		}
+	}
+
+	// The symlink isn't replaced by a directory
+	if _, mode, err := img.resolve("/usr"); err != nil || mode&unix.S_IFMT != unix.S_IFDIR {
+		t.Errorf("expected /usr to be a directory, got mode %o: %v", mode, err)
+	}
+	if mode, err := img.lookup("/", "lib"); err != nil || mode&unix.S_IFMT != unix.S_IFLNK {
+		t.Errorf("expected /lib to be a symlink, got mode %o: %v", mode, err)
+	}
+
+	// The filesystem is consistent after the edits
+	if out, err := exec.Command("e2fsck", "-n", "-f", file).CombinedOutput(); err != nil {
+		t.Errorf("e2fsck found errors: %s", out)
+	}
+}
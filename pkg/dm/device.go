@@ -14,12 +14,7 @@ import (
 
 type Device struct {
 	*api.PoolDevice
-	prefixer *util.Prefixer
-	pool     *Pool
-
-	// These flags are for filesystem and snapshot creation
-	mkfs   bool
-	resize bool
+	pool *Pool
 }
 
 var _ blockDevice = &Device{}
@@ -27,116 +22,120 @@ var _ blockDevice = &Device{}
 // Additional space to add to volumes to compensate for the ext4 partition
 var extraSize = meta.NewSizeFromBytes(constants.POOL_VOLUME_EXTRA_SIZE)
 
-func (p *Pool) CreateVolume(size meta.Size, metadataPath string) (*Device, error) {
+// CreateVolume creates a new empty thin volume in the pool, the caller needs to create a filesystem on it
+func (p *Pool) CreateVolume(deviceType api.PoolDeviceType, size meta.Size, metadataPath string) (*Device, error) {
 	// The pool needs to be active for this
 	if err := p.activate(); err != nil {
 		return nil, err
 	}
 
-	if volume, err := p.newDevice(func(id meta.DMID) (*Device, error) {
-		if err := dmsetup("message", p.Path(), "0", fmt.Sprintf("create_thin %s", id)); err != nil {
+	return p.newDevice(func(id meta.DMID) (*Device, error) {
+		// Devices missing from the database are leaked by crashed operations, replace them
+		_ = p.message("delete %s", id)
+
+		if err := p.message("create_thin %s", id); err != nil {
 			return nil, err
 		}
 
 		return &Device{
 			PoolDevice: &api.PoolDevice{
-				Size: size.Add(extraSize),
-				// Parent:       nil,
+				Size:         size,
+				Parent:       meta.NewPoolDMID(),
+				Type:         deviceType,
 				MetadataPath: metadataPath,
 			},
 			pool: p,
-			mkfs: true, // This is a new volume, create a new filesystem for it on activation
 		}, nil
-	}); err != nil {
-		return nil, err
-	} else {
-		return volume, volume.activate()
-	}
+	})
 }
 
-func (d *Device) CreateSnapshot(size meta.Size, metadataPath string) (*Device, error) {
-	// The device needs to be active for this
-	if err := d.activate(); err != nil {
-		return nil, err
+// CreateSnapshot creates a thin snapshot of the device, which shares the blocks of the device until either
+// of them is written to. Snapshots can be larger than their parents, the caller needs to resize the filesystem.
+func (d *Device) CreateSnapshot(deviceType api.PoolDeviceType, size meta.Size, metadataPath string) (*Device, error) {
+	if size.Bytes() < d.Size.Bytes() {
+		return nil, fmt.Errorf("snapshot size %s is smaller than the size of its parent %s", size, d.Size)
 	}
 
-	if snapshot, err := d.pool.newDevice(func(id meta.DMID) (*Device, error) {
-		if err := dmsetup("suspend", d.Path()); err != nil {
-			return nil, err
-		}
+	// The pool needs to be active for this
+	if err := d.pool.activate(); err != nil {
+		return nil, err
+	}
 
-		if err := dmsetup("message", d.pool.Path(), "0",
-			fmt.Sprintf("create_snap %s %s", id, d.pool.getID(d))); err != nil {
-			return nil, err
+	parent := d.ID()
+	return d.pool.newDevice(func(id meta.DMID) (*Device, error) {
+		// Devices missing from the database are leaked by crashed operations, replace them
+		_ = d.pool.message("delete %s", id)
+
+		// An active origin needs to be suspended while the snapshot is taken
+		if d.active() {
+			if err := dmsetup("suspend", d.Path()); err != nil {
+				return nil, err
+			}
+			defer func() {
+				if err := dmsetup("resume", d.Path()); err != nil {
+					log.Warnf("Failed to resume device %q: %v", d.Path(), err)
+				}
+			}()
 		}
 
-		if err := dmsetup("resume", d.Path()); err != nil {
+		if err := d.pool.message("create_snap %s %s", id, parent); err != nil {
 			return nil, err
 		}
 
-		// TODO: Prevent snapshots smaller than their parents?
 		return &Device{
 			PoolDevice: &api.PoolDevice{
 				Size:         size,
-				Parent:       d.pool.getID(d),
+				Parent:       parent,
+				Type:         deviceType,
 				MetadataPath: metadataPath,
 			},
-			pool:   d.pool,
-			resize: size != d.Size, // Set the resize flag if the size differs from the parent
+			pool: d.pool,
 		}, nil
-	}); err != nil {
-		return nil, err
-	} else {
-		return snapshot, snapshot.activate()
-	}
+	})
+}
+
+// ID returns the ID of the device in the pool
+func (d *Device) ID() meta.DMID {
+	return d.pool.getID(d)
 }
 
 func (d *Device) activate() error {
-	id := d.pool.getID(d)
-	parent := d.pool.GetDevice(d.Parent)
+	_, err := d.ActivateAs(d.name())
+	return err
+}
 
-	if parent == nil {
-		// Activate the pool as the base device
-		if err := d.pool.activate(); err != nil {
-			return err
-		}
-	} else {
-		// Check if all parents are active
-		// TODO: Reference count this for deactivation
-		if err := parent.activate(); err != nil {
-			return err
-		}
+// ActivateAs sets up the device with the given name and returns its path. The devices of the pool don't depend
+// on each other, so only the pool is activated together with the device. The device may only be active once.
+func (d *Device) ActivateAs(name string) (string, error) {
+	devicePath := path.Join("/dev/mapper", name)
+
+	// Activate the pool as the base device
+	if err := d.pool.activate(); err != nil {
+		return "", err
 	}
 
 	// Don't try to activate an already active device
-	if d.active() {
-		return nil
+	if util.FileExists(devicePath) {
+		return devicePath, nil
 	}
 
 	dmTable := fmt.Sprintf("0 %d thin %s %s",
 		d.Size.Sectors(),
 		d.pool.Path(),
-		id,
+		d.ID(),
 	)
 
-	log.Infof("Activate device: %s\n", id)
-	if err := dmsetup("create", d.name(id), "--table", dmTable); err != nil {
-		return err
+	log.Debugf("Activating device %s as %s", d.ID(), name)
+	if err := dmsetup("create", "--verifyudev", name, "--table", dmTable); err != nil {
+		return "", err
 	}
 
-	if d.mkfs {
-		log.Infof("Creating new filesystem on device: %s\n", id)
-		if err := mkfs(d); err != nil {
-			return err
-		}
-	} else if d.resize { // If the resize flag has been set, resize the filesystem to fill the device
-		log.Infof("Resizing filesystem on device: %s\n", id)
-		if err := resize2fs(d); err != nil {
-			return err
-		}
-	}
+	return devicePath, nil
+}
 
-	return nil
+// Deactivate removes the device set up by activate
+func (d *Device) Deactivate() error {
+	return removeDevice(d.name())
 }
 
 func (d *Device) Import(src source.Source) (*util.MountPoint, error) {
@@ -153,12 +152,13 @@ func (d *Device) Import(src source.Source) (*util.MountPoint, error) {
 	return mountPoint, nil
 }
 
-func (d *Device) name(id meta.DMID) string {
-	return d.prefixer.Prefix(id.String())
+// name returns the default name of the device, e.g. ignite-thin-3
+func (d *Device) name() string {
+	return util.NewPrefixer(constants.IGNITE_PREFIX).Prefix("thin", d.ID())
 }
 
 func (d *Device) Path() string {
-	return path.Join("/dev/mapper", d.name(d.pool.getID(d)))
+	return path.Join("/dev/mapper", d.name())
 }
 
 // If /dev/mapper/<name> exists the device is active
@@ -3,12 +3,16 @@ package dm
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
+// dmsetupNotFound is the error message when dmsetup can't find a device
+const dmsetupNotFound = "No such device or address"
+
 type blockDevice interface {
 	Path() string
 	activate() error
@@ -21,24 +25,21 @@ func dmsetup(args ...string) error {
 	return err
 }
 
-func mkfs(device blockDevice) error {
-	mkfsArgs := []string{
-		"-I",
-		"256",
-		"-F",
-		"-E",
-		"lazy_itable_init=0,lazy_journal_init=0",
-		device.Path(),
-	}
-
-	_, err := util.ExecuteCommand("mkfs.ext4", mkfsArgs...)
+// resize2fs repairs the filesystem on the device and resizes it to fill the device
+func resize2fs(devicePath string) error {
+	// e2fsck throws an error if the filesystem gets repaired, so just ignore it
+	_, _ = util.ExecuteCommand("e2fsck", "-pf", devicePath)
+	_, err := util.ExecuteCommand("resize2fs", devicePath)
 	return err
 }
 
-func resize2fs(device blockDevice) error {
-	_, _ = util.ExecuteCommand("e2fsck", "-pf", device.Path())
-	_, err := util.ExecuteCommand("resize2fs", device.Path())
-	return err
+// removeDevice removes the device mapper device with the given name, it's a no-op if it doesn't exist
+func removeDevice(name string) error {
+	if err := dmsetup("remove", "--verifyudev", name); err != nil && !strings.Contains(err.Error(), dmsetupNotFound) {
+		return err
+	}
+
+	return nil
 }
 
 func allocateBackingFile(p string, size meta.Size) error {
@@ -78,3 +79,13 @@ func activateBackingDevice(p string, readOnly bool) (blockDevice, error) {
 
 	return device, device.activate()
 }
+
+// detachBackingDevice detaches the loop device of a backing file, the device is kept
+// until the pool using it is removed. Physical devices don't need to be detached.
+func detachBackingDevice(device blockDevice) {
+	if ld, ok := device.(*loopDevice); ok && ld.active() {
+		if err := ld.Detach(); err != nil {
+			log.Warnf("Failed to detach loop device %q: %v", ld.Path(), err)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package dm
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/operations/lookup"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// Driver is the thinpool storage driver. Images, kernels and the disks of VMs are thin devices in one pool:
+// the filesystem of an image is copied into a volume when the first VM is created from it, the kernel
+// modules are added to a snapshot of the image, and the disk of a VM is a snapshot of that layer.
+// The database of the devices is stored at constants.SNAPSHOTTER_POOL_FILE.
+type Driver struct {
+	spec *api.PoolSpec
+}
+
+var _ snapshotter.Driver = &Driver{}
+
+// NewDriver returns the thinpool storage driver, the pool is created with the given spec
+func NewDriver(spec *api.PoolSpec) *Driver {
+	return &Driver{spec: spec}
+}
+
+func (*Driver) Name() api.StorageDriver {
+	return api.StorageDriverThinPool
+}
+
+func (*Driver) HasDisk(vm *api.VM) bool {
+	if !util.FileExists(constants.SNAPSHOTTER_POOL_FILE) {
+		return false
+	}
+
+	// The database is replaced atomically, it can be read without holding the lock
+	pool, err := LoadPool(nil)
+	if err != nil {
+		log.Warnf("Failed to load the pool: %v", err)
+		return false
+	}
+
+	return findVMDevice(pool, vm) != nil
+}
+
+// CreateDisk creates the disk of the VM as a snapshot of the layer of its image and kernel.
+// The disk is at least as large as the layer, its filesystem is resized to fill it.
+func (d *Driver) CreateDisk(vm *api.VM) error {
+	return d.withPool(func(pool *Pool) (err error) {
+		if findVMDevice(pool, vm) != nil {
+			return fmt.Errorf("the disk of %s %q exists already", vm.GetKind(), vm.GetUID())
+		}
+
+		layer, err := kernelLayer(pool, vm)
+		if err != nil {
+			return err
+		}
+
+		size := vm.Spec.DiskSize
+		if size.Bytes() < layer.Size.Bytes() {
+			log.Warnf("warning: requested disk size (%s) < image and kernel size (%s), using image and kernel size for disk\n", size, layer.Size)
+			size = layer.Size
+		}
+
+		device, err := layer.CreateSnapshot(api.PoolDeviceTypeVM, size, vmMetadataPath(vm))
+		if err != nil {
+			return err
+		}
+		defer deleteOnError(pool, device, &err)
+
+		devicePath, err := device.ActivateAs(vm.PrefixedID())
+		if err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, func() error { return removeDevice(vm.PrefixedID()) })
+
+		if size.Bytes() > layer.Size.Bytes() {
+			if err = resize2fs(devicePath); err != nil {
+				return err
+			}
+		}
+
+		return populate(devicePath, func(mountPoint string) error {
+			return dmlegacy.PopulateFilesystem(vm, mountPoint)
+		})
+	})
+}
+
+// ImportDisk creates the disk of the VM as a volume holding a copy of the filesystem on the given device.
+// The disk doesn't share any blocks with the layer of its image and kernel.
+func (d *Driver) ImportDisk(vm *api.VM, devicePath string) error {
+	return d.withPool(func(pool *Pool) (err error) {
+		if findVMDevice(pool, vm) != nil {
+			return fmt.Errorf("the disk of %s %q exists already", vm.GetKind(), vm.GetUID())
+		}
+
+		size, err := util.DeviceSize(devicePath)
+		if err != nil {
+			return err
+		}
+
+		device, err := pool.CreateVolume(api.PoolDeviceTypeVM, meta.NewSizeFromBytes(uint64(size)), vmMetadataPath(vm))
+		if err != nil {
+			return err
+		}
+		defer deleteOnError(pool, device, &err)
+
+		// The VM may be using its name for the device being imported
+		if err = device.activate(); err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, device.Deactivate)
+
+		return util.CopySparse(device.Path(), devicePath)
+	})
+}
+
+func (d *Driver) ActivateDisk(vm *api.VM) (devicePath string, err error) {
+	devicePath = vm.SnapshotDev()
+
+	// Return if the disk is already set up
+	if util.FileExists(devicePath) {
+		return
+	}
+
+	err = d.withPool(func(pool *Pool) error {
+		device := findVMDevice(pool, vm)
+		if device == nil {
+			return fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+		}
+
+		if _, err := device.ActivateAs(vm.PrefixedID()); err != nil {
+			return err
+		}
+
+		// Repair the filesystem in case it has errors
+		// e2fsck throws an error if the filesystem gets repaired, so just ignore it
+		_, _ = util.ExecuteCommand("e2fsck", "-p", "-f", devicePath)
+		return nil
+	})
+
+	return
+}
+
+func (*Driver) DeactivateDisk(vm *api.VM) error {
+	return dmlegacy.DeactivateSnapshot(vm)
+}
+
+func (d *Driver) RemoveDisk(vm *api.VM) error {
+	if err := d.DeactivateDisk(vm); err != nil {
+		return err
+	}
+
+	return d.withPool(func(pool *Pool) error {
+		if device := findVMDevice(pool, vm); device != nil {
+			return pool.DeleteDevice(device)
+		}
+
+		return nil
+	})
+}
+
+// ResizeDisk grows the device of the disk of the VM and resizes its filesystem. The blocks past the previous
+// end of the device aren't mapped, they don't take space in the pool until they're written to.
+func (d *Driver) ResizeDisk(vm *api.VM, size meta.Size) error {
+	return d.withPool(func(pool *Pool) (err error) {
+		device := findVMDevice(pool, vm)
+		if device == nil {
+			return fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+		}
+
+		device.Size = size
+		devicePath, err := device.ActivateAs(vm.PrefixedID())
+		if err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, func() error { return removeDevice(vm.PrefixedID()) })
+
+		return resize2fs(devicePath)
+	})
+}
+
+// CompactDisk discards the unused blocks of the filesystem of the VM, which unmaps them in the pool. The
+// pool passes the discards down to its data file, which releases the blocks that aren't shared anymore.
+func (d *Driver) CompactDisk(vm *api.VM) error {
+	return d.withPool(func(pool *Pool) (err error) {
+		device := findVMDevice(pool, vm)
+		if device == nil {
+			return fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+		}
+
+		devicePath, err := device.ActivateAs(vm.PrefixedID())
+		if err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, func() error { return removeDevice(vm.PrefixedID()) })
+
+		mp, err := util.Mount(devicePath)
+		if err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, mp.Umount)
+
+		_, err = util.ExecuteCommand("fstrim", mp.Path)
+		return err
+	})
+}
+
+// DiskUsage returns the size of the disk of the VM and the space its blocks take in the pool, including
+// the blocks shared with its image and kernel. The disks of stopped VMs are activated to query the pool.
+func (d *Driver) DiskUsage(vm *api.VM) (u *snapshotter.DiskUsage, err error) {
+	if util.FileExists(vm.SnapshotDev()) {
+		pool, err := LoadPool(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return diskUsage(pool, vm, vm.PrefixedID())
+	}
+
+	err = d.withPool(func(pool *Pool) (err error) {
+		device := findVMDevice(pool, vm)
+		if device == nil {
+			return fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+		}
+
+		if err = device.activate(); err != nil {
+			return err
+		}
+		defer util.DeferErr(&err, device.Deactivate)
+
+		u, err = diskUsage(pool, vm, device.name())
+		return err
+	})
+
+	return
+}
+
+// RemoveImage deletes the layers of the image, and the layers of the kernels added to it
+func (d *Driver) RemoveImage(image *api.Image) error {
+	metadataPath := path.Join(image.ObjectPath(), constants.METADATA)
+	return d.removeLayers(func(device *api.PoolDevice) bool {
+		return device.Type == api.PoolDeviceTypeImage && device.MetadataPath == metadataPath
+	})
+}
+
+// RemoveKernel deletes the layers adding the kernel to images
+func (d *Driver) RemoveKernel(kernel *api.Kernel) error {
+	metadataPath := path.Join(kernel.ObjectPath(), constants.METADATA)
+	return d.removeLayers(func(device *api.PoolDevice) bool {
+		return device.Type == api.PoolDeviceTypeKernel && device.MetadataPath == metadataPath
+	})
+}
+
+// removeLayers deletes the matching layers and their kernel layers, the layers are only active while they're created
+func (d *Driver) removeLayers(matchFunc func(*api.PoolDevice) bool) error {
+	if !util.FileExists(constants.SNAPSHOTTER_POOL_FILE) {
+		return nil
+	}
+
+	return d.withPool(func(pool *Pool) error {
+		var layers []*Device
+		_ = pool.ForDevices(func(_ meta.DMID, device *Device) error {
+			if matchFunc(device.PoolDevice) {
+				layers = append(layers, device)
+			}
+
+			return nil
+		})
+
+		for _, layer := range layers {
+			// The kernel layers are snapshots of the image layers
+			id := layer.ID()
+			var kernelLayers []*Device
+			_ = pool.ForDevices(func(_ meta.DMID, device *Device) error {
+				if device.Type == api.PoolDeviceTypeKernel && device.Parent == id {
+					kernelLayers = append(kernelLayers, device)
+				}
+
+				return nil
+			})
+
+			for _, device := range append(kernelLayers, layer) {
+				if err := pool.DeleteDevice(device); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// withPool calls the given function with the loaded pool while holding the global device mapper lock,
+// and saves the pool afterwards. The pool is saved even if the function fails, as devices may have
+// been deleted already. The pool is created with the spec of the driver if it doesn't exist yet.
+func (d *Driver) withPool(fn func(*Pool) error) (err error) {
+	unlock, err := dmlegacy.Lock()
+	if err != nil {
+		return
+	}
+	defer util.DeferErr(&err, unlock)
+
+	pool, err := LoadPool(d.spec)
+	if err != nil {
+		return
+	}
+	defer util.DeferErr(&err, pool.Save)
+
+	return fn(pool)
+}
+
+// imageLayer returns the layer of the image of the VM, which is a volume the image file is copied into
+func imageLayer(pool *Pool, vm *api.VM) (device *Device, err error) {
+	imageUID, err := lookup.ImageUIDForVM(vm, providers.Client)
+	if err != nil {
+		return
+	}
+
+	imageDir := path.Join(constants.IMAGE_DIR, imageUID.String())
+	metadataPath := path.Join(imageDir, constants.METADATA)
+	if device = pool.FindDevice(func(device *api.PoolDevice) bool {
+		return device.Type == api.PoolDeviceTypeImage && device.MetadataPath == metadataPath
+	}); device != nil {
+		return
+	}
+
+	imageFile := path.Join(imageDir, constants.IMAGE_FS)
+	size, err := util.DeviceSize(imageFile)
+	if err != nil {
+		return
+	}
+
+	log.Infof("Copying image %q into the pool...", imageUID)
+	if device, err = pool.CreateVolume(api.PoolDeviceTypeImage, meta.NewSizeFromBytes(uint64(size)), metadataPath); err != nil {
+		return
+	}
+	defer deleteOnError(pool, device, &err)
+
+	if err = device.activate(); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, device.Deactivate)
+
+	err = util.CopySparse(device.Path(), imageFile)
+	return
+}
+
+// kernelLayer returns the layer of the kernel of the VM on top of the layer of its image, which is a snapshot
+// of the image layer holding the kernel modules. The layer is grown to fit the files of the kernel.
+func kernelLayer(pool *Pool, vm *api.VM) (device *Device, err error) {
+	image, err := imageLayer(pool, vm)
+	if err != nil {
+		return
+	}
+
+	kernelUID, err := lookup.KernelUIDForVM(vm, providers.Client)
+	if err != nil {
+		return
+	}
+
+	kernelDir := path.Join(constants.KERNEL_DIR, kernelUID.String())
+	metadataPath := path.Join(kernelDir, constants.METADATA)
+	imageID := image.ID()
+	if device = pool.FindDevice(func(device *api.PoolDevice) bool {
+		return device.Type == api.PoolDeviceTypeKernel && device.MetadataPath == metadataPath && device.Parent == imageID
+	}); device != nil {
+		return
+	}
+
+	size := image.Size.Add(extraSize)
+	if info, err := os.Stat(path.Join(kernelDir, constants.KERNEL_TAR)); err == nil {
+		size = size.Add(meta.NewSizeFromBytes(uint64(info.Size())))
+	}
+
+	if device, err = image.CreateSnapshot(api.PoolDeviceTypeKernel, size, metadataPath); err != nil {
+		return
+	}
+	defer deleteOnError(pool, device, &err)
+
+	if err = device.activate(); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, device.Deactivate)
+
+	if err = resize2fs(device.Path()); err != nil {
+		return
+	}
+
+	err = populate(device.Path(), func(mountPoint string) error {
+		return dmlegacy.CopyKernelFiles(vm, mountPoint)
+	})
+	return
+}
+
+// populate mounts the filesystem on the device and calls the given function with the mount point
+func populate(devicePath string, fn func(string) error) (err error) {
+	mp, err := util.Mount(devicePath)
+	if err != nil {
+		return
+	}
+	defer util.DeferErr(&err, mp.Umount)
+
+	if err = fn(mp.Path); err != nil {
+		return
+	}
+
+	// Set the root permissions of the filesystem
+	return os.Chmod(mp.Path, constants.DATA_DIR_PERM)
+}
+
+// deleteOnError deletes the device from the pool if *err is set, the device needs to be inactive by then
+func deleteOnError(pool *Pool, device *Device, err *error) {
+	if *err == nil {
+		return
+	}
+
+	if deleteErr := pool.DeleteDevice(device); deleteErr != nil {
+		log.Warnf("Failed to delete device %s: %v", device.ID(), deleteErr)
+	}
+}
+
+// diskUsage returns the usage of the disk of the VM, which is active with the given name
+func diskUsage(pool *Pool, vm *api.VM, name string) (*snapshotter.DiskUsage, error) {
+	device := findVMDevice(pool, vm)
+	if device == nil {
+		return nil, fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+	}
+
+	mapped, err := getMappedBytes(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshotter.DiskUsage{
+		Size:      device.Size.Bytes(),
+		Allocated: mapped,
+	}, nil
+}
+
+// findVMDevice returns the device of the disk of the VM, or nil if it doesn't exist
+func findVMDevice(pool *Pool, vm *api.VM) *Device {
+	metadataPath := vmMetadataPath(vm)
+	return pool.FindDevice(func(device *api.PoolDevice) bool {
+		return device.Type == api.PoolDeviceTypeVM && device.MetadataPath == metadataPath
+	})
+}
+
+func vmMetadataPath(vm *api.VM) string {
+	return path.Join(vm.ObjectPath(), constants.METADATA)
+}
+
+// GetPoolUsage returns the pool and its usage, the usage is nil if the pool isn't active.
+// The pool is nil if it hasn't been created yet.
+func GetPoolUsage() (*Pool, *PoolUsage, error) {
+	if !util.FileExists(constants.SNAPSHOTTER_POOL_FILE) {
+		return nil, nil, nil
+	}
+
+	pool, err := LoadPool(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !pool.Active() {
+		return pool, nil, nil
+	}
+
+	usage, err := pool.Usage()
+	return pool, usage, err
+}
@@ -2,18 +2,19 @@ package dm
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/apis/ignite/scheme"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
-var (
-	defaultDMPrefixer = util.NewPrefixer(constants.IGNITE_PREFIX)
-	poolName          = defaultDMPrefixer.Prefix("pool")
-)
+var poolName = util.NewPrefixer(constants.IGNITE_PREFIX).Prefix("pool")
 
 type Pool struct {
 	api.Pool
@@ -47,6 +48,57 @@ func (p *Pool) getID(device *Device) meta.DMID {
 	panic("pool getID: device not found")
 }
 
+// LoadPool loads the pool from its database at constants.SNAPSHOTTER_POOL_FILE. If the pool
+// doesn't exist yet, a new one is configured with the given spec, or the defaults if it's nil.
+func LoadPool(spec *api.PoolSpec) (*Pool, error) {
+	pool := &api.Pool{}
+
+	if util.FileExists(constants.SNAPSHOTTER_POOL_FILE) {
+		if err := scheme.Serializer.DecodeFileInto(constants.SNAPSHOTTER_POOL_FILE, pool); err != nil {
+			return nil, fmt.Errorf("failed to load the pool: %v", err)
+		}
+	} else {
+		if spec != nil {
+			pool.Spec = *spec
+		}
+
+		if err := scheme.Serializer.DefaultInternal(pool); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewPool(pool), nil
+}
+
+// Save writes the pool to its database. The file is replaced atomically, so it can be read without locking.
+func (p *Pool) Save() error {
+	b, err := scheme.Serializer.EncodeJSON(&p.Pool)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(constants.SNAPSHOTTER_DIR, constants.DATA_DIR_PERM); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(constants.SNAPSHOTTER_DIR, filepath.Base(constants.SNAPSHOTTER_POOL_FILE))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), constants.SNAPSHOTTER_POOL_FILE)
+}
+
 // GetDevice dynamically spawns a device from a api.PoolDevice
 func (p *Pool) GetDevice(id meta.DMID) *Device {
 	// If querying for the pool's ID, return nil
@@ -71,6 +123,17 @@ func (p *Pool) GetDevice(id meta.DMID) *Device {
 	}
 }
 
+// FindDevice returns the first device matching the given function, or nil if there's none
+func (p *Pool) FindDevice(matchFunc func(*api.PoolDevice) bool) *Device {
+	for i, spec := range p.Status.Devices {
+		if spec != nil && matchFunc(spec) {
+			return p.GetDevice(meta.NewDMID(i))
+		}
+	}
+
+	return nil
+}
+
 // This is a custom iterator to iterate over existing devices only (it skips nil slots)
 func (p *Pool) ForDevices(iterFunc func(meta.DMID, *Device) error) error {
 	for i := 0; i < len(p.Status.Devices); i++ {
@@ -87,6 +150,12 @@ func (p *Pool) ForDevices(iterFunc func(meta.DMID, *Device) error) error {
 }
 
 func (p *Pool) allocate() error {
+	for _, file := range []string{p.Spec.MetadataPath, p.Spec.DataPath} {
+		if err := os.MkdirAll(path.Dir(file), constants.DATA_DIR_PERM); err != nil {
+			return err
+		}
+	}
+
 	// Allocate the backing files (if not allocated already)
 	if err := allocateBackingFile(p.Spec.MetadataPath, p.Spec.MetadataSize); err != nil {
 		return fmt.Errorf("failed to allocate metadata backing file: %v", err)
@@ -110,16 +179,19 @@ func (p *Pool) activate() error {
 		return err
 	}
 
-	// Activate the backing devices
+	// Activate the backing devices. Loop devices are detached once the pool is set up,
+	// they're removed automatically when the pool releases them.
 	metadataDev, err := activateBackingDevice(p.Spec.MetadataPath, false)
 	if err != nil {
 		return err
 	}
+	defer detachBackingDevice(metadataDev)
 
 	dataDev, err := activateBackingDevice(p.Spec.DataPath, false)
 	if err != nil {
 		return err
 	}
+	defer detachBackingDevice(dataDev)
 
 	dmTable := fmt.Sprintf("0 %d thin-pool %s %s %d 0",
 		p.Spec.DataSize.Sectors(),
@@ -128,11 +200,22 @@ func (p *Pool) activate() error {
 		p.Spec.AllocationSize.Sectors(),
 	)
 
-	if err := dmsetup("create", poolName, "--table", dmTable); err != nil {
-		return err
+	return dmsetup("create", "--verifyudev", poolName, "--table", dmTable)
+}
+
+// Active returns true if the pool is set up with device mapper
+func (p *Pool) Active() bool {
+	return p.active()
+}
+
+// Usage returns the usage of the data and metadata of the active pool
+func (p *Pool) Usage() (*PoolUsage, error) {
+	out, err := util.ExecuteCommand("dmsetup", "status", poolName)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return parsePoolUsage(out, p.Spec.AllocationSize.Bytes())
 }
 
 func (p *Pool) Path() string {
@@ -203,13 +286,34 @@ func (p *Pool) newDevice(genFunc func(meta.DMID) (*Device, error)) (*Device, err
 	device, err := genFunc(id)
 	if err != nil {
 		p.free = free
-	} else {
-		p.Status.Devices[id.Index()] = device.PoolDevice
+		return nil, err
 	}
 
+	p.Status.Devices[id.Index()] = device.PoolDevice
 	return device, nil
 }
 
+// message sends the given message to the pool, the pool needs to be active for this
+func (p *Pool) message(format string, args ...interface{}) error {
+	return dmsetup("message", p.Path(), "0", fmt.Sprintf(format, args...))
+}
+
+// DeleteDevice deletes the inactive device from the pool, releasing its blocks. Blocks shared with
+// snapshots of the device or its parent aren't released, snapshots stay valid after deleting them.
+func (p *Pool) DeleteDevice(device *Device) error {
+	if err := p.activate(); err != nil {
+		return err
+	}
+
+	id := p.getID(device)
+	if err := p.message("delete %s", id); err != nil {
+		return err
+	}
+
+	p.Remove(id)
+	return nil
+}
+
 func (p *Pool) Remove(id meta.DMID) {
 	if p.GetDevice(id) != nil {
 		p.Status.Devices[id.Index()] = nil
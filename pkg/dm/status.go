@@ -0,0 +1,114 @@
+package dm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+const (
+	// sectorSize is the size of the sectors device mapper reports sizes in
+	sectorSize = 512
+	// metadataBlockSize is the size of the blocks of the metadata of thin pools
+	metadataBlockSize = 4096
+)
+
+// PoolUsage describes the usage of the data and metadata of a thin pool
+type PoolUsage struct {
+	// UsedData and TotalData are the allocated and total bytes of the data of the pool
+	UsedData  uint64
+	TotalData uint64
+	// UsedMetadata and TotalMetadata are the allocated and total bytes of the metadata of the pool
+	UsedMetadata  uint64
+	TotalMetadata uint64
+	// Mode is "rw", or "ro" or "out_of_data_space" if the pool can't be written to. It's "Fail" if the pool failed.
+	Mode string
+}
+
+// DataPercentage returns the percentage of the data of the pool in use
+func (u *PoolUsage) DataPercentage() float64 {
+	return percentage(u.UsedData, u.TotalData)
+}
+
+// MetadataPercentage returns the percentage of the metadata of the pool in use
+func (u *PoolUsage) MetadataPercentage() float64 {
+	return percentage(u.UsedMetadata, u.TotalMetadata)
+}
+
+func percentage(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(used) / float64(total) * 100
+}
+
+// parsePoolUsage parses the output of "dmsetup status" for a thin pool, e.g.
+// "0 209715200 thin-pool 0 280/524288 16/1638400 - rw discard_passdown queue_if_no_space - 1024",
+// where the metadata is given in blocks of 4 KiB and the data in blocks of the given size
+func parsePoolUsage(out string, dataBlockSize uint64) (*PoolUsage, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 4 || fields[2] != "thin-pool" {
+		return nil, fmt.Errorf("unexpected pool status %q", out)
+	}
+
+	// The status is replaced with "Fail" if the pool failed
+	if len(fields) < 8 {
+		return &PoolUsage{Mode: fields[3]}, nil
+	}
+
+	usage := &PoolUsage{Mode: fields[7]}
+	for _, f := range []struct {
+		field       string
+		blockSize   uint64
+		used, total *uint64
+	}{
+		{fields[4], metadataBlockSize, &usage.UsedMetadata, &usage.TotalMetadata},
+		{fields[5], dataBlockSize, &usage.UsedData, &usage.TotalData},
+	} {
+		values := strings.Split(f.field, "/")
+		if len(values) != 2 {
+			return nil, fmt.Errorf("unexpected pool status %q", out)
+		}
+
+		for i, ptr := range []*uint64{f.used, f.total} {
+			blocks, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected pool status %q: %v", out, err)
+			}
+
+			*ptr = blocks * f.blockSize
+		}
+	}
+
+	return usage, nil
+}
+
+// getMappedBytes returns the number of bytes of the given active thin device mapped to blocks of the pool
+func getMappedBytes(name string) (uint64, error) {
+	out, err := util.ExecuteCommand("dmsetup", "status", name)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseMappedBytes(out)
+}
+
+// parseMappedBytes parses the output of "dmsetup status" for a thin device, e.g.
+// "0 8388608 thin 20480 8388607", where the number of mapped and the highest mapped sector are given
+func parseMappedBytes(out string) (uint64, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 4 || fields[2] != "thin" {
+		return 0, fmt.Errorf("unexpected thin device status %q", out)
+	}
+
+	// The status is replaced with "Fail" if the device failed
+	sectors, err := strconv.ParseUint(fields[3], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected thin device status %q: %v", out, err)
+	}
+
+	return sectors * sectorSize, nil
+}
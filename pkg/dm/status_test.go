@@ -0,0 +1,98 @@
+package dm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePoolUsage(t *testing.T) {
+	cases := []struct {
+		name, out string
+		expected  *PoolUsage
+		err       bool
+	}{
+		{
+			name: "active",
+			out:  "0 209715200 thin-pool 3 280/524288 16/1638400 - rw discard_passdown queue_if_no_space - 1024\n",
+			expected: &PoolUsage{
+				UsedData:      16 * 65536,
+				TotalData:     1638400 * 65536,
+				UsedMetadata:  280 * 4096,
+				TotalMetadata: 524288 * 4096,
+				Mode:          "rw",
+			},
+		},
+		{
+			name:     "out of space",
+			out:      "0 209715200 thin-pool 3 280/524288 1638400/1638400 - out_of_data_space discard_passdown queue_if_no_space - 1024",
+			expected: &PoolUsage{UsedData: 1638400 * 65536, TotalData: 1638400 * 65536, UsedMetadata: 280 * 4096, TotalMetadata: 524288 * 4096, Mode: "out_of_data_space"},
+		},
+		{
+			name:     "failed",
+			out:      "0 209715200 thin-pool Fail",
+			expected: &PoolUsage{Mode: "Fail"},
+		},
+		{
+			name: "thin device",
+			out:  "0 8388608 thin 20480 8388607",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := parsePoolUsage(c.out, 65536)
+		if (err != nil) != c.err {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, actual)
+		}
+	}
+
+	if p := (&PoolUsage{UsedData: 1, TotalData: 4}).DataPercentage(); p != 25 {
+		t.Errorf("expected a data percentage of 25, got %v", p)
+	}
+}
+
+func TestParseMappedBytes(t *testing.T) {
+	cases := []struct {
+		name, out string
+		expected  uint64
+		err       bool
+	}{
+		{
+			name:     "active",
+			out:      "0 8388608 thin 20480 8388607\n",
+			expected: 20480 * 512,
+		},
+		{
+			name:     "empty",
+			out:      "0 8388608 thin 0 -",
+			expected: 0,
+		},
+		{
+			name: "failed",
+			out:  "0 8388608 thin Fail",
+			err:  true,
+		},
+		{
+			name: "snapshot",
+			out:  "0 8388608 snapshot 1024/8388608 16",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := parseMappedBytes(c.out)
+		if (err != nil) != c.err {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+
+		if actual != c.expected {
+			t.Errorf("%s: expected %d, got %d", c.name, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package dmlegacy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+const (
+	// snapshotDiscardFeature makes discards of the snapshot zero the chunks of the overlay holding
+	// the discarded blocks. They aren't passed down to the image, which is shared between VMs.
+	snapshotDiscardFeature = "discard_zeroes_cow"
+	// punchBlockSize is the granularity zeroes are punched out of overlays with, it's the chunk size of the snapshots
+	punchBlockSize = 8 * sectorSize
+	// punchBufferSize is the amount of the overlay read at once when looking for zeroes
+	punchBufferSize = 1 << 20
+)
+
+// snapshotDiscardVersion is the first version of the snapshot target supporting discards, it's part of Linux 5.4
+var snapshotDiscardVersion = [3]int{1, 16, 0}
+
+// snapshotFeatureArgs returns the optional feature arguments of the snapshot target, which
+// enable discards on kernels supporting them. The arguments are empty on older kernels.
+func snapshotFeatureArgs() string {
+	out, err := util.ExecuteCommand("dmsetup", "targets")
+	if err != nil {
+		log.Debugf("Failed to list the device mapper targets, discards on snapshots are disabled: %v", err)
+		return ""
+	}
+
+	if !versionAtLeast(parseTargetVersion(out, "snapshot"), snapshotDiscardVersion) {
+		return ""
+	}
+
+	return fmt.Sprintf(" 1 %s", snapshotDiscardFeature)
+}
+
+// parseTargetVersion returns the version of the given target in the output of "dmsetup targets",
+// which lists a target per line with its version, e.g. "snapshot v1.16.0". It's zero if not found.
+func parseTargetVersion(out, target string) (version [3]int) {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != target {
+			continue
+		}
+
+		for i, part := range strings.SplitN(strings.TrimPrefix(fields[1], "v"), ".", len(version)) {
+			if n, err := strconv.Atoi(part); err == nil {
+				version[i] = n
+			}
+		}
+		break
+	}
+
+	return
+}
+
+// versionAtLeast returns true if version is equal to or newer than min
+func versionAtLeast(version, min [3]int) bool {
+	for i := range version {
+		if version[i] != min[i] {
+			return version[i] > min[i]
+		}
+	}
+
+	return true
+}
+
+// CompactOverlay releases the blocks freed inside the filesystem of a stopped VM from its sparse overlay. The
+// filesystem is trimmed through the snapshot, which zeroes the chunks of the freed blocks in the overlay, and
+// all zeroed chunks are punched out of the overlay. Without discards on snapshots, only the chunks that are
+// zeroed already are punched out.
+func CompactOverlay(vm *api.VM) error {
+	if snapshotFeatureArgs() == "" {
+		log.Warnf("The kernel doesn't support discards on snapshots, only the zeroed blocks of the overlay of %s %q can be released", vm.GetKind(), vm.GetUID())
+	} else if err := trimSnapshot(vm); err != nil {
+		return fmt.Errorf("failed to trim the filesystem of %s %q: %v", vm.GetKind(), vm.GetUID(), err)
+	}
+
+	return PunchZeroes(vm.OverlayFile())
+}
+
+// trimSnapshot discards the unused blocks of the filesystem of the VM through its snapshot
+func trimSnapshot(vm *api.VM) (err error) {
+	if _, err = ActivateSnapshot(vm); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, func() error { return DeactivateSnapshot(vm) })
+
+	mp, err := util.Mount(vm.SnapshotDev())
+	if err != nil {
+		return
+	}
+	defer util.DeferErr(&err, mp.Umount)
+
+	_, err = util.ExecuteCommand("fstrim", mp.Path)
+	return
+}
+
+// PunchZeroes deallocates the blocks of the given file that only contain zeroes, which
+// turns them into holes. The contents of the file and its apparent size don't change.
+func PunchZeroes(filename string) error {
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	fd := int(f.Fd())
+	zeroes := make([]byte, punchBlockSize)
+	buf := make([]byte, punchBufferSize)
+
+	for offset := int64(0); offset < info.Size(); {
+		// Skip over the holes of the file, filesystems without support for it report everything as data
+		data, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err == unix.ENXIO {
+			break
+		} else if err != nil {
+			return err
+		}
+		data -= data % punchBlockSize
+
+		n, err := f.ReadAt(buf, data)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+
+		// Punch out runs of zeroed blocks at once
+		start := int64(-1)
+		for i := 0; i < n; i += punchBlockSize {
+			end := i + punchBlockSize
+			if end > n {
+				end = n
+			}
+
+			zeroed := bytes.Equal(buf[i:end], zeroes[:end-i])
+			if zeroed && start < 0 {
+				start = data + int64(i)
+			} else if !zeroed && start >= 0 {
+				if err := punchHole(fd, start, data+int64(i)-start); err != nil {
+					return err
+				}
+				start = -1
+			}
+		}
+
+		if start >= 0 {
+			if err := punchHole(fd, start, data+int64(n)-start); err != nil {
+				return err
+			}
+		}
+
+		offset = data + int64(n)
+	}
+
+	return nil
+}
+
+// punchHole deallocates the given range of the file, keeping its size
+func punchHole(fd int, offset, length int64) error {
+	if err := unix.Fallocate(fd, unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, length); err != nil {
+		return fmt.Errorf("failed to punch a hole of %d bytes at offset %d: %v", length, offset, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package dmlegacy
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+func TestParseTargetVersion(t *testing.T) {
+	out := "snapshot-merge   v1.4.0\nsnapshot         v1.16.0\nzero             v1.1.0\nlinear           v1.4.0\n"
+
+	cases := []struct {
+		target   string
+		expected [3]int
+		discard  bool
+	}{
+		{target: "snapshot", expected: [3]int{1, 16, 0}, discard: true},
+		{target: "zero", expected: [3]int{1, 1, 0}},
+		{target: "thin", expected: [3]int{}},
+	}
+
+	for _, c := range cases {
+		actual := parseTargetVersion(out, c.target)
+		if actual != c.expected {
+			t.Errorf("%s: expected version %v, got %v", c.target, c.expected, actual)
+		}
+
+		if discard := versionAtLeast(actual, snapshotDiscardVersion); discard != c.discard {
+			t.Errorf("%s: expected discard support %t, got %t", c.target, c.discard, discard)
+		}
+	}
+
+	if versionAtLeast([3]int{1, 15, 9}, snapshotDiscardVersion) || !versionAtLeast([3]int{2, 0, 0}, snapshotDiscardVersion) {
+		t.Errorf("unexpected version comparison")
+	}
+}
+
+func TestPunchZeroes(t *testing.T) {
+	f, err := ioutil.TempFile("", "ignite-overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	// A block of data, 64 allocated blocks of zeroes, a partial block of data and trailing zeroes
+	data := bytes.Repeat([]byte{1}, punchBlockSize)
+	contents := append(append(append(data, make([]byte, 64*punchBlockSize)...), data[:100]...), make([]byte, 1000)...)
+	if _, err := f.Write(contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, before, err := util.FileSize(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PunchZeroes(f.Name()); err != nil {
+		if strings.Contains(err.Error(), "operation not supported") {
+			t.Skipf("punching holes isn't supported by the filesystem of %q", f.Name())
+		}
+		t.Fatal(err)
+	}
+
+	actual, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, contents) {
+		t.Errorf("the contents of the file changed")
+	}
+
+	size, after, err := util.FileSize(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != uint64(len(contents)) {
+		t.Errorf("expected size %d, got %d", len(contents), size)
+	}
+	if after >= before {
+		t.Errorf("expected less than %d allocated bytes, got %d", before, after)
+	}
+}
@@ -0,0 +1,237 @@
+package dmlegacy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nightlyone/lockfile"
+	"github.com/weaveworks/ignite/pkg/util"
+	"golang.org/x/sys/unix"
+)
+
+// sysBlockDir lists the block devices of the host
+const sysBlockDir = "/sys/block"
+
+// sectorSize is the size of the sectors device mapper reports sizes in
+const sectorSize = 512
+
+// LoopDevice describes an attached loop device
+type LoopDevice struct {
+	// Path is the path of the device, e.g. /dev/loop0
+	Path string
+	// BackingFile is the file the device is attached to
+	BackingFile string
+	// Held is true if the device is in use by another device, e.g. a snapshot
+	Held bool
+}
+
+// SnapshotStatus describes the usage of the exception store of a snapshot, which is the overlay of a VM
+type SnapshotStatus struct {
+	// Allocated is the number of bytes of the exception store holding changed chunks
+	Allocated uint64
+	// Total is the size of the exception store in bytes
+	Total uint64
+	// Metadata is the number of bytes of the allocated space holding metadata
+	Metadata uint64
+	// Invalid is true if the snapshot overflowed or failed, it can't be used anymore
+	Invalid bool
+}
+
+// FillPercentage returns the percentage of the exception store that is allocated
+func (s *SnapshotStatus) FillPercentage() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+
+	return float64(s.Allocated) / float64(s.Total) * 100
+}
+
+// ListDevices returns the names of the device mapper devices
+func ListDevices() ([]string, error) {
+	out, err := util.ExecuteCommand("dmsetup", "ls")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeviceList(out), nil
+}
+
+// parseDeviceList parses the output of "dmsetup ls", a device per line with its name and
+// device numbers, which are formatted as "(253:0)" or "(253, 0)" depending on the version
+func parseDeviceList(out string) []string {
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		// dmsetup prints "No devices found" without any devices
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "(") {
+			continue
+		}
+
+		names = append(names, fields[0])
+	}
+
+	return names
+}
+
+// GetSnapshotStatus returns the status of the given snapshot device
+func GetSnapshotStatus(name string) (*SnapshotStatus, error) {
+	out, err := util.ExecuteCommand("dmsetup", "status", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSnapshotStatus(out)
+}
+
+// parseSnapshotStatus parses the output of "dmsetup status" for a snapshot, e.g.
+// "0 8388608 snapshot 1024/8388608 16", where the usage is given in sectors
+func parseSnapshotStatus(out string) (*SnapshotStatus, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 4 || fields[2] != "snapshot" {
+		return nil, fmt.Errorf("unexpected snapshot status %q", out)
+	}
+
+	// The usage is replaced with "Invalid", "Overflow" or "Merge failed" if the snapshot can't be used
+	usage := strings.Split(fields[3], "/")
+	if len(usage) != 2 {
+		return &SnapshotStatus{Invalid: true}, nil
+	}
+
+	var values [3]uint64
+	for i, field := range append(usage, fields[4:]...) {
+		if i >= len(values) {
+			break
+		}
+
+		sectors, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected snapshot status %q: %v", out, err)
+		}
+
+		values[i] = sectors * sectorSize
+	}
+
+	return &SnapshotStatus{
+		Allocated: values[0],
+		Total:     values[1],
+		Metadata:  values[2],
+	}, nil
+}
+
+// RemoveDevices removes the given device mapper devices, in the given order. Snapshots
+// need to be removed before the devices backing them. Devices that are gone are skipped.
+func RemoveDevices(names ...string) (err error) {
+	// Serialize the interaction with device mapper with the activation of snapshots
+	unlock, err := Lock()
+	if err != nil {
+		return
+	}
+	defer util.DeferErr(&err, unlock)
+
+	return RemoveDevicesLocked(names...)
+}
+
+// RemoveDevicesLocked removes the given devices like RemoveDevices, for callers holding the lock obtained with Lock
+func RemoveDevicesLocked(names ...string) error {
+	for _, name := range names {
+		if _, err := util.ExecuteCommand("dmsetup", "remove", "--verifyudev", name); err != nil {
+			if !strings.Contains(err.Error(), dmsetupNotFound) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ListLoopDevices returns the attached loop devices
+func ListLoopDevices() ([]*LoopDevice, error) {
+	return listLoopDevices(sysBlockDir)
+}
+
+func listLoopDevices(sysDir string) ([]*LoopDevice, error) {
+	entries, err := ioutil.ReadDir(sysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*LoopDevice
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "loop") {
+			continue
+		}
+
+		// Only attached devices have a backing file
+		backingFile, err := ioutil.ReadFile(filepath.Join(sysDir, entry.Name(), "loop", "backing_file"))
+		if err != nil {
+			continue
+		}
+
+		holders, err := ioutil.ReadDir(filepath.Join(sysDir, entry.Name(), "holders"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		devices = append(devices, &LoopDevice{
+			Path:        filepath.Join("/dev", entry.Name()),
+			BackingFile: strings.TrimSpace(string(backingFile)),
+			Held:        len(holders) > 0,
+		})
+	}
+
+	return devices, nil
+}
+
+// DetachLoopDevice detaches the loop device from its backing file
+func DetachLoopDevice(path string) error {
+	f, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.IoctlSetInt(int(f.Fd()), unix.LOOP_CLR_FD, 0); err != nil {
+		return fmt.Errorf("failed to detach loop device %q: %v", path, err)
+	}
+
+	return nil
+}
+
+// DetachLeakedLoopDevices detaches the loop devices of the files in the given directory that aren't used by any
+// device, and returns their paths. They're left behind if setting up a snapshot fails. The devices are listed and
+// detached while holding the lock of the activation of snapshots, so the devices of new snapshots aren't detached.
+func DetachLeakedLoopDevices(dir string, dryRun bool) (detached []string, err error) {
+	lock, err := lockfile.New(filepath.Join(os.TempDir(), snapshotLockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lockfile: %w", err)
+	}
+	if err = obtainLock(lock); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, lock.Unlock)
+
+	devices, err := ListLoopDevices()
+	if err != nil {
+		return
+	}
+
+	for _, device := range devices {
+		if device.Held || !strings.HasPrefix(device.BackingFile, dir+"/") {
+			continue
+		}
+
+		if !dryRun {
+			if err = DetachLoopDevice(device.Path); err != nil {
+				return
+			}
+		}
+
+		detached = append(detached, device.Path)
+	}
+
+	return
+}
@@ -0,0 +1,119 @@
+package dmlegacy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDeviceList(t *testing.T) {
+	cases := []struct {
+		name, out string
+		expected  []string
+	}{
+		{
+			name:     "no devices",
+			out:      "No devices found",
+			expected: nil,
+		},
+		{
+			name:     "colon separated",
+			out:      "ignite-9a10b07d7c0d4ce9\t(253:1)\nignite-9a10b07d7c0d4ce9-base\t(253:0)",
+			expected: []string{"ignite-9a10b07d7c0d4ce9", "ignite-9a10b07d7c0d4ce9-base"},
+		},
+		{
+			name:     "comma separated",
+			out:      "ubuntu--vg-root\t(253, 0)\nignite-1c0a4d8e5b3f2a71\t(253, 2)",
+			expected: []string{"ubuntu--vg-root", "ignite-1c0a4d8e5b3f2a71"},
+		},
+	}
+
+	for _, c := range cases {
+		if actual := parseDeviceList(c.out); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestListLoopDevices(t *testing.T) {
+	sysDir, err := ioutil.TempDir("", "ignite-sys-block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sysDir)
+
+	// loop0 backs a snapshot, loop1 is leaked, loop2 isn't attached and sda isn't a loop device
+	for _, dir := range []string{"loop0/loop", "loop0/holders/dm-1", "loop1/loop", "loop1/holders", "loop2/holders", "sda/holders"} {
+		if err := os.MkdirAll(filepath.Join(sysDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for dir, file := range map[string]string{
+		"loop0": "/var/lib/firecracker/image/4f7a0b2e9c1d3e5f/image.ext4",
+		"loop1": "/var/lib/firecracker/vm/9a10b07d7c0d4ce9/overlay.dm (deleted)",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(sysDir, dir, "loop", "backing_file"), []byte(file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devices, err := listLoopDevices(sysDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*LoopDevice{
+		{Path: "/dev/loop0", BackingFile: "/var/lib/firecracker/image/4f7a0b2e9c1d3e5f/image.ext4", Held: true},
+		{Path: "/dev/loop1", BackingFile: "/var/lib/firecracker/vm/9a10b07d7c0d4ce9/overlay.dm (deleted)"},
+	}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Errorf("expected %v, got %v", expected, devices)
+	}
+}
+
+func TestParseSnapshotStatus(t *testing.T) {
+	cases := []struct {
+		name, out string
+		expected  *SnapshotStatus
+		err       bool
+	}{
+		{
+			name:     "active",
+			out:      "0 8388608 snapshot 1024/8388608 16\n",
+			expected: &SnapshotStatus{Allocated: 1024 * 512, Total: 8388608 * 512, Metadata: 16 * 512},
+		},
+		{
+			name:     "without metadata",
+			out:      "0 8388608 snapshot 2048/8388608",
+			expected: &SnapshotStatus{Allocated: 2048 * 512, Total: 8388608 * 512},
+		},
+		{
+			name:     "overflowed",
+			out:      "0 8388608 snapshot Overflow",
+			expected: &SnapshotStatus{Invalid: true},
+		},
+		{
+			name: "linear device",
+			out:  "0 8388608 linear",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := parseSnapshotStatus(c.out)
+		if (err != nil) != c.err {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, actual)
+		}
+	}
+
+	if p := (&SnapshotStatus{Allocated: 1, Total: 4}).FillPercentage(); p != 25 {
+		t.Errorf("expected a fill percentage of 25, got %v", p)
+	}
+}
@@ -0,0 +1,107 @@
+package dmlegacy
+
+import (
+	"fmt"
+	"os"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// Driver is the legacy storage driver. The disk of every VM is a sparse overlay file,
+// which is set up as a device mapper snapshot on top of the image file when the VM starts.
+type Driver struct{}
+
+var _ snapshotter.Driver = &Driver{}
+
+// NewDriver returns the legacy storage driver
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+func (*Driver) Name() api.StorageDriver {
+	return api.StorageDriverLegacy
+}
+
+func (*Driver) HasDisk(vm *api.VM) bool {
+	return util.FileExists(vm.OverlayFile())
+}
+
+func (*Driver) CreateDisk(vm *api.VM) error {
+	return AllocateAndPopulateOverlay(vm)
+}
+
+// ImportDisk isn't supported, overlays only store the changes to the image
+func (*Driver) ImportDisk(vm *api.VM, _ string) error {
+	return fmt.Errorf("the %s storage driver can't import the disk of %s %q", api.StorageDriverLegacy, vm.GetKind(), vm.GetUID())
+}
+
+func (*Driver) ActivateDisk(vm *api.VM) (string, error) {
+	return ActivateSnapshot(vm)
+}
+
+func (*Driver) DeactivateDisk(vm *api.VM) error {
+	return DeactivateSnapshot(vm)
+}
+
+func (*Driver) RemoveDisk(vm *api.VM) error {
+	if err := DeactivateSnapshot(vm); err != nil {
+		return err
+	}
+
+	if err := os.Remove(vm.OverlayFile()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (*Driver) ResizeDisk(vm *api.VM, size meta.Size) error {
+	return ResizeOverlay(vm, size)
+}
+
+func (*Driver) CompactDisk(vm *api.VM) error {
+	return CompactOverlay(vm)
+}
+
+// DiskUsage returns the size of the overlay of the VM and the space it takes on disk.
+// The fill percentage of the overlay is reported by the snapshot while it's active.
+func (*Driver) DiskUsage(vm *api.VM) (*snapshotter.DiskUsage, error) {
+	size, allocated, err := util.FileSize(vm.OverlayFile())
+	if err != nil {
+		return nil, err
+	}
+
+	u := &snapshotter.DiskUsage{
+		Size:      size,
+		Allocated: allocated,
+	}
+
+	if util.FileExists(vm.SnapshotDev()) {
+		status, err := GetSnapshotStatus(vm.PrefixedID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the status of the snapshot of %s %q: %v", vm.GetKind(), vm.GetUID(), err)
+		}
+
+		if status.Invalid {
+			u.Invalid = true
+		} else {
+			fill := status.FillPercentage()
+			u.FillPercentage = &fill
+		}
+	}
+
+	return u, nil
+}
+
+// RemoveImage is a no-op, the image file is removed together with the directory of the image
+func (*Driver) RemoveImage(*api.Image) error {
+	return nil
+}
+
+// RemoveKernel is a no-op, the kernel files are removed together with the directory of the kernel
+func (*Driver) RemoveKernel(*api.Kernel) error {
+	return nil
+}
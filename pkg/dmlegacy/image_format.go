@@ -6,93 +6,165 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
-	"strings"
-	"unicode"
+	"syscall"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/debugfs"
 	"github.com/weaveworks/ignite/pkg/source"
 	"github.com/weaveworks/ignite/pkg/util"
 )
 
 const (
-	blockSize       = 4096   // Block size to use for the ext4 filesystems, this is the default
-	minimumBaseSize = 500000 // Mimimum size of the base image, ~ half a megabyte.
+	blockSize     = 4096 // Block size to use for the ext4 filesystems, this is the default
+	inodeSize     = 256  // Inode size to use for the ext4 filesystems, this is the default
+	minimumBlocks = 4096 // Minimum size of the filesystem of images in blocks, 16 MiB
+	mkfsAttempts  = 3    // How often mkfs.ext4 is tried with a doubled size if the estimate was too small
 )
 
-// CreateImageFilesystem creates an ext4 filesystem in a file, containing the files from the source
+// CreateImageFilesystem creates an ext4 filesystem in a file, containing the files from the source.
+// The files are extracted into a directory, which mkfs.ext4 copies into a filesystem sized to fit them.
+// The owners, modes and device nodes of the files are applied to the filesystem with debugfs afterwards.
+// Nothing is mounted and nothing is extracted as root, so creating images needs no privileges.
 func CreateImageFilesystem(img *api.Image, src source.Source) error {
-	log.Debugf("Allocating image file and formatting it with ext4...")
-	p := path.Join(img.ObjectPath(), constants.IMAGE_FS)
-	imageFile, err := os.Create(p)
+	// Extract next to the image file, the temporary directory of the host may be a small tmpfs
+	tempDir, err := ioutil.TempDir(img.ObjectPath(), "rootfs-")
 	if err != nil {
-		return errors.Wrapf(err, "failed to create image file for %s", img.GetUID())
+		return err
 	}
-	defer imageFile.Close()
+	defer os.RemoveAll(tempDir)
 
-	// To accommodate space for the tar file contents and the ext4 journal + other metadata,
-	// make the base image a sparse file three times the size of the source contents. This
-	// will be shrunk to fit by resizeToMinimum later.
-	var baseImageSize int64
-	threeTimesImageSize := img.Status.OCISource.Size.Bytes() * 3
-	// If the base image is too small, filesystem creation using mkfs fails with
-	// not enough space error. Ensure the base image size is at least the
-	// minimum base size.
-	if threeTimesImageSize < minimumBaseSize {
-		baseImageSize = int64(minimumBaseSize)
-	} else {
-		baseImageSize = int64(threeTimesImageSize)
+	log.Debugf("Extracting the files of the image from a source...")
+	tree, err := source.ExtractTree(src, tempDir)
+	if err != nil {
+		return err
 	}
 
-	if err := imageFile.Truncate(baseImageSize); err != nil {
-		return errors.Wrapf(err, "failed to allocate space for image %s", img.GetUID())
+	if err := setupResolvConf(tempDir); err != nil {
+		return err
 	}
 
-	// Use mkfs.ext4 to create the new image with an inode size of 256
-	// (gexto doesn't support anything but 128, but as long as we're not using that it's fine)
-	if _, err := util.ExecuteCommand("mkfs.ext4", "-b", strconv.Itoa(blockSize),
-		"-I", "256", "-F", "-E", "lazy_itable_init=0,lazy_journal_init=0", p); err != nil {
-		return errors.Wrapf(err, "failed to format image %s", img.GetUID())
+	usage, err := measureTree(tempDir)
+	if err != nil {
+		return err
 	}
+	// Device nodes and FIFOs are only created in the filesystem
+	for _, inode := range tree.Inodes {
+		if inode.Special() {
+			usage.inodes++
+		}
+	}
+	blocks, inodes, journal := usage.filesystemSize()
 
-	// Proceed with populating the image with files
-	if err := addFiles(img, src); err != nil {
-		return err
+	p := path.Join(img.ObjectPath(), constants.IMAGE_FS)
+	for attempt := 1; ; attempt++ {
+		log.Debugf("Creating an ext4 filesystem of %d blocks with %d inodes from the files...", blocks, inodes)
+		if err = mkfs(p, tempDir, blocks, inodes, journal); err == nil || attempt == mkfsAttempts {
+			break
+		}
+
+		// The size is an estimate, retry with more space in case the files didn't fit
+		log.Debugf("Failed to create the filesystem, retrying with a doubled size: %v", err)
+		blocks, inodes = 2*blocks, 2*inodes
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to format image %s", img.GetUID())
 	}
 
-	// Resize the image to its minimum size
-	return resizeToMinimum(img)
+	log.Debugf("Applying the owners, modes and device nodes of the files...")
+	return errors.Wrapf(applyInodes(p, tempDir, tree), "failed to format image %s", img.GetUID())
 }
 
-// addFiles copies the contents of the tar file into the ext4 filesystem
-func addFiles(img *api.Image, src source.Source) (err error) {
-	log.Debugf("Copying in files to the image file from a source...")
-	p := path.Join(img.ObjectPath(), constants.IMAGE_FS)
-	tempDir, err := ioutil.TempDir("", "")
+// applyInodes sets the owners and modes in the filesystem in the file p to those of the extracted tree and
+// creates its device nodes and FIFOs. mkfs.ext4 copied the files as they were extracted, owned by the current
+// user. Files added after extracting, like /etc/resolv.conf, are owned by root.
+func applyInodes(p, dir string, tree *source.Tree) error {
+	var commands []string
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if name == "." {
+			name = ""
+		}
+
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return fmt.Errorf("failed to stat %q", file)
+		}
+
+		inode, ok := tree.Inodes[name]
+		if !ok {
+			inode = &source.Inode{Mode: st.Mode}
+		}
+
+		// The root directory is always set, mkfs.ext4 doesn't copy it
+		if len(name) > 0 && inode.Mode == st.Mode && inode.UID == st.Uid && inode.GID == st.Gid {
+			return nil
+		}
+
+		cmds, err := debugfs.SetInode("/"+name, inode.Mode, inode.UID, inode.GID)
+		commands = append(commands, cmds...)
+		return err
+	})
 	if err != nil {
-		return
+		return err
 	}
-	defer os.RemoveAll(tempDir)
 
-	if _, err := util.ExecuteCommand("mount", "-o", "loop", p, tempDir); err != nil {
-		return fmt.Errorf("failed to mount image %q: %v", p, err)
+	var special []string
+	for name, inode := range tree.Inodes {
+		if inode.Special() {
+			special = append(special, name)
+		}
+	}
+	sort.Strings(special)
+
+	for _, name := range special {
+		inode := tree.Inodes[name]
+		cmds, err := debugfs.Mknod("/"+name, inode.Mode, inode.UID, inode.GID, inode.Major, inode.Minor)
+		if err != nil {
+			return err
+		}
+		commands = append(commands, cmds...)
 	}
-	defer util.DeferErr(&err, func() error {
-		_, execErr := util.ExecuteCommand("umount", tempDir)
-		return execErr
-	})
 
-	err = source.TarExtract(src, tempDir)
-	if err != nil {
-		return
+	return debugfs.NewImage(p).Edit(commands...)
+}
+
+// mkfs creates an ext4 filesystem of the given size in blocks in the file p, populated with the files in dir
+func mkfs(p, dir string, blocks, inodes, journal uint64) error {
+	// mkfs.ext4 only grows existing files, start with an empty file
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	err = setupResolvConf(tempDir)
+	if err := ioutil.WriteFile(p, nil, constants.DATA_DIR_FILE_PERM); err != nil {
+		return err
+	}
 
-	return
+	// The file is sparse, only the blocks written by mkfs.ext4 take space
+	if err := os.Truncate(p, int64(blocks*blockSize)); err != nil {
+		return err
+	}
+
+	// Use mkfs.ext4 to create the new image with an inode size of 256
+	// (gexto doesn't support anything but 128, but as long as we're not using that it's fine)
+	_, err := util.ExecuteCommand("mkfs.ext4", "-b", strconv.Itoa(blockSize),
+		"-I", strconv.Itoa(inodeSize), "-N", strconv.FormatUint(inodes, 10),
+		"-J", fmt.Sprintf("size=%d", journal*blockSize/constants.MB),
+		"-F", "-E", "lazy_itable_init=0,lazy_journal_init=0", "-d", dir, p)
+	return err
 }
 
 // setupResolvConf makes sure there is a resolv.conf file, otherwise
@@ -119,75 +191,88 @@ func setupResolvConf(tempDir string) error {
 	return os.Symlink("../proc/net/pnp", resolvConf)
 }
 
-// resizeToMinimum resizes the given image to the smallest size possible
-func resizeToMinimum(img *api.Image) (err error) {
-	p := path.Join(img.ObjectPath(), constants.IMAGE_FS)
-	var minSize int64
-	var imageFile *os.File
+// treeUsage counts the blocks and inodes the files of a directory tree take in an ext4 filesystem
+type treeUsage struct {
+	blocks uint64
+	inodes uint64
+}
 
-	if minSize, err = getMinSize(p); err != nil {
-		return
-	}
+// measureTree walks the directory tree at dir and counts the blocks and inodes its files need.
+// The data of hard linked files is only counted once.
+func measureTree(dir string) (*treeUsage, error) {
+	u := &treeUsage{}
+	var dirEntryBytes uint64
+	links := make(map[uint64]bool)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Every entry takes a record of 8 bytes plus its name padded to 4 bytes in its directory
+		dirEntryBytes += 8 + (uint64(len(info.Name()))+3)&^3
+
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Nlink > 1 && !info.IsDir() {
+			if links[st.Ino] {
+				return nil
+			}
+			links[st.Ino] = true
+		}
+
+		u.inodes++
+		switch mode := info.Mode(); {
+		case mode.IsRegular():
+			u.blocks += blocksFor(uint64(info.Size()))
+		case mode.IsDir():
+			u.blocks++
+		case mode&os.ModeSymlink != 0 && info.Size() >= 60:
+			// Targets shorter than 60 bytes are stored in the inode
+			u.blocks++
+		}
 
-	if imageFile, err = os.OpenFile(p, os.O_RDWR, constants.DATA_DIR_FILE_PERM); err != nil {
-		return
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	defer util.DeferErr(&err, imageFile.Close)
 
-	minSizeBytes := minSize * blockSize
+	u.blocks += blocksFor(dirEntryBytes)
+	return u, nil
+}
 
-	log.Debugf("Truncating %q to %d bytes", p, minSizeBytes)
-	if err = imageFile.Truncate(minSizeBytes); err != nil {
-		err = fmt.Errorf("failed to shrink image %q: %v", img.GetUID(), err)
+// filesystemSize estimates the size, the number of inodes and the size of the journal of an ext4 filesystem holding
+// the files, in blocks. It leaves room for the files added to the disks of VMs, which are grown when VMs are created.
+func (u *treeUsage) filesystemSize() (blocks, inodes, journal uint64) {
+	// The first 11 inodes are reserved, lost+found and the files of VMs need some more
+	inodes = u.inodes + u.inodes/8 + 256
+
+	// Leave room for extent tree blocks, the inode tables and the
+	// bitmaps, group descriptors and blocks reserved for resizing
+	blocks = u.blocks + u.blocks/32 + blocksFor(inodes*inodeSize)
+	blocks += blocks/64 + 64
+	journal = journalBlocks(blocks)
+	blocks += journal
+
+	if blocks < minimumBlocks {
+		blocks = minimumBlocks
 	}
 
 	return
 }
 
-// getMinSize retrieves the minimum size for a block device file
-// containing a filesystem and shrinks the filesystem to that size
-func getMinSize(p string) (minSize int64, err error) {
-	// Loop mount the image for resize2fs
-	imageLoop, err := newLoopDev(p, false)
-	if err != nil {
-		return
-	}
-
-	// Defer the detach
-	defer util.DeferErr(&err, imageLoop.Detach)
-
-	// Call e2fsck for resize2fs, it sometimes requires this
-	// e2fsck throws an error if the filesystem gets repaired, so just ignore it
-	_, _ = util.ExecuteCommand("e2fsck", "-p", "-f", imageLoop.Path())
-
-	// Retrieve the minimum size for the filesystem
-	log.Debugf("Retrieving minimum size for %q", imageLoop.Path())
-	out, err := util.ExecuteCommand("resize2fs", "-P", imageLoop.Path())
-	if err != nil {
-		return
+// journalBlocks returns the size of the journal in blocks for a filesystem of the given
+// size, it scales with the size of the filesystem like the default size of mkfs.ext4
+func journalBlocks(blocks uint64) uint64 {
+	switch {
+	case blocks < 256*1024:
+		return 1024
+	case blocks < 4096*1024:
+		return 4096
+	default:
+		return 16384
 	}
-
-	if minSize, err = parseResize2fsOutputForMinSize(out); err != nil {
-		return
-	}
-
-	log.Debugf("Minimum size: %d blocks", minSize)
-
-	// Perform the filesystem resize
-	_, err = util.ExecuteCommand("resize2fs", imageLoop.Path(), strconv.FormatInt(minSize, 10))
-	return
 }
 
-// parseResize2fsOutputForMinSize extracts the trailing number from `resize2fs -P`
-func parseResize2fsOutputForMinSize(out string) (int64, error) {
-	// LANG=en_US.utf8
-	//   resize2fs 1.45.3 (14-Jul-2019)
-	//   Estimated minimum size of the filesystem: 5813528
-	// LANG=zh_CN.utf8  https://github.com/tytso/e2fsprogs/blob/v1.45.4/po/zh_CN.po#L7240-L7241
-	//   resize2fs 1.44.1 (24-Mar-2018)
-	//   预计文件系统的最小尺寸：61817
-	split := strings.FieldsFunc(out, func(r rune) bool {
-		return unicode.IsPunct(r) || unicode.IsSpace(r)
-	})
-	return strconv.ParseInt(split[len(split)-1], 10, 64)
+func blocksFor(bytes uint64) uint64 {
+	return (bytes + blockSize - 1) / blockSize
 }
@@ -0,0 +1,31 @@
+package dmlegacy
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// ResizeOverlay grows the overlay of a stopped VM to the given size, together with the filesystem inside
+// it. The base device extending the image with zeroes and the snapshot are sized after the overlay when the
+// snapshot is activated, which resizes the filesystem to fill the snapshot. The chunks in the overlay stay
+// valid, as they're addressed by their offset in the base device, which is only extended.
+func ResizeOverlay(vm *api.VM, size meta.Size) (err error) {
+	// Truncate only accepts an int64
+	if size.Bytes() > math.MaxInt64 {
+		return fmt.Errorf("requested size %d too large, cannot truncate", size.Bytes())
+	}
+
+	if err = os.Truncate(vm.OverlayFile(), int64(size.Bytes())); err != nil {
+		return fmt.Errorf("failed to resize overlay file for VM %q: %v", vm.GetUID(), err)
+	}
+
+	if _, err = ActivateSnapshot(vm); err != nil {
+		return
+	}
+
+	return DeactivateSnapshot(vm)
+}
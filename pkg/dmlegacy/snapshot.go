@@ -108,8 +108,9 @@ func ActivateSnapshot(vm *api.VM) (devicePath string, err error) {
 		basePath = fmt.Sprintf("/dev/mapper/%s", baseDevice)
 	}
 
-	// "0 8388608 snapshot /dev/{loop0,mapper/ignite-<uid>-base} /dev/loop1 P 8"
-	dmTable := []byte(fmt.Sprintf("0 %d snapshot %s %s P 8", overlayLoopSize, basePath, overlayLoop.Path()))
+	// "0 8388608 snapshot /dev/{loop0,mapper/ignite-<uid>-base} /dev/loop1 P 8 1 discard_zeroes_cow"
+	// Discards are enabled if the kernel supports them, so the freed blocks can be released from the overlay
+	dmTable := []byte(fmt.Sprintf("0 %d snapshot %s %s P 8%s", overlayLoopSize, basePath, overlayLoop.Path(), snapshotFeatureArgs()))
 
 	// setup the main boot device
 	if err = runDMSetup(device, dmTable); err != nil {
@@ -138,6 +139,20 @@ func ActivateSnapshot(vm *api.VM) (devicePath string, err error) {
 	return
 }
 
+// Lock obtains the global lock serializing the setup of loop devices and the interactions with
+// device mapper, and returns the function releasing it. The lock isn't reentrant.
+func Lock() (func() error, error) {
+	lock, err := lockfile.New(filepath.Join(os.TempDir(), snapshotLockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lockfile: %w", err)
+	}
+	if err := obtainLock(lock); err != nil {
+		return nil, err
+	}
+
+	return lock.Unlock, nil
+}
+
 // obtainLock tries to obtain a lock and retries if the lock is owned by
 // another process, until a lock is obtained.
 func obtainLock(lock lockfile.Lockfile) error {
@@ -21,13 +21,18 @@ import (
 
 const (
 	hostsFileTmpl = `127.0.0.1	localhost
-%s	%s
-# The following lines are desirable for IPv6 capable hosts
+%s# The following lines are desirable for IPv6 capable hosts
 ::1     ip6-localhost ip6-loopback
 fe00::0 ip6-localnet
 ff00::0 ip6-mcastprefix
 ff02::1 ip6-allnodes
 ff02::2 ip6-allrouters
+`
+	linkFileTmpl = `[Match]
+MACAddress=%s
+
+[Link]
+Name=%s
 `
 	vmAuthorizedKeys = "/root/.ssh/authorized_keys"
 )
@@ -96,10 +101,16 @@ func copyToOverlay(vm *api.VM) (err error) {
 	defer util.DeferErr(&err, mp.Umount)
 
 	// Copy the kernel files to the VM. TODO: Use snapshot overlaying instead.
-	if err = copyKernelToOverlay(vm, mp.Path); err != nil {
+	if err = CopyKernelFiles(vm, mp.Path); err != nil {
 		return
 	}
 
+	return PopulateFilesystem(vm, mp.Path)
+}
+
+// PopulateFilesystem copies the files, SSH key and volume mounts of the VM into
+// its mounted filesystem, and writes the hostname, hosts and network link files
+func PopulateFilesystem(vm *api.VM, mountPoint string) (err error) {
 	// do not mutate vm.Spec.CopyFiles
 	fileMappings := vm.Spec.CopyFiles
 
@@ -123,7 +134,7 @@ func copyToOverlay(vm *api.VM) (err error) {
 
 	// TODO: File/directory permissions?
 	for _, mapping := range fileMappings {
-		vmFilePath := path.Join(mp.Path, mapping.VMPath)
+		vmFilePath := path.Join(mountPoint, mapping.VMPath)
 		if err = os.MkdirAll(path.Dir(vmFilePath), constants.DATA_DIR_PERM); err != nil {
 			return
 		}
@@ -133,33 +144,41 @@ func copyToOverlay(vm *api.VM) (err error) {
 		}
 	}
 
-	ip := net.IP{127, 0, 0, 1}
+	ips := []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
 	if len(vm.Status.Network.IPAddresses) > 0 {
-		ip = vm.Status.Network.IPAddresses[0]
+		ips = vm.Status.Network.IPAddresses
+	} else if staticIP := vm.StaticIP(); staticIP != nil {
+		ips = []net.IP{staticIP}
 	}
 
 	// Write /etc/hosts for the VM
-	if err = writeEtcHosts(mp.Path, vm.GetUID().String(), ip); err != nil {
+	if err = writeEtcHosts(mountPoint, vm.GetUID().String(), ips); err != nil {
 		return
 	}
 
 	// Write the UID to /etc/hostname for the VM
-	if err = writeEtcHostname(mp.Path, vm.GetUID().String()); err != nil {
+	if err = writeEtcHostname(mountPoint, vm.GetUID().String()); err != nil {
+		return
+	}
+
+	// Rename the interfaces with a guest name in the VM
+	if err = writeLinkFiles(vm, mountPoint); err != nil {
 		return
 	}
 
 	// Populate /etc/fstab with the VM's volume mounts
-	if err = populateFstab(vm, mp.Path); err != nil {
+	if err = populateFstab(vm, mountPoint); err != nil {
 		return
 	}
 
 	// Set overlay root permissions
-	err = os.Chmod(mp.Path, constants.DATA_DIR_PERM)
+	err = os.Chmod(mountPoint, constants.DATA_DIR_PERM)
 
 	return
 }
 
-func copyKernelToOverlay(vm *api.VM, mountPoint string) error {
+// CopyKernelFiles extracts the kernel.tar of the kernel of the VM, holding the kernel modules, into the mounted filesystem
+func CopyKernelFiles(vm *api.VM, mountPoint string) error {
 	kernelUID, err := lookup.KernelUIDForVM(vm, providers.Client)
 	if err != nil {
 		return err
@@ -180,7 +199,8 @@ func copyKernelToOverlay(vm *api.VM, mountPoint string) error {
 
 // writeEtcHosts populates the /etc/hosts file to avoid errors like
 // sudo: unable to resolve host 4462576f8bf5b689
-func writeEtcHosts(tmpDir, hostname string, primaryIP net.IP) error {
+// The hostname resolves to all given IPv4 and IPv6 addresses.
+func writeEtcHosts(tmpDir, hostname string, ips []net.IP) error {
 	hostFilePath := filepath.Join(tmpDir, "/etc/hosts")
 	empty, err := util.FileIsEmpty(hostFilePath)
 	if err != nil {
@@ -191,7 +211,12 @@ func writeEtcHosts(tmpDir, hostname string, primaryIP net.IP) error {
 		return nil
 	}
 
-	content := []byte(fmt.Sprintf(hostsFileTmpl, primaryIP.String(), hostname))
+	var hosts string
+	for _, ip := range ips {
+		hosts += fmt.Sprintf("%s\t%s\n", ip.String(), hostname)
+	}
+
+	content := []byte(fmt.Sprintf(hostsFileTmpl, hosts))
 	return ioutil.WriteFile(hostFilePath, content, 0644)
 }
 
@@ -200,6 +225,29 @@ func writeEtcHostname(tmpDir, hostname string) error {
 	return ioutil.WriteFile(hostnameFilePath, []byte(hostname), 0644)
 }
 
+// writeLinkFiles writes a systemd .link file for every interface with a guest name,
+// udev matches the interface by its MAC address and renames it on boot
+func writeLinkFiles(vm *api.VM, tmpDir string) error {
+	for _, intf := range vm.Spec.Network.Interfaces {
+		if len(intf.GuestName) == 0 {
+			continue
+		}
+
+		linkDir := filepath.Join(tmpDir, "/etc/systemd/network")
+		if err := os.MkdirAll(linkDir, 0755); err != nil {
+			return err
+		}
+
+		linkFilePath := filepath.Join(linkDir, fmt.Sprintf("10-ignite-%s.link", intf.Name))
+		content := []byte(fmt.Sprintf(linkFileTmpl, vm.MACAddress(intf.Name), intf.GuestName))
+		if err := ioutil.WriteFile(linkFilePath, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Generate a new SSH keypair for the vm
 func newSSHKeypair(vm *api.VM) (string, error) {
 	privKeyPath := path.Join(vm.ObjectPath(), fmt.Sprintf(constants.VM_SSH_KEY_TEMPLATE, vm.GetUID()))
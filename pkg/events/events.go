@@ -0,0 +1,206 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/libgitops/pkg/runtime"
+	"golang.org/x/sys/unix"
+)
+
+// Type describes what happened to an object
+type Type string
+
+const (
+	// TypeCreate is recorded when a VM has been created
+	TypeCreate Type = "create"
+	// TypeStart is recorded when the sandbox container of a VM has been started
+	TypeStart Type = "start"
+	// TypeReady is recorded by ignite-spawn when Firecracker has booted the VM
+	TypeReady Type = "ready"
+	// TypeStop is recorded when a VM is requested to stop or is killed
+	TypeStop Type = "stop"
+	// TypeDie is recorded by ignite-spawn when the Firecracker process has exited, or by
+	// ignite and ignited when the container of a VM marked running has exited or is gone
+	TypeDie Type = "die"
+	// TypeRemove is recorded when the resources of a VM have been removed
+	TypeRemove Type = "remove"
+	// TypeImport is recorded when an image or kernel has been imported
+	TypeImport Type = "import"
+)
+
+// logFilePerm makes the event log readable for non-root users
+const logFilePerm = 0644
+
+// LogPath is the path to the event log. ignite-spawn overrides
+// this with the path the log is bind-mounted to in the sandbox.
+var LogPath = constants.EVENTS_FILE
+
+// Event describes a lifecycle event of an Ignite object
+type Event struct {
+	Time    time.Time   `json:"time"`
+	Type    Type        `json:"type"`
+	Kind    string      `json:"kind"`
+	UID     runtime.UID `json:"uid"`
+	Name    string      `json:"name"`
+	Message string      `json:"message,omitempty"`
+}
+
+// String returns a human readable, single line description of the Event
+func (e *Event) String() string {
+	s := fmt.Sprintf("%s %s %s %s (name=%s)", e.Time.Format(time.RFC3339Nano), e.Kind, e.Type, e.UID, e.Name)
+	if len(e.Message) > 0 {
+		s = fmt.Sprintf("%s: %s", s, e.Message)
+	}
+
+	return s
+}
+
+// Record appends an event of the given type for the object to the event log. The
+// event log is informational, a failure to write it doesn't fail the operation.
+func Record(obj runtime.Object, t Type, message string) {
+	e := &Event{
+		Time:    time.Now().UTC(),
+		Type:    t,
+		Kind:    obj.GetKind().Lower(),
+		UID:     obj.GetUID(),
+		Name:    obj.GetName(),
+		Message: message,
+	}
+
+	if err := write(LogPath, e); err != nil {
+		log.Warnf("Failed to record %s event for %s %q: %v", t, obj.GetKind(), obj.GetUID(), err)
+	}
+}
+
+// Recorded returns true if an event of the given type has been recorded for the object since the given time
+func Recorded(obj runtime.Object, t Type, since time.Time) (bool, error) {
+	f, err := os.Open(LogPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	r := &Reader{f: f, r: bufio.NewReader(f)}
+	defer r.Close()
+
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		if e.Type == t && e.UID == obj.GetUID() && e.Kind == obj.GetKind().Lower() && !e.Time.Before(since) {
+			return true, nil
+		}
+	}
+}
+
+// EnsureLog creates the event log if it doesn't exist yet, so it can be
+// bind-mounted into the sandbox container of a VM
+func EnsureLog() error {
+	f, err := os.OpenFile(LogPath, os.O_CREATE|os.O_WRONLY, logFilePerm)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func write(logPath string, e *Event) (err error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logFilePerm)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// ignite, ignited and ignite-spawn may write concurrently, lock the log to not interleave
+	// lines. The lock is released when the file is closed.
+	if err = unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return
+	}
+
+	_, err = f.Write(append(b, '\n'))
+	return
+}
+
+// Reader reads events from the event log
+type Reader struct {
+	f       *os.File
+	r       *bufio.Reader
+	partial []byte
+}
+
+// NewReader opens the event log for reading from the beginning.
+// If the log doesn't exist yet, it is created.
+func NewReader() (*Reader, error) {
+	f, err := os.Open(LogPath)
+	if os.IsNotExist(err) {
+		if err = EnsureLog(); err == nil {
+			f, err = os.Open(LogPath)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{f: f, r: bufio.NewReader(f)}, nil
+}
+
+// Next returns the next event in the log. If no complete event has
+// been written yet, io.EOF is returned and Next can be called again
+// later to continue reading when more events have been written.
+func (r *Reader) Next() (*Event, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err == io.EOF {
+			// Keep the incomplete line until the rest of it has been written
+			r.partial = append(r.partial, line...)
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(r.partial) > 0 {
+			line = append(r.partial, line...)
+			r.partial = nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		e := &Event{}
+		if err := json.Unmarshal(line, e); err != nil {
+			log.Debugf("Skipping malformed event %q: %v", line, err)
+			continue
+		}
+
+		return e, nil
+	}
+}
+
+// Close closes the event log
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
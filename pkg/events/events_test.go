@@ -0,0 +1,101 @@
+package events
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/libgitops/pkg/runtime"
+	"gotest.tools/assert"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	LogPath = path.Join(t.TempDir(), "events.log")
+
+	vm := &api.VM{
+		ObjectMeta: runtime.ObjectMeta{
+			Name: "my-vm",
+			UID:  "20e1d566ce318ada",
+		},
+	}
+	vm.SetGroupVersionKind(api.SchemeGroupVersion.WithKind(api.KindVM.Title()))
+
+	r, err := NewReader()
+	assert.NilError(t, err)
+	defer r.Close()
+
+	_, err = r.Next()
+	assert.Equal(t, err, io.EOF)
+
+	Record(vm, TypeCreate, "")
+	Record(vm, TypeStart, "")
+
+	e, err := r.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, e.Type, TypeCreate)
+	assert.Equal(t, e.Kind, "vm")
+	assert.Equal(t, e.UID, runtime.UID("20e1d566ce318ada"))
+	assert.Equal(t, e.Name, "my-vm")
+
+	e, err = r.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, e.Type, TypeStart)
+
+	// A partially written event is returned once it has been completed
+	f, err := os.OpenFile(LogPath, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(`{"time":"2000-01-01T00:00:00Z","type":"die",`)
+	assert.NilError(t, err)
+	_, err = r.Next()
+	assert.Equal(t, err, io.EOF)
+
+	_, err = f.WriteString(`"kind":"vm","uid":"20e1d566ce318ada","name":"my-vm","message":"exited"}` + "\n")
+	assert.NilError(t, err)
+	e, err = r.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, e.Type, TypeDie)
+	assert.Equal(t, e.Message, "exited")
+}
+
+func TestRecorded(t *testing.T) {
+	LogPath = path.Join(t.TempDir(), "events.log")
+
+	vm := &api.VM{
+		ObjectMeta: runtime.ObjectMeta{
+			Name: "my-vm",
+			UID:  "20e1d566ce318ada",
+		},
+	}
+	vm.SetGroupVersionKind(api.SchemeGroupVersion.WithKind(api.KindVM.Title()))
+
+	// A missing log has no events
+	recorded, err := Recorded(vm, TypeDie, time.Time{})
+	assert.NilError(t, err)
+	assert.Assert(t, !recorded)
+
+	since := time.Now()
+	Record(vm, TypeStart, "")
+
+	other := vm.DeepCopy()
+	other.SetUID("bfc80c948b1e2419")
+	Record(other, TypeDie, "")
+
+	recorded, err = Recorded(vm, TypeDie, since)
+	assert.NilError(t, err)
+	assert.Assert(t, !recorded)
+
+	Record(vm, TypeDie, "")
+	recorded, err = Recorded(vm, TypeDie, since)
+	assert.NilError(t, err)
+	assert.Assert(t, recorded)
+
+	// Events before the given time don't count
+	recorded, err = Recorded(vm, TypeDie, time.Now().Add(time.Second))
+	assert.NilError(t, err)
+	assert.Assert(t, !recorded)
+}
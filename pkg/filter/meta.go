@@ -6,8 +6,6 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
-
-	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 )
 
 const (
@@ -21,15 +19,15 @@ type metaFilter struct {
 	operator      string
 }
 
-func (mf metaFilter) isExpected(object *api.VM) (bool, error) {
+func (mf metaFilter) isExpected(object interface{}) (bool, error) {
 	w := &bytes.Buffer{}
-	tm, err := template.New("generic-filtering-vm").Parse(mf.identifier)
+	tm, err := template.New("generic-filtering").Parse(mf.identifier)
 	if err != nil {
 		return false, fmt.Errorf("failed to configure filtering with following template: %s", mf.identifier)
 	}
 	err = tm.Execute(w, object)
 	if err != nil {
-		return false, fmt.Errorf("failed to apply filtering on VM, the filter might be incorrect")
+		return false, fmt.Errorf("failed to apply filtering on object, the filter might be incorrect")
 	}
 	res := w.String()
 	switch mf.operator {
@@ -61,8 +59,9 @@ type MultipleMetaFilter struct {
 	filters []metaFilter
 }
 
-// AreExpected checks fileting rules are expected, an AND logical condition is applid between the underlying filters
-func (mmf *MultipleMetaFilter) AreExpected(object *api.VM) (bool, error) {
+// AreExpected checks fileting rules are expected, an AND logical condition is applid between the underlying filters.
+// The object can be any type the filter templates can be executed on, e.g. an *api.VM or an *events.Event
+func (mmf *MultipleMetaFilter) AreExpected(object interface{}) (bool, error) {
 	for _, mf := range mmf.filters {
 		res, err := mf.isExpected(object)
 		if err != nil {
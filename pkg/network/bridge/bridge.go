@@ -0,0 +1,422 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+
+	"github.com/coreos/go-iptables/iptables"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/runtime"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// defaultBridgeName is the name of the bridge device created on the host
+	defaultBridgeName = "ignitebr0"
+	// defaultSubnet is the IPv4 subnet of the bridge network. It's different from the one of ignite's default CNI
+	// network, so both can be used on the same host without routing conflicts. See the CNI network plugin for the
+	// considerations behind the choice of the range.
+	defaultSubnet = "10.62.0.0/16"
+	// defaultSubnet6 is the IPv6 subnet of the bridge network, next to the one of ignite's default CNI network
+	defaultSubnet6 = "fd69:676e:6974:6501::/64"
+	// IsolatedBridgeName is the name of the bridge device of the isolated network
+	IsolatedBridgeName = "igniteiso0"
+	// isolatedSubnet is the IPv4 subnet of the isolated network, the isolated network has no IPv6 subnet
+	isolatedSubnet = "10.63.0.0/16"
+	// containerInterface is the name of the interface of the bridge network in the container
+	containerInterface = constants.IGNITE_SPAWN_MAIN_INTERFACE
+)
+
+// bridgeNetwork describes a network of VMs connected to a bridge on the host
+type bridgeNetwork struct {
+	bridgeName string
+	subnets    []string
+	// isolated networks only connect the VMs with each other and the host. Their traffic isn't masqueraded
+	// or forwarded, the VMs get no default route and no ports are forwarded to them.
+	isolated bool
+}
+
+type bridgeNetworkPlugin struct {
+	name    network.PluginName
+	network *bridgeNetwork
+	runtime runtime.Interface
+	ipam    *ipam
+}
+
+// GetBridgeNetworkPlugin returns the network plugin managing ignite's own bridge network. It sets up the
+// bridge, the addresses, masquerading and port forwards itself using netlink and iptables, so neither
+// CNI plugins nor Docker networking are needed.
+func GetBridgeNetworkPlugin(r runtime.Interface) network.Plugin {
+	return &bridgeNetworkPlugin{
+		name: network.PluginBridge,
+		network: &bridgeNetwork{
+			bridgeName: defaultBridgeName,
+			subnets:    []string{defaultSubnet, defaultSubnet6},
+		},
+		runtime: r,
+		ipam:    newIPAM(constants.BRIDGE_IPAM_FILE),
+	}
+}
+
+// GetIsolatedNetworkPlugin returns the network plugin managing the isolated bridge network.
+// VMs on it can reach each other and the host, but nothing else.
+func GetIsolatedNetworkPlugin(r runtime.Interface) network.Plugin {
+	return &bridgeNetworkPlugin{
+		name: network.PluginIsolated,
+		network: &bridgeNetwork{
+			bridgeName: IsolatedBridgeName,
+			subnets:    []string{isolatedSubnet},
+			isolated:   true,
+		},
+		runtime: r,
+		ipam:    newIPAM(constants.ISOLATED_IPAM_FILE),
+	}
+}
+
+// DefaultSubnets returns the subnets of the bridge and isolated networks
+func DefaultSubnets() []string {
+	return []string{defaultSubnet, defaultSubnet6, isolatedSubnet}
+}
+
+func (plugin *bridgeNetworkPlugin) Name() network.PluginName {
+	return plugin.name
+}
+
+func (*bridgeNetworkPlugin) PrepareContainerSpec(container *runtime.ContainerConfig) error {
+	// No need for the container runtime to set up networking, as this plugin will do it
+	container.NetworkMode = "none"
+	return nil
+}
+
+func (plugin *bridgeNetworkPlugin) SetupContainerNetwork(containerID string, ips []net.IP, _ []network.Attachment, portMappings ...meta.PortMapping) (*network.Result, error) {
+	c, err := plugin.runtime.InspectContainer(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %q: %v", containerID, err)
+	}
+
+	if plugin.network.isolated && len(portMappings) > 0 {
+		return nil, fmt.Errorf("ports can't be forwarded to VMs on the %q network", plugin.name)
+	}
+
+	bridge, subnets, err := plugin.network.ensureBridge()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up bridge %q: %v", plugin.network.bridgeName, err)
+	}
+
+	var addrs []net.IP
+	if err := plugin.ipam.update(func(state *ipamState) (err error) {
+		if addrs, err = state.allocate(containerID, subnets, ips); err == nil {
+			state.Allocations[containerID].Ports = portMappings
+		}
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	result, err := plugin.network.setupContainer(containerID, int(c.PID), bridge, subnets, addrs, portMappings)
+	if err != nil {
+		// Don't leak the addresses and rules of a container that failed to start
+		if cleanupErr := plugin.RemoveContainerNetwork(containerID, nil); cleanupErr != nil {
+			log.Warnf("Failed to clean up the network of container %q: %v", containerID, cleanupErr)
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (plugin *bridgeNetworkPlugin) RemoveContainerNetwork(containerID string, _ []network.Attachment, _ ...meta.PortMapping) error {
+	var a *allocation
+	if err := plugin.ipam.update(func(state *ipamState) error {
+		a = state.release(containerID)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// The host end of the veth pair is removed together with the network namespace of the container,
+	// but the container may not have exited yet
+	if link, err := netlink.LinkByName(vethName(containerID)); err == nil {
+		if err := netlink.LinkDel(link); err != nil {
+			log.Debugf("Failed to remove veth %q: %v", link.Attrs().Name, err)
+		}
+	}
+
+	if a == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, ip := range a.IPs {
+		ipt, err := iptablesFor(ip)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		errs = append(errs, deleteRules(ipt, portRules(containerID, ip, a.Ports))...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove the port forwards of container %q: %v", containerID, errs)
+	}
+
+	return nil
+}
+
+// ensureBridge creates the bridge and its gateway addresses and rules if needed, and returns the
+// subnets of the network. The IPv6 subnet is skipped if the host doesn't support IPv6.
+func (n *bridgeNetwork) ensureBridge() (netlink.Link, []*net.IPNet, error) {
+	bridge, err := netlink.LinkByName(n.bridgeName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); !ok {
+			return nil, nil, err
+		}
+
+		la := netlink.NewLinkAttrs()
+		la.Name = n.bridgeName
+		if err := netlink.LinkAdd(&netlink.Bridge{LinkAttrs: la}); err != nil && !os.IsExist(err) {
+			return nil, nil, err
+		}
+
+		if bridge, err = netlink.LinkByName(n.bridgeName); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := netlink.LinkSetUp(bridge); err != nil {
+		return nil, nil, err
+	}
+
+	var subnets []*net.IPNet
+	for _, s := range n.subnets {
+		_, subnet, _ := net.ParseCIDR(s)
+		if err := n.ensureGateway(bridge, subnet); err != nil {
+			if subnet.IP.To4() != nil {
+				return nil, nil, err
+			}
+
+			log.Debugf("Skipping the IPv6 subnet of the bridge network: %v", err)
+			continue
+		}
+
+		subnets = append(subnets, subnet)
+	}
+
+	if n.isolated {
+		if err := n.ensureIsolation(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return bridge, subnets, nil
+}
+
+// ensureIsolation drops the traffic forwarded from and to the isolated bridge for both IP families. The VMs
+// may configure IPv6 addresses and routes themselves, even if the network has no IPv6 subnet, so the IPv6
+// rules are only skipped if the host has IPv6 disabled.
+func (n *bridgeNetwork) ensureIsolation() error {
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		if protocol == iptables.ProtocolIPv6 && !network.IPv6Enabled() {
+			continue
+		}
+
+		ipt, err := iptables.NewWithProtocol(protocol)
+		if err == nil {
+			if err = ensureChains(ipt); err == nil {
+				err = insertRules(ipt, isolatedRules(n.bridgeName))
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to isolate bridge %q: %v", n.bridgeName, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureGateway assigns the gateway address of the subnet to the bridge, and
+// enables forwarding and the rules for the traffic of the subnet
+func (n *bridgeNetwork) ensureGateway(bridge netlink.Link, subnet *net.IPNet) error {
+	ipt, err := iptablesFor(subnet.IP)
+	if err != nil {
+		return err
+	}
+
+	gw := &netlink.Addr{IPNet: &net.IPNet{IP: gateway(subnet), Mask: subnet.Mask}}
+	if gw.IP.To4() == nil {
+		gw.Flags = unix.IFA_F_NODAD
+	}
+
+	if err := netlink.AddrReplace(bridge, gw); err != nil {
+		return err
+	}
+
+	if err := ensureChains(ipt); err != nil {
+		return err
+	}
+
+	// The isolated network doesn't forward any traffic, ensureIsolation drops it
+	if n.isolated {
+		return nil
+	}
+
+	if gw.IP.To4() != nil {
+		err = writeSysctls(map[string]string{
+			"net/ipv4/ip_forward": "1",
+			// Allow port forwards from localhost to be routed to the bridge
+			fmt.Sprintf("net/ipv4/conf/%s/route_localnet", n.bridgeName): "1",
+		})
+	} else {
+		err = writeSysctls(map[string]string{"net/ipv6/conf/all/forwarding": "1"})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return addRules(ipt, networkRules(n.bridgeName, subnet))
+}
+
+// setupContainer connects the container to the bridge using a veth pair, and forwards its ports
+func (n *bridgeNetwork) setupContainer(containerID string, pid int, bridge netlink.Link, subnets []*net.IPNet, addrs []net.IP, portMappings meta.PortMappings) (*network.Result, error) {
+	hostName := vethName(containerID)
+	// The peer is renamed in the container, its temporary name needs to be unique on the host
+	peerName := hostName + "c"
+
+	la := netlink.NewLinkAttrs()
+	la.Name = hostName
+	la.MasterIndex = bridge.Attrs().Index
+	veth := &netlink.Veth{LinkAttrs: la, PeerName: peerName}
+	if err := netlink.LinkAdd(veth); err != nil {
+		return nil, fmt.Errorf("failed to create veth %q: %v", hostName, err)
+	}
+
+	if err := netlink.LinkSetUp(veth); err != nil {
+		return nil, err
+	}
+
+	peer, err := netlink.LinkByName(peerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := netlink.LinkSetNsPid(peer, pid); err != nil {
+		return nil, fmt.Errorf("failed to move veth %q into container %q: %v", peerName, containerID, err)
+	}
+
+	ns, err := netns.GetFromPid(pid)
+	if err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Delete()
+
+	if err := n.configureContainerInterface(handle, peerName, subnets, addrs); err != nil {
+		return nil, fmt.Errorf("failed to configure the network of container %q: %v", containerID, err)
+	}
+
+	result := &network.Result{}
+	for i, ip := range addrs {
+		address := network.Address{IP: ip}
+		if !n.isolated {
+			address.Gateway = gateway(subnets[i])
+		}
+		result.Addresses = append(result.Addresses, address)
+
+		ipt, err := iptablesFor(ip)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addRules(ipt, portRules(containerID, ip, portMappings)); err != nil {
+			return nil, fmt.Errorf("failed to forward the ports of container %q: %v", containerID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// configureContainerInterface renames the veth in the container, assigns the addresses and adds the default routes
+func (n *bridgeNetwork) configureContainerInterface(handle *netlink.Handle, peerName string, subnets []*net.IPNet, addrs []net.IP) error {
+	link, err := handle.LinkByName(peerName)
+	if err != nil {
+		return err
+	}
+
+	if err := handle.LinkSetName(link, containerInterface); err != nil {
+		return err
+	}
+
+	for i, ip := range addrs {
+		addr := &netlink.Addr{IPNet: &net.IPNet{IP: ip, Mask: subnets[i].Mask}}
+		if ip.To4() == nil {
+			// ignite-spawn moves the address to the VM right away, it can't wait for duplicate address detection
+			addr.Flags = unix.IFA_F_NODAD
+		}
+
+		if err := handle.AddrAdd(link, addr); err != nil {
+			return err
+		}
+	}
+
+	if err := handle.LinkSetUp(link); err != nil {
+		return err
+	}
+
+	if lo, err := handle.LinkByName("lo"); err == nil {
+		if err := handle.LinkSetUp(lo); err != nil {
+			return err
+		}
+	}
+
+	// Without a default route, ignite-spawn doesn't give the VM one either
+	if n.isolated {
+		return nil
+	}
+
+	for i := range addrs {
+		route := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Gw:        gateway(subnets[i]),
+		}
+
+		if err := handle.RouteAdd(route); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// vethName returns the name of the host end of the container's veth pair
+func vethName(containerID string) string {
+	sum := sha256.Sum256([]byte(containerID))
+	return fmt.Sprintf("veth%x", sum[:4])
+}
+
+// writeSysctls sets the given sysctls, keyed by their path in /proc/sys
+func writeSysctls(sysctls map[string]string) error {
+	for key, value := range sysctls {
+		if err := ioutil.WriteFile("/proc/sys/"+key, []byte(value), 0644); err != nil {
+			return fmt.Errorf("failed to set sysctl %q: %v", key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"golang.org/x/sys/unix"
+)
+
+// maxScan limits how many addresses of a subnet are tried when looking for a free one
+const maxScan = 1 << 16
+
+// ipamState is the persisted state of the bridge network, it survives restarts of ignite and the host
+type ipamState struct {
+	// Allocations maps the IDs of the containers to the addresses and port forwards given to them
+	Allocations map[string]*allocation `json:"allocations"`
+}
+
+// allocation describes the addresses and port forwards of a container
+type allocation struct {
+	IPs   []net.IP          `json:"ips"`
+	Ports meta.PortMappings `json:"ports,omitempty"`
+}
+
+// ipam hands out the addresses of the bridge network, recording them in a file.
+// The file is locked while it's updated, as multiple ignite processes may start VMs at once.
+type ipam struct {
+	path string
+}
+
+func newIPAM(path string) *ipam {
+	return &ipam{path: path}
+}
+
+// update locks the state file and passes the state to the given function, the state is saved if it returns no error
+func (i *ipam) update(fn func(state *ipamState) error) error {
+	if err := os.MkdirAll(filepath.Dir(i.path), constants.DATA_DIR_PERM); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(i.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %q: %v", i.path, err)
+	}
+	defer unix.Flock(int(f.Fd()), unix.LOCK_UN)
+
+	state := &ipamState{}
+	if err := json.NewDecoder(f).Decode(state); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read %q: %v", i.path, err)
+	}
+
+	if state.Allocations == nil {
+		state.Allocations = make(map[string]*allocation)
+	}
+
+	if err := fn(state); err != nil {
+		return err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(f).Encode(state)
+}
+
+// allocate gives the container an address from every subnet. Static IPs are handed out if they're part of a
+// subnet and unused, otherwise the first free address is taken. The first address of a subnet is the gateway.
+// A stored allocation is returned if it still matches the subnets, which change when the host loses or gains
+// IPv6 support, otherwise it's replaced.
+func (s *ipamState) allocate(containerID string, subnets []*net.IPNet, static []net.IP) ([]net.IP, error) {
+	if a, ok := s.Allocations[containerID]; ok {
+		if a.matches(subnets, static) {
+			return a.IPs, nil
+		}
+
+		s.release(containerID)
+	}
+
+	used := map[string]bool{}
+	for _, a := range s.Allocations {
+		for _, ip := range a.IPs {
+			used[ip.String()] = true
+		}
+	}
+
+	for _, ip := range static {
+		if !containedIn(ip, subnets) {
+			return nil, fmt.Errorf("static IP %s is not part of the subnets %v of the bridge network", ip, subnets)
+		}
+	}
+
+	var ips []net.IP
+	for _, subnet := range subnets {
+		ip, err := allocateFrom(subnet, static, used)
+		if err != nil {
+			return nil, err
+		}
+
+		ips = append(ips, ip)
+	}
+
+	s.Allocations[containerID] = &allocation{IPs: ips}
+	return ips, nil
+}
+
+// release removes the container's allocation and returns it, if any
+func (s *ipamState) release(containerID string) *allocation {
+	a := s.Allocations[containerID]
+	delete(s.Allocations, containerID)
+	return a
+}
+
+// matches returns true if the allocation holds an address of every subnet, in order, and the static IPs
+func (a *allocation) matches(subnets []*net.IPNet, static []net.IP) bool {
+	if len(a.IPs) != len(subnets) {
+		return false
+	}
+
+	for i, subnet := range subnets {
+		if !subnet.Contains(a.IPs[i]) {
+			return false
+		}
+
+		for _, ip := range static {
+			if subnet.Contains(ip) && !ip.Equal(a.IPs[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// allocateFrom returns the static IP in the subnet, or the first free address of it
+func allocateFrom(subnet *net.IPNet, static []net.IP, used map[string]bool) (net.IP, error) {
+	for _, ip := range static {
+		if !subnet.Contains(ip) {
+			continue
+		}
+
+		if used[ip.String()] || ip.Equal(gateway(subnet)) {
+			return nil, fmt.Errorf("static IP %s is already in use", ip)
+		}
+
+		return normalize(ip), nil
+	}
+
+	ip := gateway(subnet)
+	for n := 0; n < maxScan; n++ {
+		ip = nextIP(ip)
+		if !subnet.Contains(ip) || isBroadcast(ip, subnet) {
+			break
+		}
+
+		if !used[ip.String()] {
+			return ip, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no free addresses left in subnet %s", subnet)
+}
+
+// gateway returns the first address of the subnet, which is assigned to the bridge
+func gateway(subnet *net.IPNet) net.IP {
+	return nextIP(subnet.IP.Mask(subnet.Mask))
+}
+
+// nextIP returns the address following the given one
+func nextIP(ip net.IP) net.IP {
+	ip = normalize(ip)
+	i := new(big.Int).SetBytes(ip)
+	b := i.Add(i, big.NewInt(1)).Bytes()
+
+	next := make(net.IP, len(ip))
+	copy(next[len(next)-len(b):], b)
+	return next
+}
+
+// isBroadcast returns true if the given address is the IPv4 broadcast address of the subnet
+func isBroadcast(ip net.IP, subnet *net.IPNet) bool {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false
+	}
+
+	for i := range ip4 {
+		if ip4[i]|subnet.Mask[len(subnet.Mask)-net.IPv4len+i] != 0xff {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containedIn(ip net.IP, subnets []*net.IPNet) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize converts IPv4 addresses to their 4-byte form
+func normalize(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+
+	return ip
+}
@@ -0,0 +1,229 @@
+package bridge
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"gotest.tools/assert"
+)
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, subnet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return subnet
+}
+
+func TestAllocate(t *testing.T) {
+	subnets := []*net.IPNet{mustParseCIDR("10.62.0.0/29"), mustParseCIDR("fd69:676e:6974:6501::/64")}
+
+	cases := []struct {
+		name        string
+		allocations map[string]*allocation
+		static      []net.IP
+		wantIPs     []string
+		wantErr     bool
+	}{
+		{
+			name:    "first free addresses",
+			wantIPs: []string{"10.62.0.2", "fd69:676e:6974:6501::2"},
+		},
+		{
+			name: "static address",
+			allocations: map[string]*allocation{
+				"other": {IPs: []net.IP{net.ParseIP("10.62.0.2")}},
+			},
+			static:  []net.IP{net.ParseIP("fd69:676e:6974:6501::10")},
+			wantIPs: []string{"10.62.0.3"},
+		},
+		{
+			name:    "static address outside of the subnets",
+			static:  []net.IP{net.ParseIP("10.61.0.2")},
+			wantErr: true,
+		},
+		{
+			name:    "static address of the gateway",
+			static:  []net.IP{net.ParseIP("10.62.0.1")},
+			wantErr: true,
+		},
+		{
+			name: "static address in use",
+			allocations: map[string]*allocation{
+				"other": {IPs: []net.IP{net.ParseIP("10.62.0.3")}},
+			},
+			static:  []net.IP{net.ParseIP("10.62.0.3")},
+			wantErr: true,
+		},
+		{
+			name: "subnet exhausted",
+			allocations: map[string]*allocation{
+				"a": {IPs: []net.IP{net.ParseIP("10.62.0.2")}},
+				"b": {IPs: []net.IP{net.ParseIP("10.62.0.3")}},
+				"c": {IPs: []net.IP{net.ParseIP("10.62.0.4")}},
+				"d": {IPs: []net.IP{net.ParseIP("10.62.0.5")}},
+				"e": {IPs: []net.IP{net.ParseIP("10.62.0.6")}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			state := &ipamState{Allocations: rt.allocations}
+			if state.Allocations == nil {
+				state.Allocations = make(map[string]*allocation)
+			}
+
+			ips, err := state.allocate("container", subnets, rt.static)
+			if rt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+
+			if len(rt.static) > 0 {
+				// The static address is handed out for its subnet
+				assert.Equal(t, ips[1].String(), rt.static[0].String())
+				ips = ips[:1]
+			}
+
+			var got []string
+			for _, ip := range ips {
+				got = append(got, ip.String())
+			}
+			assert.DeepEqual(t, got, rt.wantIPs)
+		})
+	}
+}
+
+func TestAllocateStale(t *testing.T) {
+	subnet4, subnet6 := mustParseCIDR("10.62.0.0/29"), mustParseCIDR("fd69:676e:6974:6501::/64")
+
+	cases := []struct {
+		name    string
+		stored  []string
+		subnets []*net.IPNet
+		static  []net.IP
+		wantIPs []string
+	}{
+		{
+			name:    "matching allocation",
+			stored:  []string{"10.62.0.3", "fd69:676e:6974:6501::3"},
+			subnets: []*net.IPNet{subnet4, subnet6},
+			wantIPs: []string{"10.62.0.3", "fd69:676e:6974:6501::3"},
+		},
+		{
+			name:    "IPv6 subnet skipped",
+			stored:  []string{"10.62.0.3", "fd69:676e:6974:6501::3"},
+			subnets: []*net.IPNet{subnet4},
+			wantIPs: []string{"10.62.0.2"},
+		},
+		{
+			name:    "IPv6 subnet added",
+			stored:  []string{"10.62.0.3"},
+			subnets: []*net.IPNet{subnet4, subnet6},
+			wantIPs: []string{"10.62.0.2", "fd69:676e:6974:6501::2"},
+		},
+		{
+			name:    "subnet changed",
+			stored:  []string{"10.61.0.2"},
+			subnets: []*net.IPNet{subnet4},
+			wantIPs: []string{"10.62.0.2"},
+		},
+		{
+			name:    "static address changed",
+			stored:  []string{"10.62.0.3"},
+			subnets: []*net.IPNet{subnet4},
+			static:  []net.IP{net.ParseIP("10.62.0.4")},
+			wantIPs: []string{"10.62.0.4"},
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			var stored []net.IP
+			for _, ip := range rt.stored {
+				stored = append(stored, net.ParseIP(ip))
+			}
+
+			state := &ipamState{Allocations: map[string]*allocation{"container": {IPs: stored}}}
+			ips, err := state.allocate("container", rt.subnets, rt.static)
+			assert.NilError(t, err)
+
+			var got []string
+			for _, ip := range ips {
+				got = append(got, ip.String())
+			}
+			assert.DeepEqual(t, got, rt.wantIPs)
+			assert.Equal(t, len(state.Allocations["container"].IPs), len(rt.subnets))
+		})
+	}
+}
+
+func TestIPAMPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-bridge-ipam")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	i := newIPAM(filepath.Join(dir, "network", "ipam.json"))
+	subnets := []*net.IPNet{mustParseCIDR("10.62.0.0/16")}
+	ports := meta.PortMappings{{HostPort: 8080, VMPort: 80, Protocol: meta.ProtocolTCP}}
+
+	for _, id := range []string{"first", "second"} {
+		assert.NilError(t, i.update(func(state *ipamState) error {
+			_, err := state.allocate(id, subnets, nil)
+			return err
+		}))
+	}
+
+	assert.NilError(t, i.update(func(state *ipamState) error {
+		// Allocating again for the same container returns its addresses
+		ips, err := state.allocate("second", subnets, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, ips[0].String(), "10.62.0.3")
+
+		state.Allocations["second"].Ports = ports
+		state.release("first")
+		return nil
+	}))
+
+	assert.NilError(t, i.update(func(state *ipamState) error {
+		assert.Equal(t, len(state.Allocations), 1)
+		assert.DeepEqual(t, state.Allocations["second"].Ports, ports)
+
+		// The released address is handed out again
+		ips, err := state.allocate("third", subnets, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, ips[0].String(), "10.62.0.2")
+		return nil
+	}))
+}
+
+func TestPortRules(t *testing.T) {
+	ports := meta.PortMappings{
+		{HostPort: 8080, VMPort: 80},
+		{BindAddress: net.ParseIP("127.0.0.1"), HostPort: 5353, VMPort: 53, Protocol: meta.ProtocolUDP},
+		{BindAddress: net.ParseIP("::1"), HostPort: 2222, VMPort: 22, Protocol: meta.ProtocolTCP},
+	}
+
+	rules4 := portRules("container", net.ParseIP("10.62.0.2"), ports)
+	assert.Equal(t, len(rules4), 2)
+	assert.DeepEqual(t, rules4[1].spec, []string{
+		"-p", "udp", "-d", "127.0.0.1", "--dport", "5353",
+		"-m", "comment", "--comment", "ignite container",
+		"-j", "DNAT", "--to-destination", "10.62.0.2:53",
+	})
+
+	rules6 := portRules("container", net.ParseIP("fd69:676e:6974:6501::2"), ports)
+	assert.Equal(t, len(rules6), 2)
+	assert.DeepEqual(t, rules6[0].spec, []string{
+		"-p", "tcp", "--dport", "8080",
+		"-m", "comment", "--comment", "ignite container",
+		"-j", "DNAT", "--to-destination", "[fd69:676e:6974:6501::2]:80",
+	})
+}
@@ -0,0 +1,189 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/coreos/go-iptables/iptables"
+	log "github.com/sirupsen/logrus"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+const (
+	// forwardChain accepts the traffic from the bridge, and the forwarded traffic to it
+	forwardChain = "IGNITE-BRIDGE-FORWARD"
+	// postroutingChain masquerades the traffic leaving the bridge network
+	postroutingChain = "IGNITE-BRIDGE-POSTROUTING"
+	// dnatChain holds the port forwards of the VMs
+	dnatChain = "IGNITE-BRIDGE-DNAT"
+)
+
+// rule is an iptables rule in the given table and chain
+type rule struct {
+	table string
+	chain string
+	spec  []string
+}
+
+// jumpRules hook ignite's chains into the builtin ones
+var jumpRules = []rule{
+	{"filter", "FORWARD", []string{"-m", "comment", "--comment", "ignite bridge network", "-j", forwardChain}},
+	{"nat", "POSTROUTING", []string{"-m", "comment", "--comment", "ignite bridge network", "-j", postroutingChain}},
+	{"nat", "PREROUTING", []string{"-m", "addrtype", "--dst-type", "LOCAL", "-m", "comment", "--comment", "ignite bridge network", "-j", dnatChain}},
+	{"nat", "OUTPUT", []string{"-m", "addrtype", "--dst-type", "LOCAL", "-m", "comment", "--comment", "ignite bridge network", "-j", dnatChain}},
+}
+
+// networkRules returns the rules for the subnet of one IP family of the bridge
+func networkRules(bridgeName string, subnet *net.IPNet) []rule {
+	rules := []rule{
+		{"filter", forwardChain, []string{"-i", bridgeName, "-j", "ACCEPT"}},
+		{"filter", forwardChain, []string{"-o", bridgeName, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED,DNAT", "-j", "ACCEPT"}},
+		{"nat", postroutingChain, []string{"-s", subnet.String(), "!", "-o", bridgeName, "-j", "MASQUERADE"}},
+	}
+
+	// Port forwards from localhost are routed to the bridge, the VMs need to see a source they can reply to
+	if subnet.IP.To4() != nil {
+		rules = append(rules, rule{"nat", postroutingChain, []string{"-s", "127.0.0.0/8", "-o", bridgeName, "-j", "MASQUERADE"}})
+	}
+
+	return rules
+}
+
+// isolatedRules returns the rules for an isolated bridge, the VMs can only reach each other and the host.
+// They need to be evaluated before the rules of other bridges, which accept the traffic coming from them.
+func isolatedRules(bridgeName string) []rule {
+	return []rule{
+		{"filter", forwardChain, []string{"-i", bridgeName, "-o", bridgeName, "-j", "ACCEPT"}},
+		{"filter", forwardChain, []string{"-i", bridgeName, "-j", "DROP"}},
+		{"filter", forwardChain, []string{"-o", bridgeName, "-j", "DROP"}},
+	}
+}
+
+// portRules returns the port forwards of a container to the given address
+func portRules(containerID string, ip net.IP, ports meta.PortMappings) []rule {
+	var rules []rule
+	for _, port := range ports {
+		// Port mappings without a bind address are forwarded for both IP families
+		if port.BindAddress != nil && (port.BindAddress.To4() == nil) != (ip.To4() == nil) {
+			continue
+		}
+
+		protocol := port.Protocol
+		if len(protocol) == 0 {
+			protocol = meta.ProtocolTCP
+		}
+
+		spec := []string{"-p", protocol.String()}
+		if port.BindAddress != nil && !port.BindAddress.IsUnspecified() {
+			spec = append(spec, "-d", port.BindAddress.String())
+		}
+
+		spec = append(spec,
+			"--dport", strconv.FormatUint(port.HostPort, 10),
+			"-m", "comment", "--comment", fmt.Sprintf("ignite %s", containerID),
+			"-j", "DNAT", "--to-destination", net.JoinHostPort(ip.String(), strconv.FormatUint(port.VMPort, 10)),
+		)
+
+		rules = append(rules, rule{"nat", dnatChain, spec})
+	}
+
+	return rules
+}
+
+// iptablesFor returns the iptables handle for the IP family of the given address
+func iptablesFor(ip net.IP) (*iptables.IPTables, error) {
+	if ip.To4() != nil {
+		return iptables.NewWithProtocol(iptables.ProtocolIPv4)
+	}
+
+	return iptables.NewWithProtocol(iptables.ProtocolIPv6)
+}
+
+// ensureChains creates ignite's chains and hooks them into the builtin chains
+func ensureChains(ipt *iptables.IPTables) error {
+	for _, chain := range []rule{
+		{table: "filter", chain: forwardChain},
+		{table: "nat", chain: postroutingChain},
+		{table: "nat", chain: dnatChain},
+	} {
+		if err := ipt.NewChain(chain.table, chain.chain); err != nil {
+			if e, ok := err.(*iptables.Error); !ok || e.ExitStatus() != 1 {
+				return err
+			}
+			// The chain already exists
+		}
+	}
+
+	for _, r := range jumpRules {
+		exists, err := ipt.Exists(r.table, r.chain, r.spec...)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		// The forwarding rules need to be evaluated before e.g. the DROP policy set by Docker
+		if r.chain == "FORWARD" {
+			err = ipt.Insert(r.table, r.chain, 1, r.spec...)
+		} else {
+			err = ipt.Append(r.table, r.chain, r.spec...)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addRules appends the given rules to their chains, unless they exist already
+func addRules(ipt *iptables.IPTables, rules []rule) error {
+	for _, r := range rules {
+		if err := ipt.AppendUnique(r.table, r.chain, r.spec...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertRules inserts the given rules at the top of their chains in the given order, unless they exist already
+func insertRules(ipt *iptables.IPTables, rules []rule) error {
+	for i, r := range rules {
+		exists, err := ipt.Exists(r.table, r.chain, r.spec...)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		if err := ipt.Insert(r.table, r.chain, i+1, r.spec...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteRules removes the given rules, rules that don't exist are skipped
+func deleteRules(ipt *iptables.IPTables, rules []rule) (errs []error) {
+	for _, r := range rules {
+		exists, err := ipt.Exists(r.table, r.chain, r.spec...)
+		if err == nil && exists {
+			err = ipt.Delete(r.table, r.chain, r.spec...)
+		}
+
+		if err != nil {
+			log.Debugf("Failed to remove iptables rule %v from %s/%s: %v", r.spec, r.table, r.chain, err)
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,108 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-iptables/iptables"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/weaveworks/ignite/pkg/network"
+)
+
+// isolationChain drops the traffic forwarded between isolated bridges and the other bridges of ignite
+const isolationChain = "IGNITE-ISOLATION"
+
+// isolationJump is the rule hooking the isolation chain into the FORWARD chain
+var isolationJump = rule{"filter", "FORWARD", []string{"-m", "comment", "--comment", "ignite network isolation", "-j", isolationChain}}
+
+// bridgeWildcard matches the bridges of all networks of ignite by their name
+const bridgeWildcard = "ignite+"
+
+// isolationRules returns the rules isolating the given bridge, the traffic within it returns to the FORWARD chain
+func isolationRules(bridgeName string) []rule {
+	return []rule{
+		{"filter", isolationChain, []string{"-i", bridgeName, "-o", bridgeName, "-j", "RETURN"}},
+		{"filter", isolationChain, []string{"-i", bridgeName, "-o", bridgeWildcard, "-j", "DROP"}},
+		{"filter", isolationChain, []string{"-i", bridgeWildcard, "-o", bridgeName, "-j", "DROP"}},
+	}
+}
+
+// IsolateBridge drops all traffic forwarded between the given bridge and the other bridges of ignite, which are
+// recognized by their names starting with "ignite". The rules are evaluated before all other forwarding rules,
+// such as the ones of the CNI firewall plugin accepting the traffic of the VMs, so this needs to be called after
+// the network of a VM has been set up.
+func IsolateBridge(bridgeName string) error {
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		// Without IPv6 on the host there's no IPv6 traffic to drop
+		if protocol == iptables.ProtocolIPv6 && !network.IPv6Enabled() {
+			log.Debugf("Skipping IPv6 isolation of bridge %q, IPv6 is disabled", bridgeName)
+			continue
+		}
+
+		ipt, err := iptables.NewWithProtocol(protocol)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureIsolationChain(ipt); err != nil {
+			return fmt.Errorf("failed to set up the isolation chain: %v", err)
+		}
+
+		if err := addRules(ipt, isolationRules(bridgeName)); err != nil {
+			return fmt.Errorf("failed to isolate bridge %q: %v", bridgeName, err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveBridge removes the given bridge and the rules isolating it, if any
+func RemoveBridge(bridgeName string) error {
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		ipt, err := iptables.NewWithProtocol(protocol)
+		if err != nil {
+			continue
+		}
+
+		if errs := deleteRules(ipt, isolationRules(bridgeName)); len(errs) > 0 {
+			return fmt.Errorf("failed to remove the isolation of bridge %q: %v", bridgeName, errs)
+		}
+	}
+
+	link, err := netlink.LinkByName(bridgeName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return err
+	}
+
+	return netlink.LinkDel(link)
+}
+
+// ensureIsolationChain creates the isolation chain and makes sure it's the first rule of the FORWARD chain
+func ensureIsolationChain(ipt *iptables.IPTables) error {
+	if err := ipt.NewChain("filter", isolationChain); err != nil {
+		if e, ok := err.(*iptables.Error); !ok || e.ExitStatus() != 1 {
+			return err
+		}
+		// The chain already exists
+	}
+
+	rules, err := ipt.List(isolationJump.table, isolationJump.chain)
+	if err != nil {
+		return err
+	}
+
+	// The first entry is the policy of the chain
+	if len(rules) > 1 && strings.HasSuffix(rules[1], "-j "+isolationChain) {
+		return nil
+	}
+
+	if errs := deleteRules(ipt, []rule{isolationJump}); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return ipt.Insert(isolationJump.table, isolationJump.chain, 1, isolationJump.spec...)
+}
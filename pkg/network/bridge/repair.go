@@ -0,0 +1,61 @@
+package bridge
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/weaveworks/ignite/pkg/network"
+)
+
+var _ network.Repairer = &bridgeNetworkPlugin{}
+
+// ReleaseLeaked releases the addresses and port forwards of the leaked containers. All containers on
+// the network are ignite's own, it's managed by this plugin.
+func (plugin *bridgeNetworkPlugin) ReleaseLeaked(_ []network.Attachment, leaked network.LeakedFunc) ([]string, error) {
+	var containerIDs []string
+	if err := plugin.ipam.update(func(state *ipamState) error {
+		for containerID := range state.Allocations {
+			if leaked(containerID, true) {
+				containerIDs = append(containerIDs, containerID)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(containerIDs)
+	released := containerIDs[:0]
+	for _, containerID := range containerIDs {
+		log.Infof("Releasing the addresses of container %q on the %q network", containerID, plugin.name)
+		if err := plugin.RemoveContainerNetwork(containerID, nil); err != nil {
+			log.Warnf("Failed to release container %q: %v", containerID, err)
+			continue
+		}
+
+		released = append(released, containerID)
+	}
+
+	return released, nil
+}
+
+// CheckContainerNetwork verifies the bridge exists and the container is connected to it
+func (plugin *bridgeNetworkPlugin) CheckContainerNetwork(containerID string, _ []network.Attachment) error {
+	bridge, err := netlink.LinkByName(plugin.network.bridgeName)
+	if err != nil {
+		return fmt.Errorf("bridge %q is missing: %v", plugin.network.bridgeName, err)
+	}
+
+	veth, err := netlink.LinkByName(vethName(containerID))
+	if err != nil {
+		return fmt.Errorf("veth %q is missing: %v", vethName(containerID), err)
+	}
+
+	if veth.Attrs().MasterIndex != bridge.Attrs().Index {
+		return fmt.Errorf("veth %q isn't connected to bridge %q", veth.Attrs().Name, plugin.network.bridgeName)
+	}
+
+	return nil
+}
@@ -7,10 +7,13 @@ import (
 	"net"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 
 	gocni "github.com/containerd/go-cni"
+	cnilibrary "github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/containernetworking/plugins/pkg/ip"
 	"github.com/containernetworking/plugins/pkg/utils"
 	"github.com/coreos/go-iptables/iptables"
@@ -23,11 +26,9 @@ import (
 )
 
 const (
-	// TODO: CNIBinDir and CNIConfDir should maybe be globally configurable?
-
-	// CNIBinDir describes the directory where the CNI binaries are stored
+	// CNIBinDir describes the default directory where the CNI binaries are stored
 	CNIBinDir = "/opt/cni/bin"
-	// CNIConfDir describes the directory where the CNI plugin's configuration is stored
+	// CNIConfDir describes the default directory where the CNI plugin's configuration is stored
 	CNIConfDir = "/etc/cni/net.d"
 	// netNSPathFmt gives the path to the a process network namespace, given the pid
 	netNSPathFmt = "/proc/%d/ns/net"
@@ -50,10 +51,34 @@ const (
 	// It's still best to pick a unique, right-sized subnet to avoid confusion and make documentation and issue threads easier to search for.
 	// Since a large host could potentially start thousands to tens-of-thousands of firecracker vm's, perhaps a /18, /17, or /16 is appropriate.
 	defaultSubnet = "10.61.0.0/16"
+	// defaultSubnet6 is the default IPv6 subnet used in the defaultCNIConf. It's a unique local address (RFC 4193) range,
+	// with the global and subnet ID spelling "ignite" in ASCII. VMs get an address from both subnets (dual-stack) if the
+	// host supports IPv6, and outgoing IPv6 traffic is masqueraded just like IPv4 traffic.
+	defaultSubnet6 = "fd69:676e:6974:6500::/64"
 )
 
-// defaultCNIConf is a CNI configuration chain that enables VMs to access the internet (docker-bridge style)
-var defaultCNIConf = fmt.Sprintf(`{
+// DefaultSubnets returns the subnets of ignite's default CNI network
+func DefaultSubnets() []string {
+	return []string{defaultSubnet, defaultSubnet6}
+}
+
+// loConfList is the CNI configuration list of the loopback network, as attached by go-cni
+var loConfList = []byte(`{
+	"cniVersion": "0.3.1",
+	"name": "cni-loopback",
+	"plugins": [{"type": "loopback"}]
+}`)
+
+// defaultCNIConf returns a CNI configuration chain that enables VMs to access the internet (docker-bridge style).
+// The IPv6 range is only added if the host supports IPv6, the bridge plugin fails to set up the network otherwise.
+func defaultCNIConf(ipv6 bool) string {
+	ranges := fmt.Sprintf(`[{"subnet": "%s"}]`, defaultSubnet)
+	if ipv6 {
+		ranges += fmt.Sprintf(`,
+					[{"subnet": "%s"}]`, defaultSubnet6)
+	}
+
+	return fmt.Sprintf(`{
 	"cniVersion": "0.4.0",
 	"name": "%s",
 	"plugins": [
@@ -64,9 +89,14 @@ var defaultCNIConf = fmt.Sprintf(`{
 			"isDefaultGateway": true,
 			"promiscMode": true,
 			"ipMasq": true,
+			"capabilities": {
+				"ips": true
+			},
 			"ipam": {
 				"type": "host-local",
-				"subnet": "%s"
+				"ranges": [
+					%s
+				]
 			}
 		},
 		{
@@ -80,35 +110,40 @@ var defaultCNIConf = fmt.Sprintf(`{
 		}
 	]
 }
-`, defaultNetworkName, defaultBridgeName, defaultSubnet)
+`, defaultNetworkName, defaultBridgeName, ranges)
+}
 
 type cniNetworkPlugin struct {
 	cni       gocni.CNI
-	cniConfig *gocni.ConfigResult
+	cniConfig *cnilibrary.CNIConfig
 	runtime   runtime.Interface
 	once      *sync.Once
+	confDir   string
 }
 
-func GetCNINetworkPlugin(runtime runtime.Interface) (network.Plugin, error) {
+// GetCNINetworkPlugin returns the CNI network plugin, using the plugin binaries
+// of the given directories and the network configurations of confDir
+func GetCNINetworkPlugin(runtime runtime.Interface, binDirs []string, confDir string) (network.Plugin, error) {
 	// If the CNI configuration directory doesn't exist, create it
-	if !util.DirExists(CNIConfDir) {
-		if err := os.MkdirAll(CNIConfDir, constants.DATA_DIR_PERM); err != nil {
+	if !util.DirExists(confDir) {
+		if err := os.MkdirAll(confDir, constants.DATA_DIR_PERM); err != nil {
 			return nil, err
 		}
 	}
 
-	binDirs := []string{CNIBinDir}
 	cniInstance, err := gocni.New(gocni.WithMinNetworkCount(2),
-		gocni.WithPluginConfDir(CNIConfDir),
+		gocni.WithPluginConfDir(confDir),
 		gocni.WithPluginDir(binDirs))
 	if err != nil {
 		return nil, err
 	}
 
 	return &cniNetworkPlugin{
-		runtime: runtime,
-		cni:     cniInstance,
-		once:    &sync.Once{},
+		runtime:   runtime,
+		cni:       cniInstance,
+		cniConfig: cnilibrary.NewCNIConfig(binDirs, nil),
+		once:      &sync.Once{},
+		confDir:   confDir,
 	}, nil
 }
 
@@ -122,44 +157,73 @@ func (plugin *cniNetworkPlugin) PrepareContainerSpec(container *runtime.Containe
 	return nil
 }
 
-func (plugin *cniNetworkPlugin) SetupContainerNetwork(containerid string, portMappings ...meta.PortMapping) (*network.Result, error) {
-	if err := plugin.initialize(); err != nil {
-		return nil, err
-	}
-
+func (plugin *cniNetworkPlugin) SetupContainerNetwork(containerid string, ips []net.IP, networks []network.Attachment, portMappings ...meta.PortMapping) (*network.Result, error) {
 	c, err := plugin.runtime.InspectContainer(containerid)
 	if err != nil {
 		return nil, fmt.Errorf("CNI failed to retrieve network namespace path: %v", err)
 	}
 
-	pms := make([]gocni.PortMapping, 0, len(portMappings))
-	for _, pm := range portMappings {
-		hostIP := ""
-		if pm.BindAddress != nil {
-			hostIP = pm.BindAddress.String()
+	netnsPath := fmt.Sprintf(netNSPathFmt, c.PID)
+	pms := cniPortMappings(portMappings)
+
+	if len(networks) > 0 {
+		attachments, err := plugin.attachments(containerid, netnsPath, ips, networks, pms)
+		if err != nil {
+			return nil, err
 		}
-		pms = append(pms, gocni.PortMapping{
-			HostPort:      int32(pm.HostPort),
-			ContainerPort: int32(pm.VMPort),
-			Protocol:      pm.Protocol.String(),
-			HostIP:        hostIP,
-		})
+
+		result := &network.Result{}
+		for i, a := range attachments {
+			r, err := plugin.cniConfig.AddNetworkList(context.Background(), a.confList, a.rt)
+			if err != nil {
+				log.Errorf("failed to setup network %q for namespace %q: %v", a.confList.Name, containerid, err)
+
+				// Don't leak the networks attached already, the failed one may be attached partially
+				if delErr := plugin.delAttachments(containerid, attachments[:i+1]); delErr != nil {
+					log.Warnf("Failed to detach namespace %q from its networks: %v", containerid, delErr)
+				}
+
+				return nil, err
+			}
+
+			if a.rt.IfName == loIfName {
+				continue
+			}
+
+			cr, err := current.NewResultFromResult(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid result of network %q: %v", a.confList.Name, err)
+			}
+
+			addNetworkResult(result, attachmentResult(a.confList.Name, a.rt.IfName, cr))
+		}
+
+		return result, nil
 	}
 
-	netnsPath := fmt.Sprintf(netNSPathFmt, c.PID)
-	result, err := plugin.cni.Setup(context.Background(), containerid, netnsPath, gocni.WithCapabilityPortMap(pms))
+	if err := plugin.initialize(); err != nil {
+		return nil, err
+	}
+
+	opts := []gocni.NamespaceOpts{gocni.WithCapabilityPortMap(pms)}
+	if len(ips) > 0 {
+		// Request the static IPs using the "ips" capability, supported by e.g. the host-local IPAM plugin
+		opts = append(opts, gocni.WithCapability("ips", ipStrings(ips)))
+	}
+
+	result, err := plugin.cni.Setup(context.Background(), containerid, netnsPath, opts...)
 	if err != nil {
 		log.Errorf("failed to setup network for namespace %q: %v", containerid, err)
 		return nil, err
 	}
 
-	return cniToIgniteResult(result), nil
+	return cniToIgniteResult(result, plugin.cni.GetConfig().Networks), nil
 }
 
 func (plugin *cniNetworkPlugin) initialize() (err error) {
 	// If there's no existing CNI configuration, write ignite's example config to the CNI directory
-	if util.DirEmpty(CNIConfDir) {
-		if err = ioutil.WriteFile(path.Join(CNIConfDir, defaultCNIConfFilename), []byte(defaultCNIConf), constants.DATA_DIR_FILE_PERM); err != nil {
+	if util.DirEmpty(plugin.confDir) {
+		if err = ioutil.WriteFile(path.Join(plugin.confDir, defaultCNIConfFilename), []byte(defaultCNIConf(network.IPv6Enabled())), constants.DATA_DIR_FILE_PERM); err != nil {
 			return
 		}
 	}
@@ -170,31 +234,233 @@ func (plugin *cniNetworkPlugin) initialize() (err error) {
 		}
 	})
 
-	plugin.cniConfig = plugin.cni.GetConfig()
-
 	return
 }
 
-func cniToIgniteResult(r *gocni.CNIResult) *network.Result {
+// loIfName is the interface name of the loopback network
+const loIfName = "lo"
+
+// attachment is a network attached with libcni directly, as go-cni applies the same runtime configuration to all networks
+type attachment struct {
+	confList *cnilibrary.NetworkConfigList
+	rt       *cnilibrary.RuntimeConf
+}
+
+// attachments returns the networks for attaching a container to the given networks instead of the default one.
+// The interfaces are named by the index of their network, the loopback network is attached last.
+func (plugin *cniNetworkPlugin) attachments(containerID, netnsPath string, ips []net.IP, networks []network.Attachment, pms []gocni.PortMapping) ([]attachment, error) {
+	attachments := make([]attachment, 0, len(networks)+1)
+	for i, n := range networks {
+		rt := &cnilibrary.RuntimeConf{
+			ContainerID:    containerID,
+			NetNS:          netnsPath,
+			IfName:         fmt.Sprintf("eth%d", i),
+			CapabilityArgs: make(map[string]interface{}, len(n.Capabilities)+2),
+		}
+
+		keepCapabilities := make([]string, 0, len(n.Capabilities))
+		for name, value := range n.Capabilities {
+			rt.CapabilityArgs[name] = value
+			keepCapabilities = append(keepCapabilities, name)
+		}
+
+		// Only the first network provides the default route, the static IPs and the port mappings
+		if i == 0 {
+			rt.CapabilityArgs["portMappings"] = pms
+			if _, ok := rt.CapabilityArgs["ips"]; !ok && len(ips) > 0 {
+				rt.CapabilityArgs["ips"] = ipStrings(ips)
+			}
+		}
+
+		argNames := make([]string, 0, len(n.Args))
+		for name := range n.Args {
+			argNames = append(argNames, name)
+		}
+		sort.Strings(argNames)
+		for _, name := range argNames {
+			rt.Args = append(rt.Args, [2]string{name, n.Args[name]})
+		}
+
+		confList, err := plugin.loadConfList(n, i > 0, keepCapabilities)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment{confList: confList, rt: rt})
+	}
+
+	lo, err := cnilibrary.ConfListFromBytes(loConfList)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(attachments, attachment{
+		confList: lo,
+		rt: &cnilibrary.RuntimeConf{
+			ContainerID: containerID,
+			NetNS:       netnsPath,
+			IfName:      loIfName,
+		},
+	}), nil
+}
+
+// loadConfList loads the CNI configuration list of a user-defined network, or of a network of the CNI configuration
+// directory by its name. Additional networks are modified to leave the default route to the main network.
+func (plugin *cniNetworkPlugin) loadConfList(n network.Attachment, secondary bool, keepCapabilities []string) (*cnilibrary.NetworkConfigList, error) {
+	var b []byte
+	source := n.ConfListPath
+	if len(source) > 0 {
+		var err error
+		if b, err = ioutil.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("failed to read CNI configuration: %v", err)
+		}
+	} else {
+		confList, err := cnilibrary.LoadConfList(plugin.confDir, n.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CNI network %q from %q: %v", n.Name, plugin.confDir, err)
+		}
+
+		b, source = confList.Bytes, n.Name
+	}
+
+	if secondary {
+		var err error
+		if b, err = secondaryConfList(b, keepCapabilities...); err != nil {
+			return nil, fmt.Errorf("invalid CNI configuration %q: %v", source, err)
+		}
+	}
+
+	confList, err := cnilibrary.ConfListFromBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CNI configuration %q: %v", source, err)
+	}
+
+	return confList, nil
+}
+
+// cniToIgniteResult converts the result of attaching a container to the default networks with go-cni
+func cniToIgniteResult(r *gocni.CNIResult, networks []*gocni.ConfNetwork) *network.Result {
 	result := &network.Result{}
-	for _, iface := range r.Interfaces {
-		for _, i := range iface.IPConfigs {
-			result.Addresses = append(result.Addresses, network.Address{
+	for _, n := range networks {
+		config, ok := r.Interfaces[n.IFName]
+		if n.IFName == loIfName || !ok {
+			continue
+		}
+
+		networkResult := network.NetworkResult{
+			Name:      n.Config.Name,
+			Interface: n.IFName,
+			MAC:       config.Mac,
+		}
+
+		for _, i := range config.IPConfigs {
+			networkResult.Addresses = append(networkResult.Addresses, network.Address{
 				IP:      i.IP,
 				Gateway: i.Gateway,
 			})
 		}
+
+		addNetworkResult(result, networkResult)
 	}
 
 	return result
 }
 
-func (plugin *cniNetworkPlugin) RemoveContainerNetwork(containerID string, portMappings ...meta.PortMapping) (err error) {
-	if err = plugin.initialize(); err != nil {
-		return err
+// attachmentResult converts the result of attaching a container to a network with libcni
+func attachmentResult(name, ifName string, r *current.Result) network.NetworkResult {
+	result := network.NetworkResult{
+		Name:      name,
+		Interface: ifName,
+	}
+
+	ifIndex := -1
+	for i, intf := range r.Interfaces {
+		if intf.Name == ifName && len(intf.Sandbox) > 0 {
+			ifIndex = i
+			result.MAC = intf.Mac
+		}
+	}
+
+	for _, ipConfig := range r.IPs {
+		// Addresses without an interface belong to the container interface
+		if ipConfig.Interface != nil && *ipConfig.Interface != ifIndex {
+			continue
+		}
+
+		result.Addresses = append(result.Addresses, network.Address{
+			IP:      ipConfig.Address.IP,
+			Gateway: ipConfig.Gateway,
+		})
+	}
+
+	return result
+}
+
+// addNetworkResult adds the result of a network to the result, the addresses of the main interface come first
+func addNetworkResult(result *network.Result, networkResult network.NetworkResult) {
+	result.Networks = append(result.Networks, networkResult)
+	result.Addresses = append(result.Addresses, networkResult.Addresses...)
+}
+
+func cniPortMappings(portMappings []meta.PortMapping) []gocni.PortMapping {
+	pms := make([]gocni.PortMapping, 0, len(portMappings))
+	for _, pm := range portMappings {
+		hostIP := ""
+		if pm.BindAddress != nil {
+			hostIP = pm.BindAddress.String()
+		}
+		pms = append(pms, gocni.PortMapping{
+			HostPort:      int32(pm.HostPort),
+			ContainerPort: int32(pm.VMPort),
+			Protocol:      pm.Protocol.String(),
+			HostIP:        hostIP,
+		})
+	}
+
+	return pms
+}
+
+func ipStrings(ips []net.IP) []string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
 	}
 
-	cleanupErr := plugin.cleanupBridges(containerID)
+	return s
+}
+
+func (plugin *cniNetworkPlugin) RemoveContainerNetwork(containerID string, networks []network.Attachment, portMappings ...meta.PortMapping) (err error) {
+	pms := cniPortMappings(portMappings)
+
+	var attachments []attachment
+	var bridgeNetworks []string
+	if len(networks) > 0 {
+		// The network namespace path is set below, once it's known
+		if attachments, err = plugin.attachments(containerID, "", nil, networks, pms); err != nil {
+			return err
+		}
+
+		for _, a := range attachments {
+			if hasBridge(a.confList.Plugins) {
+				bridgeNetworks = append(bridgeNetworks, a.confList.Name)
+			}
+		}
+	} else {
+		if err = plugin.initialize(); err != nil {
+			return err
+		}
+
+		for _, n := range plugin.cni.GetConfig().Networks {
+			for _, p := range n.Config.Plugins {
+				if p.Network.Type == "bridge" {
+					bridgeNetworks = append(bridgeNetworks, n.Config.Name)
+					break
+				}
+			}
+		}
+	}
+
+	cleanupErr := cleanupBridges(bridgeNetworks, containerID)
 	if cleanupErr != nil {
 		defer util.DeferErr(&err, func() error {
 			return cleanupErr
@@ -214,26 +480,53 @@ func (plugin *cniNetworkPlugin) RemoveContainerNetwork(containerID string, portM
 		return nil
 	}
 
-	pms := make([]gocni.PortMapping, 0, len(portMappings))
-	for _, pm := range portMappings {
-		hostIP := ""
-		if pm.BindAddress != nil {
-			hostIP = pm.BindAddress.String()
+	if len(attachments) == 0 {
+		return plugin.cni.Remove(context.Background(), containerID, netnsPath, gocni.WithCapabilityPortMap(pms))
+	}
+
+	for _, a := range attachments {
+		a.rt.NetNS = netnsPath
+	}
+
+	return plugin.delAttachments(containerID, attachments)
+}
+
+// delAttachments detaches the container from the networks in reverse order, like the networks were attached.
+// The container is detached from all networks, even if detaching it from some of them fails.
+func (plugin *cniNetworkPlugin) delAttachments(containerID string, attachments []attachment) error {
+	var errs []error
+	for i := len(attachments) - 1; i >= 0; i-- {
+		a := attachments[i]
+		if err := plugin.cniConfig.DelNetworkList(context.Background(), a.confList, a.rt); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove network %q of namespace %q: %v", a.confList.Name, containerID, err))
 		}
-		pms = append(pms, gocni.PortMapping{
-			HostPort:      int32(pm.HostPort),
-			ContainerPort: int32(pm.VMPort),
-			Protocol:      pm.Protocol.String(),
-			HostIP:        hostIP,
-		})
 	}
 
-	return plugin.cni.Remove(context.Background(), containerID, netnsPath, gocni.WithCapabilityPortMap(pms))
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove %d networks of namespace %q: %v", len(errs), containerID, errs)
+	}
+
+	return nil
+}
+
+// hasBridge returns true if one of the plugins of a CNI network is the bridge plugin
+func hasBridge(plugins []*cnilibrary.NetworkConfig) bool {
+	for _, p := range plugins {
+		if p.Network.Type == "bridge" {
+			return true
+		}
+	}
+
+	return false
 }
 
 // cleanupBridges makes the defaultNetworkName CNI network config not leak iptables rules
 // It could possibly help with rule cleanup for other CNI network configs as well
-func (plugin *cniNetworkPlugin) cleanupBridges(containerID string) error {
+// The networks are the names of the CNI networks of the container containing a bridge
+func cleanupBridges(networks []string, containerID string) error {
 	// Get the amount of combinations between an IP mask, and an iptables chain, with the specified container ID
 	result, err := getIPChains(containerID)
 	if err != nil {
@@ -241,21 +534,12 @@ func (plugin *cniNetworkPlugin) cleanupBridges(containerID string) error {
 	}
 
 	var teardownErrs []error
-	for _, net := range plugin.cniConfig.Networks {
-		var hasBridge bool
-		for _, plugin := range net.Config.Plugins {
-			if plugin.Network.Type == "bridge" {
-				hasBridge = true
-			}
-		}
-
-		if hasBridge {
-			log.Debugf("Teardown IPMasq for container %q on CNI network %q which contains a bridge", containerID, net.Config.Name)
-			comment := utils.FormatComment(net.Config.Name, containerID)
-			for _, t := range result {
-				if err = ip.TeardownIPMasq(t.ip, t.chain, comment); err != nil {
-					teardownErrs = append(teardownErrs, err)
-				}
+	for _, name := range networks {
+		log.Debugf("Teardown IPMasq for container %q on CNI network %q which contains a bridge", containerID, name)
+		comment := utils.FormatComment(name, containerID)
+		for _, t := range result {
+			if err = ip.TeardownIPMasq(t.ip, t.chain, comment); err != nil {
+				teardownErrs = append(teardownErrs, err)
 			}
 		}
 	}
@@ -276,8 +560,27 @@ type ipChain struct {
 }
 
 func getIPChains(containerID string) (result []*ipChain, err error) {
-	ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv4)
+	// Check both the IPv4 and IPv6 rules, a dual-stack network creates both
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		var chains []*ipChain
+		if chains, err = getIPChainsForProtocol(containerID, protocol); err != nil {
+			return
+		}
+
+		result = append(result, chains...)
+	}
+
+	return
+}
+
+func getIPChainsForProtocol(containerID string, protocol iptables.Protocol) (result []*ipChain, err error) {
+	ipt, err := iptables.NewWithProtocol(protocol)
 	if err != nil {
+		if protocol == iptables.ProtocolIPv6 {
+			// The host may not support IPv6, in which case there are no rules to clean up
+			log.Debugf("Skipping IPv6 rule cleanup: %v", err)
+			return nil, nil
+		}
 		return
 	}
 
@@ -0,0 +1,166 @@
+package cni
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+
+	gocni "github.com/containerd/go-cni"
+	cnilibrary "github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types/current"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/network"
+	"gotest.tools/assert"
+)
+
+func TestAttachments(t *testing.T) {
+	confDir, err := ioutil.TempDir("", "ignite-cni")
+	assert.NilError(t, err)
+	defer os.RemoveAll(confDir)
+
+	tenant := &api.Network{Spec: api.NetworkSpec{Subnet: "10.62.0.0/24", BridgeName: "ignite-tenant"}}
+	tenant.SetName("tenant-a")
+	confList, err := NetworkConfList(tenant)
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(path.Join(confDir, "20-tenant-a.conflist"), confList, 0644))
+
+	userDefined := &api.Network{Spec: api.NetworkSpec{Subnet: "10.63.0.0/24", BridgeName: "ignite-user"}}
+	userDefined.SetName("user")
+	confList, err = NetworkConfList(userDefined)
+	assert.NilError(t, err)
+	userConfList := path.Join(confDir, "user.json")
+	assert.NilError(t, ioutil.WriteFile(userConfList, confList, 0644))
+
+	plugin := &cniNetworkPlugin{confDir: confDir}
+	pms := []gocni.PortMapping{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}
+	attachments, err := plugin.attachments("ignite-test", "/proc/1/ns/net", []net.IP{net.ParseIP("10.63.0.10")}, []network.Attachment{
+		{ConfListPath: userConfList},
+		{
+			Name:         tenant.CNIName(),
+			Args:         map[string]string{"K8S_POD_NAME": "vm", "IgnoreUnknown": "1"},
+			Capabilities: map[string]interface{}{"ips": []string{"10.62.0.10/24"}},
+		},
+	}, pms)
+	assert.NilError(t, err)
+	assert.Equal(t, len(attachments), 3)
+
+	// The main network gets the static IP and the port mappings
+	main := attachments[0]
+	assert.Equal(t, main.confList.Name, userDefined.CNIName())
+	assert.Equal(t, main.rt.IfName, "eth0")
+	assert.Equal(t, main.rt.NetNS, "/proc/1/ns/net")
+	assert.DeepEqual(t, main.rt.CapabilityArgs, map[string]interface{}{
+		"portMappings": pms,
+		"ips":          []string{"10.63.0.10"},
+	})
+
+	// The additional network keeps its own capabilities, but not the default route
+	secondary := attachments[1]
+	assert.Equal(t, secondary.confList.Name, tenant.CNIName())
+	assert.Equal(t, secondary.rt.IfName, "eth1")
+	assert.DeepEqual(t, secondary.rt.Args, [][2]string{{"IgnoreUnknown", "1"}, {"K8S_POD_NAME", "vm"}})
+	assert.DeepEqual(t, secondary.rt.CapabilityArgs, map[string]interface{}{"ips": []string{"10.62.0.10/24"}})
+	assert.Assert(t, hasBridge(secondary.confList.Plugins))
+	assert.Equal(t, secondary.confList.Plugins[0].Network.Capabilities["ips"], true)
+	assert.Assert(t, !secondary.confList.Plugins[1].Network.Capabilities["portMappings"])
+
+	assert.Equal(t, attachments[2].rt.IfName, loIfName)
+
+	_, err = plugin.attachments("ignite-test", "", nil, []network.Attachment{{Name: "unknown"}}, nil)
+	assert.ErrorContains(t, err, `failed to load CNI network "unknown"`)
+}
+
+func TestAttachmentResult(t *testing.T) {
+	hostIntf, containerIntf := 0, 1
+	_, ipv4, _ := net.ParseCIDR("10.62.0.10/24")
+	_, hostIPv4, _ := net.ParseCIDR("10.62.0.1/24")
+
+	r := &current.Result{
+		Interfaces: []*current.Interface{
+			{Name: "ignite-tenant", Mac: "02:00:00:00:00:01"},
+			{Name: "eth1", Mac: "02:00:00:00:00:02", Sandbox: "/proc/1/ns/net"},
+		},
+		IPs: []*current.IPConfig{
+			{Interface: &hostIntf, Address: *hostIPv4},
+			{Interface: &containerIntf, Address: *ipv4, Gateway: net.ParseIP("10.62.0.1")},
+		},
+	}
+
+	result := attachmentResult("tenant-a", "eth1", r)
+	assert.DeepEqual(t, result, network.NetworkResult{
+		Name:      "tenant-a",
+		Interface: "eth1",
+		MAC:       "02:00:00:00:00:02",
+		Addresses: []network.Address{{IP: ipv4.IP, Gateway: net.ParseIP("10.62.0.1")}},
+	})
+}
+
+func TestDefaultCNIConf(t *testing.T) {
+	for _, ipv6 := range []bool{false, true} {
+		confList, err := cnilibrary.ConfListFromBytes([]byte(defaultCNIConf(ipv6)))
+		assert.NilError(t, err)
+		assert.Equal(t, confList.Name, defaultNetworkName)
+
+		var conf struct {
+			IPAM struct {
+				Ranges [][]struct {
+					Subnet string `json:"subnet"`
+				} `json:"ranges"`
+			} `json:"ipam"`
+		}
+		assert.NilError(t, json.Unmarshal(confList.Plugins[0].Bytes, &conf))
+
+		expected := []string{defaultSubnet}
+		if ipv6 {
+			expected = append(expected, defaultSubnet6)
+		}
+
+		var subnets []string
+		for _, r := range conf.IPAM.Ranges {
+			subnets = append(subnets, r[0].Subnet)
+		}
+		assert.DeepEqual(t, subnets, expected)
+	}
+}
+
+func TestDelAttachments(t *testing.T) {
+	binDir, err := ioutil.TempDir("", "ignite-cni-bin")
+	assert.NilError(t, err)
+	defer os.RemoveAll(binDir)
+
+	// The plugins record the interfaces they're called for, the failing one reports an error
+	calls := path.Join(binDir, "calls")
+	for name, script := range map[string]string{
+		"ok":   "#!/bin/sh\necho $CNI_IFNAME >> " + calls + "\n",
+		"fail": "#!/bin/sh\necho $CNI_IFNAME >> " + calls + "\necho '{\"cniVersion\": \"0.3.1\", \"code\": 100, \"msg\": \"failed\"}'\nexit 1\n",
+	} {
+		assert.NilError(t, ioutil.WriteFile(path.Join(binDir, name), []byte(script), 0755))
+	}
+
+	var attachments []attachment
+	for i, pluginType := range []string{"ok", "fail", "ok"} {
+		confList, err := cnilibrary.ConfListFromBytes([]byte(`{"cniVersion": "0.3.1", "name": "net` + string(rune('0'+i)) + `", "plugins": [{"type": "` + pluginType + `"}]}`))
+		assert.NilError(t, err)
+
+		attachments = append(attachments, attachment{
+			confList: confList,
+			rt: &cnilibrary.RuntimeConf{
+				ContainerID: "ignite-test",
+				NetNS:       "/proc/1/ns/net",
+				IfName:      "eth" + string(rune('0'+i)),
+			},
+		})
+	}
+
+	plugin := &cniNetworkPlugin{cniConfig: cnilibrary.NewCNIConfigWithCacheDir([]string{binDir}, binDir, nil)}
+	err = plugin.delAttachments("ignite-test", attachments)
+	assert.ErrorContains(t, err, `failed to remove network "net1"`)
+
+	// The container is detached from all networks in reverse order, despite the error
+	b, err := ioutil.ReadFile(calls)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "eth2\neth1\neth0\n")
+}
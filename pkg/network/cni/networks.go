@@ -0,0 +1,99 @@
+package cni
+
+import (
+	"encoding/json"
+	"fmt"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+)
+
+// NetworkConfList generates the CNI configuration list of a user-defined network. It's set up like the
+// default network, but with the bridge, subnet, gateway and masquerading given by the network's spec.
+func NetworkConfList(n *api.Network) ([]byte, error) {
+	ipRange := map[string]interface{}{
+		"subnet": n.Spec.Subnet,
+	}
+
+	if n.Spec.Gateway != nil {
+		ipRange["gateway"] = n.Spec.Gateway.String()
+	}
+
+	confList := map[string]interface{}{
+		"cniVersion": "0.4.0",
+		"name":       n.CNIName(),
+		"plugins": []interface{}{
+			map[string]interface{}{
+				"type":             "bridge",
+				"bridge":           n.Spec.BridgeName,
+				"isGateway":        true,
+				"isDefaultGateway": true,
+				"promiscMode":      true,
+				"ipMasq":           n.Spec.Masquerade,
+				"capabilities": map[string]interface{}{
+					"ips": true,
+				},
+				"ipam": map[string]interface{}{
+					"type":   "host-local",
+					"ranges": [][]interface{}{{ipRange}},
+				},
+			},
+			map[string]interface{}{
+				"type": "portmap",
+				"capabilities": map[string]interface{}{
+					"portMappings": true,
+				},
+			},
+			map[string]interface{}{
+				"type": "firewall",
+			},
+		},
+	}
+
+	return json.MarshalIndent(confList, "", "\t")
+}
+
+// secondaryConfList modifies a CNI configuration list for attaching a container to it as an additional network.
+// The default route is left to the main network, and the static IPs and port mappings are only applied to it,
+// unless the capability is to be kept as it's configured for the additional network itself.
+func secondaryConfList(b []byte, keepCapabilities ...string) ([]byte, error) {
+	var confList map[string]interface{}
+	if err := json.Unmarshal(b, &confList); err != nil {
+		return nil, err
+	}
+
+	plugins, ok := confList["plugins"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no plugins found")
+	}
+
+	for _, p := range plugins {
+		plugin, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid plugin configuration %v", p)
+		}
+
+		if plugin["type"] == "bridge" {
+			plugin["isDefaultGateway"] = false
+		}
+
+		if capabilities, ok := plugin["capabilities"].(map[string]interface{}); ok {
+			for _, name := range []string{"ips", "portMappings"} {
+				if !contains(keepCapabilities, name) {
+					delete(capabilities, name)
+				}
+			}
+		}
+	}
+
+	return json.Marshal(confList)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package cni
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"gotest.tools/assert"
+)
+
+func TestNetworkConfList(t *testing.T) {
+	cases := []struct {
+		name        string
+		spec        api.NetworkSpec
+		wantGateway interface{}
+	}{
+		{
+			name: "gateway",
+			spec: api.NetworkSpec{
+				Subnet:     "10.62.0.0/24",
+				Gateway:    net.ParseIP("10.62.0.254"),
+				BridgeName: "ignite-team-a",
+				Masquerade: true,
+			},
+			wantGateway: "10.62.0.254",
+		},
+		{
+			name: "default gateway",
+			spec: api.NetworkSpec{
+				Subnet:     "10.62.1.0/24",
+				BridgeName: "ignite-team-b",
+				Isolated:   true,
+			},
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			n := &api.Network{Spec: rt.spec}
+			n.SetName("team")
+
+			b, err := NetworkConfList(n)
+			assert.NilError(t, err)
+
+			var confList map[string]interface{}
+			assert.NilError(t, json.Unmarshal(b, &confList))
+			assert.Equal(t, confList["name"], "ignite-team")
+
+			plugins := confList["plugins"].([]interface{})
+			assert.Equal(t, len(plugins), 3)
+
+			bridge := plugins[0].(map[string]interface{})
+			assert.Equal(t, bridge["bridge"], rt.spec.BridgeName)
+			assert.Equal(t, bridge["ipMasq"], rt.spec.Masquerade)
+			assert.Equal(t, bridge["isDefaultGateway"], true)
+
+			ipRange := bridge["ipam"].(map[string]interface{})["ranges"].([]interface{})[0].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, ipRange["subnet"], rt.spec.Subnet)
+			assert.Equal(t, ipRange["gateway"], rt.wantGateway)
+		})
+	}
+}
+
+func TestSecondaryConfList(t *testing.T) {
+	n := &api.Network{Spec: api.NetworkSpec{Subnet: "10.62.0.0/24", BridgeName: "ignite-team-a"}}
+	b, err := NetworkConfList(n)
+	assert.NilError(t, err)
+
+	b, err = secondaryConfList(b)
+	assert.NilError(t, err)
+
+	var confList map[string]interface{}
+	assert.NilError(t, json.Unmarshal(b, &confList))
+
+	for _, p := range confList["plugins"].([]interface{}) {
+		plugin := p.(map[string]interface{})
+		if plugin["type"] == "bridge" {
+			assert.Equal(t, plugin["isDefaultGateway"], false)
+		}
+
+		if capabilities, ok := plugin["capabilities"].(map[string]interface{}); ok {
+			assert.Equal(t, len(capabilities), 0, "plugin %v", plugin["type"])
+		}
+	}
+
+	b, err = NetworkConfList(n)
+	assert.NilError(t, err)
+
+	b, err = secondaryConfList(b, "ips")
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(b, &confList))
+
+	bridge := confList["plugins"].([]interface{})[0].(map[string]interface{})
+	assert.DeepEqual(t, bridge["capabilities"], map[string]interface{}{"ips": true})
+
+	_, err = secondaryConfList([]byte(`{"name": "invalid"}`))
+	assert.ErrorContains(t, err, "no plugins found")
+}
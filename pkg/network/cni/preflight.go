@@ -0,0 +1,94 @@
+package cni
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cnilibrary "github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/invoke"
+	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/preflight"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// PluginChecker verifies that the binary of a CNI plugin is in one of the CNI binary directories
+type PluginChecker struct {
+	pluginType string
+	binDirs    []string
+}
+
+var _ preflight.Checker = PluginChecker{}
+
+func NewPluginChecker(pluginType string, binDirs []string) PluginChecker {
+	return PluginChecker{
+		pluginType: pluginType,
+		binDirs:    binDirs,
+	}
+}
+
+func (pc PluginChecker) Check() error {
+	if _, err := invoke.FindInPath(pc.pluginType, pc.binDirs); err != nil {
+		return fmt.Errorf("CNI plugin %q not found in %v", pc.pluginType, pc.binDirs)
+	}
+	return nil
+}
+
+func (pc PluginChecker) Name() string {
+	return fmt.Sprintf("CNIPlugin-%s", pc.pluginType)
+}
+
+func (pc PluginChecker) Type() string {
+	return "CNIPlugin"
+}
+
+// PluginTypes returns the types of the CNI plugins used by the given configuration lists, including their
+// IPAM plugins. The loopback plugin is always included, as every container is attached to the loopback network.
+func PluginTypes(confLists ...*cnilibrary.NetworkConfigList) []string {
+	types := map[string]bool{"loopback": true}
+	for _, confList := range confLists {
+		for _, p := range confList.Plugins {
+			types[p.Network.Type] = true
+			if len(p.Network.IPAM.Type) > 0 {
+				types[p.Network.IPAM.Type] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(types))
+	for t := range types {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// DefaultConfList returns the configuration list of the default network, the first network of the CNI configuration
+// directory like go-cni loads it. Without any networks, that's the network ignite writes to the directory on start.
+func DefaultConfList(confDir string) (*cnilibrary.NetworkConfigList, error) {
+	if !util.DirExists(confDir) || util.DirEmpty(confDir) {
+		return cnilibrary.ConfListFromBytes([]byte(defaultCNIConf(network.IPv6Enabled())))
+	}
+
+	files, err := cnilibrary.ConfFiles(confDir, []string{".conf", ".conflist", ".json"})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no network configuration found in %q", confDir)
+	}
+
+	sort.Strings(files)
+	if strings.HasSuffix(files[0], ".conflist") {
+		return cnilibrary.ConfListFromFile(files[0])
+	}
+
+	conf, err := cnilibrary.ConfFromFile(files[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return cnilibrary.ConfListFromConf(conf)
+}
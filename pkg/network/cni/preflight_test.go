@@ -0,0 +1,50 @@
+package cni
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDefaultConfListPluginTypes(t *testing.T) {
+	confDir, err := ioutil.TempDir("", "ignite-cni")
+	assert.NilError(t, err)
+	defer os.RemoveAll(confDir)
+
+	// Without any networks, the default network of ignite is used
+	confList, err := DefaultConfList(confDir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, PluginTypes(confList), []string{"bridge", "firewall", "host-local", "loopback", "portmap"})
+
+	// Otherwise the first network of the directory is, single configurations are converted to lists
+	assert.NilError(t, ioutil.WriteFile(path.Join(confDir, "20-other.conflist"), []byte(`{
+	"cniVersion": "0.4.0",
+	"name": "other",
+	"plugins": [{"type": "bridge"}]
+}`), 0644))
+	assert.NilError(t, ioutil.WriteFile(path.Join(confDir, "10-macvlan.conf"), []byte(`{
+	"cniVersion": "0.4.0",
+	"name": "macvlan",
+	"type": "macvlan",
+	"ipam": {"type": "dhcp"}
+}`), 0644))
+
+	confList, err = DefaultConfList(confDir)
+	assert.NilError(t, err)
+	assert.Equal(t, confList.Name, "macvlan")
+	assert.DeepEqual(t, PluginTypes(confList), []string{"dhcp", "loopback", "macvlan"})
+}
+
+func TestPluginChecker(t *testing.T) {
+	binDir, err := ioutil.TempDir("", "ignite-cni-bin")
+	assert.NilError(t, err)
+	defer os.RemoveAll(binDir)
+
+	assert.NilError(t, ioutil.WriteFile(path.Join(binDir, "bridge"), nil, 0755))
+
+	assert.NilError(t, NewPluginChecker("bridge", []string{"/nonexistent", binDir}).Check())
+	assert.ErrorContains(t, NewPluginChecker("portmap", []string{binDir}).Check(), `CNI plugin "portmap" not found`)
+}
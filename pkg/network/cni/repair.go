@@ -0,0 +1,483 @@
+package cni
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	cnilibrary "github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/version"
+	"github.com/coreos/go-iptables/iptables"
+	log "github.com/sirupsen/logrus"
+	"github.com/weaveworks/ignite/pkg/network"
+)
+
+const (
+	// hostPortDNATChain is the chain of the portmap plugin jumping to the port forwards of the containers
+	hostPortDNATChain = "CNI-HOSTPORT-DNAT"
+	// leaseDirName is the directory of the host-local IPAM plugin in the CNI state directory
+	leaseDirName = "networks"
+	// resultDirName is the directory of the results libcni caches on ADD in the CNI state directory
+	resultDirName = "results"
+)
+
+// commentRegexp matches the iptables comments the bridge and portmap plugins tag their rules of containers with,
+// e.g. /* name: "ignite-cni-bridge" id: "ignite-9a10b07d7c0d4ce9" */ or /* dnat name: "..." id: "..." */
+var commentRegexp = regexp.MustCompile(`name: \\?"([^"\\]+)\\?" id: \\?"([^"\\]+)\\?"`)
+
+var _ network.Repairer = &cniNetworkPlugin{}
+
+// lease is a network a container is attached to according to the state of CNI
+type lease struct {
+	network     string
+	containerID string
+	ifName      string
+	// confList is the configuration the container was attached with, if known
+	confList *cnilibrary.NetworkConfigList
+	rt       *cnilibrary.RuntimeConf
+}
+
+// ReleaseLeaked detaches the leaked containers from the CNI networks. The cached results of libcni and the leases of
+// the host-local IPAM plugin tell which containers are attached to which networks, they're removed with CNI DEL
+// without a network namespace. The masquerading and port forwarding rules left without a lease are removed last.
+func (plugin *cniNetworkPlugin) ReleaseLeaked(networks []network.Attachment, leaked network.LeakedFunc) ([]string, error) {
+	if err := plugin.initialize(); err != nil {
+		return nil, err
+	}
+
+	confLists, owned, err := plugin.knownConfLists(networks)
+	if err != nil {
+		return nil, err
+	}
+
+	leases, err := cachedLeases(cnilibrary.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ipamLeases, err := ipamLeases(cnilibrary.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The leases of the IPAM plugin are only needed for the containers without a cached result
+	cached := make(map[string]bool, len(leases))
+	for _, l := range leases {
+		cached[l.network+"/"+l.containerID] = true
+	}
+
+	for _, l := range ipamLeases {
+		if !cached[l.network+"/"+l.containerID] {
+			leases = append(leases, l)
+		}
+	}
+
+	released := map[string]bool{}
+	for _, l := range leases {
+		if !leaked(l.containerID, owned[l.network]) {
+			continue
+		}
+
+		if l.confList == nil {
+			l.confList = confLists[l.network]
+		}
+
+		if err := plugin.releaseLease(l); err != nil {
+			log.Warnf("Failed to release container %q from CNI network %q: %v", l.containerID, l.network, err)
+			continue
+		}
+
+		released[l.containerID] = true
+	}
+
+	// Remove the rules of the containers, which aren't removed by CNI without a network namespace or lease
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		ipt, err := iptables.NewWithProtocol(protocol)
+		if err != nil {
+			// The host may not support IPv6, in which case there are no rules to clean up
+			log.Debugf("Skipping the rules of protocol %v: %v", protocol, err)
+			continue
+		}
+
+		containerIDs, err := removeLeakedPortForwards(ipt, owned, leaked)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, containerID := range containerIDs {
+			released[containerID] = true
+		}
+
+		rules, err := ipt.Stats("nat", "POSTROUTING")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range rules {
+			name, containerID := ruleComment(rule)
+			if len(containerID) == 0 || !leaked(containerID, owned[name]) {
+				continue
+			}
+
+			if err := cleanupBridges([]string{name}, containerID); err != nil {
+				return nil, err
+			}
+
+			released[containerID] = true
+		}
+	}
+
+	containerIDs := make([]string, 0, len(released))
+	for containerID := range released {
+		containerIDs = append(containerIDs, containerID)
+	}
+	sort.Strings(containerIDs)
+
+	return containerIDs, nil
+}
+
+// CheckContainerNetwork verifies the networking of a running container with CNI CHECK. Networks of
+// configuration versions without CHECK, before 0.4.0, are skipped.
+func (plugin *cniNetworkPlugin) CheckContainerNetwork(containerID string, networks []network.Attachment) error {
+	c, err := plugin.runtime.InspectContainer(containerID)
+	if err != nil {
+		return fmt.Errorf("CNI failed to retrieve network namespace path: %v", err)
+	}
+
+	netnsPath := fmt.Sprintf(netNSPathFmt, c.PID)
+
+	var attachments []attachment
+	if len(networks) > 0 {
+		if attachments, err = plugin.attachments(containerID, netnsPath, nil, networks, nil); err != nil {
+			return err
+		}
+	} else {
+		if err := plugin.initialize(); err != nil {
+			return err
+		}
+
+		for _, n := range plugin.cni.GetConfig().Networks {
+			confList, err := cnilibrary.ConfListFromBytes([]byte(n.Config.Source))
+			if err != nil {
+				return err
+			}
+
+			attachments = append(attachments, attachment{
+				confList: confList,
+				rt: &cnilibrary.RuntimeConf{
+					ContainerID: containerID,
+					NetNS:       netnsPath,
+					IfName:      n.IFName,
+				},
+			})
+		}
+	}
+
+	for _, a := range attachments {
+		if a.rt.IfName == loIfName {
+			continue
+		}
+
+		if ok, err := version.GreaterThanOrEqualTo(a.confList.CNIVersion, "0.4.0"); err != nil || !ok {
+			log.Debugf("Skipping the check of CNI network %q of version %q", a.confList.Name, a.confList.CNIVersion)
+			continue
+		}
+
+		if err := plugin.cniConfig.CheckNetworkList(context.Background(), a.confList, a.rt); err != nil {
+			return fmt.Errorf("network %q: %v", a.confList.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// knownConfLists returns the configuration lists of the CNI configuration directory and the given networks by their
+// names, and which of them are ignite's own. Containers can be removed from them if their cached result is missing.
+func (plugin *cniNetworkPlugin) knownConfLists(networks []network.Attachment) (map[string]*cnilibrary.NetworkConfigList, map[string]bool, error) {
+	confLists := map[string]*cnilibrary.NetworkConfigList{}
+	owned := map[string]bool{defaultNetworkName: true}
+
+	files, err := cnilibrary.ConfFiles(plugin.confDir, []string{".conflist"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		confList, err := cnilibrary.ConfListFromFile(file)
+		if err != nil {
+			log.Warnf("Skipping invalid CNI configuration %q: %v", file, err)
+			continue
+		}
+
+		confLists[confList.Name] = confList
+	}
+
+	// The user-defined networks are ignite's own, they're given by the paths of their configuration lists
+	for _, n := range networks {
+		if len(n.ConfListPath) == 0 {
+			continue
+		}
+
+		confList, err := cnilibrary.ConfListFromFile(n.ConfListPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		confLists[confList.Name] = confList
+		owned[confList.Name] = true
+	}
+
+	return confLists, owned, nil
+}
+
+// releaseLease removes the container from the network with CNI DEL, the network namespace of the
+// container is gone. Without a known configuration, the address is released from the IPAM state directly.
+func (plugin *cniNetworkPlugin) releaseLease(l *lease) error {
+	if l.confList == nil {
+		log.Infof("Releasing the addresses of container %q on unknown CNI network %q", l.containerID, l.network)
+		return releaseIPAMLeases(cnilibrary.CacheDir, l.network, l.containerID)
+	}
+
+	rt := l.rt
+	if rt == nil {
+		rt = &cnilibrary.RuntimeConf{
+			ContainerID: l.containerID,
+			IfName:      l.ifName,
+		}
+	}
+	rt.NetNS = ""
+
+	log.Infof("Removing container %q from CNI network %q", l.containerID, l.network)
+	if err := plugin.cniConfig.DelNetworkList(context.Background(), l.confList, rt); err != nil {
+		return err
+	}
+
+	// The bridge plugin doesn't remove its masquerading rules without a network namespace
+	if hasBridge(l.confList.Plugins) {
+		if err := cleanupBridges([]string{l.network}, l.containerID); err != nil {
+			return err
+		}
+	}
+
+	// Addresses may be left if the IPAM plugin of the cached configuration differs
+	return releaseIPAMLeases(cnilibrary.CacheDir, l.network, l.containerID)
+}
+
+// cachedLeases returns the networks containers are attached to according to the results cached by libcni
+func cachedLeases(stateDir string) ([]*lease, error) {
+	files, err := ioutil.ReadDir(filepath.Join(stateDir, resultDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var leases []*lease
+	for _, file := range files {
+		path := filepath.Join(stateDir, resultDirName, file.Name())
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var cached struct {
+			ContainerID    string                 `json:"containerId"`
+			Config         []byte                 `json:"config"`
+			IfName         string                 `json:"ifName"`
+			NetworkName    string                 `json:"networkName"`
+			CniArgs        [][2]string            `json:"cniArgs,omitempty"`
+			CapabilityArgs map[string]interface{} `json:"capabilityArgs,omitempty"`
+		}
+
+		// Results cached by older versions of libcni don't record the network and the configuration
+		if err := json.Unmarshal(b, &cached); err != nil || len(cached.NetworkName) == 0 || len(cached.Config) == 0 {
+			log.Debugf("Skipping cached CNI result %q", path)
+			continue
+		}
+
+		confList, err := cnilibrary.ConfListFromBytes(cached.Config)
+		if err != nil {
+			log.Debugf("Skipping cached CNI result %q: %v", path, err)
+			continue
+		}
+
+		leases = append(leases, &lease{
+			network:     cached.NetworkName,
+			containerID: cached.ContainerID,
+			ifName:      cached.IfName,
+			confList:    confList,
+			rt: &cnilibrary.RuntimeConf{
+				ContainerID:    cached.ContainerID,
+				IfName:         cached.IfName,
+				Args:           cached.CniArgs,
+				CapabilityArgs: cached.CapabilityArgs,
+			},
+		})
+	}
+
+	return leases, nil
+}
+
+// ipamLeases returns the networks containers are attached to according to the addresses reserved by the host-local
+// IPAM plugin. Its files are named by the address, and hold the container ID and the interface on separate lines.
+func ipamLeases(stateDir string) ([]*lease, error) {
+	networkDirs, err := ioutil.ReadDir(filepath.Join(stateDir, leaseDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var leases []*lease
+	seen := map[string]bool{}
+	for _, networkDir := range networkDirs {
+		if !networkDir.IsDir() {
+			continue
+		}
+
+		err := forEachIPAMLease(stateDir, networkDir.Name(), func(_, containerID, ifName string) error {
+			if key := networkDir.Name() + "/" + containerID + "/" + ifName; !seen[key] {
+				seen[key] = true
+				leases = append(leases, &lease{
+					network:     networkDir.Name(),
+					containerID: containerID,
+					ifName:      ifName,
+				})
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return leases, nil
+}
+
+// releaseIPAMLeases removes the addresses the host-local IPAM plugin reserved for the container on the network
+func releaseIPAMLeases(stateDir, networkName, containerID string) error {
+	return forEachIPAMLease(stateDir, networkName, func(path, id, _ string) error {
+		if id != containerID {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// forEachIPAMLease calls fn with the path, the container ID and the interface of the addresses reserved on the network
+func forEachIPAMLease(stateDir, networkName string, fn func(path, containerID, ifName string) error) error {
+	dir := filepath.Join(stateDir, leaseDirName, networkName)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		// The directory also holds the lock and the last reserved address of each range
+		if file.IsDir() || file.Name() == "lock" || strings.HasPrefix(file.Name(), "last_reserved_ip") {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		lines := strings.Fields(string(b))
+		if len(lines) == 0 {
+			continue
+		}
+
+		// Older versions of the plugin only record the container ID
+		ifName := "eth0"
+		if len(lines) > 1 {
+			ifName = lines[1]
+		}
+
+		if err := fn(path, lines[0], ifName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeLeakedPortForwards removes the port forwards of the portmap plugin for the leaked containers. The jumps to the
+// chains of the containers are removed by their rule number, in reverse order so the numbers of the others don't change.
+func removeLeakedPortForwards(ipt *iptables.IPTables, owned map[string]bool, leaked network.LeakedFunc) ([]string, error) {
+	chains, err := ipt.ListChains("nat")
+	if err != nil {
+		return nil, err
+	}
+
+	if !contains(chains, hostPortDNATChain) {
+		return nil, nil
+	}
+
+	rules, err := ipt.Stats("nat", hostPortDNATChain)
+	if err != nil {
+		return nil, err
+	}
+
+	const statTargetIndex = 2
+	var containerIDs []string
+	for i := len(rules) - 1; i >= 0; i-- {
+		name, containerID := ruleComment(rules[i])
+		if len(containerID) == 0 || !leaked(containerID, owned[name]) {
+			continue
+		}
+
+		log.Infof("Removing the port forwards of container %q on CNI network %q", containerID, name)
+		if err := ipt.Delete("nat", hostPortDNATChain, strconv.Itoa(i+1)); err != nil {
+			return nil, err
+		}
+
+		if target := rules[i][statTargetIndex]; strings.HasPrefix(target, "CNI-DN-") && contains(chains, target) {
+			if err := ipt.ClearChain("nat", target); err != nil {
+				return nil, err
+			}
+
+			if err := ipt.DeleteChain("nat", target); err != nil {
+				return nil, err
+			}
+		}
+
+		containerIDs = append(containerIDs, containerID)
+	}
+
+	return containerIDs, nil
+}
+
+// ruleComment returns the network and the container ID of the comment of a rule listed with Stats, if any
+func ruleComment(rule []string) (string, string) {
+	const statOptionsIndex = 9
+	if len(rule) <= statOptionsIndex {
+		return "", ""
+	}
+
+	match := commentRegexp.FindStringSubmatch(rule[statOptionsIndex])
+	if match == nil {
+		return "", ""
+	}
+
+	return match[1], match[2]
+}
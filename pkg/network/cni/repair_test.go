@@ -0,0 +1,119 @@
+package cni
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCachedLeases(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "ignite-cni")
+	assert.NilError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	resultDir := path.Join(stateDir, resultDirName)
+	assert.NilError(t, os.MkdirAll(resultDir, 0755))
+
+	// The configuration is cached base64 encoded
+	cached := `{
+		"kind": "cniCacheV1",
+		"containerId": "ignite-9a10b07d7c0d4ce9",
+		"config": "eyJjbmlWZXJzaW9uIjoiMC40LjAiLCJuYW1lIjoiaWduaXRlLWNuaS1icmlkZ2UiLCJwbHVnaW5zIjpbeyJ0eXBlIjoiYnJpZGdlIn1dfQ==",
+		"ifName": "eth0",
+		"networkName": "ignite-cni-bridge",
+		"cniArgs": [["IgnoreUnknown", "1"]],
+		"capabilityArgs": {"portMappings": []},
+		"result": {}
+	}`
+	assert.NilError(t, ioutil.WriteFile(path.Join(resultDir, "ignite-cni-bridge-ignite-9a10b07d7c0d4ce9-eth0"), []byte(cached), 0600))
+	// Results of older versions of libcni are skipped
+	assert.NilError(t, ioutil.WriteFile(path.Join(resultDir, "ignite-cni-bridge-ignite-0000000000000000-eth0"), []byte(`{"cniVersion": "0.3.1"}`), 0600))
+
+	leases, err := cachedLeases(stateDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(leases), 1)
+	assert.Equal(t, leases[0].network, "ignite-cni-bridge")
+	assert.Equal(t, leases[0].containerID, "ignite-9a10b07d7c0d4ce9")
+	assert.Equal(t, leases[0].ifName, "eth0")
+	assert.Equal(t, leases[0].confList.Name, "ignite-cni-bridge")
+	assert.Equal(t, leases[0].confList.CNIVersion, "0.4.0")
+	assert.DeepEqual(t, leases[0].rt.Args, [][2]string{{"IgnoreUnknown", "1"}})
+}
+
+func TestIPAMLeases(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "ignite-cni")
+	assert.NilError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	networkDir := path.Join(stateDir, leaseDirName, "tenant-a")
+	assert.NilError(t, os.MkdirAll(networkDir, 0755))
+
+	files := map[string]string{
+		"10.62.0.2":              "ignite-9a10b07d7c0d4ce9\r\neth1",
+		"fd69:676e:6974:6500::2": "ignite-9a10b07d7c0d4ce9\r\neth1",
+		"10.62.0.3":              "ignite-1c0a4d8e5b3f2a71",
+		"last_reserved_ip.0":     "10.62.0.3",
+		"lock":                   "",
+	}
+	for name, content := range files {
+		assert.NilError(t, ioutil.WriteFile(path.Join(networkDir, name), []byte(content), 0644))
+	}
+
+	leases, err := ipamLeases(stateDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(leases), 2)
+	for _, l := range leases {
+		assert.Equal(t, l.network, "tenant-a")
+		switch l.containerID {
+		case "ignite-9a10b07d7c0d4ce9":
+			assert.Equal(t, l.ifName, "eth1")
+		case "ignite-1c0a4d8e5b3f2a71":
+			assert.Equal(t, l.ifName, "eth0")
+		default:
+			t.Fatalf("unexpected lease of container %q", l.containerID)
+		}
+	}
+
+	assert.NilError(t, releaseIPAMLeases(stateDir, "tenant-a", "ignite-9a10b07d7c0d4ce9"))
+	remaining, err := ioutil.ReadDir(networkDir)
+	assert.NilError(t, err)
+	var names []string
+	for _, f := range remaining {
+		names = append(names, f.Name())
+	}
+	assert.DeepEqual(t, names, []string{"10.62.0.3", "last_reserved_ip.0", "lock"})
+}
+
+func TestRuleComment(t *testing.T) {
+	tests := []struct {
+		rule        []string
+		network     string
+		containerID string
+	}{
+		{
+			rule:        []string{"0", "0", "CNI-a1b2c3d4e5f6a7b8c9d0e1f2", "all", "--", "*", "*", "10.61.0.2", "0.0.0.0/0", `/* name: "ignite-cni-bridge" id: "ignite-9a10b07d7c0d4ce9" */`},
+			network:     "ignite-cni-bridge",
+			containerID: "ignite-9a10b07d7c0d4ce9",
+		},
+		{
+			rule:        []string{"0", "0", "CNI-DN-a1b2c3d4e5f6a7b8c9d0e", "tcp", "--", "*", "*", "0.0.0.0/0", "0.0.0.0/0", `/* dnat name: \"tenant-a\" id: \"ignite-1c0a4d8e5b3f2a71\" */ multiport dports 8080`},
+			network:     "tenant-a",
+			containerID: "ignite-1c0a4d8e5b3f2a71",
+		},
+		{
+			rule: []string{"0", "0", "MASQUERADE", "all", "--", "*", "!docker0", "172.17.0.0/16", "0.0.0.0/0", ""},
+		},
+		{
+			rule: []string{"0", "0", "ACCEPT"},
+		},
+	}
+
+	for _, rt := range tests {
+		network, containerID := ruleComment(rt.rule)
+		assert.Equal(t, network, rt.network)
+		assert.Equal(t, containerID, rt.containerID)
+	}
+}
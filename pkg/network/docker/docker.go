@@ -26,25 +26,36 @@ func (*dockerNetworkPlugin) PrepareContainerSpec(_ *runtime.ContainerConfig) err
 	return nil
 }
 
-func (plugin *dockerNetworkPlugin) SetupContainerNetwork(containerID string, _ ...meta.PortMapping) (*network.Result, error) {
+func (plugin *dockerNetworkPlugin) SetupContainerNetwork(containerID string, _ []net.IP, _ []network.Attachment, _ ...meta.PortMapping) (*network.Result, error) {
+	// The default Docker bridge doesn't support static IPs, VMs requesting them are rejected by validation
 	// This is used to fetch the IP address the runtime gives to the VM container
 	result, err := plugin.runtime.InspectContainer(containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inspect container %s: %v", containerID, err)
 	}
 
+	var addresses []network.Address
+	if ip := result.IPAddress.To4(); ip != nil {
+		addresses = append(addresses, network.Address{
+			IP: ip,
+			// TODO: Make this auto-detect if the gateway is not using the standard setup
+			Gateway: net.IPv4(ip[0], ip[1], ip[2], 1),
+		})
+	}
+
+	// The container only has an IPv6 address if IPv6 is enabled for the Docker network
+	if result.IPv6Address != nil {
+		addresses = append(addresses, network.Address{
+			IP: result.IPv6Address,
+		})
+	}
+
 	return &network.Result{
-		Addresses: []network.Address{
-			{
-				IP: result.IPAddress,
-				// TODO: Make this auto-detect if the gateway is not using the standard setup
-				Gateway: net.IPv4(result.IPAddress[0], result.IPAddress[1], result.IPAddress[2], 1),
-			},
-		},
+		Addresses: addresses,
 	}, nil
 }
 
-func (*dockerNetworkPlugin) RemoveContainerNetwork(_ string, _ ...meta.PortMapping) error {
+func (*dockerNetworkPlugin) RemoveContainerNetwork(_ string, _ []network.Attachment, _ ...meta.PortMapping) error {
 	// no-op for docker, this is handled automatically
 	return nil
 }
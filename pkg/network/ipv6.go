@@ -0,0 +1,15 @@
+package network
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// disableIPv6Sysctl is set if IPv6 is disabled on all interfaces, it's missing if the kernel has no IPv6 support
+const disableIPv6Sysctl = "/proc/sys/net/ipv6/conf/all/disable_ipv6"
+
+// IPv6Enabled returns whether the host supports IPv6 and hasn't disabled it
+func IPv6Enabled() bool {
+	b, err := ioutil.ReadFile(disableIPv6Sysctl)
+	return err == nil && strings.TrimSpace(string(b)) == "0"
+}
@@ -0,0 +1,36 @@
+package none
+
+import (
+	"net"
+
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/runtime"
+)
+
+type noneNetworkPlugin struct{}
+
+// GetNoneNetworkPlugin returns the network plugin for VMs without networking. The
+// container only has a loopback interface, and ignite-spawn gives the VM no interfaces.
+func GetNoneNetworkPlugin() network.Plugin {
+	return &noneNetworkPlugin{}
+}
+
+func (*noneNetworkPlugin) Name() network.PluginName {
+	return network.PluginNone
+}
+
+func (*noneNetworkPlugin) PrepareContainerSpec(container *runtime.ContainerConfig) error {
+	container.NetworkMode = "none"
+	return nil
+}
+
+func (*noneNetworkPlugin) SetupContainerNetwork(_ string, _ []net.IP, _ []network.Attachment, _ ...meta.PortMapping) (*network.Result, error) {
+	// no-op, the VM has no network
+	return &network.Result{}, nil
+}
+
+func (*noneNetworkPlugin) RemoveContainerNetwork(_ string, _ []network.Attachment, _ ...meta.PortMapping) error {
+	// no-op, the VM has no network
+	return nil
+}
@@ -18,14 +18,56 @@ type Plugin interface {
 
 	// SetupContainerNetwork sets up the networking for a container
 	// This is ran _after_ the container has been started
-	SetupContainerNetwork(containerID string, portmappings ...meta.PortMapping) (*Result, error)
+	// The given static IPs are requested for the container if the plugin supports it,
+	// otherwise ignite-spawn configures them for the VM
+	// The networks are the user-defined networks and the networks of the CNI configuration directory to
+	// attach the container to instead of the default network, only plugins supporting them (see SupportsNetworks) get any
+	SetupContainerNetwork(containerID string, ips []net.IP, networks []Attachment, portmappings ...meta.PortMapping) (*Result, error)
 
 	// RemoveContainerNetwork is the method called before a container using the network plugin can be deleted
-	RemoveContainerNetwork(containerID string, portmappings ...meta.PortMapping) error
+	RemoveContainerNetwork(containerID string, networks []Attachment, portmappings ...meta.PortMapping) error
+}
+
+// Repairer is implemented by network plugins keeping state on the host, which
+// is left behind if containers are gone without their networking being removed,
+// e.g. after a host reboot or a crash of the container runtime
+type Repairer interface {
+	// ReleaseLeaked removes the networking of the containers the given function reports as leaked, and
+	// returns their IDs. The networks are the user-defined networks the containers may be attached to.
+	ReleaseLeaked(networks []Attachment, leaked LeakedFunc) ([]string, error)
+
+	// CheckContainerNetwork verifies the networking of a running container is intact
+	CheckContainerNetwork(containerID string, networks []Attachment) error
+}
+
+// LeakedFunc reports whether the networking of the container is leaked. Owned is true if the network
+// is managed by ignite, containers on other networks may not have been started by ignite.
+type LeakedFunc func(containerID string, owned bool) bool
+
+// Attachment attaches a container to a network other than the default network of the plugin
+type Attachment struct {
+	// ConfListPath is the path of the CNI configuration list of a user-defined network
+	ConfListPath string
+	// Name is the name of a network in the CNI configuration directory, used if ConfListPath is unset
+	Name string
+	// Args are passed to the plugins of the network as CNI_ARGS
+	Args map[string]string
+	// Capabilities are the runtime configuration of the capabilities of the plugins of the network
+	Capabilities map[string]interface{}
 }
 
 type Result struct {
 	Addresses []Address
+	// Networks are the results per network, in the order of the container's interfaces
+	Networks []NetworkResult
+}
+
+// NetworkResult is the result of attaching a container to a network
+type NetworkResult struct {
+	Name      string
+	Interface string
+	MAC       string
+	Addresses []Address
 }
 
 type Address struct {
@@ -47,12 +89,26 @@ const (
 	PluginCNI PluginName = "cni"
 	// PluginDockerBridge specifies the default docker bridge network is used
 	PluginDockerBridge PluginName = "docker-bridge"
+	// PluginBridge specifies the network mode where ignite manages a bridge itself, without CNI or Docker
+	PluginBridge PluginName = "bridge"
+	// PluginIsolated specifies the network mode where VMs can only reach each other and the host
+	PluginIsolated PluginName = "isolated"
+	// PluginNone specifies the network mode where VMs have no network interfaces
+	PluginNone PluginName = "none"
 )
 
+// SupportsNetworks returns true if the network plugin can attach containers to user-defined networks
+func (pn PluginName) SupportsNetworks() bool {
+	return pn == PluginCNI
+}
+
 // ListPlugins gets the list of available network plugins
 func ListPlugins() []PluginName {
 	return []PluginName{
 		PluginCNI,
 		PluginDockerBridge,
+		PluginBridge,
+		PluginIsolated,
+		PluginNone,
 	}
 }
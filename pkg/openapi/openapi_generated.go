@@ -14,92 +14,105 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.BlockDeviceVolume": schema_pkg_apis_ignite_v1alpha2_BlockDeviceVolume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.FileMapping":       schema_pkg_apis_ignite_v1alpha2_FileMapping(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Image":             schema_pkg_apis_ignite_v1alpha2_Image(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.ImageSpec":         schema_pkg_apis_ignite_v1alpha2_ImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.ImageStatus":       schema_pkg_apis_ignite_v1alpha2_ImageStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Kernel":            schema_pkg_apis_ignite_v1alpha2_Kernel(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.KernelSpec":        schema_pkg_apis_ignite_v1alpha2_KernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.KernelStatus":      schema_pkg_apis_ignite_v1alpha2_KernelStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.OCIImageSource":    schema_pkg_apis_ignite_v1alpha2_OCIImageSource(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Pool":              schema_pkg_apis_ignite_v1alpha2_Pool(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolDevice":        schema_pkg_apis_ignite_v1alpha2_PoolDevice(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolSpec":          schema_pkg_apis_ignite_v1alpha2_PoolSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolStatus":        schema_pkg_apis_ignite_v1alpha2_PoolStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Runtime":           schema_pkg_apis_ignite_v1alpha2_Runtime(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.SSH":               schema_pkg_apis_ignite_v1alpha2_SSH(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VM":                schema_pkg_apis_ignite_v1alpha2_VM(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMImageSpec":       schema_pkg_apis_ignite_v1alpha2_VMImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMKernelSpec":      schema_pkg_apis_ignite_v1alpha2_VMKernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMNetworkSpec":     schema_pkg_apis_ignite_v1alpha2_VMNetworkSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMSandboxSpec":     schema_pkg_apis_ignite_v1alpha2_VMSandboxSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMSpec":            schema_pkg_apis_ignite_v1alpha2_VMSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMStatus":          schema_pkg_apis_ignite_v1alpha2_VMStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMStorageSpec":     schema_pkg_apis_ignite_v1alpha2_VMStorageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Volume":            schema_pkg_apis_ignite_v1alpha2_Volume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VolumeMount":       schema_pkg_apis_ignite_v1alpha2_VolumeMount(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.BlockDeviceVolume": schema_pkg_apis_ignite_v1alpha3_BlockDeviceVolume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Configuration":     schema_pkg_apis_ignite_v1alpha3_Configuration(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ConfigurationSpec": schema_pkg_apis_ignite_v1alpha3_ConfigurationSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.FileMapping":       schema_pkg_apis_ignite_v1alpha3_FileMapping(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Image":             schema_pkg_apis_ignite_v1alpha3_Image(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ImageSpec":         schema_pkg_apis_ignite_v1alpha3_ImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ImageStatus":       schema_pkg_apis_ignite_v1alpha3_ImageStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Kernel":            schema_pkg_apis_ignite_v1alpha3_Kernel(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.KernelSpec":        schema_pkg_apis_ignite_v1alpha3_KernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.KernelStatus":      schema_pkg_apis_ignite_v1alpha3_KernelStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Network":           schema_pkg_apis_ignite_v1alpha3_Network(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.OCIImageSource":    schema_pkg_apis_ignite_v1alpha3_OCIImageSource(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Pool":              schema_pkg_apis_ignite_v1alpha3_Pool(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolDevice":        schema_pkg_apis_ignite_v1alpha3_PoolDevice(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolSpec":          schema_pkg_apis_ignite_v1alpha3_PoolSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolStatus":        schema_pkg_apis_ignite_v1alpha3_PoolStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Runtime":           schema_pkg_apis_ignite_v1alpha3_Runtime(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.SSH":               schema_pkg_apis_ignite_v1alpha3_SSH(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VM":                schema_pkg_apis_ignite_v1alpha3_VM(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMImageSpec":       schema_pkg_apis_ignite_v1alpha3_VMImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMKernelSpec":      schema_pkg_apis_ignite_v1alpha3_VMKernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMNetworkSpec":     schema_pkg_apis_ignite_v1alpha3_VMNetworkSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMSandboxSpec":     schema_pkg_apis_ignite_v1alpha3_VMSandboxSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMSpec":            schema_pkg_apis_ignite_v1alpha3_VMSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMStatus":          schema_pkg_apis_ignite_v1alpha3_VMStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMStorageSpec":     schema_pkg_apis_ignite_v1alpha3_VMStorageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Volume":            schema_pkg_apis_ignite_v1alpha3_Volume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VolumeMount":       schema_pkg_apis_ignite_v1alpha3_VolumeMount(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.BlockDeviceVolume": schema_pkg_apis_ignite_v1alpha4_BlockDeviceVolume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Configuration":     schema_pkg_apis_ignite_v1alpha4_Configuration(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ConfigurationSpec": schema_pkg_apis_ignite_v1alpha4_ConfigurationSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.FileMapping":       schema_pkg_apis_ignite_v1alpha4_FileMapping(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Image":             schema_pkg_apis_ignite_v1alpha4_Image(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec":         schema_pkg_apis_ignite_v1alpha4_ImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus":       schema_pkg_apis_ignite_v1alpha4_ImageStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Kernel":            schema_pkg_apis_ignite_v1alpha4_Kernel(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec":        schema_pkg_apis_ignite_v1alpha4_KernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus":      schema_pkg_apis_ignite_v1alpha4_KernelStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Network":           schema_pkg_apis_ignite_v1alpha4_Network(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource":    schema_pkg_apis_ignite_v1alpha4_OCIImageSource(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Pool":              schema_pkg_apis_ignite_v1alpha4_Pool(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolDevice":        schema_pkg_apis_ignite_v1alpha4_PoolDevice(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolSpec":          schema_pkg_apis_ignite_v1alpha4_PoolSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolStatus":        schema_pkg_apis_ignite_v1alpha4_PoolStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Runtime":           schema_pkg_apis_ignite_v1alpha4_Runtime(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.SSH":               schema_pkg_apis_ignite_v1alpha4_SSH(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VM":                schema_pkg_apis_ignite_v1alpha4_VM(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMImageSpec":       schema_pkg_apis_ignite_v1alpha4_VMImageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMKernelSpec":      schema_pkg_apis_ignite_v1alpha4_VMKernelSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMNetworkSpec":     schema_pkg_apis_ignite_v1alpha4_VMNetworkSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSandboxSpec":     schema_pkg_apis_ignite_v1alpha4_VMSandboxSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSpec":            schema_pkg_apis_ignite_v1alpha4_VMSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMStatus":          schema_pkg_apis_ignite_v1alpha4_VMStatus(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMStorageSpec":     schema_pkg_apis_ignite_v1alpha4_VMStorageSpec(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Volume":            schema_pkg_apis_ignite_v1alpha4_Volume(ref),
-		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VolumeMount":       schema_pkg_apis_ignite_v1alpha4_VolumeMount(ref),
-		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.DMID":                schema_pkg_apis_meta_v1alpha1_DMID(ref),
-		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIContentID":        schema_pkg_apis_meta_v1alpha1_OCIContentID(ref),
-		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef":         schema_pkg_apis_meta_v1alpha1_OCIImageRef(ref),
-		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping":         schema_pkg_apis_meta_v1alpha1_PortMapping(ref),
-		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.Size":                schema_pkg_apis_meta_v1alpha1_Size(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.BlockDeviceVolume":       schema_pkg_apis_ignite_v1alpha2_BlockDeviceVolume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.FileMapping":             schema_pkg_apis_ignite_v1alpha2_FileMapping(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Image":                   schema_pkg_apis_ignite_v1alpha2_Image(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.ImageSpec":               schema_pkg_apis_ignite_v1alpha2_ImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.ImageStatus":             schema_pkg_apis_ignite_v1alpha2_ImageStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Kernel":                  schema_pkg_apis_ignite_v1alpha2_Kernel(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.KernelSpec":              schema_pkg_apis_ignite_v1alpha2_KernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.KernelStatus":            schema_pkg_apis_ignite_v1alpha2_KernelStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.OCIImageSource":          schema_pkg_apis_ignite_v1alpha2_OCIImageSource(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Pool":                    schema_pkg_apis_ignite_v1alpha2_Pool(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolDevice":              schema_pkg_apis_ignite_v1alpha2_PoolDevice(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolSpec":                schema_pkg_apis_ignite_v1alpha2_PoolSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.PoolStatus":              schema_pkg_apis_ignite_v1alpha2_PoolStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Runtime":                 schema_pkg_apis_ignite_v1alpha2_Runtime(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.SSH":                     schema_pkg_apis_ignite_v1alpha2_SSH(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VM":                      schema_pkg_apis_ignite_v1alpha2_VM(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMImageSpec":             schema_pkg_apis_ignite_v1alpha2_VMImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMKernelSpec":            schema_pkg_apis_ignite_v1alpha2_VMKernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMNetworkSpec":           schema_pkg_apis_ignite_v1alpha2_VMNetworkSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMSandboxSpec":           schema_pkg_apis_ignite_v1alpha2_VMSandboxSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMSpec":                  schema_pkg_apis_ignite_v1alpha2_VMSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMStatus":                schema_pkg_apis_ignite_v1alpha2_VMStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VMStorageSpec":           schema_pkg_apis_ignite_v1alpha2_VMStorageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.Volume":                  schema_pkg_apis_ignite_v1alpha2_Volume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha2.VolumeMount":             schema_pkg_apis_ignite_v1alpha2_VolumeMount(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.BlockDeviceVolume":       schema_pkg_apis_ignite_v1alpha3_BlockDeviceVolume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Configuration":           schema_pkg_apis_ignite_v1alpha3_Configuration(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ConfigurationSpec":       schema_pkg_apis_ignite_v1alpha3_ConfigurationSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.FileMapping":             schema_pkg_apis_ignite_v1alpha3_FileMapping(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Image":                   schema_pkg_apis_ignite_v1alpha3_Image(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ImageSpec":               schema_pkg_apis_ignite_v1alpha3_ImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.ImageStatus":             schema_pkg_apis_ignite_v1alpha3_ImageStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Kernel":                  schema_pkg_apis_ignite_v1alpha3_Kernel(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.KernelSpec":              schema_pkg_apis_ignite_v1alpha3_KernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.KernelStatus":            schema_pkg_apis_ignite_v1alpha3_KernelStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Network":                 schema_pkg_apis_ignite_v1alpha3_Network(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.OCIImageSource":          schema_pkg_apis_ignite_v1alpha3_OCIImageSource(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Pool":                    schema_pkg_apis_ignite_v1alpha3_Pool(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolDevice":              schema_pkg_apis_ignite_v1alpha3_PoolDevice(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolSpec":                schema_pkg_apis_ignite_v1alpha3_PoolSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.PoolStatus":              schema_pkg_apis_ignite_v1alpha3_PoolStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Runtime":                 schema_pkg_apis_ignite_v1alpha3_Runtime(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.SSH":                     schema_pkg_apis_ignite_v1alpha3_SSH(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VM":                      schema_pkg_apis_ignite_v1alpha3_VM(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMImageSpec":             schema_pkg_apis_ignite_v1alpha3_VMImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMKernelSpec":            schema_pkg_apis_ignite_v1alpha3_VMKernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMNetworkSpec":           schema_pkg_apis_ignite_v1alpha3_VMNetworkSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMSandboxSpec":           schema_pkg_apis_ignite_v1alpha3_VMSandboxSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMSpec":                  schema_pkg_apis_ignite_v1alpha3_VMSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMStatus":                schema_pkg_apis_ignite_v1alpha3_VMStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VMStorageSpec":           schema_pkg_apis_ignite_v1alpha3_VMStorageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.Volume":                  schema_pkg_apis_ignite_v1alpha3_Volume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha3.VolumeMount":             schema_pkg_apis_ignite_v1alpha3_VolumeMount(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.BlockDeviceVolume":       schema_pkg_apis_ignite_v1alpha4_BlockDeviceVolume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNIBandwidth":            schema_pkg_apis_ignite_v1alpha4_CNIBandwidth(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNINetworkAttachment":    schema_pkg_apis_ignite_v1alpha4_CNINetworkAttachment(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Configuration":           schema_pkg_apis_ignite_v1alpha4_Configuration(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ConfigurationSpec":       schema_pkg_apis_ignite_v1alpha4_ConfigurationSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPOptions":             schema_pkg_apis_ignite_v1alpha4_DHCPOptions(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPRoute":               schema_pkg_apis_ignite_v1alpha4_DHCPRoute(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.FileMapping":             schema_pkg_apis_ignite_v1alpha4_FileMapping(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Image":                   schema_pkg_apis_ignite_v1alpha4_Image(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec":               schema_pkg_apis_ignite_v1alpha4_ImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus":             schema_pkg_apis_ignite_v1alpha4_ImageStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.InterfaceAddress":        schema_pkg_apis_ignite_v1alpha4_InterfaceAddress(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Kernel":                  schema_pkg_apis_ignite_v1alpha4_Kernel(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec":              schema_pkg_apis_ignite_v1alpha4_KernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus":            schema_pkg_apis_ignite_v1alpha4_KernelStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Network":                 schema_pkg_apis_ignite_v1alpha4_Network(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkAttachmentStatus": schema_pkg_apis_ignite_v1alpha4_NetworkAttachmentStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkInterface":        schema_pkg_apis_ignite_v1alpha4_NetworkInterface(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicy":           schema_pkg_apis_ignite_v1alpha4_NetworkPolicy(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyPort":       schema_pkg_apis_ignite_v1alpha4_NetworkPolicyPort(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRule":       schema_pkg_apis_ignite_v1alpha4_NetworkPolicyRule(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRules":      schema_pkg_apis_ignite_v1alpha4_NetworkPolicyRules(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkSpec":             schema_pkg_apis_ignite_v1alpha4_NetworkSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource":          schema_pkg_apis_ignite_v1alpha4_OCIImageSource(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Pool":                    schema_pkg_apis_ignite_v1alpha4_Pool(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolDevice":              schema_pkg_apis_ignite_v1alpha4_PoolDevice(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolSpec":                schema_pkg_apis_ignite_v1alpha4_PoolSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolStatus":              schema_pkg_apis_ignite_v1alpha4_PoolStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Runtime":                 schema_pkg_apis_ignite_v1alpha4_Runtime(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.SSH":                     schema_pkg_apis_ignite_v1alpha4_SSH(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VM":                      schema_pkg_apis_ignite_v1alpha4_VM(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMImageSpec":             schema_pkg_apis_ignite_v1alpha4_VMImageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMKernelSpec":            schema_pkg_apis_ignite_v1alpha4_VMKernelSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMNetworkSpec":           schema_pkg_apis_ignite_v1alpha4_VMNetworkSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMNetworkStatus":         schema_pkg_apis_ignite_v1alpha4_VMNetworkStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSandboxSpec":           schema_pkg_apis_ignite_v1alpha4_VMSandboxSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSpec":                  schema_pkg_apis_ignite_v1alpha4_VMSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMStatus":                schema_pkg_apis_ignite_v1alpha4_VMStatus(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMStorageSpec":           schema_pkg_apis_ignite_v1alpha4_VMStorageSpec(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Volume":                  schema_pkg_apis_ignite_v1alpha4_Volume(ref),
+		"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VolumeMount":             schema_pkg_apis_ignite_v1alpha4_VolumeMount(ref),
+		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.DMID":                      schema_pkg_apis_meta_v1alpha1_DMID(ref),
+		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIContentID":              schema_pkg_apis_meta_v1alpha1_OCIContentID(ref),
+		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef":               schema_pkg_apis_meta_v1alpha1_OCIImageRef(ref),
+		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping":               schema_pkg_apis_meta_v1alpha1_PortMapping(ref),
+		"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.Size":                      schema_pkg_apis_meta_v1alpha1_Size(ref),
 	}
 }
 
@@ -1891,6 +1904,111 @@ func schema_pkg_apis_ignite_v1alpha4_BlockDeviceVolume(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_ignite_v1alpha4_CNIBandwidth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CNIBandwidth configures the \"bandwidth\" capability of CNI, the rates are in bits per second and the bursts in bits",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"ingressRate": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+					"ingressBurst": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+					"egressRate": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+					"egressBurst": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_CNINetworkAttachment(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CNINetworkAttachment attaches a VM to a network of the CNI configuration directory",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the network, as given in its configuration",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"args": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Args are passed to the plugins of the network as CNI_ARGS",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"bandwidth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Bandwidth limits the traffic of the interface, for plugins supporting the \"bandwidth\" capability",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNIBandwidth"),
+						},
+					},
+					"ips": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IPs are the addresses in CIDR notation requested for the interface, for plugins supporting the \"ips\" capability. The static IP of the VM is requested for the main interface if unset.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"mac": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MAC is the MAC address requested for the sandbox interface, for plugins supporting the \"mac\" capability",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNIBandwidth"},
+	}
+}
+
 func schema_pkg_apis_ignite_v1alpha4_Configuration(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1962,11 +2080,174 @@ func schema_pkg_apis_ignite_v1alpha4_ConfigurationSpec(ref common.ReferenceCallb
 							Format: "",
 						},
 					},
+					"embeddedDNS": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EmbeddedDNS hands out the host bridge as the first DNS server to the VMs, ignited answers <vm-name>.<network>.ignite on it and forwards all other queries to the host's DNS servers",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"cniBinDirs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CNIBinDirs are the directories of the CNI plugin binaries, defaults to /opt/cni/bin",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"cniConfDir": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CNIConfDir is the directory of the CNI network configurations, defaults to /etc/cni/net.d",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"storageDriver": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StorageDriver stores the disks of new VMs, defaults to legacy. VMs keep the driver they were created with.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"thinPool": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ThinPool configures the pool of the thinpool storage driver when it's created",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolSpec"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSpec"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.PoolSpec", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMSpec"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_DHCPOptions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DHCPOptions configures the options handed out to the VM for its dhcp-bridge interfaces",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"nameServers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NameServers replace the DNS servers of the sandbox, and the embedded DNS resolver",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "byte",
+									},
+								},
+							},
+						},
+					},
+					"searchDomains": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SearchDomains are the DNS search domains of the VM (option 119)",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"ntpServers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NTPServers are the IPv4 addresses of the NTP servers of the VM (option 42)",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "byte",
+									},
+								},
+							},
+						},
+					},
+					"domainName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DomainName is the domain name of the VM (option 15), it overrides the domain of the network of the embedded DNS",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"mtu": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MTU is the MTU of the interfaces that don't set their own, defaults to the MTU of the sandbox interfaces",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"routes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Routes are classless static routes (option 121), handed out for the interface their gateway is reachable on",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPRoute"),
+									},
+								},
+							},
+						},
+					},
+					"leaseDuration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LeaseDuration is the duration of the leases, they're infinite by default",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPRoute", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_DHCPRoute(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DHCPRoute is a classless static IPv4 route handed out to the VM",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"destination": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Destination is the destination subnet of the route in CIDR notation",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gateway": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Gateway is the next hop of the route, it needs to be in the subnet of an interface of the VM",
+							Type:        []string{"string"},
+							Format:      "byte",
+						},
+					},
+				},
+				Required: []string{"destination", "gateway"},
+			},
+		},
 	}
 }
 
@@ -2011,200 +2292,532 @@ func schema_pkg_apis_ignite_v1alpha4_Image(ref common.ReferenceCallback) common.
 							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"),
 						},
 					},
-					"metadata": {
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID Name is available at the .metadata.name JSON path ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus"),
+						},
+					},
+				},
+				Required: []string{"TypeMeta", "metadata", "spec", "status"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus", "github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta", "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_ImageSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ImageSpec declares what the image contains",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"oci": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"),
+						},
+					},
+				},
+				Required: []string{"oci"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_ImageStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ImageStatus defines the status of the image",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"ociSource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "OCISource contains the information about how this OCI image was imported",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"),
+						},
+					},
+				},
+				Required: []string{"ociSource"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_InterfaceAddress(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "InterfaceAddress defines a static IP address of an interface",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"ip": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IP is the IP address, optionally with a prefix length in CIDR notation (e.g. 10.61.0.10/16). Without a prefix length, the prefix length of the address given to the sandbox is used.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gateway": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Gateway overrides the gateway given to the sandbox by the network plugin",
+							Type:        []string{"string"},
+							Format:      "byte",
+						},
+					},
+				},
+				Required: []string{"ip"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_Kernel(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Kernel is a serializable object that caches information about imported kernels This file is stored in /var/lib/firecracker/kernels/{oci-image-digest}/metadata.json",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"TypeMeta": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"),
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID Name is available at the .metadata.name JSON path ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus"),
+						},
+					},
+				},
+				Required: []string{"TypeMeta", "metadata", "spec", "status"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus", "github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta", "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_KernelSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KernelSpec describes the properties of a kernel",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"oci": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"),
+						},
+					},
+				},
+				Required: []string{"oci"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_KernelStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KernelStatus describes the status of a kernel",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Default: "",
+							Type:    []string{"string"},
+							Format:  "",
+						},
+					},
+					"ociSource": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"),
+						},
+					},
+				},
+				Required: []string{"version", "ociSource"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_Network(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Network is a user-defined network VMs can be attached to, it's set up by the CNI network plugin using a CNI configuration generated from its spec These files are stored in /var/lib/firecracker/network/{network-id}/metadata.json",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"TypeMeta": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"),
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID Name is available at the .metadata.name JSON path ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkSpec"),
+						},
+					},
+				},
+				Required: []string{"TypeMeta", "metadata", "spec"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkSpec", "github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta", "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_NetworkAttachmentStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NetworkAttachmentStatus is the result of attaching the sandbox of a VM to a network",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the network, as given in its CNI configuration",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"interface": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interface is the name of the sandbox interface attached to the network",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"mac": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MAC is the MAC address of the sandbox interface",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"ipAddresses": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IPAddresses are the addresses allocated for the interface",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "byte",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name", "interface"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_NetworkInterface(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NetworkInterface defines an interface of the sandbox that is passed to the VM",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the interface in the sandbox, e.g. eth0",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Mode defines how the interface is passed to the VM, defaults to dhcp-bridge",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"macAddress": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MACAddress is the MAC address of the interface in the VM. For dhcp-bridge interfaces it's derived from the VM UID and the interface name by default, tc-redirect interfaces use the sandbox interface's one.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"mtu": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MTU is the MTU of the interface, defaults to the MTU of the sandbox interface",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"guestName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID Name is available at the .metadata.name JSON path ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)",
-							Default:     map[string]interface{}{},
-							Ref:         ref("github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta"),
+							Description: "GuestName is the name of the interface in the VM, it's kept by the kernel if unset",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"spec": {
+					"address": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec"),
+							Description: "Address is a static IP address given to the VM for the interface",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.InterfaceAddress"),
 						},
 					},
-					"status": {
+					"parent": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus"),
+							Description: "Parent is the host device the interface is created on for the macvlan and ipvlan modes. If unset, the device is expected to be created in the sandbox by another system.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
 				},
-				Required: []string{"TypeMeta", "metadata", "spec", "status"},
+				Required: []string{"name"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageSpec", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.ImageStatus", "github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta", "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.InterfaceAddress"},
 	}
 }
 
-func schema_pkg_apis_ignite_v1alpha4_ImageSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_ignite_v1alpha4_NetworkPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "ImageSpec declares what the image contains",
+				Description: "NetworkPolicy restricts the traffic of the dhcp-bridge interfaces of a VM to allow lists, it's enforced in the sandbox of the VM. Replies to allowed connections, ARP and IPv6 neighbor discovery are always allowed.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"oci": {
+					"ingress": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"),
+							Description: "Ingress restricts the connections to the VM, they're all allowed if unset",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRules"),
 						},
 					},
-				},
-				Required: []string{"oci"},
-			},
-		},
-		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"},
-	}
-}
-
-func schema_pkg_apis_ignite_v1alpha4_ImageStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
-	return common.OpenAPIDefinition{
-		Schema: spec.Schema{
-			SchemaProps: spec.SchemaProps{
-				Description: "ImageStatus defines the status of the image",
-				Type:        []string{"object"},
-				Properties: map[string]spec.Schema{
-					"ociSource": {
+					"egress": {
 						SchemaProps: spec.SchemaProps{
-							Description: "OCISource contains the information about how this OCI image was imported",
-							Default:     map[string]interface{}{},
-							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"),
+							Description: "Egress restricts the connections from the VM, they're all allowed if unset",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRules"),
 						},
 					},
 				},
-				Required: []string{"ociSource"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRules"},
 	}
 }
 
-func schema_pkg_apis_ignite_v1alpha4_Kernel(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_ignite_v1alpha4_NetworkPolicyPort(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "Kernel is a serializable object that caches information about imported kernels This file is stored in /var/lib/firecracker/kernels/{oci-image-digest}/metadata.json",
+				Description: "NetworkPolicyPort is a destination port, or a range of ports, of a NetworkPolicyRule",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"TypeMeta": {
-						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"),
-						},
-					},
-					"metadata": {
+					"protocol": {
 						SchemaProps: spec.SchemaProps{
-							Description: "runtime.ObjectMeta is also embedded into the struct, and defines the human-readable name, and the machine-readable ID Name is available at the .metadata.name JSON path ID is available at the .metadata.uid JSON path (the Go type is k8s.io/apimachinery/pkg/types.UID, which is only a typed string)",
-							Default:     map[string]interface{}{},
-							Ref:         ref("github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta"),
+							Description: "Protocol is the protocol of the port, tcp or udp. Defaults to tcp.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"spec": {
+					"port": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec"),
+							Description: "Port is the destination port, or the first port of the range",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"status": {
+					"endPort": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus"),
+							Description: "EndPort is the last port of the range, if any",
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
 				},
-				Required: []string{"TypeMeta", "metadata", "spec", "status"},
+				Required: []string{"port"},
 			},
 		},
-		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelSpec", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.KernelStatus", "github.com/weaveworks/libgitops/pkg/runtime.ObjectMeta", "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"},
 	}
 }
 
-func schema_pkg_apis_ignite_v1alpha4_KernelSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_ignite_v1alpha4_NetworkPolicyRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KernelSpec describes the properties of a kernel",
+				Description: "NetworkPolicyRule allows the connections matching both its peers and its ports",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"oci": {
+					"cidrs": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"),
+							Description: "CIDRs are the subnets of the peers, the sources for ingress and the destinations for egress. All peers are allowed if unset.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"ports": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ports are the destination ports, all ports and protocols are allowed if unset",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyPort"),
+									},
+								},
+							},
 						},
 					},
 				},
-				Required: []string{"oci"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.OCIImageRef"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyPort"},
 	}
 }
 
-func schema_pkg_apis_ignite_v1alpha4_KernelStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_ignite_v1alpha4_NetworkPolicyRules(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KernelStatus describes the status of a kernel",
+				Description: "NetworkPolicyRules lists the allowed connections of one direction",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"version": {
-						SchemaProps: spec.SchemaProps{
-							Default: "",
-							Type:    []string{"string"},
-							Format:  "",
-						},
-					},
-					"ociSource": {
+					"allow": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"),
+							Description: "Allow lists the allowed connections, all other connections are dropped",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRule"),
+									},
+								},
+							},
 						},
 					},
 				},
-				Required: []string{"version", "ociSource"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicyRule"},
 	}
 }
 
-func schema_pkg_apis_ignite_v1alpha4_Network(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_ignite_v1alpha4_NetworkSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "Network specifies the VM's network information.",
+				Description: "NetworkSpec describes the configuration of a network",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"plugin": {
+					"subnet": {
 						SchemaProps: spec.SchemaProps{
-							Default: "",
-							Type:    []string{"string"},
-							Format:  "",
+							Description: "Subnet is the IPv4 or IPv6 subnet the addresses of the VMs are allocated from, in CIDR notation",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"ipAddresses": {
+					"gateway": {
 						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Type:   []string{"string"},
-										Format: "byte",
-									},
-								},
-							},
+							Description: "Gateway is the address of the bridge in the subnet, defaults to the first address of the subnet",
+							Type:        []string{"string"},
+							Format:      "byte",
+						},
+					},
+					"bridgeName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BridgeName is the name of the bridge device on the host, it needs to start with \"ignite-\". Defaults to \"ignite-\" and the first characters of the ID",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"masquerade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Masquerade masquerades the traffic leaving the network, which gives the VMs outbound connectivity",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"isolated": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Isolated drops all traffic forwarded between the network and the other networks of ignite",
+							Type:        []string{"boolean"},
+							Format:      "",
 						},
 					},
 				},
-				Required: []string{"plugin", "ipAddresses"},
+				Required: []string{"subnet"},
 			},
 		},
 	}
@@ -2546,11 +3159,137 @@ func schema_pkg_apis_ignite_v1alpha4_VMNetworkSpec(ref common.ReferenceCallback)
 							},
 						},
 					},
+					"interfaces": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interfaces configures the interfaces of the sandbox that are passed to the VM",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkInterface"),
+									},
+								},
+							},
+						},
+					},
+					"stickyIP": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StickyIP keeps the IP address allocated for the main interface on the first start of the VM, by recording it as the static address of the interface",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"networks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Networks are the names of the user-defined networks the VM is attached to. The VM gets an interface for each of them, the first one is the main interface providing the default route. The default network of the network plugin is used if unset.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"cniNetworks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CNINetworks are the networks of the CNI configuration directory the VM is attached to, with the cni network plugin. Like with Networks, the VM gets an interface for each of them, the first one is the main interface. The first network of the directory is used if unset. Can't be combined with Networks.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNINetworkAttachment"),
+									},
+								},
+							},
+						},
+					},
+					"dhcp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DHCP overrides the options the DHCP server of ignite-spawn hands out to the VM",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPOptions"),
+						},
+					},
+					"policy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Policy restricts the traffic of the VM, all traffic is allowed if unset",
+							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicy"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.CNINetworkAttachment", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.DHCPOptions", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkInterface", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkPolicy", "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping"},
+	}
+}
+
+func schema_pkg_apis_ignite_v1alpha4_VMNetworkStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "VMNetworkStatus specifies the VM's network information.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"plugin": {
+						SchemaProps: spec.SchemaProps{
+							Default: "",
+							Type:    []string{"string"},
+							Format:  "",
+						},
+					},
+					"ipAddresses": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "byte",
+									},
+								},
+							},
+						},
+					},
+					"ports": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ports are the port mappings of the running VM, with the allocated host ports",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping"),
+									},
+								},
+							},
+						},
+					},
+					"networks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Networks are the results of attaching the sandbox of the VM to its networks, in the order of its interfaces",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkAttachmentStatus"),
+									},
+								},
+							},
+						},
+					},
 				},
+				Required: []string{"plugin", "ipAddresses"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.NetworkAttachmentStatus", "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1.PortMapping"},
 	}
 }
 
@@ -2653,6 +3392,13 @@ func schema_pkg_apis_ignite_v1alpha4_VMSpec(ref common.ReferenceCallback) common
 							Ref:         ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.SSH"),
 						},
 					},
+					"restartPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RestartPolicy decides if ignited restarts the VM when it starts, e.g. after a host reboot.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"image", "sandbox", "kernel", "cpus", "memory", "diskSize"},
 			},
@@ -2688,7 +3434,7 @@ func schema_pkg_apis_ignite_v1alpha4_VMStatus(ref common.ReferenceCallback) comm
 					},
 					"network": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Network"),
+							Ref: ref("github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMNetworkStatus"),
 						},
 					},
 					"image": {
@@ -2715,7 +3461,7 @@ func schema_pkg_apis_ignite_v1alpha4_VMStatus(ref common.ReferenceCallback) comm
 			},
 		},
 		Dependencies: []string{
-			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Network", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Runtime", "github.com/weaveworks/libgitops/pkg/runtime.Time"},
+			"github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.OCIImageSource", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.Runtime", "github.com/weaveworks/ignite/pkg/apis/ignite/v1alpha4.VMNetworkStatus", "github.com/weaveworks/libgitops/pkg/runtime.Time"},
 	}
 }
 
@@ -2897,7 +3643,7 @@ func schema_pkg_apis_meta_v1alpha1_PortMapping(ref common.ReferenceCallback) com
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "PortMapping defines a port mapping between the VM and the host",
+				Description: "PortMapping defines a port mapping between the VM and the host. A HostPort of zero requests a free host port to be allocated when the VM is started, the allocated port is recorded in the VM status.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"bindAddress": {
@@ -0,0 +1,45 @@
+package operations
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// CompactVM releases the blocks freed inside a stopped VM from the storage backing its disk,
+// see snapshotter.Driver.CompactDisk. It returns the number of bytes released.
+func CompactVM(vm *api.VM) (uint64, error) {
+	if vm.Running() || util.FileExists(vm.SnapshotDev()) {
+		return 0, fmt.Errorf("%s %q is running, stop it before compacting it", vm.GetKind(), vm.GetUID())
+	}
+
+	driver := providers.StorageDriverForVM(vm)
+	if !driver.HasDisk(vm) {
+		log.Infof("%s %q doesn't have a disk, there's nothing to compact", vm.GetKind(), vm.GetUID())
+		return 0, nil
+	}
+
+	before, err := driver.DiskUsage(vm)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Compacting the disk of %s %q...", vm.GetKind(), vm.GetUID())
+	if err := driver.CompactDisk(vm); err != nil {
+		return 0, err
+	}
+
+	after, err := driver.DiskUsage(vm)
+	if err != nil {
+		return 0, err
+	}
+
+	if after.Allocated > before.Allocated {
+		return 0, nil
+	}
+
+	return before.Allocated - after.Allocated, nil
+}
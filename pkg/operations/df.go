@@ -0,0 +1,215 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/dm"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// DiskUsage describes the disk space used by the images, kernels and VMs
+type DiskUsage struct {
+	Images  []*ImageDiskUsage  `json:"images"`
+	Kernels []*KernelDiskUsage `json:"kernels"`
+	VMs     []*VMDiskUsage     `json:"vms"`
+	// Pool is unset if the pool of the thinpool storage driver hasn't been created
+	Pool *PoolDiskUsage `json:"pool,omitempty"`
+}
+
+// ImageDiskUsage describes the disk space used by the filesystem of an image
+type ImageDiskUsage struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Size is the apparent size of the filesystem, Allocated the space it takes on disk
+	Size      uint64 `json:"size"`
+	Allocated uint64 `json:"allocated"`
+	// VMs is the number of VMs created from the image, it's reclaimable if there are none
+	VMs         int  `json:"vms"`
+	Reclaimable bool `json:"reclaimable"`
+}
+
+// KernelDiskUsage describes the disk space used by the files of a kernel
+type KernelDiskUsage struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// KernelSize is the size of the vmlinux file, TarSize of the kernel.tar holding the kernel modules
+	KernelSize uint64 `json:"kernelSize"`
+	TarSize    uint64 `json:"tarSize"`
+	// VMs is the number of VMs booting the kernel, it's reclaimable if there are none
+	VMs         int  `json:"vms"`
+	Reclaimable bool `json:"reclaimable"`
+}
+
+// VMDiskUsage describes the disk space used by the disk of a VM
+type VMDiskUsage struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	// StorageDriver is the driver storing the disk, the disks of the thinpool driver are stored in the pool
+	StorageDriver api.StorageDriver `json:"storageDriver"`
+	// Size is the apparent size of the disk. Allocated is the space the blocks written by the VM take on
+	// disk for overlays, and the space mapped in the pool, including the blocks shared with the image, for
+	// thin devices.
+	Size      uint64 `json:"size"`
+	Allocated uint64 `json:"allocated"`
+	// SnapshotFillPercentage is the percentage of the overlay used by the snapshot of
+	// a running VM, as reported by device mapper. It's unset if the snapshot isn't active.
+	SnapshotFillPercentage *float64 `json:"snapshotFillPercentage,omitempty"`
+	// SnapshotInvalid is true if the snapshot overflowed or failed
+	SnapshotInvalid bool `json:"snapshotInvalid,omitempty"`
+}
+
+// PoolDiskUsage describes the disk space used by the pool of the thinpool storage driver
+type PoolDiskUsage struct {
+	// Devices is the number of thin devices in the pool, which are the layers of images and kernels and the disks of VMs
+	Devices int `json:"devices"`
+	// DataSize and MetadataSize are the configured sizes of the data and metadata of the pool
+	DataSize     uint64 `json:"dataSize"`
+	MetadataSize uint64 `json:"metadataSize"`
+	// Allocated is the space the sparse data and metadata files of the pool take on disk
+	Allocated uint64 `json:"allocated"`
+	// Active is true if the pool is set up, the usage is only reported by device mapper for active pools
+	Active       bool   `json:"active"`
+	UsedData     uint64 `json:"usedData"`
+	UsedMetadata uint64 `json:"usedMetadata"`
+	Mode         string `json:"mode,omitempty"`
+}
+
+// GetDiskUsage computes the disk space used by the images, kernels and VMs. Files
+// that are missing, e.g. the overlays of VMs that never started, count as empty.
+func GetDiskUsage() (*DiskUsage, error) {
+	dirs, err := listVMDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := providers.Client.Images().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	kernels, err := providers.Client.Kernels().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	imageVMs := make(map[string]int, len(images))
+	kernelVMs := make(map[string]int, len(kernels))
+	for _, vm := range dirs.vms {
+		imageVMs[vm.Spec.Image.OCI.String()]++
+		kernelVMs[vm.Spec.Kernel.OCI.String()]++
+	}
+
+	du := &DiskUsage{
+		Images:  make([]*ImageDiskUsage, 0, len(images)),
+		Kernels: make([]*KernelDiskUsage, 0, len(kernels)),
+		VMs:     make([]*VMDiskUsage, 0, len(dirs.vms)),
+	}
+
+	for _, image := range images {
+		u := &ImageDiskUsage{
+			ID:   image.GetUID().String(),
+			Name: image.GetName(),
+			VMs:  imageVMs[image.GetName()],
+		}
+
+		u.Size, u.Allocated = fileSize(filepath.Join(image.ObjectPath(), constants.IMAGE_FS))
+		u.Reclaimable = u.VMs == 0
+		du.Images = append(du.Images, u)
+	}
+
+	for _, kernel := range kernels {
+		u := &KernelDiskUsage{
+			ID:   kernel.GetUID().String(),
+			Name: kernel.GetName(),
+			VMs:  kernelVMs[kernel.GetName()],
+		}
+
+		u.KernelSize, _ = fileSize(filepath.Join(kernel.ObjectPath(), constants.KERNEL_FILE))
+		u.TarSize, _ = fileSize(filepath.Join(kernel.ObjectPath(), constants.KERNEL_TAR))
+		u.Reclaimable = u.VMs == 0
+		du.Kernels = append(du.Kernels, u)
+	}
+
+	for _, vm := range dirs.vms {
+		du.VMs = append(du.VMs, vmDiskUsage(vm))
+	}
+
+	if du.Pool, err = poolDiskUsage(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(du.Images, func(i, j int) bool { return du.Images[i].Name < du.Images[j].Name })
+	sort.Slice(du.Kernels, func(i, j int) bool { return du.Kernels[i].Name < du.Kernels[j].Name })
+	sort.Slice(du.VMs, func(i, j int) bool { return du.VMs[i].Name < du.VMs[j].Name })
+
+	return du, nil
+}
+
+// vmDiskUsage computes the disk space used by the disk of the VM, and the fill percentage of its active snapshot
+func vmDiskUsage(vm *api.VM) *VMDiskUsage {
+	driver := providers.StorageDriverForVM(vm)
+	u := &VMDiskUsage{
+		ID:            vm.GetUID().String(),
+		Name:          vm.GetName(),
+		Running:       vm.Running(),
+		StorageDriver: driver.Name(),
+	}
+
+	// VMs that don't have a disk yet count as empty
+	if !driver.HasDisk(vm) {
+		return u
+	}
+
+	usage, err := driver.DiskUsage(vm)
+	if err != nil {
+		log.Warnf("Failed to get the disk usage of %s %q: %v", vm.GetKind(), vm.GetUID(), err)
+		return u
+	}
+
+	u.Size, u.Allocated = usage.Size, usage.Allocated
+	u.SnapshotFillPercentage, u.SnapshotInvalid = usage.FillPercentage, usage.Invalid
+	return u
+}
+
+// poolDiskUsage computes the disk space used by the pool of the thinpool storage driver, it's nil if there's no pool
+func poolDiskUsage() (*PoolDiskUsage, error) {
+	pool, usage, err := dm.GetPoolUsage()
+	if err != nil || pool == nil {
+		return nil, err
+	}
+
+	u := &PoolDiskUsage{
+		Devices:      pool.Size(),
+		DataSize:     pool.Spec.DataSize.Bytes(),
+		MetadataSize: pool.Spec.MetadataSize.Bytes(),
+		Active:       usage != nil,
+	}
+
+	for _, file := range []string{pool.Spec.DataPath, pool.Spec.MetadataPath} {
+		_, allocated := fileSize(file)
+		u.Allocated += allocated
+	}
+
+	if usage != nil {
+		u.UsedData, u.UsedMetadata, u.Mode = usage.UsedData, usage.UsedMetadata, usage.Mode
+	}
+
+	return u, nil
+}
+
+// fileSize returns the apparent and allocated size of the given file, which are zero if it doesn't exist
+func fileSize(filename string) (uint64, uint64) {
+	size, allocated, err := util.FileSize(filename)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("Failed to get the size of %q: %v", filename, err)
+	}
+
+	return size, allocated
+}
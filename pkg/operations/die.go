@@ -0,0 +1,34 @@
+package operations
+
+import (
+	ctderrdefs "github.com/containerd/containerd/errdefs"
+	dockererrdefs "github.com/docker/docker/errdefs"
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/events"
+)
+
+// ContainerNotFound returns true if inspecting the container of a VM failed because it doesn't exist
+func ContainerNotFound(err error) bool {
+	return err != nil && (ctderrdefs.IsNotFound(err) || dockererrdefs.IsNotFound(err))
+}
+
+// RecordDie records the die event of a VM marked running whose container has exited or is gone. ignite-spawn
+// only records it when Firecracker returns, not when its container is killed or the host goes down. The status
+// of the VM isn't necessarily updated when this is detected, so the event is only recorded once per start.
+func RecordDie(vm *api.VM, message string) {
+	// VMs that never finished starting didn't die
+	if !vm.Running() || vm.Status.StartTime == nil {
+		return
+	}
+
+	recorded, err := events.Recorded(vm, events.TypeDie, vm.Status.StartTime.Time.Time)
+	if err != nil {
+		log.Warnf("Failed to read the events of %s %q: %v", vm.GetKind(), vm.GetUID(), err)
+		return
+	}
+
+	if !recorded {
+		events.Record(vm, events.TypeDie, message)
+	}
+}
@@ -0,0 +1,62 @@
+package operations
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/events"
+	"github.com/weaveworks/libgitops/pkg/runtime"
+	"gotest.tools/assert"
+)
+
+func TestRecordDie(t *testing.T) {
+	events.LogPath = path.Join(t.TempDir(), "events.log")
+
+	vm := &api.VM{
+		ObjectMeta: runtime.ObjectMeta{
+			Name: "my-vm",
+			UID:  "20e1d566ce318ada",
+		},
+	}
+	vm.SetGroupVersionKind(api.SchemeGroupVersion.WithKind(api.KindVM.Title()))
+
+	// VMs that aren't running or never finished starting didn't die
+	RecordDie(vm, "the container is gone")
+	vm.Status.Running = true
+	RecordDie(vm, "the container is gone")
+	assert.Equal(t, readDieEvents(t), 0)
+
+	// The death of a started VM is recorded once, even if it's detected again
+	startTime := runtime.Timestamp()
+	vm.Status.StartTime = &startTime
+	RecordDie(vm, "the container is exited")
+	RecordDie(vm, "the container is gone")
+	assert.Equal(t, readDieEvents(t), 1)
+
+	// The death after the next start is recorded again
+	startTime = runtime.Time{}
+	startTime.Time.Time = time.Now().Add(time.Second)
+	RecordDie(vm, "the container is gone")
+	assert.Equal(t, readDieEvents(t), 2)
+}
+
+// readDieEvents returns the number of die events in the event log
+func readDieEvents(t *testing.T) int {
+	r, err := events.NewReader()
+	assert.NilError(t, err)
+	defer r.Close()
+
+	n := 0
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return n
+		}
+
+		if e.Type == events.TypeDie {
+			n++
+		}
+	}
+}
@@ -12,10 +12,12 @@ import (
 	"github.com/weaveworks/ignite/pkg/client"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/metadata"
 	"github.com/weaveworks/ignite/pkg/source"
 	"github.com/weaveworks/ignite/pkg/util"
 	"github.com/weaveworks/libgitops/pkg/filter"
+	"github.com/weaveworks/libgitops/pkg/runtime"
 	"github.com/weaveworks/libgitops/pkg/storage/filterer"
 )
 
@@ -33,22 +35,48 @@ func FindOrImportImage(c *client.Client, ociRef meta.OCIImageRef) (*api.Image, e
 
 	switch err.(type) {
 	case *filterer.NonexistentError:
-		return importImage(c, ociRef)
+		dockerSource := source.NewDockerSource()
+		src, err := dockerSource.Parse(ociRef)
+		if err != nil {
+			return nil, err
+		}
+
+		return importImage(c, ociRef, dockerSource, src)
 	default:
 		return nil, err
 	}
 }
 
-// importKernel imports an image from an OCI image
-func importImage(c *client.Client, ociRef meta.OCIImageRef) (*api.Image, error) {
-	log.Debugf("Importing image with ociRef %q", ociRef)
-	// Parse the source
-	dockerSource := source.NewDockerSource()
-	src, err := dockerSource.Parse(ociRef)
+// ImportImageFromSource imports an image from the given source, like a tarball or a directory, named by
+// the given reference. If an image with that name exists already, it's returned if it was imported from
+// the same content. Images with the same name imported from other content need to be removed first.
+func ImportImageFromSource(c *client.Client, ociRef meta.OCIImageRef, imageSource source.Source) (_ *api.Image, err error) {
+	src, err := imageSource.Parse(ociRef)
 	if err != nil {
 		return nil, err
 	}
 
+	// Remove the resources of the source, like the buffered copy of stdin, also if the import fails
+	defer util.DeferErr(&err, imageSource.Cleanup)
+
+	image, err := c.Images().Find(filter.NewIDNameFilter(ociRef.String()))
+	switch err.(type) {
+	case nil:
+		if err := checkImportedFrom(image, image.Status.OCISource, src); err != nil {
+			return nil, err
+		}
+
+		return image, nil
+	case *filterer.NonexistentError:
+		return importImage(c, ociRef, imageSource, src)
+	default:
+		return nil, err
+	}
+}
+
+// importImage imports an image from the given source, which has been parsed into src
+func importImage(c *client.Client, ociRef meta.OCIImageRef, imageSource source.Source, src *api.OCIImageSource) (*api.Image, error) {
+	log.Debugf("Importing image with ociRef %q", ociRef)
 	image := c.Images().New()
 	// Set the image name
 	image.Name = ociRef.String()
@@ -64,8 +92,8 @@ func importImage(c *client.Client, ociRef meta.OCIImageRef) (*api.Image, error)
 
 	log.Infoln("Starting image import...")
 
-	// Truncate a file for the filesystem, format it with ext4, and copy in the files from the source
-	if err := dmlegacy.CreateImageFilesystem(image, dockerSource); err != nil {
+	// Extract the files from the source and create an ext4 filesystem sized to fit them
+	if err := dmlegacy.CreateImageFilesystem(image, imageSource); err != nil {
 		return nil, err
 	}
 
@@ -73,6 +101,8 @@ func importImage(c *client.Client, ociRef meta.OCIImageRef) (*api.Image, error)
 		return nil, err
 	}
 
+	events.Record(image, events.TypeImport, ociRef.String())
+
 	log.Infof("Imported OCI image %q (%s) to base image with UID %q", ociRef, image.Status.OCISource.Size, image.GetUID())
 	return image, nil
 }
@@ -91,22 +121,49 @@ func FindOrImportKernel(c *client.Client, ociRef meta.OCIImageRef) (*api.Kernel,
 
 	switch err.(type) {
 	case *filterer.NonexistentError:
-		return importKernel(c, ociRef)
+		dockerSource := source.NewDockerSource()
+		src, err := dockerSource.Parse(ociRef)
+		if err != nil {
+			return nil, err
+		}
+
+		return importKernel(c, ociRef, dockerSource, src)
 	default:
 		return nil, err
 	}
 }
 
-// importKernel imports a kernel from an OCI image
-func importKernel(c *client.Client, ociRef meta.OCIImageRef) (*api.Kernel, error) {
-	log.Debugf("Importing kernel with ociRef %q", ociRef)
-	// Parse the source
-	dockerSource := source.NewDockerSource()
-	src, err := dockerSource.Parse(ociRef)
+// ImportKernelFromSource imports a kernel from the given source, like a tarball or a directory, named by
+// the given reference. If a kernel with that name exists already, it's returned if it was imported from
+// the same content. Kernels with the same name imported from other content need to be removed first.
+func ImportKernelFromSource(c *client.Client, ociRef meta.OCIImageRef, kernelSource source.Source) (_ *api.Kernel, err error) {
+	src, err := kernelSource.Parse(ociRef)
 	if err != nil {
 		return nil, err
 	}
 
+	// Remove the resources of the source, like the buffered copy of stdin, also if the import fails
+	defer util.DeferErr(&err, kernelSource.Cleanup)
+
+	kernel, err := c.Kernels().Find(filter.NewIDNameFilter(ociRef.String()))
+	switch err.(type) {
+	case nil:
+		if err := checkImportedFrom(kernel, kernel.Status.OCISource, src); err != nil {
+			return nil, err
+		}
+
+		return kernel, nil
+	case *filterer.NonexistentError:
+		return importKernel(c, ociRef, kernelSource, src)
+	default:
+		return nil, err
+	}
+}
+
+// importKernel imports a kernel from the given source, which has been parsed into src
+func importKernel(c *client.Client, ociRef meta.OCIImageRef, kernelSource source.Source, src *api.OCIImageSource) (*api.Kernel, error) {
+	log.Debugf("Importing kernel with ociRef %q", ociRef)
+
 	kernel := c.Kernels().New()
 	// Set the kernel name
 	kernel.Name = ociRef.String()
@@ -136,7 +193,7 @@ func importKernel(c *client.Client, ociRef meta.OCIImageRef) (*api.Kernel, error
 		}
 
 		// Extract only the /boot and /lib directories of the tar stream into the tempDir
-		err = source.TarExtract(dockerSource, tempDir, "boot", "lib/modules")
+		err = source.TarExtract(kernelSource, tempDir, "boot", "lib/modules")
 		if err != nil {
 			return nil, err
 		}
@@ -179,10 +236,23 @@ func importKernel(c *client.Client, ociRef meta.OCIImageRef) (*api.Kernel, error
 		return nil, err
 	}
 
+	events.Record(kernel, events.TypeImport, ociRef.String())
+
 	log.Infof("Imported OCI image %q (%s) to kernel image with UID %q", ociRef, kernel.Status.OCISource.Size, kernel.GetUID())
 	return kernel, nil
 }
 
+// checkImportedFrom returns an error if the existing image or kernel wasn't imported from the content of src
+func checkImportedFrom(obj runtime.Object, imported api.OCIImageSource, src *api.OCIImageSource) error {
+	if imported.ID == nil || imported.ID.String() != src.ID.String() {
+		return fmt.Errorf("%s %q exists already, but was imported from %s instead of %s, remove it first to import it again",
+			obj.GetKind(), obj.GetName(), imported.ID, src.ID)
+	}
+
+	log.Infof("%s %q was imported from %s already", obj.GetKind(), obj.GetName(), src.ID)
+	return nil
+}
+
 func findKernel(tmpDir string) (string, error) {
 	// find the path to the kernel, resolve symlinks if necessary
 	bootDir := path.Join(tmpDir, "boot")
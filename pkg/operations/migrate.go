@@ -0,0 +1,45 @@
+package operations
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// MigrateVMDisk moves the disk of a stopped VM to the given storage driver. The filesystem on the disk
+// is copied into a new disk of the driver, and the old disk is removed once the copy succeeded. It
+// returns false if the driver stores the disk already.
+func MigrateVMDisk(vm *api.VM, driver snapshotter.Driver) (bool, error) {
+	if vm.Running() || util.FileExists(vm.SnapshotDev()) {
+		return false, fmt.Errorf("%s %q is running, stop it before migrating its disk", vm.GetKind(), vm.GetUID())
+	}
+
+	current := providers.StorageDriverForVM(vm)
+	if !current.HasDisk(vm) {
+		return false, fmt.Errorf("%s %q doesn't have a disk to migrate", vm.GetKind(), vm.GetUID())
+	}
+
+	if current.Name() == driver.Name() {
+		return false, nil
+	}
+
+	log.Infof("Migrating the disk of %s %q from the %s to the %s storage driver...", vm.GetKind(), vm.GetUID(), current.Name(), driver.Name())
+	devicePath, err := current.ActivateDisk(vm)
+	if err != nil {
+		return false, err
+	}
+
+	if err := driver.ImportDisk(vm, devicePath); err != nil {
+		if deactivateErr := current.DeactivateDisk(vm); deactivateErr != nil {
+			log.Warnf("Failed to deactivate the disk of %s %q: %v", vm.GetKind(), vm.GetUID(), deactivateErr)
+		}
+
+		return false, err
+	}
+
+	return true, current.RemoveDisk(vm)
+}
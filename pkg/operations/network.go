@@ -0,0 +1,204 @@
+package operations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/network/bridge"
+	"github.com/weaveworks/ignite/pkg/network/cni"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/libgitops/pkg/filter"
+)
+
+// WriteNetworkConfList generates the CNI configuration list of the network and writes it to the network's directory
+func WriteNetworkConfList(n *api.Network) error {
+	confList, err := cni.NetworkConfList(n)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(n.ConfListPath(), confList, constants.DATA_DIR_FILE_PERM)
+}
+
+// CheckNetworkConflicts verifies that the bridge name of the network isn't taken by another network, and that its
+// subnet doesn't overlap with the subnets of the other networks or of the default networks of ignite's plugins
+func CheckNetworkConflicts(n *api.Network) error {
+	networks, err := providers.Client.Networks().FindAll(filter.NewAllFilter())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	_, subnet, err := net.ParseCIDR(n.Spec.Subnet)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range networks {
+		if other.GetUID() == n.GetUID() {
+			continue
+		}
+
+		if other.Spec.BridgeName == n.Spec.BridgeName {
+			return fmt.Errorf("bridge %q is already used by network %q", n.Spec.BridgeName, other.GetName())
+		}
+
+		if _, otherSubnet, err := net.ParseCIDR(other.Spec.Subnet); err == nil && overlaps(subnet, otherSubnet) {
+			return fmt.Errorf("subnet %s overlaps with subnet %s of network %q", subnet, otherSubnet, other.GetName())
+		}
+	}
+
+	for _, s := range append(cni.DefaultSubnets(), bridge.DefaultSubnets()...) {
+		if _, defaultSubnet, _ := net.ParseCIDR(s); overlaps(subnet, defaultSubnet) {
+			return fmt.Errorf("subnet %s overlaps with subnet %s of a default network", subnet, defaultSubnet)
+		}
+	}
+
+	return nil
+}
+
+// overlaps returns true if one of the subnets contains the other
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// RemoveNetwork removes the bridge of the network and its isolation rules, and deletes the network
+func RemoveNetwork(n *api.Network) error {
+	if err := bridge.RemoveBridge(n.Spec.BridgeName); err != nil {
+		return fmt.Errorf("failed to remove bridge %q of network %q: %v", n.Spec.BridgeName, n.GetName(), err)
+	}
+
+	return providers.Client.Networks().Delete(n.GetUID())
+}
+
+// LookupNetworks returns the user-defined networks of the VM, in the order they're attached in
+func LookupNetworks(vm *api.VM) ([]*api.Network, error) {
+	if len(vm.Spec.Network.Networks) > 0 && !providers.NetworkPlugin.Name().SupportsNetworks() {
+		return nil, fmt.Errorf("the %q network plugin doesn't support user-defined networks", providers.NetworkPlugin.Name())
+	}
+
+	if len(vm.Spec.Network.CNINetworks) > 0 && providers.NetworkPlugin.Name() != network.PluginCNI {
+		return nil, fmt.Errorf("the %q network plugin doesn't support CNI networks", providers.NetworkPlugin.Name())
+	}
+
+	networks := make([]*api.Network, 0, len(vm.Spec.Network.Networks))
+	for _, name := range vm.Spec.Network.Networks {
+		n, err := providers.Client.Networks().Find(filter.NewNameFilter(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find network %q of VM %q: %v", name, vm.GetUID(), err)
+		}
+
+		networks = append(networks, n)
+	}
+
+	return networks, nil
+}
+
+// networkAttachments returns the attachments of the VM to its networks for the network plugin. The CNI configuration
+// lists of the user-defined networks are written first, the VM is attached to its CNI networks by their names.
+func networkAttachments(vm *api.VM, networks []*api.Network) ([]network.Attachment, error) {
+	attachments := make([]network.Attachment, 0, len(networks)+len(vm.Spec.Network.CNINetworks))
+	for _, n := range networks {
+		if err := WriteNetworkConfList(n); err != nil {
+			return nil, fmt.Errorf("failed to write the CNI configuration of network %q: %v", n.GetName(), err)
+		}
+
+		attachments = append(attachments, network.Attachment{ConfListPath: n.ConfListPath()})
+	}
+
+	for _, a := range vm.Spec.Network.CNINetworks {
+		attachments = append(attachments, cniAttachment(a))
+	}
+
+	return attachments, nil
+}
+
+// cniAttachment converts a CNI network attachment of a VM, its capabilities
+// are passed to the plugins as the runtime configuration of CNI
+func cniAttachment(a api.CNINetworkAttachment) network.Attachment {
+	capabilities := make(map[string]interface{})
+	if a.Bandwidth != nil {
+		capabilities["bandwidth"] = map[string]uint64{
+			"ingressRate":  a.Bandwidth.IngressRate,
+			"ingressBurst": a.Bandwidth.IngressBurst,
+			"egressRate":   a.Bandwidth.EgressRate,
+			"egressBurst":  a.Bandwidth.EgressBurst,
+		}
+	}
+
+	if len(a.IPs) > 0 {
+		capabilities["ips"] = a.IPs
+	}
+
+	if len(a.MAC) > 0 {
+		capabilities["mac"] = a.MAC
+	}
+
+	return network.Attachment{
+		Name:         a.Name,
+		Args:         a.Args,
+		Capabilities: capabilities,
+	}
+}
+
+// networkStatus converts the per-network results of the network plugin for the status of the VM
+func networkStatus(result *network.Result) []api.NetworkAttachmentStatus {
+	var status []api.NetworkAttachmentStatus
+	for _, n := range result.Networks {
+		s := api.NetworkAttachmentStatus{
+			Name:      n.Name,
+			Interface: n.Interface,
+			MAC:       n.MAC,
+		}
+
+		for _, addr := range n.Addresses {
+			s.IPAddresses = append(s.IPAddresses, addr.IP)
+		}
+
+		status = append(status, s)
+	}
+
+	return status
+}
+
+// isolateNetworks isolates the bridges of the isolated networks, once the VM has been attached to them
+func isolateNetworks(networks []*api.Network) error {
+	for _, n := range networks {
+		if !n.Spec.Isolated {
+			continue
+		}
+
+		if err := bridge.IsolateBridge(n.Spec.BridgeName); err != nil {
+			return fmt.Errorf("failed to isolate network %q: %v", n.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// removalAttachments returns the attachments of the networks to detach the VM from. User-defined
+// networks that don't exist anymore are skipped, there's nothing left to detach from.
+func removalAttachments(vm *api.VM) []network.Attachment {
+	var networks []*api.Network
+	for _, name := range vm.Spec.Network.Networks {
+		n, err := providers.Client.Networks().Find(filter.NewNameFilter(name))
+		if err != nil {
+			log.Warnf("Skipping network %q of VM %q: %v", name, vm.GetUID(), err)
+			continue
+		}
+
+		networks = append(networks, n)
+	}
+
+	attachments, err := networkAttachments(vm, networks)
+	if err != nil {
+		log.Warnf("Failed to write the CNI configuration of the networks of VM %q: %v", vm.GetUID(), err)
+	}
+
+	return attachments
+}
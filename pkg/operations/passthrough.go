@@ -0,0 +1,112 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+// attachPassthroughInterfaces creates the macvlan and ipvlan interfaces of the VM on their host parent
+// devices and moves them into the network namespace of the VM container, where ignite-spawn picks them up.
+// The interfaces are removed by the kernel together with the network namespace when the container exits.
+// Interfaces without a parent device are expected to be created in the container by another system.
+func attachPassthroughInterfaces(vm *api.VM, containerID string) error {
+	var intfs []api.NetworkInterface
+	for _, intf := range vm.Spec.Network.Interfaces {
+		if intf.Mode.IsPassthrough() && len(intf.Parent) > 0 {
+			intfs = append(intfs, intf)
+		}
+	}
+
+	if len(intfs) == 0 {
+		return nil
+	}
+
+	result, err := providers.Runtime.InspectContainer(containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %q: %v", containerID, err)
+	}
+
+	ns, err := netns.GetFromPid(int(result.PID))
+	if err != nil {
+		return fmt.Errorf("failed to get the network namespace of container %q: %v", containerID, err)
+	}
+	defer ns.Close()
+
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return err
+	}
+	defer handle.Delete()
+
+	for i, intf := range intfs {
+		if err := attachPassthroughInterface(vm, &intf, passthroughLinkName(vm, i), int(result.PID), handle); err != nil {
+			return fmt.Errorf("failed to attach %s interface %q on %q: %v", intf.Mode, intf.Name, intf.Parent, err)
+		}
+
+		log.Infof("Attached %s interface %q on host device %q to VM %q", intf.Mode, intf.Name, intf.Parent, vm.GetUID())
+	}
+
+	return nil
+}
+
+// attachPassthroughInterface creates the device under a temporary name, as the name of the
+// interface in the sandbox may be taken on the host, and renames it after the move
+func attachPassthroughInterface(vm *api.VM, intf *api.NetworkInterface, tmpName string, pid int, handle *netlink.Handle) error {
+	parent, err := netlink.LinkByName(intf.Parent)
+	if err != nil {
+		return err
+	}
+
+	la := netlink.NewLinkAttrs()
+	la.Name = tmpName
+	la.ParentIndex = parent.Attrs().Index
+	if intf.MTU > 0 {
+		la.MTU = int(intf.MTU)
+	}
+
+	var link netlink.Link
+	switch intf.Mode {
+	case api.InterfaceModeMacvlan:
+		// Firecracker only supports TAP devices, so a macvlan device is handed to ignite-spawn, which
+		// connects it to the VM's TAP device. The VM uses the MAC address of the macvlan device.
+		if la.HardwareAddr, err = net.ParseMAC(vm.MACAddress(intf.Name)); err != nil {
+			return err
+		}
+		link = &netlink.Macvlan{LinkAttrs: la, Mode: netlink.MACVLAN_MODE_BRIDGE}
+	case api.InterfaceModeIPVLAN:
+		link = &netlink.IPVlan{LinkAttrs: la, Mode: netlink.IPVLAN_MODE_L2}
+	default:
+		return fmt.Errorf("unsupported mode %q", intf.Mode)
+	}
+
+	if err := netlink.LinkAdd(link); err != nil {
+		return err
+	}
+
+	if err := netlink.LinkSetNsPid(link, pid); err != nil {
+		_ = netlink.LinkDel(link)
+		return err
+	}
+
+	if link, err = handle.LinkByName(tmpName); err != nil {
+		return err
+	}
+
+	return handle.LinkSetName(link, intf.Name)
+}
+
+// passthroughLinkName returns a host-unique temporary name for the i-th passthrough interface of the VM
+func passthroughLinkName(vm *api.VM, i int) string {
+	uid := vm.GetUID().String()
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+
+	return fmt.Sprintf("ig%s%d", uid, i)
+}
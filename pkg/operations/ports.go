@@ -0,0 +1,109 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+// portAllocationAttempts limits how many free ports are requested
+// from the kernel before giving up on finding an unused one
+const portAllocationAttempts = 100
+
+// allocatePorts returns the port mappings of the given VM, with a free host port
+// allocated for every mapping that doesn't specify one. CNI forwards the ports with
+// iptables rules instead of listening on them, so the ports of the other running VMs
+// look free to the kernel and need to be excluded explicitly.
+func allocatePorts(vm *api.VM) (meta.PortMappings, error) {
+	ports := make(meta.PortMappings, 0, len(vm.Spec.Network.Ports))
+	used := map[string]bool{}
+
+	vms, err := providers.Client.VMs().List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range vms {
+		if other.GetUID() == vm.GetUID() || !other.Running() || other.Status.Network == nil {
+			continue
+		}
+
+		for _, port := range other.Status.Network.Ports {
+			used[portKey(port.HostPort, port.Protocol)] = true
+		}
+	}
+
+	// Explicitly requested ports can't be handed out either
+	for _, port := range vm.Spec.Network.Ports {
+		if port.HostPort != 0 {
+			used[portKey(port.HostPort, port.Protocol)] = true
+		}
+	}
+
+	for _, port := range vm.Spec.Network.Ports {
+		if port.HostPort == 0 {
+			if port.HostPort, err = freePort(port.BindAddress, port.Protocol, used); err != nil {
+				return nil, fmt.Errorf("failed to allocate a host port for VM port %d: %v", port.VMPort, err)
+			}
+
+			used[portKey(port.HostPort, port.Protocol)] = true
+		}
+
+		ports = append(ports, port)
+	}
+
+	return ports, nil
+}
+
+// freePort asks the kernel for a free port on the given address, skipping the used ones
+func freePort(bindAddress net.IP, protocol meta.Protocol, used map[string]bool) (uint64, error) {
+	host := ""
+	if bindAddress != nil {
+		host = bindAddress.String()
+	}
+	address := net.JoinHostPort(host, "0")
+
+	for i := 0; i < portAllocationAttempts; i++ {
+		var port uint64
+
+		if protocol == meta.ProtocolUDP {
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				return 0, err
+			}
+
+			port = uint64(conn.LocalAddr().(*net.UDPAddr).Port)
+			if err := conn.Close(); err != nil {
+				return 0, err
+			}
+		} else {
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				return 0, err
+			}
+
+			port = uint64(listener.Addr().(*net.TCPAddr).Port)
+			if err := listener.Close(); err != nil {
+				return 0, err
+			}
+		}
+
+		if !used[portKey(port, protocol)] {
+			return port, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no free port found in %d attempts", portAllocationAttempts)
+}
+
+func portKey(port uint64, protocol meta.Protocol) string {
+	if len(protocol) == 0 {
+		protocol = meta.ProtocolTCP
+	}
+
+	return strconv.FormatUint(port, 10) + "/" + protocol.String()
+}
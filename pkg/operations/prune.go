@@ -0,0 +1,381 @@
+package operations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/util"
+	"github.com/weaveworks/libgitops/pkg/runtime"
+)
+
+// PruneClass is a class of resources Prune removes
+type PruneClass string
+
+const (
+	// PruneContainers are the sandbox containers of VMs that don't exist
+	PruneContainers PruneClass = "containers"
+	// PruneDevices are the snapshot and base devices of VMs that don't exist or aren't running
+	PruneDevices PruneClass = "devices"
+	// PruneLoopDevices are the loop devices of ignite's files that aren't used by any device
+	PruneLoopDevices PruneClass = "loop-devices"
+	// PruneNetworks is the networking leaked by containers, see RepairNetworks
+	PruneNetworks PruneClass = "networks"
+	// PruneVMDirs are the directories of VMs without metadata
+	PruneVMDirs PruneClass = "vm-dirs"
+	// PruneImages are the images no VM is created from
+	PruneImages PruneClass = "images"
+	// PruneKernels are the kernels no VM boots
+	PruneKernels PruneClass = "kernels"
+)
+
+// containerNameLabel is the label of the sandbox containers of VMs holding the name of the VM
+const containerNameLabel = "ignite.name"
+
+// deviceRegexp matches the names of the snapshot and base devices of VMs, e.g. ignite-9a10b07d7c0d4ce9-base
+var deviceRegexp = regexp.MustCompile(`^(.+)-([0-9a-f]{16})(-base)?$`)
+
+// LeakedPruneClasses returns the classes of resources leaked by crashed operations, which are pruned by
+// default, in the pruning order. The VM directories hold the disks of the VMs, they're only pruned on request.
+func LeakedPruneClasses() []PruneClass {
+	return []PruneClass{
+		PruneContainers,
+		PruneDevices,
+		PruneLoopDevices,
+		PruneNetworks,
+	}
+}
+
+// PruneClasses returns all classes of resources, in the pruning order
+func PruneClasses() []PruneClass {
+	return []PruneClass{
+		PruneContainers,
+		PruneDevices,
+		PruneLoopDevices,
+		PruneVMDirs,
+		PruneNetworks,
+		PruneImages,
+		PruneKernels,
+	}
+}
+
+// PrunedResource is a resource removed by Prune
+type PrunedResource struct {
+	Class PruneClass
+	// Name identifies the resource, e.g. the name of a device or the path of a directory
+	Name string
+}
+
+// Prune removes the resources of the given classes leaked by crashed operations, by cross-referencing the VMs,
+// images and kernels with the devices, the containers and the networking of the host. The classes are pruned in
+// the order of PruneClasses, so the devices of removed containers are pruned too. A dry run only reports them.
+func Prune(classes []PruneClass, dryRun bool) ([]PrunedResource, error) {
+	selected := make(map[PruneClass]bool, len(classes))
+	for _, class := range classes {
+		selected[class] = true
+	}
+
+	dirs, err := listVMDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []PrunedResource
+	for _, class := range PruneClasses() {
+		if !selected[class] {
+			continue
+		}
+
+		var names []string
+		switch class {
+		case PruneContainers:
+			names, err = pruneContainers(dirs, dryRun)
+		case PruneDevices:
+			names, err = pruneDevices(dirs, dryRun)
+		case PruneLoopDevices:
+			names, err = dmlegacy.DetachLeakedLoopDevices(constants.DATA_DIR, dryRun)
+		case PruneVMDirs:
+			names, err = removeAll(dirs.leaked, dryRun)
+		case PruneNetworks:
+			names, err = pruneNetworks(dryRun)
+		case PruneImages:
+			names, err = pruneImages(dirs, dryRun)
+		case PruneKernels:
+			names, err = pruneKernels(dirs, dryRun)
+		}
+		if err != nil {
+			return pruned, fmt.Errorf("failed to prune %s: %v", class, err)
+		}
+
+		for _, name := range names {
+			pruned = append(pruned, PrunedResource{Class: class, Name: name})
+		}
+	}
+
+	return pruned, nil
+}
+
+// vmDirs are the VM directories found by listVMDirs
+type vmDirs struct {
+	vms []*api.VM
+	// leaked are the directories without metadata, they're left behind if creating a VM fails
+	leaked []string
+	// invalid are the UIDs of the VMs whose metadata can't be loaded. Nothing of them is pruned, as it's unknown
+	// what they use, and it's up to the user to fix or remove them.
+	invalid map[string]bool
+}
+
+// listVMDirs loads the VMs of the VM directories one by one, listing them all at once fails
+// if the metadata of any of them is invalid
+func listVMDirs() (*vmDirs, error) {
+	dirs := &vmDirs{invalid: map[string]bool{}}
+	entries, err := ioutil.ReadDir(constants.VM_DIR)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dirs, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(constants.VM_DIR, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, constants.METADATA)); os.IsNotExist(err) {
+			dirs.leaked = append(dirs.leaked, dir)
+			continue
+		}
+
+		vm, err := providers.Client.VMs().Get(runtime.UID(entry.Name()))
+		if err != nil {
+			log.Warnf("Skipping VM %q and its resources, failed to load its metadata: %v", entry.Name(), err)
+			dirs.invalid[entry.Name()] = true
+			continue
+		}
+
+		dirs.vms = append(dirs.vms, vm)
+	}
+
+	return dirs, nil
+}
+
+// checkInvalid returns an error if there are VMs with invalid metadata, the images and kernels they use are unknown
+func (dirs *vmDirs) checkInvalid() error {
+	if len(dirs.invalid) == 0 {
+		return nil
+	}
+
+	uids := make([]string, 0, len(dirs.invalid))
+	for uid := range dirs.invalid {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	return fmt.Errorf("the metadata of VMs %s can't be loaded, fix or remove them first", strings.Join(uids, ", "))
+}
+
+// pruneContainers removes the sandbox containers of VMs that don't exist. Containers of other runtimes
+// can't be listed, but the runtime of a removed VM is unknown, so only the current runtime is pruned.
+func pruneContainers(dirs *vmDirs, dryRun bool) ([]string, error) {
+	known := make(map[string]bool, len(dirs.vms))
+	for _, vm := range dirs.vms {
+		known[vm.PrefixedID()] = true
+	}
+
+	containers, err := providers.Runtime.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, c := range containers {
+		if _, ok := c.Labels[containerNameLabel]; !ok || known[c.Name] {
+			continue
+		}
+
+		// The containers are named like the devices of the VMs
+		if match := deviceRegexp.FindStringSubmatch(c.Name); match != nil && dirs.invalid[match[2]] {
+			continue
+		}
+
+		if !dryRun {
+			log.Infof("Removing container %q of removed VM %q", c.Name, c.Labels[containerNameLabel])
+			// The container may still be running, or be removed by the runtime once it's killed
+			_ = providers.Runtime.KillContainer(c.Name, signalSIGQUIT)
+			if err := providers.Runtime.RemoveContainer(c.Name); err != nil {
+				return names, err
+			}
+		}
+
+		names = append(names, c.Name)
+	}
+
+	return names, nil
+}
+
+// pruneDevices removes the snapshot and base devices of VMs that don't exist or whose containers aren't running.
+// The devices are listed and removed while holding the lock of the activation of snapshots, and the devices of
+// VMs that are being started are skipped, as their containers don't run yet.
+func pruneDevices(dirs *vmDirs, dryRun bool) (names []string, err error) {
+	// The prefixes the devices of the VMs can be named with, and the VMs by their device names
+	prefixes := map[string]bool{constants.IGNITE_PREFIX: true, providers.IDPrefix: true}
+	known := make(map[string]*api.VM, len(dirs.vms))
+	for _, vm := range dirs.vms {
+		prefixes[vm.NewPrefixer().Prefix()] = true
+		known[vm.PrefixedID()] = vm
+	}
+
+	unlock, err := dmlegacy.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer util.DeferErr(&err, unlock)
+
+	devices, err := dmlegacy.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots are removed before their base devices
+	var snapshots, bases []string
+	for _, device := range devices {
+		match := deviceRegexp.FindStringSubmatch(device)
+		if match == nil || !prefixes[match[1]] || dirs.invalid[match[2]] || vmStarting(match[2]) {
+			continue
+		}
+
+		if vm := known[match[1]+"-"+match[2]]; vm != nil {
+			if ir, err := providers.Runtime.InspectContainer(vm.PrefixedID()); err == nil && ir.Status == runtimeRunningStatus {
+				continue
+			}
+		}
+
+		if len(match[3]) > 0 {
+			bases = append(bases, device)
+		} else {
+			snapshots = append(snapshots, device)
+		}
+	}
+
+	names = append(snapshots, bases...)
+	if !dryRun && len(names) > 0 {
+		log.Infof("Removing devices %s", strings.Join(names, ", "))
+		if err := dmlegacy.RemoveDevicesLocked(names...); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// pruneNetworks releases the networking leaked by containers and removes the bridges of removed networks
+func pruneNetworks(dryRun bool) ([]string, error) {
+	result, err := RepairNetworks(dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	names := append([]string{}, result.ReleasedContainers...)
+	for _, name := range result.RemovedBridges {
+		names = append(names, "bridge "+name)
+	}
+
+	return names, nil
+}
+
+// pruneImages removes the images no VM is created from, together with their layers kept by the storage drivers
+func pruneImages(dirs *vmDirs, dryRun bool) ([]string, error) {
+	if err := dirs.checkInvalid(); err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(dirs.vms))
+	for _, vm := range dirs.vms {
+		used[vm.Spec.Image.OCI.String()] = true
+	}
+
+	images, err := providers.Client.Images().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var names []string
+	for _, image := range images {
+		if used[image.GetName()] {
+			continue
+		}
+
+		if !dryRun {
+			log.Infof("Removing image %q", image.GetName())
+			if err := RemoveImage(image); err != nil {
+				return names, err
+			}
+		}
+
+		names = append(names, image.GetName())
+	}
+
+	return names, nil
+}
+
+// pruneKernels removes the kernels no VM boots, together with their layers kept by the storage drivers
+func pruneKernels(dirs *vmDirs, dryRun bool) ([]string, error) {
+	if err := dirs.checkInvalid(); err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(dirs.vms))
+	for _, vm := range dirs.vms {
+		used[vm.Spec.Kernel.OCI.String()] = true
+	}
+
+	kernels, err := providers.Client.Kernels().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var names []string
+	for _, kernel := range kernels {
+		if used[kernel.GetName()] {
+			continue
+		}
+
+		if !dryRun {
+			log.Infof("Removing kernel %q", kernel.GetName())
+			if err := RemoveKernel(kernel); err != nil {
+				return names, err
+			}
+		}
+
+		names = append(names, kernel.GetName())
+	}
+
+	return names, nil
+}
+
+// removeAll removes the given directories
+func removeAll(dirs []string, dryRun bool) ([]string, error) {
+	for i, dir := range dirs {
+		if dryRun {
+			continue
+		}
+
+		log.Infof("Removing directory %q", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return dirs[:i], err
+		}
+	}
+
+	return dirs, nil
+}
@@ -26,6 +26,10 @@ var (
 		Name: "vm_stop_counter",
 		Help: "The count of VMs stopped",
 	})
+	vmRecovered = go_prom.NewCounter(go_prom.CounterOpts{
+		Name: "vm_recover_counter",
+		Help: "The count of VMs restarted when ignited started",
+	})
 	kindIgnored = go_prom.NewCounter(go_prom.CounterOpts{
 		Name: "kind_ignored_counter",
 		Help: "A counter of non-vm manifests ignored",
@@ -34,7 +38,7 @@ var (
 
 func startMetricsThread() {
 	reg, server := prometheus.New()
-	reg.MustRegister(vmCreated, vmDeleted, vmStarted, vmStopped, kindIgnored)
+	reg.MustRegister(vmCreated, vmDeleted, vmStarted, vmStopped, vmRecovered, kindIgnored)
 
 	go func() {
 		// create a new registry and http.Server. don't register custom metrics to the registry quite yet
@@ -6,10 +6,9 @@ import (
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/apis/ignite/validation"
 	"github.com/weaveworks/ignite/pkg/client"
-	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/operations"
 	"github.com/weaveworks/ignite/pkg/providers"
-	"github.com/weaveworks/ignite/pkg/util"
 	"github.com/weaveworks/libgitops/pkg/storage/cache"
 	"github.com/weaveworks/libgitops/pkg/storage/manifest"
 	"github.com/weaveworks/libgitops/pkg/storage/watch/update"
@@ -44,7 +43,7 @@ func ReconcileManifests(s *manifest.ManifestStorage) {
 				Status: api.VMStatus{
 					Running: true, // TODO: Fix this in StopVM
 					Runtime: &ignite.Runtime{},
-					Network: &ignite.Network{},
+					Network: &ignite.VMNetworkStatus{},
 				},
 			}
 		} else {
@@ -95,6 +94,8 @@ func handleChange(vm *api.VM) (err error) {
 	// differs from the current state
 	running := currentState(vm)
 	if vm.Status.Running && !running {
+		// A started VM whose container is gone has died, e.g. its sandbox was killed
+		operations.RecordDie(vm, "the container is gone")
 		err = start(vm)
 	} else if !vm.Status.Running && running {
 		err = stop(vm)
@@ -114,8 +115,13 @@ func create(vm *api.VM) error {
 		return err
 	}
 	vmCreated.Inc()
-	// Allocate and populate the overlay file
-	return dmlegacy.AllocateAndPopulateOverlay(vm)
+	// Allocate and populate the disk with the selected storage driver
+	if err := providers.StorageDriver.CreateDisk(vm); err != nil {
+		return err
+	}
+
+	events.Record(vm, events.TypeCreate, "")
+	return nil
 }
 
 // ensureOCIImages imports the base/kernel OCI images if needed
@@ -143,8 +149,8 @@ func ensureOCIImages(vm *api.VM) error {
 }
 
 func start(vm *api.VM) error {
-	// create the overlay if it doesn't exist
-	if !util.FileExists(vm.OverlayFile()) {
+	// create the disk if it doesn't exist
+	if !providers.StorageDriverForVM(vm).HasDisk(vm) {
 		if err := create(vm); err != nil {
 			return err
 		}
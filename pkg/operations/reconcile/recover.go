@@ -0,0 +1,118 @@
+package reconcile
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/operations"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+// RecoveryOptions rate-limits the restarts of the VMs by RecoverVMs, so
+// a host with many VMs isn't overloaded by starting them all at once
+type RecoveryOptions struct {
+	// Parallelism is the number of VMs started at once, restarts are disabled if it's 0
+	Parallelism int
+	// Interval is the minimum time between starting two VMs
+	Interval time.Duration
+}
+
+// RecoverVMs brings the VMs back to their desired state when ignited starts, e.g. after a host reboot. The VMs
+// whose containers are gone are marked stopped and their leaked networking is released, see operations.RepairNetworks.
+// The VMs that should run according to their restart policy are started again, which reactivates their snapshots.
+func RecoverVMs(opts RecoveryOptions) {
+	vms, err := providers.Client.VMs().List()
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to list the VMs to recover: %v", err)
+		return
+	}
+
+	// The desired state is recorded first, the repair marks the VMs whose containers are gone stopped
+	var restart []*api.VM
+	for _, vm := range vms {
+		if shouldRestart(vm) {
+			restart = append(restart, vm)
+		}
+	}
+
+	log.Infof("Repairing the networking state...")
+	if _, err := operations.RepairNetworks(false); err != nil {
+		log.Errorf("Failed to repair the networking state: %v", err)
+	}
+
+	if opts.Parallelism <= 0 {
+		return
+	}
+
+	sort.Slice(restart, func(i, j int) bool {
+		return restart[i].GetName() < restart[j].GetName()
+	})
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Parallelism)
+	started := 0
+	for _, vm := range restart {
+		if containerRunning(vm) {
+			continue
+		}
+
+		if started > 0 {
+			time.Sleep(opts.Interval)
+		}
+		started++
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(vm *api.VM) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			runHandle(func() error {
+				return recoverVM(vm)
+			})
+		}(vm)
+	}
+
+	wg.Wait()
+}
+
+// shouldRestart returns true if the VM should be running after ignited starts. VMs without a
+// restart policy follow their desired state, which is to run if they're marked running.
+func shouldRestart(vm *api.VM) bool {
+	switch vm.Spec.RestartPolicy {
+	case api.RestartPolicyAlways:
+		return true
+	case api.RestartPolicyNo:
+		return false
+	default:
+		return vm.Status.Running
+	}
+}
+
+// recoverVM starts the VM again, with its latest state from the storage
+func recoverVM(vm *api.VM) error {
+	vm, err := providers.Client.VMs().Get(vm.GetUID())
+	if err != nil {
+		return err
+	}
+
+	// VMs that never started have no disk yet, they're left to the reconciliation of their manifests
+	if !providers.StorageDriverForVM(vm).HasDisk(vm) {
+		log.Warnf("Skipping the recovery of VM %q with name %q, it has never been started", vm.GetUID(), vm.GetName())
+		return nil
+	}
+
+	log.Infof("Recovering VM %q with name %q...", vm.GetUID(), vm.GetName())
+	vmRecovered.Inc()
+	return operations.StartVM(vm, true)
+}
+
+// containerRunning returns true if the container of the VM is running
+func containerRunning(vm *api.VM) bool {
+	ir, err := providers.Runtime.InspectContainer(vm.PrefixedID())
+	return err == nil && ir.Status == "running"
+}
@@ -0,0 +1,32 @@
+package reconcile
+
+import (
+	"testing"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+)
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		policy  api.RestartPolicy
+		running bool
+		restart bool
+	}{
+		{policy: "", running: true, restart: true},
+		{policy: "", running: false, restart: false},
+		{policy: api.RestartPolicyNo, running: true, restart: false},
+		{policy: api.RestartPolicyAlways, running: false, restart: true},
+		{policy: api.RestartPolicyAlways, running: true, restart: true},
+	}
+
+	for _, rt := range tests {
+		vm := &api.VM{
+			Spec:   api.VMSpec{RestartPolicy: rt.policy},
+			Status: api.VMStatus{Running: rt.running},
+		}
+
+		if actual := shouldRestart(vm); actual != rt.restart {
+			t.Errorf("expected restart %t for policy %q and running %t, got %t", rt.restart, rt.policy, rt.running, actual)
+		}
+	}
+}
@@ -8,8 +8,9 @@ import (
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/client"
-	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/logs"
+	"github.com/weaveworks/ignite/pkg/network"
 	"github.com/weaveworks/ignite/pkg/providers"
 	"github.com/weaveworks/ignite/pkg/runtime"
 )
@@ -46,14 +47,13 @@ func CleanupVM(vm *api.VM) error {
 		RemoveVMContainer(inspectResult)
 	}
 
-	// After removing the VM container, if the Snapshot Device is still there, clean up
-	if _, err := os.Stat(vm.SnapshotDev()); err == nil {
-		// try remove it again with DeactivateSnapshot
-		if err := dmlegacy.DeactivateSnapshot(vm); err != nil {
-			return err
-		}
+	// After removing the VM container, deactivate the device of the disk if it's still there, and remove the disk
+	if err := providers.StorageDriverForVM(vm).RemoveDisk(vm); err != nil {
+		return err
 	}
 
+	events.Record(vm, events.TypeRemove, "")
+
 	if logs.Quiet {
 		fmt.Println(vm.GetUID())
 	} else {
@@ -86,7 +86,7 @@ func StopVM(vm *api.VM, kill, silent bool) error {
 	}
 
 	// Remove VM networking
-	if err = removeNetworking(vm.Status.Runtime.ID, vm.Spec.Network.Ports...); err != nil {
+	if err = removeNetworking(vm.Status.Runtime.ID, removalAttachments(vm), vm.Ports()...); err != nil {
 		log.Warnf("Failed to cleanup networking for stopped container %s %q: %v", vm.GetKind(), vm.GetUID(), err)
 
 		return err
@@ -105,6 +105,8 @@ func StopVM(vm *api.VM, kill, silent bool) error {
 			return fmt.Errorf("failed to %s container for %s %q: %v", action, vm.GetKind(), vm.GetUID(), err)
 		}
 
+		events.Record(vm, events.TypeStop, action)
+
 		if silent {
 			return nil
 		}
@@ -119,7 +121,37 @@ func StopVM(vm *api.VM, kill, silent bool) error {
 	return nil
 }
 
-func removeNetworking(containerID string, portmappings ...meta.PortMapping) error {
+func removeNetworking(containerID string, networks []network.Attachment, portmappings ...meta.PortMapping) error {
 	log.Infof("Removing the container with ID %q from the %q network", containerID, providers.NetworkPlugin.Name())
-	return providers.NetworkPlugin.RemoveContainerNetwork(containerID, portmappings...)
+	return providers.NetworkPlugin.RemoveContainerNetwork(containerID, networks, portmappings...)
+}
+
+// RemoveImage removes the layers the storage drivers keep of the image, and the directory of the image
+func RemoveImage(image *api.Image) error {
+	for _, driver := range providers.StorageDrivers {
+		if err := driver.RemoveImage(image); err != nil {
+			return fmt.Errorf("unable to remove the layers of %s %q: %v", image.GetKind(), image.GetUID(), err)
+		}
+	}
+
+	if err := os.RemoveAll(image.ObjectPath()); err != nil {
+		return fmt.Errorf("unable to remove directory for %s %q: %v", image.GetKind(), image.GetUID(), err)
+	}
+
+	return nil
+}
+
+// RemoveKernel removes the layers the storage drivers keep of the kernel, and the directory of the kernel
+func RemoveKernel(kernel *api.Kernel) error {
+	for _, driver := range providers.StorageDrivers {
+		if err := driver.RemoveKernel(kernel); err != nil {
+			return fmt.Errorf("unable to remove the layers of %s %q: %v", kernel.GetKind(), kernel.GetUID(), err)
+		}
+	}
+
+	if err := os.RemoveAll(kernel.ObjectPath()); err != nil {
+		return fmt.Errorf("unable to remove directory for %s %q: %v", kernel.GetKind(), kernel.GetUID(), err)
+	}
+
+	return nil
 }
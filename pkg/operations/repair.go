@@ -0,0 +1,204 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/network/bridge"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+// runtimeRunningStatus is the status of running containers reported by the container runtimes
+const runtimeRunningStatus = "running"
+
+// networkBridgeRegexp matches the names of the bridges of user-defined networks, which need to have the prefix
+var networkBridgeRegexp = regexp.MustCompile("^" + regexp.QuoteMeta(constants.NETWORK_NAME_PREFIX) + ".+$")
+
+// RepairResult lists what RepairNetworks corrected, or would correct in a dry run
+type RepairResult struct {
+	// StoppedVMs are the VMs marked running whose containers are gone
+	StoppedVMs []*api.VM
+	// ReleasedContainers are the IDs of the containers whose leaked networking was removed
+	ReleasedContainers []string
+	// RemovedBridges are the bridges of networks that don't exist anymore
+	RemovedBridges []string
+	// BrokenVMs are the running VMs whose networking failed the check of the network plugin
+	BrokenVMs []*api.VM
+}
+
+// RepairNetworks brings the networking state of the host in line with the VMs, after the host
+// rebooted or the containers of the VMs were removed without ignite. VMs marked running
+// whose containers are gone are marked stopped, the networking leaked by their containers
+// is removed and the networking of the running VMs is checked. A dry run only reports
+// what would be corrected.
+func RepairNetworks(dryRun bool) (*RepairResult, error) {
+	if err := providers.Runtime.PreflightChecker().Check(); err != nil {
+		return nil, fmt.Errorf("the %q container runtime isn't available: %v", providers.RuntimeName, err)
+	}
+
+	vms, err := providers.Client.VMs().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	networks, err := providers.Client.Networks().List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	result := &RepairResult{}
+	// known are the containers of ignite's VMs, running are the ones with live containers
+	known, running := map[string]bool{}, map[string]bool{}
+	for _, vm := range vms {
+		ids := []string{vm.PrefixedID()}
+		if vm.Status.Runtime != nil && len(vm.Status.Runtime.ID) > 0 {
+			ids = append(ids, vm.Status.Runtime.ID)
+		}
+
+		for _, id := range ids {
+			known[id] = true
+		}
+
+		// VMs of another runtime can't be verified, their networking is left untouched
+		if vm.Status.Runtime != nil && vm.Status.Runtime.Name != providers.RuntimeName {
+			if vm.Running() {
+				for _, id := range ids {
+					running[id] = true
+				}
+			}
+			continue
+		}
+
+		// The container is looked up even if the VM isn't marked running, it may be starting
+		if ir, err := providers.Runtime.InspectContainer(vm.PrefixedID()); err == nil && ir.Status == runtimeRunningStatus {
+			for _, id := range append(ids, ir.ID) {
+				running[id] = true
+			}
+			continue
+		}
+
+		if !vm.Running() {
+			continue
+		}
+
+		if !dryRun {
+			if err := markStopped(vm); err != nil {
+				return nil, err
+			}
+		}
+
+		result.StoppedVMs = append(result.StoppedVMs, vm)
+	}
+
+	if repairer, ok := providers.NetworkPlugin.(network.Repairer); ok {
+		attachments := make([]network.Attachment, 0, len(networks))
+		for _, n := range networks {
+			attachments = append(attachments, network.Attachment{ConfListPath: n.ConfListPath()})
+		}
+
+		prefix := providers.IDPrefix + "-"
+		leaked := func(containerID string, owned bool) bool {
+			// Containers on networks not managed by ignite may belong to others
+			return !running[containerID] && (owned || known[containerID] || strings.HasPrefix(containerID, prefix))
+		}
+
+		if dryRun {
+			// Record the leaked containers without releasing anything
+			wouldRelease := map[string]bool{}
+			leakedFn := leaked
+			leaked = func(containerID string, owned bool) bool {
+				if leakedFn(containerID, owned) && !wouldRelease[containerID] {
+					wouldRelease[containerID] = true
+					result.ReleasedContainers = append(result.ReleasedContainers, containerID)
+				}
+				return false
+			}
+		}
+
+		released, err := repairer.ReleaseLeaked(attachments, leaked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release the leaked networking of the %q network plugin: %v", providers.NetworkPlugin.Name(), err)
+		}
+
+		if !dryRun {
+			result.ReleasedContainers = released
+		}
+
+		for _, vm := range vms {
+			if !vm.Running() || vm.Status.Network == nil || vm.Status.Network.Plugin != providers.NetworkPlugin.Name() ||
+				vm.Status.Runtime == nil || vm.Status.Runtime.Name != providers.RuntimeName {
+				continue
+			}
+
+			if err := repairer.CheckContainerNetwork(vm.Status.Runtime.ID, removalAttachments(vm)); err != nil {
+				log.Warnf("The networking of %s %q is broken, restart it to repair it: %v", vm.GetKind(), vm.GetUID(), err)
+				result.BrokenVMs = append(result.BrokenVMs, vm)
+			}
+		}
+	}
+
+	if result.RemovedBridges, err = removeOrphanedBridges(networks, dryRun); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// markStopped resets the status of a VM whose container is gone, like ignite-spawn does when the VM stops
+func markStopped(vm *api.VM) error {
+	log.Infof("Marking %s %q stopped, its container is gone", vm.GetKind(), vm.GetUID())
+	RecordDie(vm, "the container is gone")
+
+	// The snapshot device is left behind if the container didn't exit cleanly
+	if _, err := os.Stat(vm.SnapshotDev()); err == nil {
+		if err := providers.StorageDriverForVM(vm).DeactivateDisk(vm); err != nil {
+			return err
+		}
+	}
+
+	vm.Status.Running = false
+	vm.Status.Network = nil
+	vm.Status.Runtime = nil
+	vm.Status.StartTime = nil
+
+	return providers.Client.VMs().Set(vm)
+}
+
+// removeOrphanedBridges removes the bridges of user-defined networks that don't exist anymore
+func removeOrphanedBridges(networks []*api.Network, dryRun bool) ([]string, error) {
+	bridges := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		bridges[n.Spec.BridgeName] = true
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, link := range links {
+		name := link.Attrs().Name
+		if link.Type() != "bridge" || bridges[name] || !networkBridgeRegexp.MatchString(name) {
+			continue
+		}
+
+		if !dryRun {
+			log.Infof("Removing bridge %q of a removed network", name)
+			if err := bridge.RemoveBridge(name); err != nil {
+				return nil, fmt.Errorf("failed to remove bridge %q: %v", name, err)
+			}
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
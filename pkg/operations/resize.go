@@ -0,0 +1,46 @@
+package operations
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// ResizeVMDisk grows the disk of a stopped VM to the given size and updates its DiskSize. Disks can't
+// shrink. The disks of VMs that don't have one yet are created with the new size.
+func ResizeVMDisk(vm *api.VM, size meta.Size) error {
+	if vm.Running() || util.FileExists(vm.SnapshotDev()) {
+		return fmt.Errorf("%s %q is running, stop it before resizing its disk", vm.GetKind(), vm.GetUID())
+	}
+
+	// The disk is at least as large as the image, it may be larger than the requested disk size
+	current := vm.Spec.DiskSize
+	driver := providers.StorageDriverForVM(vm)
+	hasDisk := driver.HasDisk(vm)
+	if hasDisk {
+		usage, err := driver.DiskUsage(vm)
+		if err != nil {
+			return err
+		}
+
+		current = current.Max(meta.NewSizeFromBytes(usage.Size))
+	}
+
+	if size.Bytes() < current.Bytes() {
+		return fmt.Errorf("can't shrink the disk of %s %q from %s to %s", vm.GetKind(), vm.GetUID(), current, size)
+	}
+
+	if hasDisk && size.Bytes() > current.Bytes() {
+		log.Infof("Resizing the disk of %s %q from %s to %s...", vm.GetKind(), vm.GetUID(), current, size)
+		if err := driver.ResizeDisk(vm, size); err != nil {
+			return err
+		}
+	}
+
+	vm.Spec.DiskSize = size
+	return providers.Client.VMs().Set(vm)
+}
@@ -2,6 +2,8 @@ package operations
 
 import (
 	"fmt"
+	"net"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
@@ -11,13 +13,14 @@ import (
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
 	"github.com/weaveworks/ignite/pkg/constants"
-	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/events"
 	"github.com/weaveworks/ignite/pkg/logs"
 	"github.com/weaveworks/ignite/pkg/operations/lookup"
 	"github.com/weaveworks/ignite/pkg/providers"
 	"github.com/weaveworks/ignite/pkg/runtime"
 	"github.com/weaveworks/ignite/pkg/util"
 	apiruntime "github.com/weaveworks/libgitops/pkg/runtime"
+	"golang.org/x/sys/unix"
 )
 
 // VMChannels can be used to get signals for different stages of VM lifecycle
@@ -49,8 +52,15 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 		SpawnFinished: make(chan error),
 	}
 
-	// Setup the snapshot overlay filesystem
-	snapshotDevPath, err := dmlegacy.ActivateSnapshot(vm)
+	// The devices of the disk aren't used by a running container until it's started, prune skips them meanwhile
+	unlock, err := lockStarting(vm.GetUID().String())
+	if err != nil {
+		return vmChans, err
+	}
+	defer unlock()
+
+	// Setup the device or file of the disk
+	diskPath, err := providers.StorageDriverForVM(vm).ActivateDisk(vm)
 	if err != nil {
 		return vmChans, err
 	}
@@ -69,11 +79,35 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 		return vmChans, err
 	}
 
+	// Allocate host ports for the port mappings that don't specify one
+	ports, err := allocatePorts(vm)
+	if err != nil {
+		return vmChans, err
+	}
+
+	// Make sure the event log exists for ignite-spawn to record to
+	if err := events.EnsureLog(); err != nil {
+		return vmChans, err
+	}
+
+	cmd := []string{
+		fmt.Sprintf("--log-level=%s", logs.Logger.Level.String()),
+		fmt.Sprintf("--network-plugin=%s", providers.NetworkPluginName),
+	}
+
+	// The VM is handed the embedded DNS resolver served by ignited
+	if providers.EmbeddedDNS() {
+		cmd = append(cmd, "--embedded-dns")
+	}
+
+	// Disk files are stored in the VM directory, which is mounted into the container at the same path
+	diskIsDevice := util.IsDeviceFile(diskPath) == nil
+	if !diskIsDevice {
+		cmd = append(cmd, fmt.Sprintf("--disk-file=%s", diskPath))
+	}
+
 	config := &runtime.ContainerConfig{
-		Cmd: []string{
-			fmt.Sprintf("--log-level=%s", logs.Logger.Level.String()),
-			vm.GetUID().String(),
-		},
+		Cmd:    append(cmd, vm.GetUID().String()),
 		Labels: map[string]string{"ignite.name": vm.GetName()},
 		Binds: []*runtime.Bind{
 			{
@@ -90,19 +124,30 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 				HostPath:      path.Join(kernelDir, constants.KERNEL_FILE),
 				ContainerPath: constants.IGNITE_SPAWN_VMLINUX_FILE_PATH,
 			},
+			{
+				// Mount the event log into the container, to a well-known place for ignite-spawn to record to
+				HostPath:      constants.EVENTS_FILE,
+				ContainerPath: constants.IGNITE_SPAWN_EVENTS_FILE_PATH,
+			},
 		},
 		CapAdds: []string{
-			"SYS_ADMIN", // Needed to run "dmsetup remove" inside the container
 			"NET_ADMIN", // Needed for removing the IP from the container's interface
 		},
 		Devices: []*runtime.Bind{
-			runtime.BindBoth("/dev/mapper/control"), // This enables containerized Ignite to remove its own dm snapshot
-			runtime.BindBoth("/dev/net/tun"),        // Needed for creating TAP adapters
-			runtime.BindBoth("/dev/kvm"),            // Pass through virtualization support
-			runtime.BindBoth(snapshotDevPath),       // The block device to boot from
+			runtime.BindBoth("/dev/net/tun"), // Needed for creating TAP adapters
+			runtime.BindBoth("/dev/kvm"),     // Pass through virtualization support
 		},
 		StopTimeout:  constants.STOP_TIMEOUT + constants.IGNITE_TIMEOUT,
-		PortBindings: vm.Spec.Network.Ports, // Add the port mappings to Docker
+		PortBindings: ports, // Add the port mappings to Docker
+	}
+
+	// The block device to boot from, ignite-spawn removes its dm snapshot when the VM stops
+	if diskIsDevice {
+		config.CapAdds = append(config.CapAdds, "SYS_ADMIN") // Needed to run "dmsetup remove" inside the container
+		config.Devices = append(config.Devices,
+			runtime.BindBoth("/dev/mapper/control"), // This enables containerized Ignite to remove its own dm snapshot
+			runtime.BindBoth(diskPath),
+		)
 	}
 
 	var envVars []string
@@ -131,6 +176,17 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 		return vmChans, err
 	}
 
+	// Look up the user-defined networks to attach the VM to, if any
+	networks, err := LookupNetworks(vm)
+	if err != nil {
+		return vmChans, err
+	}
+
+	attachments, err := networkAttachments(vm, networks)
+	if err != nil {
+		return vmChans, err
+	}
+
 	// If we're not debugging, remove the container post-run
 	if !debug {
 		config.AutoRemove = true
@@ -142,12 +198,28 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 		return vmChans, fmt.Errorf("failed to start container for VM %q: %v", vm.GetUID(), err)
 	}
 
-	// Set up the networking
-	result, err := providers.NetworkPlugin.SetupContainerNetwork(containerID, vm.Spec.Network.Ports...)
+	// Set up the networking, requesting the static IP of the VM if it has one
+	var ips []net.IP
+	staticIP := vm.StaticIP()
+	if staticIP != nil {
+		ips = append(ips, staticIP)
+	}
+
+	result, err := providers.NetworkPlugin.SetupContainerNetwork(containerID, ips, attachments, ports...)
 	if err != nil {
 		return vmChans, err
 	}
 
+	// Isolate the networks now, their rules need to come before the ones set up for the VM
+	if err := isolateNetworks(networks); err != nil {
+		return vmChans, err
+	}
+
+	// Move the macvlan and ipvlan interfaces of the VM into the container
+	if err := attachPassthroughInterfaces(vm, containerID); err != nil {
+		return vmChans, err
+	}
+
 	if !logs.Quiet {
 		log.Infof("Networking is handled by %q", providers.NetworkPlugin.Name())
 		log.Infof("Started Firecracker VM %q in a container with ID %q", vm.GetUID(), containerID)
@@ -157,19 +229,36 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 	vm.Status.Runtime.ID = containerID
 	vm.Status.Runtime.Name = providers.RuntimeName
 
-	// Append non-loopback runtime IP addresses of the VM to its state
+	// Append non-loopback runtime IP addresses of the VM to its state. The static IP replaces
+	// the runtime IP of the same family, as ignite-spawn configures the VM with it.
+	if staticIP != nil {
+		vm.Status.Network.IPAddresses = append(vm.Status.Network.IPAddresses, staticIP)
+	}
 	for _, addr := range result.Addresses {
-		if !addr.IP.IsLoopback() {
-			vm.Status.Network.IPAddresses = append(vm.Status.Network.IPAddresses, addr.IP)
+		if addr.IP.IsLoopback() || (staticIP != nil && (addr.IP.To4() == nil) == (staticIP.To4() == nil)) {
+			continue
 		}
+
+		vm.Status.Network.IPAddresses = append(vm.Status.Network.IPAddresses, addr.IP)
+	}
+
+	// Keep the first allocated IP for the following starts if requested
+	if vm.Spec.Network.StickyIP && staticIP == nil && len(vm.Status.Network.IPAddresses) > 0 {
+		ip := vm.Status.Network.IPAddresses[0]
+		vm.SetInterfaceAddress(constants.IGNITE_SPAWN_MAIN_INTERFACE, &api.InterfaceAddress{IP: ip.String()})
+		log.Infof("Keeping IP address %s for VM %q", ip, vm.GetUID())
 	}
 	vm.Status.Network.Plugin = providers.NetworkPluginName
+	vm.Status.Network.Ports = ports
+	vm.Status.Network.Networks = networkStatus(result)
 
 	// write the API object in a non-running state before we wait for spawn's network logic and firecracker
 	if err := providers.Client.VMs().Set(vm); err != nil {
 		return vmChans, err
 	}
 
+	events.Record(vm, events.TypeStart, "")
+
 	// TODO: This is temporary until we have proper communication to the container
 	// It's best to perform any imperative changes to the VM object pointer before this go-routine starts
 	go waitForSpawn(vm, vmChans)
@@ -177,6 +266,41 @@ func StartVMNonBlocking(vm *api.VM, debug bool) (*VMChannels, error) {
 	return vmChans, nil
 }
 
+// lockStarting marks the VM of the given UID as being started until the returned function is called, see vmStarting
+func lockStarting(uid string) (func(), error) {
+	path := filepath.Join(constants.VM_DIR, uid, constants.VM_START_LOCK)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, constants.DATA_DIR_FILE_PERM)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %q: %v", path, err)
+	}
+
+	return func() {
+		_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// vmStarting returns true if the VM of the given UID is being started by another process
+func vmStarting(uid string) bool {
+	f, err := os.Open(filepath.Join(constants.VM_DIR, uid, constants.VM_START_LOCK))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_SH|unix.LOCK_NB); err != nil {
+		return err == unix.EWOULDBLOCK
+	}
+
+	_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+	return false
+}
+
 // verifyPulled pulls the ignite-spawn image if it's not present
 func verifyPulled(image meta.OCIImageRef) error {
 	if _, err := providers.Runtime.InspectImage(image); err != nil {
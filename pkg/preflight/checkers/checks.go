@@ -3,16 +3,22 @@ package checkers
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
 	"strings"
 
+	cnilibrary "github.com/containernetworking/cni/libcni"
 	api "github.com/weaveworks/ignite/pkg/apis/ignite"
 	"github.com/weaveworks/ignite/pkg/constants"
 	"github.com/weaveworks/ignite/pkg/network"
+	"github.com/weaveworks/ignite/pkg/network/cni"
 	"github.com/weaveworks/ignite/pkg/preflight"
 	"github.com/weaveworks/ignite/pkg/providers"
+	cniprovider "github.com/weaveworks/ignite/pkg/providers/cni"
+	"github.com/weaveworks/ignite/pkg/reflink"
+	"github.com/weaveworks/libgitops/pkg/filter"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -95,19 +101,66 @@ func (bipc BinInPathChecker) Type() string {
 	return "BinaryInPath"
 }
 
+// ReflinkChecker checks that the filesystem of the directory supports reflinks by cloning a file in it
+type ReflinkChecker struct {
+	dir string
+}
+
+func (rc ReflinkChecker) Check() (err error) {
+	src, err := ioutil.TempFile(rc.dir, ".ignite-reflink")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(src.Name())
+
+	_, err = src.WriteString("ignite")
+	if closeErr := src.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	clone := src.Name() + ".clone"
+	if err := reflink.Clone(clone, src.Name()); err != nil {
+		return err
+	}
+
+	return os.Remove(clone)
+}
+
+func (rc ReflinkChecker) Name() string {
+	return fmt.Sprintf("Reflink-%s", strings.Replace(rc.dir, oldPathString, newPathString, noReplaceLimit))
+}
+
+func (rc ReflinkChecker) Type() string {
+	return "Reflink"
+}
+
 func StartCmdChecks(vm *api.VM, ignoredPreflightErrors sets.String) error {
 	checks := []preflight.Checker{}
 	for _, dependency := range constants.PathDependencies {
 		checks = append(checks, ExistingFileChecker{filePath: dependency})
 	}
+	checks = append(checks, storageDriverChecks(vm)...)
 	if providers.NetworkPluginName == network.PluginCNI {
-		for _, dependency := range constants.CNIDependencies {
-			checks = append(checks, ExistingFileChecker{filePath: dependency})
+		cniChecks, err := cniPluginChecks(vm)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, cniChecks...)
+	}
+	if providers.NetworkPluginName == network.PluginBridge || providers.NetworkPluginName == network.PluginIsolated {
+		for _, dependency := range constants.BridgeDependencies {
+			checks = append(checks, BinInPathChecker{binaryNames: []string{dependency}})
 		}
 	}
 
 	checks = append(checks, providers.Runtime.PreflightChecker())
 	for _, port := range vm.Spec.Network.Ports {
+		if port.HostPort == 0 {
+			continue // Allocated on start
+		}
 		checks = append(checks, PortOpenChecker{port: port.HostPort})
 	}
 
@@ -128,6 +181,71 @@ func StartCmdChecks(vm *api.VM, ignoredPreflightErrors sets.String) error {
 	return runChecks(checks, ignoredPreflightErrors)
 }
 
+// storageDriverChecks returns the checks for the storage driver storing the disk of the VM. The reflink driver
+// needs a data directory supporting reflinks, the device mapper drivers need dmsetup and its control device.
+func storageDriverChecks(vm *api.VM) []preflight.Checker {
+	if providers.StorageDriverForVM(vm).Name() == api.StorageDriverReflink {
+		return []preflight.Checker{ReflinkChecker{dir: constants.DATA_DIR}}
+	}
+
+	var checks []preflight.Checker
+	for _, dependency := range constants.DMPathDependencies {
+		checks = append(checks, ExistingFileChecker{filePath: dependency})
+	}
+	for _, dependency := range constants.DMBinaryDependencies {
+		checks = append(checks, BinInPathChecker{binaryNames: []string{dependency}})
+	}
+	return checks
+}
+
+// cniPluginChecks returns the checks for the CNI plugins used by the networks of the VM, or by the default network
+// if it isn't attached to any. The plugins are looked up in the configured CNI binary directories.
+func cniPluginChecks(vm *api.VM) ([]preflight.Checker, error) {
+	binDirs, confDir := cniprovider.Dirs()
+
+	var confLists []*cnilibrary.NetworkConfigList
+	for _, name := range vm.Spec.Network.Networks {
+		n, err := providers.Client.Networks().Find(filter.NewNameFilter(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find network %q of VM %q: %v", name, vm.GetUID(), err)
+		}
+
+		b, err := cni.NetworkConfList(n)
+		if err != nil {
+			return nil, err
+		}
+
+		confList, err := cnilibrary.ConfListFromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		confLists = append(confLists, confList)
+	}
+
+	for _, a := range vm.Spec.Network.CNINetworks {
+		confList, err := cnilibrary.LoadConfList(confDir, a.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CNI network %q from %q: %v", a.Name, confDir, err)
+		}
+		confLists = append(confLists, confList)
+	}
+
+	if len(confLists) == 0 {
+		confList, err := cni.DefaultConfList(confDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the default CNI network from %q: %v", confDir, err)
+		}
+		confLists = append(confLists, confList)
+	}
+
+	var checks []preflight.Checker
+	for _, pluginType := range cni.PluginTypes(confLists...) {
+		checks = append(checks, cni.NewPluginChecker(pluginType, binDirs))
+	}
+
+	return checks, nil
+}
+
 func runChecks(checks []preflight.Checker, ignoredPreflightErrors sets.String) error {
 	var errBuffer bytes.Buffer
 
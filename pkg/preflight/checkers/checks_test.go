@@ -2,6 +2,8 @@ package checkers
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -116,3 +118,22 @@ func TestIsIgnoredPreflightError(t *testing.T) {
 		})
 	}
 }
+
+func TestReflinkChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ReflinkChecker{dir: dir}.Check()
+
+	// The test files are removed whether the check passes or not
+	files, readErr := ioutil.ReadDir(dir)
+	assert.NoError(t, readErr)
+	assert.Empty(t, files)
+
+	if err != nil {
+		t.Skipf("the filesystem of %q doesn't support reflinks: %v", dir, err)
+	}
+}
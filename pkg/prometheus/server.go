@@ -1,13 +1,21 @@
 package prometheus
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
+// scrapeTimeout is the maximum time to wait for a metrics socket to respond
+const scrapeTimeout = 5 * time.Second
+
 func New() (*prometheus.Registry, *http.Server) {
 	// Create a registry to register metrics into
 	registry := prometheus.NewRegistry()
@@ -31,3 +39,28 @@ func ServeOnSocket(server *http.Server, socketPath string) error {
 
 	return server.Serve(unixListener)
 }
+
+// ScrapeSocket fetches and parses the metrics served on the given unix socket
+func ScrapeSocket(socketPath string) (map[string]*dto.MetricFamily, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: scrapeTimeout,
+	}
+
+	// The host part of the URL is ignored, the request always goes to the socket
+	resp, err := client.Get("http://localhost/metrics")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %q returned status %q", socketPath, resp.Status)
+	}
+
+	return (&expfmt.TextParser{}).TextToMetricFamilies(resp.Body)
+}
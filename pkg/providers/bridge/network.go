@@ -0,0 +1,19 @@
+package bridge
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/weaveworks/ignite/pkg/network/bridge"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+func SetBridgeNetworkPlugin() error {
+	log.Trace("Initializing the bridge network provider...")
+	providers.NetworkPlugin = bridge.GetBridgeNetworkPlugin(providers.Runtime)
+	return nil
+}
+
+func SetIsolatedNetworkPlugin() error {
+	log.Trace("Initializing the isolated network provider...")
+	providers.NetworkPlugin = bridge.GetIsolatedNetworkPlugin(providers.Runtime)
+	return nil
+}
@@ -8,6 +8,25 @@ import (
 
 func SetCNINetworkPlugin() (err error) {
 	log.Trace("Initializing the CNI provider...")
-	providers.NetworkPlugin, err = cni.GetCNINetworkPlugin(providers.Runtime)
+
+	binDirs, confDir := Dirs()
+	providers.NetworkPlugin, err = cni.GetCNINetworkPlugin(providers.Runtime, binDirs, confDir)
+	return
+}
+
+// Dirs returns the directories of the CNI plugin binaries and of the CNI network configurations.
+// The CNI directories can be overridden by the ComponentConfig.
+func Dirs() (binDirs []string, confDir string) {
+	binDirs, confDir = []string{cni.CNIBinDir}, cni.CNIConfDir
+	if c := providers.ComponentConfig; c != nil {
+		if len(c.Spec.CNIBinDirs) > 0 {
+			binDirs = c.Spec.CNIBinDirs
+		}
+
+		if len(c.Spec.CNIConfDir) > 0 {
+			confDir = c.Spec.CNIConfDir
+		}
+	}
+
 	return
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/weaveworks/ignite/pkg/providers/network"
 	"github.com/weaveworks/ignite/pkg/providers/runtime"
 	storageprovider "github.com/weaveworks/ignite/pkg/providers/storage"
+	"github.com/weaveworks/ignite/pkg/providers/storagedriver"
 )
 
 // Preload providers need to be loaded before flag parsing has finished
@@ -17,6 +18,7 @@ var Preload = []providers.ProviderInitFunc{
 // NOTE: Provider initialization is order-dependent!
 // E.g. the network plugin depends on the runtime.
 var Providers = []providers.ProviderInitFunc{
-	runtime.SetRuntime,       // Set the selected runtime
-	network.SetNetworkPlugin, // Set the selected network plugin
+	runtime.SetRuntime,             // Set the selected runtime
+	network.SetNetworkPlugin,       // Set the selected network plugin
+	storagedriver.SetStorageDriver, // Set the selected storage driver
 }
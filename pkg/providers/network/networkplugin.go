@@ -5,8 +5,10 @@ import (
 
 	"github.com/weaveworks/ignite/pkg/network"
 	"github.com/weaveworks/ignite/pkg/providers"
+	bridgeprovider "github.com/weaveworks/ignite/pkg/providers/bridge"
 	cniprovider "github.com/weaveworks/ignite/pkg/providers/cni"
 	dockerprovider "github.com/weaveworks/ignite/pkg/providers/docker"
+	noneprovider "github.com/weaveworks/ignite/pkg/providers/none"
 )
 
 func SetNetworkPlugin() error {
@@ -15,6 +17,12 @@ func SetNetworkPlugin() error {
 		return dockerprovider.SetDockerNetwork() // Use the Docker bridge network
 	case network.PluginCNI:
 		return cniprovider.SetCNINetworkPlugin() // Use the CNI Network plugin
+	case network.PluginBridge:
+		return bridgeprovider.SetBridgeNetworkPlugin() // Use ignite's own bridge network
+	case network.PluginIsolated:
+		return bridgeprovider.SetIsolatedNetworkPlugin() // Use ignite's own bridge network, without external connectivity
+	case network.PluginNone:
+		return noneprovider.SetNoneNetworkPlugin() // Don't give the VM any networking
 	}
 
 	return fmt.Errorf("unknown network plugin %q", providers.NetworkPluginName)
@@ -0,0 +1,13 @@
+package none
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/weaveworks/ignite/pkg/network/none"
+	"github.com/weaveworks/ignite/pkg/providers"
+)
+
+func SetNoneNetworkPlugin() error {
+	log.Trace("Initializing the none network provider...")
+	providers.NetworkPlugin = none.GetNoneNetworkPlugin()
+	return nil
+}
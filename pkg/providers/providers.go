@@ -6,6 +6,7 @@ import (
 	"github.com/weaveworks/ignite/pkg/client"
 	"github.com/weaveworks/ignite/pkg/network"
 	"github.com/weaveworks/ignite/pkg/runtime"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
 	"github.com/weaveworks/libgitops/pkg/storage"
 )
 
@@ -29,6 +30,30 @@ var RuntimeName runtime.Name
 // This should be set after parsing user input on what runtime to use
 var Runtime runtime.Interface
 
+// StorageDriverName binds to the ComponentConfig to select the storage driver of new VMs
+// The default storage driver is "legacy"
+var StorageDriverName api.StorageDriver
+
+// StorageDriver provides the chosen storage driver that new VMs should be created with
+// This should be set after parsing user input on what storage driver to use
+var StorageDriver snapshotter.Driver
+
+// StorageDrivers provides all storage drivers, the disks of existing VMs
+// are managed by the driver they were created with
+var StorageDrivers []snapshotter.Driver
+
+// StorageDriverForVM returns the storage driver storing the disk of the given VM,
+// or the chosen storage driver if the VM doesn't have a disk yet
+func StorageDriverForVM(vm *api.VM) snapshotter.Driver {
+	for _, driver := range StorageDrivers {
+		if driver.HasDisk(vm) {
+			return driver
+		}
+	}
+
+	return StorageDriver
+}
+
 // Client is the default client that can be easily used
 var Client *client.Client
 
@@ -37,6 +62,11 @@ var Storage storage.Storage
 
 var ComponentConfig *api.Configuration
 
+// EmbeddedDNS returns true if the embedded DNS resolver is enabled by the ComponentConfig
+func EmbeddedDNS() bool {
+	return ComponentConfig != nil && ComponentConfig.Spec.EmbeddedDNS
+}
+
 // RegistryConfigDir is the container runtime registry configuration directory.
 // This is used during operations like image import for loading registry
 // configurations.
@@ -0,0 +1,34 @@
+package storagedriver
+
+import (
+	"fmt"
+
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/dm"
+	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/reflink"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
+)
+
+func SetStorageDriver() error {
+	var poolSpec *api.PoolSpec
+	if providers.ComponentConfig != nil {
+		poolSpec = providers.ComponentConfig.Spec.ThinPool
+	}
+
+	providers.StorageDrivers = []snapshotter.Driver{
+		dmlegacy.NewDriver(),   // Store the disks of VMs in overlay files
+		dm.NewDriver(poolSpec), // Store images, kernels and the disks of VMs in a thin pool
+		reflink.NewDriver(),    // Store the disks of VMs in clones of the image files
+	}
+
+	for _, driver := range providers.StorageDrivers {
+		if driver.Name() == providers.StorageDriverName {
+			providers.StorageDriver = driver
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown storage driver %q", providers.StorageDriverName)
+}
@@ -0,0 +1,52 @@
+package reflink
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Clone creates the file at dst as a copy-on-write clone of the file at src. The clone shares all blocks with
+// src until either of them is written to, so cloning takes constant time and no space regardless of the size.
+// Both files need to be on the same filesystem, and the filesystem needs to support reflinks like XFS or btrfs.
+func Clone(dst, src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	if err = unix.IoctlFileClone(int(out.Fd()), int(in.Fd())); err != nil {
+		return cloneError(dst, src, err)
+	}
+
+	return nil
+}
+
+// cloneError explains the errors returned when the filesystem doesn't support reflinks
+func cloneError(dst, src string, err error) error {
+	switch err {
+	case unix.EXDEV:
+		return fmt.Errorf("failed to clone %q to %q, they're on different filesystems: %v", src, dst, err)
+	case unix.EOPNOTSUPP, unix.ENOTTY, unix.EINVAL:
+		return fmt.Errorf("failed to clone %q to %q, the filesystem doesn't support reflinks, "+
+			"the reflink storage driver requires XFS with reflink=1 or btrfs: %v", src, dst, err)
+	}
+
+	return fmt.Errorf("failed to clone %q to %q: %v", src, dst, err)
+}
@@ -0,0 +1,64 @@
+package reflink
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestClone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-reflink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := bytes.Repeat([]byte("ignite"), 4096)
+	src := filepath.Join(dir, "src")
+	if err := ioutil.WriteFile(src, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := Clone(dst, src); err != nil {
+		// The clone is removed again if the filesystem doesn't support reflinks
+		if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+			t.Errorf("expected %q to be removed after the failed clone", dst)
+		}
+
+		t.Skipf("the filesystem of %q doesn't support reflinks: %v", dir, err)
+	}
+
+	actual, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, contents) {
+		t.Errorf("the contents of the clone differ")
+	}
+
+	// Clones don't replace existing files
+	if err := Clone(dst, src); err == nil {
+		t.Errorf("expected cloning to an existing file to fail")
+	}
+}
+
+func TestCloneError(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected string
+	}{
+		{unix.EXDEV, "different filesystems"},
+		{unix.EOPNOTSUPP, "doesn't support reflinks"},
+		{unix.EIO, "failed to clone"},
+	} {
+		if err := cloneError("dst", "src", c.err); !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("expected the error for %v to contain %q, got %q", c.err, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package reflink
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/debugfs"
+	"github.com/weaveworks/ignite/pkg/dmlegacy"
+	"github.com/weaveworks/ignite/pkg/operations/lookup"
+	"github.com/weaveworks/ignite/pkg/providers"
+	"github.com/weaveworks/ignite/pkg/snapshotter"
+	"github.com/weaveworks/ignite/pkg/util"
+)
+
+// Driver is the reflink storage driver. The disk of every VM is a copy-on-write clone of the image file,
+// which requires the data directory to be on a filesystem supporting reflinks like XFS or btrfs. The kernel
+// modules and the files of the VM are added to the clone with debugfs, and Firecracker boots from the file
+// directly, so the driver needs no loop or device mapper devices.
+type Driver struct{}
+
+var _ snapshotter.Driver = &Driver{}
+
+// NewDriver returns the reflink storage driver
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+func (*Driver) Name() api.StorageDriver {
+	return api.StorageDriverReflink
+}
+
+func (*Driver) HasDisk(vm *api.VM) bool {
+	return util.FileExists(vm.DiskFile())
+}
+
+// CreateDisk clones the image file of the VM, grows the clone to the disk size of the VM and populates it.
+// The disk is at least as large as the image. It's only moved into place once it's complete.
+func (*Driver) CreateDisk(vm *api.VM) (err error) {
+	if util.FileExists(vm.DiskFile()) {
+		return fmt.Errorf("the disk of %s %q exists already", vm.GetKind(), vm.GetUID())
+	}
+
+	imageUID, err := lookup.ImageUIDForVM(vm, providers.Client)
+	if err != nil {
+		return err
+	}
+	imageFile := path.Join(constants.IMAGE_DIR, imageUID.String(), constants.IMAGE_FS)
+
+	// Make sure the all directories above the disk file exist
+	if err = os.MkdirAll(path.Dir(vm.DiskFile()), constants.DATA_DIR_PERM); err != nil {
+		return err
+	}
+
+	tempFile, err := prepareTempFile(vm)
+	if err != nil {
+		return err
+	}
+	defer removeOnError(tempFile, &err)
+
+	if err = Clone(tempFile, imageFile); err != nil {
+		return err
+	}
+
+	imageSize, err := util.DeviceSize(imageFile)
+	if err != nil {
+		return err
+	}
+
+	size := vm.Spec.DiskSize
+	if size.Bytes() < uint64(imageSize) {
+		log.Warnf("warning: requested disk size (%s) < image size (%s), using image size for disk\n",
+			size, meta.NewSizeFromBytes(uint64(imageSize)))
+	} else if size.Bytes() > uint64(imageSize) {
+		if err = resizeFile(tempFile, size); err != nil {
+			return err
+		}
+	}
+
+	if err = populate(vm, tempFile); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile, vm.DiskFile())
+}
+
+// ImportDisk creates the disk of the VM as a sparse copy of the filesystem on the given device.
+// The disk doesn't share any blocks with the image file.
+func (*Driver) ImportDisk(vm *api.VM, devicePath string) (err error) {
+	if util.FileExists(vm.DiskFile()) {
+		return fmt.Errorf("the disk of %s %q exists already", vm.GetKind(), vm.GetUID())
+	}
+
+	size, err := util.DeviceSize(devicePath)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := prepareTempFile(vm)
+	if err != nil {
+		return err
+	}
+	defer removeOnError(tempFile, &err)
+
+	if err = ioutil.WriteFile(tempFile, nil, 0644); err != nil {
+		return err
+	}
+
+	if err = os.Truncate(tempFile, size); err != nil {
+		return err
+	}
+
+	if err = util.CopySparse(tempFile, devicePath); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile, vm.DiskFile())
+}
+
+// ActivateDisk repairs the filesystem of the disk of the VM and returns the path to the disk file,
+// which is attached to Firecracker as is. No global lock is taken, VMs can be started concurrently.
+func (*Driver) ActivateDisk(vm *api.VM) (string, error) {
+	diskFile := vm.DiskFile()
+	if !util.FileExists(diskFile) {
+		return "", fmt.Errorf("the disk of %s %q doesn't exist", vm.GetKind(), vm.GetUID())
+	}
+
+	// Repair the filesystem in case it has errors
+	// e2fsck throws an error if the filesystem gets repaired, so just ignore it
+	_, _ = util.ExecuteCommand("e2fsck", "-p", "-f", diskFile)
+	return diskFile, nil
+}
+
+// DeactivateDisk is a no-op, the disk file isn't set up as a device
+func (*Driver) DeactivateDisk(*api.VM) error {
+	return nil
+}
+
+func (*Driver) RemoveDisk(vm *api.VM) error {
+	for _, file := range []string{vm.DiskFile(), diskTempFile(vm)} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResizeDisk grows the disk file of the VM and resizes its filesystem. The file stays sparse,
+// the space past the previous end of the file isn't allocated until it's written to.
+func (*Driver) ResizeDisk(vm *api.VM, size meta.Size) error {
+	return resizeFile(vm.DiskFile(), size)
+}
+
+// CompactDisk discards the unused blocks of the filesystem of the VM, which punches holes into
+// the disk file, and deallocates the blocks that only contain zeroes. Blocks shared with the
+// image file are only released for the VM, the image file keeps them.
+func (*Driver) CompactDisk(vm *api.VM) (err error) {
+	if err = trimFile(vm.DiskFile()); err != nil {
+		return fmt.Errorf("failed to trim the filesystem of %s %q: %v", vm.GetKind(), vm.GetUID(), err)
+	}
+
+	return dmlegacy.PunchZeroes(vm.DiskFile())
+}
+
+// DiskUsage returns the size of the disk file of the VM and the space allocated for it. The
+// allocated space includes the blocks the file shares with the image file and other clones.
+func (*Driver) DiskUsage(vm *api.VM) (*snapshotter.DiskUsage, error) {
+	size, allocated, err := util.FileSize(vm.DiskFile())
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshotter.DiskUsage{
+		Size:      size,
+		Allocated: allocated,
+	}, nil
+}
+
+// RemoveImage is a no-op, the disks of VMs don't depend on the image file once they're cloned
+func (*Driver) RemoveImage(*api.Image) error {
+	return nil
+}
+
+// RemoveKernel is a no-op, the kernel files are copied into the disks of VMs
+func (*Driver) RemoveKernel(*api.Kernel) error {
+	return nil
+}
+
+// diskTempFile returns the path the disk of the VM is prepared at before it's moved into place
+func diskTempFile(vm *api.VM) string {
+	return vm.DiskFile() + ".tmp"
+}
+
+// prepareTempFile removes the leftovers of a failed operation and returns the path of the temporary disk file
+func prepareTempFile(vm *api.VM) (string, error) {
+	tempFile := diskTempFile(vm)
+	if err := os.Remove(tempFile); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return tempFile, nil
+}
+
+func removeOnError(file string, err *error) {
+	if *err != nil {
+		if removeErr := os.Remove(file); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Warnf("Failed to remove %q: %v", file, removeErr)
+		}
+	}
+}
+
+// resizeFile grows the file to the given size and resizes the filesystem in it to fill it
+func resizeFile(file string, size meta.Size) error {
+	// Truncate only accepts an int64
+	if size.Bytes() > math.MaxInt64 {
+		return fmt.Errorf("requested size %d too large, cannot truncate", size.Bytes())
+	}
+
+	if err := os.Truncate(file, int64(size.Bytes())); err != nil {
+		return fmt.Errorf("failed to resize disk file %q: %v", file, err)
+	}
+
+	// e2fsck throws an error if the filesystem gets repaired, so just ignore it
+	_, _ = util.ExecuteCommand("e2fsck", "-p", "-f", file)
+	_, err := util.ExecuteCommand("resize2fs", file)
+	return err
+}
+
+// populate copies the kernel modules and the files of the VM into the disk file. They're prepared in a
+// temporary directory and copied into the filesystem with debugfs, which avoids mounting the file.
+func populate(vm *api.VM, file string) (err error) {
+	dir, err := ioutil.TempDir("", "ignite-populate")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	img := debugfs.NewImage(file)
+
+	// The files of the VM are written into /etc, and /etc/hosts is only written if it's empty in the image
+	if err = os.Mkdir(path.Join(dir, "etc"), 0755); err != nil {
+		return
+	}
+	if err = img.Dump("/etc/hosts", path.Join(dir, "etc/hosts")); err != nil {
+		return
+	}
+
+	// Copy the kernel files to the VM. TODO: Use snapshot overlaying instead.
+	if err = dmlegacy.CopyKernelFiles(vm, dir); err != nil {
+		return
+	}
+
+	if err = dmlegacy.PopulateFilesystem(vm, dir); err != nil {
+		return
+	}
+
+	return img.CopyIn(dir)
+}
+
+// trimFile discards the unused blocks of the filesystem in the disk file. e2fsck punches holes into
+// the file for them, so the file doesn't need to be mounted.
+func trimFile(file string) error {
+	// e2fsck throws an error if the filesystem gets repaired, so repair it before discarding
+	_, _ = util.ExecuteCommand("e2fsck", "-p", "-f", file)
+	_, err := util.ExecuteCommand("e2fsck", "-p", "-f", "-E", "discard", file)
+	return err
+}
@@ -47,6 +47,21 @@ func EnsureResolvConf(filepath string, perm os.FileMode) error {
 	return nil
 }
 
+// NameServers returns the DNS servers of the host, falling back to the default DNS servers if there are none.
+// Loopback addresses are kept, as the servers are used by resolvers running on the host.
+func NameServers() []string {
+	cfg, err := readDNSConfig()
+	if err != nil {
+		log.Warn(err)
+	}
+
+	if len(cfg.Servers) == 0 {
+		return fallbackNameServers
+	}
+
+	return cfg.Servers
+}
+
 // readDNSConfig reads settings from /etc/resolv.conf -- if those settings indicate
 // systemd-resolved is in use, it reads them again from /run/systemd/resolve/resolv.conf.
 // If an error occurs, cfg will be defaulted to an empty dns.ClientConfig{}.
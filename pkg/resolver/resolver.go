@@ -0,0 +1,384 @@
+package resolver
+
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/client"
+	"github.com/weaveworks/ignite/pkg/constants"
+	"github.com/weaveworks/ignite/pkg/network/bridge"
+	"github.com/weaveworks/ignite/pkg/resolvconf"
+)
+
+const (
+	// bridgePrefix matches the bridges of all network plugins and networks of ignite by their name
+	bridgePrefix = "ignite"
+	// syncInterval is the interval the addresses of the bridges are checked at, they're created with the first VM
+	syncInterval = 10 * time.Second
+	// ttl is the TTL of the answers for the VMs in seconds, kept short as the addresses change when VMs restart
+	ttl = 5
+)
+
+// domain is the fully qualified domain of the VMs
+var domain = dns.Fqdn(constants.DNS_DOMAIN)
+
+// Resolver is the embedded DNS resolver of ignite. It answers the queries for <vm-name>.<network>.ignite
+// with the addresses of the running VMs, and forwards all other queries to the DNS servers of the host.
+// The VMs can only look up the VMs of the network of the bridge they query, and the queries received
+// on isolated bridges aren't forwarded, as the VMs on them must not reach the outside world.
+type Resolver struct {
+	client    *client.Client
+	upstreams []string
+
+	serversMu sync.Mutex
+	servers   map[string][]*dns.Server
+}
+
+// New creates a Resolver looking up the VMs and networks using the given client
+func New(c *client.Client) *Resolver {
+	return &Resolver{
+		client:    c,
+		upstreams: resolvconf.NameServers(),
+		servers:   make(map[string][]*dns.Server),
+	}
+}
+
+// Run serves DNS on the addresses of the bridges of ignite until stop is closed. The bridges
+// are only created when the first VM is attached to them, so their addresses are checked periodically.
+func (r *Resolver) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.sync(); err != nil {
+			log.Errorf("DNS: Failed to list the addresses of the bridges: %v", err)
+		}
+
+		select {
+		case <-stop:
+			r.shutdown(map[string]bool{})
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// bridgeHandler answers the queries received on the addresses of a bridge
+type bridgeHandler struct {
+	*Resolver
+	bridgeName string
+}
+
+var _ dns.Handler = &bridgeHandler{}
+
+// ServeDNS answers a DNS query
+func (h *bridgeHandler) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	networks, err := h.client.Networks().List()
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("DNS: Failed to list networks: %v", err)
+		dns.HandleFailed(w, req)
+		return
+	}
+
+	if len(req.Question) != 1 || !dns.IsSubDomain(domain, req.Question[0].Name) {
+		if isolatedBridge(h.bridgeName, networks) {
+			refuse(w, req)
+			return
+		}
+
+		h.forward(w, req)
+		return
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+
+	vms, err := h.client.VMs().List()
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("DNS: Failed to list VMs: %v", err)
+		dns.HandleFailed(w, req)
+		return
+	}
+
+	// Only the addresses of the VMs in the subnets of the bridge are answered
+	subnets, err := bridgeSubnets(h.bridgeName)
+	if err != nil {
+		log.Errorf("DNS: Failed to list the addresses of bridge %q: %v", h.bridgeName, err)
+		dns.HandleFailed(w, req)
+		return
+	}
+
+	resp.Answer, resp.Rcode = resolve(req.Question[0], vms, networks, subnets)
+	if err := w.WriteMsg(resp); err != nil {
+		log.Debugf("DNS: Failed to write the answer for %q: %v", req.Question[0].Name, err)
+	}
+}
+
+// isolatedBridge returns true for the bridge of the isolated network plugin and the bridges of isolated networks
+func isolatedBridge(bridgeName string, networks []*api.Network) bool {
+	if bridgeName == bridge.IsolatedBridgeName {
+		return true
+	}
+
+	for _, network := range networks {
+		if network.Spec.BridgeName == bridgeName && network.Spec.Isolated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refuse answers a query with REFUSED, recursion isn't available to the VMs of isolated bridges
+func refuse(w dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetRcode(req, dns.RcodeRefused)
+	if err := w.WriteMsg(resp); err != nil {
+		log.Debugf("DNS: Failed to write the refusal: %v", err)
+	}
+}
+
+// resolve answers a question for <vm-name>.<network>.ignite with the addresses of the VM in the network. Only
+// the addresses in the given subnets of the bridge the query was received on are answered, the names of VMs
+// without any are unknown to the querier.
+func resolve(q dns.Question, vms []*api.VM, networks []*api.Network, scope []*net.IPNet) ([]dns.RR, int) {
+	labels := dns.SplitDomainName(strings.ToLower(q.Name))
+	if len(labels) < 3 {
+		return nil, dns.RcodeNameError
+	}
+
+	// VM names may contain dots, the network and the domain are the last two labels
+	vmName := strings.Join(labels[:len(labels)-2], ".")
+	networkName := labels[len(labels)-2]
+
+	// The addresses of VMs attached to multiple networks are told apart by the subnets of the networks
+	var subnet *net.IPNet
+	if networkName != constants.DEFAULT_NETWORK_NAME {
+		network := findNetwork(networks, networkName)
+		if network == nil {
+			return nil, dns.RcodeNameError
+		}
+
+		if _, subnet, _ = net.ParseCIDR(network.Spec.Subnet); subnet == nil {
+			return nil, dns.RcodeNameError
+		}
+	}
+
+	vm := findVM(vms, vmName, networkName)
+	if vm == nil {
+		return nil, dns.RcodeNameError
+	}
+
+	var answers []dns.RR
+	var found bool
+	for _, ip := range vm.Status.Network.IPAddresses {
+		if subnet != nil && !subnet.Contains(ip) || !containedIn(ip, scope) {
+			continue
+		}
+
+		found = true
+
+		hdr := dns.RR_Header{Name: q.Name, Class: dns.ClassINET, Ttl: ttl}
+		if ip4 := ip.To4(); ip4 != nil {
+			if q.Qtype == dns.TypeA || q.Qtype == dns.TypeANY {
+				hdr.Rrtype = dns.TypeA
+				answers = append(answers, &dns.A{Hdr: hdr, A: ip4})
+			}
+		} else if q.Qtype == dns.TypeAAAA || q.Qtype == dns.TypeANY {
+			hdr.Rrtype = dns.TypeAAAA
+			answers = append(answers, &dns.AAAA{Hdr: hdr, AAAA: ip})
+		}
+	}
+
+	if !found {
+		return nil, dns.RcodeNameError
+	}
+
+	// The name exists even if the VM has no addresses of the requested type
+	return answers, dns.RcodeSuccess
+}
+
+func containedIn(ip net.IP, subnets []*net.IPNet) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findNetwork returns the user-defined network with the given name, if any
+func findNetwork(networks []*api.Network, name string) *api.Network {
+	for _, network := range networks {
+		if strings.ToLower(network.GetName()) == name {
+			return network
+		}
+	}
+
+	return nil
+}
+
+// findVM returns the running VM with the given name attached to the given network, if any
+func findVM(vms []*api.VM, name, networkName string) *api.VM {
+	for _, vm := range vms {
+		if strings.ToLower(vm.GetName()) != name || !vm.Running() || vm.Status.Network == nil {
+			continue
+		}
+
+		if networkName == constants.DEFAULT_NETWORK_NAME && len(vm.Spec.Network.Networks) == 0 && len(vm.Spec.Network.CNINetworks) == 0 {
+			return vm
+		}
+
+		for _, network := range vm.Spec.Network.Networks {
+			if network == networkName {
+				return vm
+			}
+		}
+	}
+
+	return nil
+}
+
+// forward passes the query on to the DNS servers of the host, using the same protocol it was received with
+func (r *Resolver) forward(w dns.ResponseWriter, req *dns.Msg) {
+	c := &dns.Client{Net: w.LocalAddr().Network()}
+	for _, upstream := range r.upstreams {
+		resp, _, err := c.Exchange(req, net.JoinHostPort(upstream, "53"))
+		if err != nil {
+			log.Debugf("DNS: Failed to forward query to %s: %v", upstream, err)
+			continue
+		}
+
+		if err := w.WriteMsg(resp); err != nil {
+			log.Debugf("DNS: Failed to write the forwarded answer: %v", err)
+		}
+		return
+	}
+
+	dns.HandleFailed(w, req)
+}
+
+// sync starts serving on the new addresses of the bridges, and stops serving on the removed ones
+func (r *Resolver) sync() error {
+	addrs, err := r.bridgeAddresses()
+	if err != nil {
+		return err
+	}
+
+	r.serversMu.Lock()
+	defer r.serversMu.Unlock()
+
+	keep := make(map[string]bool, len(addrs))
+	for addr, handler := range addrs {
+		keep[addr] = true
+		if _, ok := r.servers[addr]; ok {
+			continue
+		}
+
+		log.Infof("DNS: Serving on %s", addr)
+		for _, proto := range []string{"udp", "tcp"} {
+			server := &dns.Server{Addr: addr, Net: proto, Handler: handler}
+			go func() {
+				if err := server.ListenAndServe(); err != nil {
+					log.Errorf("DNS: Failed to serve on %s/%s: %v", server.Addr, server.Net, err)
+				}
+			}()
+
+			r.servers[addr] = append(r.servers[addr], server)
+		}
+	}
+
+	r.shutdownLocked(keep)
+	return nil
+}
+
+// shutdown stops serving on all addresses not in keep
+func (r *Resolver) shutdown(keep map[string]bool) {
+	r.serversMu.Lock()
+	defer r.serversMu.Unlock()
+	r.shutdownLocked(keep)
+}
+
+func (r *Resolver) shutdownLocked(keep map[string]bool) {
+	for addr, servers := range r.servers {
+		if keep[addr] {
+			continue
+		}
+
+		log.Infof("DNS: Stopped serving on %s", addr)
+		for _, server := range servers {
+			// The server may not have started listening yet, e.g. as the address is gone already
+			_ = server.Shutdown()
+		}
+
+		delete(r.servers, addr)
+	}
+}
+
+// bridgeAddresses returns the handlers for the addresses of the bridges of ignite to serve on, keyed by host:port pairs
+func (r *Resolver) bridgeAddresses() (map[string]*bridgeHandler, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make(map[string]*bridgeHandler)
+	for _, link := range links {
+		if link.Type() != "bridge" || !strings.HasPrefix(link.Attrs().Name, bridgePrefix) {
+			continue
+		}
+
+		linkAddrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return nil, err
+		}
+
+		handler := &bridgeHandler{Resolver: r, bridgeName: link.Attrs().Name}
+		for _, addr := range globalAddrs(linkAddrs) {
+			addrs[net.JoinHostPort(addr.IP.String(), "53")] = handler
+		}
+	}
+
+	return addrs, nil
+}
+
+// bridgeSubnets returns the subnets of the global addresses of the given bridge
+func bridgeSubnets(bridgeName string) ([]*net.IPNet, error) {
+	link, err := netlink.LinkByName(bridgeName)
+	if err != nil {
+		return nil, err
+	}
+
+	linkAddrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []*net.IPNet
+	for _, addr := range globalAddrs(linkAddrs) {
+		subnets = append(subnets, &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask})
+	}
+
+	return subnets, nil
+}
+
+// globalAddrs skips the link-local addresses, they would need the zone of the bridge, VMs use the global ones
+func globalAddrs(addrs []netlink.Addr) []netlink.Addr {
+	global := addrs[:0]
+	for _, addr := range addrs {
+		if !addr.IP.IsLinkLocalUnicast() {
+			global = append(global, addr)
+		}
+	}
+
+	return global
+}
@@ -0,0 +1,213 @@
+package resolver
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	"github.com/weaveworks/ignite/pkg/network/bridge"
+	"gotest.tools/assert"
+)
+
+func newVM(name string, running bool, networks []string, ips ...string) *api.VM {
+	vm := &api.VM{}
+	vm.SetName(name)
+	vm.Spec.Network.Networks = networks
+	vm.Status.Running = running
+	vm.Status.Network = &api.VMNetworkStatus{}
+	for _, ip := range ips {
+		vm.Status.Network.IPAddresses = append(vm.Status.Network.IPAddresses, net.ParseIP(ip))
+	}
+
+	return vm
+}
+
+func newNetwork(name, subnet string) *api.Network {
+	n := &api.Network{Spec: api.NetworkSpec{Subnet: subnet}}
+	n.SetName(name)
+	return n
+}
+
+func TestResolve(t *testing.T) {
+	vms := []*api.VM{
+		newVM("web", true, nil, "10.61.0.2", "fd69:676e:6974:6500::2"),
+		newVM("db", true, []string{"team-a", "team-b"}, "10.70.0.2", "10.70.1.2"),
+		newVM("stopped", false, nil),
+		newVM("my.vm", true, nil, "10.61.0.3"),
+	}
+
+	networks := []*api.Network{
+		newNetwork("team-a", "10.70.0.0/24"),
+		newNetwork("team-b", "10.70.1.0/24"),
+	}
+
+	defaultScope := []string{"10.61.0.0/16", "fd69:676e:6974:6500::/64"}
+	teamAScope := []string{"10.70.0.0/24"}
+	teamBScope := []string{"10.70.1.0/24"}
+
+	cases := []struct {
+		name      string
+		qname     string
+		qtype     uint16
+		scope     []string
+		wantRcode int
+		wantIPs   []string
+	}{
+		{
+			name:      "default network",
+			qname:     "web.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"10.61.0.2"},
+		},
+		{
+			name:      "IPv6",
+			qname:     "web.default.ignite.",
+			qtype:     dns.TypeAAAA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"fd69:676e:6974:6500::2"},
+		},
+		{
+			name:      "case insensitive",
+			qname:     "WEB.Default.IGNITE.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"10.61.0.2"},
+		},
+		{
+			name:      "first network",
+			qname:     "db.team-a.ignite.",
+			qtype:     dns.TypeA,
+			scope:     teamAScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"10.70.0.2"},
+		},
+		{
+			name:      "second network",
+			qname:     "db.team-b.ignite.",
+			qtype:     dns.TypeA,
+			scope:     teamBScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"10.70.1.2"},
+		},
+		{
+			name:      "no addresses of the type",
+			qname:     "db.team-a.ignite.",
+			qtype:     dns.TypeAAAA,
+			scope:     teamAScope,
+			wantRcode: dns.RcodeSuccess,
+		},
+		{
+			name:      "dotted VM name",
+			qname:     "my.vm.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeSuccess,
+			wantIPs:   []string{"10.61.0.3"},
+		},
+		{
+			name:      "not in network",
+			qname:     "web.team-a.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "not in default network",
+			qname:     "db.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "unknown network",
+			qname:     "db.team-c.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "stopped VM",
+			qname:     "stopped.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "VM of another network",
+			qname:     "web.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     teamAScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "other network of the VM",
+			qname:     "db.team-b.ignite.",
+			qtype:     dns.TypeA,
+			scope:     teamAScope,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "default network of another plugin",
+			qname:     "web.default.ignite.",
+			qtype:     dns.TypeA,
+			scope:     []string{"10.63.0.0/16"},
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name:      "no network",
+			qname:     "web.ignite.",
+			qtype:     dns.TypeA,
+			scope:     defaultScope,
+			wantRcode: dns.RcodeNameError,
+		},
+	}
+
+	for _, rt := range cases {
+		t.Run(rt.name, func(t *testing.T) {
+			var scope []*net.IPNet
+			for _, cidr := range rt.scope {
+				_, subnet, err := net.ParseCIDR(cidr)
+				assert.NilError(t, err)
+				scope = append(scope, subnet)
+			}
+
+			answers, rcode := resolve(dns.Question{Name: rt.qname, Qtype: rt.qtype, Qclass: dns.ClassINET}, vms, networks, scope)
+			assert.Equal(t, rcode, rt.wantRcode)
+			assert.Equal(t, len(answers), len(rt.wantIPs))
+
+			for i, answer := range answers {
+				assert.Equal(t, answer.Header().Name, rt.qname)
+				assert.Equal(t, answer.Header().Rrtype, rt.qtype)
+
+				var ip net.IP
+				switch rr := answer.(type) {
+				case *dns.A:
+					ip = rr.A
+				case *dns.AAAA:
+					ip = rr.AAAA
+				}
+				assert.Equal(t, ip.String(), rt.wantIPs[i])
+			}
+		})
+	}
+}
+
+func TestIsolatedBridge(t *testing.T) {
+	isolated := newNetwork("team-a", "10.70.0.0/24")
+	isolated.Spec.BridgeName = "ignite-team-a"
+	isolated.Spec.Isolated = true
+
+	shared := newNetwork("team-b", "10.70.1.0/24")
+	shared.Spec.BridgeName = "ignite-team-b"
+
+	networks := []*api.Network{isolated, shared}
+	assert.Assert(t, isolatedBridge(bridge.IsolatedBridgeName, networks))
+	assert.Assert(t, isolatedBridge("ignite-team-a", networks))
+	assert.Assert(t, !isolatedBridge("ignite-team-b", networks))
+	assert.Assert(t, !isolatedBridge("ignite0", networks))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/weaveworks/ignite/pkg/runtime/auth"
 	"github.com/weaveworks/ignite/pkg/util"
 
+	cgroupsv1 "github.com/containerd/cgroups/stats/v1"
 	"github.com/containerd/console"
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/cio"
@@ -39,6 +40,7 @@ import (
 	"github.com/containerd/containerd/remotes/docker"
 	v2shim "github.com/containerd/containerd/runtime/v2/shim"
 	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/typeurl"
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/identity"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -771,6 +773,30 @@ func (cc *ctdClient) KillContainer(container, signal string) (err error) {
 	return
 }
 
+func (cc *ctdClient) ListContainers() ([]*runtime.ContainerListResult, error) {
+	containers, err := cc.client.Containers(cc.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*runtime.ContainerListResult, 0, len(containers))
+	for _, cont := range containers {
+		labels, err := cont.Labels(cc.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Containers are created with their name as the ID
+		results = append(results, &runtime.ContainerListResult{
+			ID:     cont.ID(),
+			Name:   cont.ID(),
+			Labels: labels,
+		})
+	}
+
+	return results, nil
+}
+
 func (cc *ctdClient) RemoveContainer(container string) error {
 	// Remove the container if it exists
 	cont, contLoadErr := cc.client.LoadContainer(cc.ctx, container)
@@ -846,6 +872,74 @@ func (cc *ctdClient) ContainerLogs(container string) (r io.ReadCloser, err error
 	return retriever, nil
 }
 
+func (cc *ctdClient) ContainerStats(container string) (*runtime.ContainerStatsResult, error) {
+	cont, err := cc.client.LoadContainer(cc.ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := cont.Task(cc.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metric, err := task.Metrics(cc.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := typeurl.UnmarshalAny(metric.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: Support the cgroup v2 metrics format
+	m, ok := data.(*cgroupsv1.Metrics)
+	if !ok {
+		return nil, fmt.Errorf("unsupported metrics type %T for container %q", data, container)
+	}
+
+	result := &runtime.ContainerStatsResult{
+		Read: metric.Timestamp,
+	}
+
+	if m.CPU != nil && m.CPU.Usage != nil {
+		result.CPUUsage = m.CPU.Usage.Total
+	}
+
+	if m.Memory != nil && m.Memory.Usage != nil {
+		result.MemoryUsage = m.Memory.Usage.Usage
+		result.MemoryLimit = m.Memory.Usage.Limit
+
+		// Don't count the reclaimable page cache, like the Docker CLI does
+		if m.Memory.TotalCache < result.MemoryUsage {
+			result.MemoryUsage -= m.Memory.TotalCache
+		}
+	}
+
+	if m.Pids != nil {
+		result.PIDs = m.Pids.Current
+	}
+
+	for _, n := range m.Network {
+		result.NetworkRx += n.RxBytes
+		result.NetworkTx += n.TxBytes
+	}
+
+	if m.Blkio != nil {
+		for _, entry := range m.Blkio.IoServiceBytesRecursive {
+			switch strings.ToLower(entry.Op) {
+			case "read":
+				result.BlockRead += entry.Value
+			case "write":
+				result.BlockWrite += entry.Value
+			}
+		}
+	}
+
+	return result, nil
+}
+
 func (cc *ctdClient) Name() runtime.Name {
 	return runtime.RuntimeContainerd
 }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"strings"
 	"time"
 
 	refdocker "github.com/containerd/containerd/reference/docker"
@@ -150,14 +151,39 @@ func (dc *dockerClient) InspectContainer(container string) (*runtime.ContainerIn
 	}
 
 	return &runtime.ContainerInspectResult{
-		ID:        res.ID,
-		Image:     res.Image,
-		Status:    res.State.Status,
-		IPAddress: net.ParseIP(res.NetworkSettings.IPAddress),
-		PID:       uint32(res.State.Pid),
+		ID:          res.ID,
+		Image:       res.Image,
+		Status:      res.State.Status,
+		IPAddress:   net.ParseIP(res.NetworkSettings.IPAddress),
+		IPv6Address: net.ParseIP(res.NetworkSettings.GlobalIPv6Address),
+		PID:         uint32(res.State.Pid),
 	}, nil
 }
 
+func (dc *dockerClient) ListContainers() ([]*runtime.ContainerListResult, error) {
+	containers, err := dc.client.ContainerList(context.Background(), types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*runtime.ContainerListResult, 0, len(containers))
+	for _, c := range containers {
+		result := &runtime.ContainerListResult{
+			ID:     c.ID,
+			Labels: c.Labels,
+		}
+
+		// Docker prefixes the names with a slash
+		if len(c.Names) > 0 {
+			result.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
 func (dc *dockerClient) AttachContainer(container string) (err error) {
 	// TODO: Rework to perform the attach via the Docker client,
 	// this will require manual TTY and signal emulation/handling.
@@ -290,6 +316,52 @@ func (dc *dockerClient) ContainerLogs(container string) (io.ReadCloser, error) {
 	})
 }
 
+func (dc *dockerClient) ContainerStats(container string) (result *runtime.ContainerStatsResult, err error) {
+	var stats types.ContainerStats
+	if stats, err = dc.client.ContainerStats(context.Background(), container, false); err != nil {
+		return
+	}
+	defer util.DeferErr(&err, stats.Body.Close)
+
+	var s types.StatsJSON
+	if err = json.NewDecoder(stats.Body).Decode(&s); err != nil {
+		return
+	}
+
+	result = &runtime.ContainerStatsResult{
+		CPUUsage:    s.CPUStats.CPUUsage.TotalUsage,
+		MemoryUsage: s.MemoryStats.Usage,
+		MemoryLimit: s.MemoryStats.Limit,
+		PIDs:        s.PidsStats.Current,
+		Read:        s.Read,
+	}
+
+	// Page cache is accounted to the cgroup, but it's reclaimable. Subtract it the
+	// same way the Docker CLI does: "cache" for cgroup v1, "inactive_file" for v2.
+	for _, key := range []string{"cache", "inactive_file"} {
+		if cache, ok := s.MemoryStats.Stats[key]; ok && cache < result.MemoryUsage {
+			result.MemoryUsage -= cache
+			break
+		}
+	}
+
+	for _, n := range s.Networks {
+		result.NetworkRx += n.RxBytes
+		result.NetworkTx += n.TxBytes
+	}
+
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			result.BlockRead += entry.Value
+		case "write":
+			result.BlockWrite += entry.Value
+		}
+	}
+
+	return
+}
+
 func (cc *dockerClient) Name() runtime.Name {
 	return runtime.RuntimeDocker
 }
@@ -27,12 +27,11 @@ func portBindingsToDocker(portMappings meta.PortMappings) (nat.PortMap, nat.Port
 
 		port := nat.Port(fmt.Sprintf("%d/%s", portMapping.VMPort, protocol.String()))
 		exposed[port] = struct{}{}
-		bindings[port] = []nat.PortBinding{
-			{
-				HostIP:   hostIP,
-				HostPort: strconv.FormatUint(portMapping.HostPort, 10),
-			},
-		}
+		// A VM port can be bound to multiple host addresses/ports
+		bindings[port] = append(bindings[port], nat.PortBinding{
+			HostIP:   hostIP,
+			HostPort: strconv.FormatUint(portMapping.HostPort, 10),
+		})
 	}
 
 	return bindings, exposed
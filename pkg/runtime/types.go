@@ -16,11 +16,36 @@ type ImageInspectResult struct {
 }
 
 type ContainerInspectResult struct {
-	ID        string
-	Image     string
-	Status    string
-	IPAddress net.IP
-	PID       uint32
+	ID          string
+	Image       string
+	Status      string
+	IPAddress   net.IP
+	IPv6Address net.IP
+	PID         uint32
+}
+
+// ContainerListResult describes a container of the container runtime
+type ContainerListResult struct {
+	ID string
+	// Name is the name the container was created with, it's the ID for containerd
+	Name   string
+	Labels map[string]string
+}
+
+// ContainerStatsResult describes the resource usage of a container,
+// as reported by the cgroups of the container runtime
+type ContainerStatsResult struct {
+	// CPUUsage is the cumulative CPU time consumed by the container in nanoseconds
+	CPUUsage    uint64
+	MemoryUsage uint64
+	MemoryLimit uint64
+	NetworkRx   uint64
+	NetworkTx   uint64
+	BlockRead   uint64
+	BlockWrite  uint64
+	PIDs        uint64
+	// Read is the time the stats were sampled at
+	Read time.Time
 }
 
 type Bind struct {
@@ -56,12 +81,14 @@ type Interface interface {
 	ExportImage(image meta.OCIImageRef) (io.ReadCloser, func() error, error)
 
 	InspectContainer(container string) (*ContainerInspectResult, error)
+	ListContainers() ([]*ContainerListResult, error)
 	AttachContainer(container string) error
 	RunContainer(image meta.OCIImageRef, config *ContainerConfig, name, id string) (string, error)
 	StopContainer(container string, timeout *time.Duration) error
 	KillContainer(container, signal string) error
 	RemoveContainer(container string) error
 	ContainerLogs(container string) (io.ReadCloser, error)
+	ContainerStats(container string) (*ContainerStatsResult, error)
 
 	Name() Name
 	RawClient() interface{}
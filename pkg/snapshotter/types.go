@@ -0,0 +1,49 @@
+package snapshotter
+
+import (
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// Driver stores the disks of VMs, together with the layers of the images and kernels they're created from.
+// The disk of a VM is activated as a device mapper device at vm.SnapshotDev() or as a file in the directory
+// of the VM, which is passed to its container.
+type Driver interface {
+	// Name returns the name of the driver, as selected in the Configuration
+	Name() api.StorageDriver
+	// HasDisk returns true if the disk of the VM is stored by this driver
+	HasDisk(vm *api.VM) bool
+	// CreateDisk allocates the disk of the VM on top of its image and kernel, and populates it
+	CreateDisk(vm *api.VM) error
+	// ImportDisk creates the disk of the VM as a copy of the filesystem on the given device.
+	// It's used to migrate the disks of VMs between drivers.
+	ImportDisk(vm *api.VM, device string) error
+	// ActivateDisk sets up the device of the disk of the VM and returns its path, or the path of the disk file
+	ActivateDisk(vm *api.VM) (string, error)
+	// DeactivateDisk removes the device of the disk of the VM, it's a no-op if it isn't active
+	DeactivateDisk(vm *api.VM) error
+	// RemoveDisk deactivates and deletes the disk of the VM
+	RemoveDisk(vm *api.VM) error
+	// ResizeDisk grows the disk of a stopped VM, together with its filesystem
+	ResizeDisk(vm *api.VM, size meta.Size) error
+	// CompactDisk releases the blocks freed inside the filesystem of a stopped VM from the backing storage
+	CompactDisk(vm *api.VM) error
+	// DiskUsage returns the space used by the disk of the VM
+	DiskUsage(vm *api.VM) (*DiskUsage, error)
+	// RemoveImage deletes the layers stored for the image, which isn't used by any VM
+	RemoveImage(image *api.Image) error
+	// RemoveKernel deletes the layers stored for the kernel, which isn't used by any VM
+	RemoveKernel(kernel *api.Kernel) error
+}
+
+// DiskUsage describes the space used by the disk of a VM
+type DiskUsage struct {
+	// Size is the apparent size of the disk, Allocated the space the blocks written by the VM take
+	Size      uint64
+	Allocated uint64
+	// FillPercentage is the percentage of the storage backing an active disk that's in use,
+	// for drivers with a fixed amount of it per disk. It's unset if it doesn't apply.
+	FillPercentage *float64
+	// Invalid is true if the storage backing the disk overflowed or failed
+	Invalid bool
+}
@@ -0,0 +1,210 @@
+package source
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// gzipMagic starts every gzip compressed stream
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic starts every zstd compressed stream
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress returns a reader of the decompressed stream if r is gzip compressed, or of r as is
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		return gzip.NewReader(br)
+	}
+
+	if magic, err := br.Peek(len(zstdMagic)); err == nil && bytes.Equal(magic, zstdMagic) {
+		return nil, fmt.Errorf("zstd compressed archives aren't supported")
+	}
+
+	return br, nil
+}
+
+// normalizeTar copies the tar stream from r to w with the names of the entries made relative to the root,
+// e.g. "./boot/vmlinux" becomes "boot/vmlinux", so specific entries can be extracted by their names.
+// The entry of the root directory itself is dropped.
+func normalizeTar(w io.Writer, r io.Reader) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if !normalizeHeader(hdr) {
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// normalizeHeader makes the names of the entry relative to the root and turns sparse files into regular
+// files, as the reader expands them. It returns false for the entry of the root directory itself.
+func normalizeHeader(hdr *tar.Header) bool {
+	if hdr.Name = cleanName(hdr.Name); len(hdr.Name) == 0 {
+		return false
+	}
+
+	if hdr.Typeflag == tar.TypeDir {
+		hdr.Name += "/"
+	} else if hdr.Typeflag == tar.TypeLink {
+		hdr.Linkname = cleanName(hdr.Linkname)
+	}
+
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		hdr.Typeflag = tar.TypeReg
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			delete(hdr.PAXRecords, key)
+		}
+	}
+
+	return true
+}
+
+// cleanName returns the name of a tar entry relative to the root, or an empty string for the root itself
+func cleanName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "." {
+		return ""
+	}
+
+	return name
+}
+
+// writeTree writes the directory tree at dir as a tar stream to w. The entries are written in lexical order
+// with their modification times truncated to seconds and without user and group names, so the stream
+// only changes if the tree does. Hard links are preserved, sockets are skipped like tar does.
+func writeTree(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	links := make(map[uint64]string)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, p)
+		if err != nil || name == "." {
+			return err
+		}
+
+		if info.Mode()&os.ModeSocket != 0 {
+			log.Warnf("Skipping socket %q", p)
+			return nil
+		}
+
+		var target string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, target)
+		if err != nil {
+			return fmt.Errorf("failed to archive %q: %v", p, err)
+		}
+
+		hdr.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uname, hdr.Gname = "", ""
+		hdr.ModTime = hdr.ModTime.Truncate(time.Second)
+		hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+
+		// Write the data of hard linked files once, the other names link to the first one
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Nlink > 1 && info.Mode().IsRegular() {
+			if first, ok := links[st.Ino]; ok {
+				hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeLink, first, 0
+				return tw.WriteHeader(hdr)
+			}
+			links[st.Ino] = hdr.Name
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		return copyFile(tw, p, hdr.Size)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// copyFile writes the contents of the file at p to w, which need to be of the given size
+func copyFile(w io.Writer, p string, size int64) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if n, err := io.Copy(w, io.LimitReader(f, size)); err != nil {
+		return err
+	} else if n != size {
+		return fmt.Errorf("file %q changed while archiving it", p)
+	}
+
+	return nil
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n uint64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += uint64(len(p))
+	return len(p), nil
+}
+
+// pipeReader runs fn in a goroutine and returns a reader of what it writes
+func pipeReader(fn func(w io.Writer) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(fn(pw))
+	}()
+
+	return pr
+}
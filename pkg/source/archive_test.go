@@ -0,0 +1,218 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// readEntries returns the names and contents of the entries of the tar stream
+func readEntries(t *testing.T, rc io.ReadCloser) map[string]string {
+	defer rc.Close()
+
+	entries := make(map[string]string)
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			data = []byte("link to " + hdr.Linkname)
+		}
+
+		entries[hdr.Name] = string(data)
+	}
+
+	return entries
+}
+
+func writeTarball(t *testing.T, file string, compress bool) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range []struct {
+		hdr  tar.Header
+		data string
+	}{
+		{tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "./boot/", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "./boot/vmlinux", Typeflag: tar.TypeReg, Mode: 0644, Size: 6}, "kernel"},
+		{tar.Header{Name: "/etc/hostname", Typeflag: tar.TypeReg, Mode: 0644, Size: 6}, "ignite"},
+		{tar.Header{Name: "./boot/vmlinux-5.10", Typeflag: tar.TypeLink, Linkname: "./boot/vmlinux"}, ""},
+	} {
+		if err := tw.WriteHeader(&e.hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if compress {
+		var gzBuf bytes.Buffer
+		gw := gzip.NewWriter(&gzBuf)
+		if _, err := gw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data = gzBuf.Bytes()
+	}
+
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTarSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := meta.NewOCIImageRef("my-rootfs:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"boot/":             "",
+		"boot/vmlinux":      "kernel",
+		"etc/hostname":      "ignite",
+		"boot/vmlinux-5.10": "link to boot/vmlinux",
+	}
+
+	ids := make(map[string]bool)
+	for _, compress := range []bool{false, true} {
+		file := filepath.Join(dir, "rootfs.tar")
+		writeTarball(t, file, compress)
+
+		ts := NewTarSource(file)
+		src, err := ts.Parse(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fi, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if src.Size.Bytes() != uint64(fi.Size()) {
+			t.Errorf("expected size %d, got %d", fi.Size(), src.Size.Bytes())
+		}
+		ids[src.ID.String()] = true
+
+		if ts.Ref() != ref {
+			t.Errorf("expected ref %s, got %s", ref, ts.Ref())
+		}
+
+		rc, err := ts.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := readEntries(t, rc); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("compress %t: expected entries %v, got %v", compress, expected, actual)
+		}
+	}
+
+	// The content ID is the digest of the tarball as given
+	if len(ids) != 2 {
+		t.Errorf("expected different content IDs for the compressed tarball, got %v", ids)
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "boot"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "boot", "vmlinux"), []byte("kernel"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(dir, "boot", "vmlinux"), filepath.Join(dir, "boot", "vmlinux-5.10")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("boot/vmlinux", filepath.Join(dir, "vmlinux")); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := meta.NewOCIImageRef("my-rootfs:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := NewDirSource(dir)
+	src, err := ds.Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Archiving the same tree again yields the same content ID
+	again, err := NewDirSource(dir).Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.ID.String() != again.ID.String() || src.Size != again.Size {
+		t.Errorf("expected the same content ID and size, got %s (%s) and %s (%s)", src.ID, src.Size, again.ID, again.Size)
+	}
+
+	rc, err := ds.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"boot/":             "",
+		"boot/vmlinux":      "kernel",
+		"boot/vmlinux-5.10": "link to boot/vmlinux",
+		"vmlinux":           "",
+	}
+	if actual := readEntries(t, rc); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected entries %v, got %v", expected, actual)
+	}
+
+	// Changing the tree changes the content ID
+	if err := ioutil.WriteFile(filepath.Join(dir, "boot", "config"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := NewDirSource(dir).Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.ID.String() == src.ID.String() {
+		t.Errorf("expected the content ID to change with the tree")
+	}
+
+	if _, err := NewDirSource(filepath.Join(dir, "vmlinux")).Parse(ref); err == nil {
+		t.Errorf("expected an error for a file")
+	}
+}
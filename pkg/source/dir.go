@@ -0,0 +1,72 @@
+package source
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// DirSource imports a root filesystem from a directory of the host. The directory is archived into a
+// tar stream in lexical order, the content ID is the digest of that stream.
+type DirSource struct {
+	dir      string
+	imageRef meta.OCIImageRef
+}
+
+// Compile-time assert to verify interface compatibility
+var _ Source = &DirSource{}
+
+// NewDirSource returns a source archiving the given directory
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+func (ds *DirSource) Ref() meta.OCIImageRef {
+	return ds.imageRef
+}
+
+// Parse archives the directory once to compute its digest and size, the reference names the imported image or kernel
+func (ds *DirSource) Parse(ociRef meta.OCIImageRef) (*api.OCIImageSource, error) {
+	fi, err := os.Stat(ds.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", ds.dir)
+	}
+
+	digester := digest.Canonical.Digester()
+	counter := &countingWriter{}
+	if err := writeTree(io.MultiWriter(digester.Hash(), counter), ds.dir); err != nil {
+		return nil, err
+	}
+
+	id, err := meta.ParseOCIContentID(digester.Digest().String())
+	if err != nil {
+		return nil, err
+	}
+
+	ds.imageRef = ociRef
+
+	return &api.OCIImageSource{
+		ID:   id,
+		Size: meta.NewSizeFromBytes(counter.n),
+	}, nil
+}
+
+// Reader archives the directory into a tar stream
+func (ds *DirSource) Reader() (io.ReadCloser, error) {
+	return pipeReader(func(w io.Writer) error {
+		return writeTree(w, ds.dir)
+	}), nil
+}
+
+// Cleanup is a no-op, the directory is read as is
+func (ds *DirSource) Cleanup() error {
+	return nil
+}
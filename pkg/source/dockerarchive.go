@@ -0,0 +1,265 @@
+package source
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// maxArchiveLinks limits the symlinks followed to resolve an entry of a docker archive
+const maxArchiveLinks = 8
+
+// dockerArchiveManifest is an entry of the manifest.json of a docker archive
+type dockerArchiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// archiveEntry locates the contents of an entry of an uncompressed tar archive
+type archiveEntry struct {
+	hdr    *tar.Header
+	offset int64
+}
+
+// DockerArchiveSource imports an image from an archive written by "docker save", without a container
+// runtime. The layers are applied in order. Docker archives don't carry the manifest of the image, so the
+// content ID is the digest of the image configuration, which is the image ID reported by docker.
+type DockerArchiveSource struct {
+	file     string
+	entries  map[string]*archiveEntry
+	imageRef meta.OCIImageRef
+	layers   []*layer
+}
+
+// Compile-time assert to verify interface compatibility
+var _ Source = &DockerArchiveSource{}
+
+// NewDockerArchiveSource returns a source reading the docker archive in the given file
+func NewDockerArchiveSource(file string) *DockerArchiveSource {
+	return &DockerArchiveSource{file: file}
+}
+
+func (ds *DockerArchiveSource) Ref() meta.OCIImageRef {
+	return ds.imageRef
+}
+
+// Parse indexes the archive and selects the image from its manifest. Archives holding multiple images
+// need to contain the given reference in the tags of one of them.
+func (ds *DockerArchiveSource) Parse(ociRef meta.OCIImageRef) (*api.OCIImageSource, error) {
+	if err := ds.index(); err != nil {
+		return nil, fmt.Errorf("failed to read the docker archive %q: %v", ds.file, err)
+	}
+
+	var manifests []dockerArchiveManifest
+	if err := ds.readJSON("manifest.json", &manifests); err != nil {
+		return nil, fmt.Errorf("%q is not a docker archive: %v", ds.file, err)
+	}
+
+	manifest, err := selectArchiveManifest(manifests, ociRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select the image in %q: %v", ds.file, err)
+	}
+
+	config, err := ds.readFile(manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var size uint64
+	ds.layers = make([]*layer, 0, len(manifest.Layers))
+	for _, name := range manifest.Layers {
+		e, err := ds.entry(name)
+		if err != nil {
+			return nil, err
+		}
+
+		ds.layers = append(ds.layers, &layer{
+			name: name,
+			open: func() (io.ReadCloser, error) {
+				return ds.open(e)
+			},
+		})
+		size += uint64(e.hdr.Size)
+	}
+
+	id, err := meta.ParseOCIContentID(digest.FromBytes(config).String())
+	if err != nil {
+		return nil, err
+	}
+
+	ds.imageRef = ociRef
+
+	return &api.OCIImageSource{
+		ID:   id,
+		Size: meta.NewSizeFromBytes(size),
+	}, nil
+}
+
+// Reader returns the tar stream of the layers of the image applied in order
+func (ds *DockerArchiveSource) Reader() (io.ReadCloser, error) {
+	return pipeReader(func(w io.Writer) error {
+		return flattenLayers(w, ds.layers)
+	}), nil
+}
+
+// Cleanup is a no-op, the archive is read as is
+func (ds *DockerArchiveSource) Cleanup() error {
+	return nil
+}
+
+// index records the offsets of the entries of the archive, so they can be read without scanning it again
+func (ds *DockerArchiveSource) index() error {
+	f, err := os.Open(ds.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if magic, err := bufio.NewReader(f).Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		return fmt.Errorf("compressed archives aren't supported, decompress it first")
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// The tar reader seeks over the contents of the entries, the offset of the file is at the contents of
+	// the current entry after reading its header
+	ds.entries = make(map[string]*archiveEntry)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		if name := cleanName(hdr.Name); len(name) > 0 {
+			ds.entries[name] = &archiveEntry{hdr, offset}
+		}
+	}
+
+	return nil
+}
+
+// entry returns the regular file of the given name, following symlinks within the archive. The name and
+// the targets of the links have to be relative paths not leaving the root of the archive.
+func (ds *DockerArchiveSource) entry(name string) (*archiveEntry, error) {
+	for i := 0; i < maxArchiveLinks; i++ {
+		if !validArchiveName(name) {
+			return nil, fmt.Errorf("invalid name %q in the docker archive %q", name, ds.file)
+		}
+
+		name = cleanName(name)
+		e, ok := ds.entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%q not found in the docker archive %q", name, ds.file)
+		}
+
+		switch e.hdr.Typeflag {
+		case tar.TypeReg:
+			return e, nil
+		case tar.TypeSymlink:
+			if path.IsAbs(e.hdr.Linkname) {
+				name = e.hdr.Linkname
+			} else {
+				name = path.Join(path.Dir(name), e.hdr.Linkname)
+			}
+		case tar.TypeLink:
+			name = e.hdr.Linkname
+		default:
+			return nil, fmt.Errorf("%q in the docker archive %q isn't a file", name, ds.file)
+		}
+	}
+
+	return nil, fmt.Errorf("too many levels of links resolving %q in the docker archive %q", name, ds.file)
+}
+
+// validArchiveName returns true if the name is a relative path within the archive
+func validArchiveName(name string) bool {
+	name = path.Clean(name)
+	return name != "." && name != ".." && !path.IsAbs(name) && !strings.HasPrefix(name, "../")
+}
+
+// open returns a reader of the contents of the entry
+func (ds *DockerArchiveSource) open(e *archiveEntry) (io.ReadCloser, error) {
+	f, err := os.Open(ds.file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sectionReader{io.NewSectionReader(f, e.offset, e.hdr.Size), f}, nil
+}
+
+func (ds *DockerArchiveSource) readFile(name string) ([]byte, error) {
+	e, err := ds.entry(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := ds.open(e)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+func (ds *DockerArchiveSource) readJSON(name string, v interface{}) error {
+	data, err := ds.readFile(name)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// selectArchiveManifest returns the only image, or the one tagged with the reference
+func selectArchiveManifest(manifests []dockerArchiveManifest, ociRef meta.OCIImageRef) (*dockerArchiveManifest, error) {
+	if len(manifests) == 1 {
+		return &manifests[0], nil
+	}
+
+	var tags []string
+	for i := range manifests {
+		for _, tag := range manifests[i].RepoTags {
+			if ref, err := meta.NewOCIImageRef(tag); err == nil && ref.Normalized() == ociRef.Normalized() {
+				return &manifests[i], nil
+			}
+		}
+
+		tags = append(tags, manifests[i].RepoTags...)
+	}
+
+	return nil, fmt.Errorf("found %d images, none of them tagged %q, available tags: [%s]",
+		len(manifests), ociRef, strings.Join(tags, ", "))
+}
+
+// sectionReader closes the archive together with the section read from it
+type sectionReader struct {
+	*io.SectionReader
+	file *os.File
+}
+
+func (r *sectionReader) Close() error {
+	return r.file.Close()
+}
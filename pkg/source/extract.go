@@ -0,0 +1,266 @@
+package source
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	containerderr "github.com/containerd/containerd/errdefs"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// Inode is the metadata of an entry of a tar stream
+type Inode struct {
+	// Mode is the file type and the permissions like st_mode
+	Mode uint32
+	UID  uint32
+	GID  uint32
+	// Major and Minor are the device numbers of device nodes
+	Major uint32
+	Minor uint32
+}
+
+// Special returns true for device nodes and FIFOs
+func (i *Inode) Special() bool {
+	switch i.Mode & unix.S_IFMT {
+	case unix.S_IFCHR, unix.S_IFBLK, unix.S_IFIFO:
+		return true
+	}
+
+	return false
+}
+
+// Tree is a directory tree extracted from a tar stream by ExtractTree
+type Tree struct {
+	// Inodes holds the metadata of the entries of the stream by their paths relative to the root, which is
+	// the empty path. Hard links share the inode of their target. Parent directories missing in the stream
+	// are owned by root.
+	Inodes map[string]*Inode
+}
+
+// dirTime is the modification time of an extracted directory, which is set once all entries are extracted
+type dirTime struct {
+	path    string
+	modTime time.Time
+}
+
+// ExtractTree extracts the files from a source to a directory without privileges. Unlike tar, it doesn't
+// need root to keep the owners of the files or to create device nodes. The files are created owned by the
+// current user and accessible to it, device nodes and FIFOs aren't created at all. The metadata of the
+// entries is returned instead, to be applied to the filesystem built from the directory.
+func ExtractTree(src Source, dir string) (*Tree, error) {
+	reader, err := src.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	t := &Tree{
+		Inodes: map[string]*Inode{"": {Mode: unix.S_IFDIR | 0755}},
+	}
+
+	var dirTimes []dirTime
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := cleanName(hdr.Name)
+		if err := t.extract(dir, name, hdr, tr); err != nil {
+			return nil, fmt.Errorf("failed to extract %q: %v", hdr.Name, err)
+		}
+
+		if hdr.Typeflag == tar.TypeDir {
+			dirTimes = append(dirTimes, dirTime{filepath.Join(dir, name), hdr.ModTime})
+		}
+	}
+
+	// Adding the entries of the directories changed their modification times, restore them
+	for i := len(dirTimes) - 1; i >= 0; i-- {
+		if err := os.Chtimes(dirTimes[i].path, dirTimes[i].modTime, dirTimes[i].modTime); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if err = src.Cleanup(); err != nil {
+		// Ignore the cleanup error if the resource no longer exists.
+		if !containerderr.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// extract creates the entry of the tar stream with the given name relative to dir and records its metadata
+func (t *Tree) extract(dir, name string, hdr *tar.Header, r io.Reader) error {
+	inode := &Inode{
+		Mode:  uint32(hdr.Mode) & 07777,
+		UID:   uint32(hdr.Uid),
+		GID:   uint32(hdr.Gid),
+		Major: uint32(hdr.Devmajor),
+		Minor: uint32(hdr.Devminor),
+	}
+
+	// The root directory only gets its metadata
+	if len(name) == 0 {
+		if hdr.Typeflag == tar.TypeDir {
+			inode.Mode |= unix.S_IFDIR
+			t.Inodes[name] = inode
+		}
+
+		return nil
+	}
+
+	if err := t.mkdirParents(dir, path.Dir(name)); err != nil {
+		return err
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := t.replace(target, name, hdr.Typeflag == tar.TypeDir); err != nil {
+		return err
+	}
+
+	// The files are created accessible to the current user, their modes are recorded with their owners
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		inode.Mode |= unix.S_IFDIR
+		if err := os.Mkdir(target, 0700); err != nil && !os.IsExist(err) {
+			return err
+		}
+		if err := os.Chmod(target, os.FileMode(inode.Mode&0777|0700)); err != nil {
+			return err
+		}
+	case tar.TypeReg, tar.TypeGNUSparse:
+		inode.Mode |= unix.S_IFREG
+		if err := writeFile(target, r); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, os.FileMode(inode.Mode&0777|0600)); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		inode.Mode = unix.S_IFLNK | 0777
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linkName := cleanName(hdr.Linkname)
+		linked, ok := t.Inodes[linkName]
+		if !ok || linked.Mode&unix.S_IFMT == unix.S_IFDIR {
+			return fmt.Errorf("hard link to %q, which isn't an extracted file", hdr.Linkname)
+		}
+
+		// Device nodes and FIFOs only exist in the tree, links to them become copies
+		if linked.Special() {
+			copied := *linked
+			inode = &copied
+			break
+		}
+
+		// Hard links share the inode of their target, which was checked to be within dir when it was extracted
+		if err := os.Link(filepath.Join(dir, filepath.FromSlash(linkName)), target); err != nil {
+			return err
+		}
+		inode = linked
+	case tar.TypeChar:
+		inode.Mode |= unix.S_IFCHR
+	case tar.TypeBlock:
+		inode.Mode |= unix.S_IFBLK
+	case tar.TypeFifo:
+		inode.Mode |= unix.S_IFIFO
+	default:
+		log.Debugf("Skipping %q of unsupported type %q", hdr.Name, hdr.Typeflag)
+		return nil
+	}
+
+	t.Inodes[name] = inode
+	return nil
+}
+
+// mkdirParents makes sure the parents of an entry are directories within dir. Symlinks aren't followed,
+// so entries can't be extracted outside of dir. Missing directories are created owned by root.
+func (t *Tree) mkdirParents(dir, name string) error {
+	if name == "." {
+		return nil
+	}
+
+	if err := t.mkdirParents(dir, path.Dir(name)); err != nil {
+		return err
+	}
+
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	info, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		t.Inodes[name] = &Inode{Mode: unix.S_IFDIR | 0755}
+		if err := os.Mkdir(p, 0700); err != nil {
+			return err
+		}
+		return os.Chmod(p, 0755)
+	} else if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%q is not a directory", name)
+	}
+
+	return nil
+}
+
+// replace removes the existing entry at the target, unless both are directories. Like tar, later entries
+// replace earlier ones of the same name.
+func (t *Tree) replace(target, name string, dir bool) error {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		// Device nodes and FIFOs only exist in the tree
+		delete(t.Inodes, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if dir && info.IsDir() {
+		return nil
+	}
+
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+
+	for p := range t.Inodes {
+		if p == name || strings.HasPrefix(p, name+"/") {
+			delete(t.Inodes, p)
+		}
+	}
+
+	return nil
+}
+
+func writeFile(file string, r io.Reader) (err error) {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(f, r)
+	return
+}
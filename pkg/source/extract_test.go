@@ -0,0 +1,78 @@
+package source
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outside := filepath.Join(dir, "outside")
+	if err := os.Mkdir(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name    string
+		entries []tarEntry
+		valid   bool
+	}{
+		{
+			name:    "files",
+			entries: []tarEntry{fileEntry("etc/motd", "hello"), linkEntry("etc/issue", "etc/motd")},
+			valid:   true,
+		},
+		{
+			name: "symlinked parent",
+			entries: []tarEntry{
+				{name: "lib", typeflag: tar.TypeSymlink, linkname: outside},
+				fileEntry("lib/escaped", "hello"),
+			},
+		},
+		{
+			name:    "hard link to a missing file",
+			entries: []tarEntry{linkEntry("etc/passwd", "../../etc/passwd")},
+		},
+	} {
+		tarball := filepath.Join(dir, "rootfs.tar")
+		if err := ioutil.WriteFile(tarball, tarLayer(t, false, c.entries...), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		rootfs, err := ioutil.TempDir(dir, "rootfs")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tree, err := ExtractTree(NewTarSource(tarball), rootfs)
+		if !c.valid {
+			if err == nil {
+				t.Errorf("%s: expected an error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		// Hard links share their inode, the parent directories are owned by root
+		if tree.Inodes["etc/issue"] != tree.Inodes["etc/motd"] {
+			t.Errorf("%s: expected the hard link to share the inode of its target", c.name)
+		}
+		if etc := tree.Inodes["etc"]; etc == nil || etc.UID != 0 || etc.Mode != 040755 {
+			t.Errorf("%s: expected etc to be a directory owned by root, got %+v", c.name, etc)
+		}
+	}
+
+	if files, err := ioutil.ReadDir(outside); err != nil || len(files) != 0 {
+		t.Errorf("expected no files to be extracted outside of the root, got %v: %v", files, err)
+	}
+}
@@ -0,0 +1,252 @@
+package source
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// whiteoutPrefix marks the deletion of the file with the rest of the name from the lower layers
+	whiteoutPrefix = ".wh."
+	// whiteoutMetaPrefix marks special whiteout files, which aren't deletions
+	whiteoutMetaPrefix = whiteoutPrefix + whiteoutPrefix
+	// whiteoutOpaqueDir marks its directory as opaque, hiding the contents of the lower layers
+	whiteoutOpaqueDir = whiteoutMetaPrefix + ".opq"
+)
+
+// layer is a layer of an image, a tar stream of the changes to the layers below, compressed or not
+type layer struct {
+	// name describes the layer in errors
+	name string
+	// digest of the stream as stored, it's verified if set
+	digest digest.Digest
+	// open returns a reader of the stream as stored
+	open func() (io.ReadCloser, error)
+}
+
+// pathNode is a node of the tree of the paths in the flattened layers
+type pathNode struct {
+	children map[string]*pathNode
+	// layer is the index of the layer providing the entry of the path, -1 if no layer has an entry for it
+	layer int
+	// maxLayer is the highest layer providing an entry in the subtree of the node
+	maxLayer int
+}
+
+func newPathNode() *pathNode {
+	return &pathNode{layer: -1, maxLayer: -1}
+}
+
+// find returns the node of the given path, or nil if it doesn't exist
+func (n *pathNode) find(name string) *pathNode {
+	if name == "." {
+		return n
+	}
+
+	for _, c := range strings.Split(name, "/") {
+		if n = n.children[c]; n == nil {
+			break
+		}
+	}
+
+	return n
+}
+
+// add sets the layer providing the given path. Files replacing directories hide their contents.
+func (n *pathNode) add(name string, layer int, isDir bool) {
+	for _, c := range strings.Split(name, "/") {
+		if n.maxLayer < layer {
+			n.maxLayer = layer
+		}
+
+		child := n.children[c]
+		if child == nil {
+			child = newPathNode()
+			if n.children == nil {
+				n.children = make(map[string]*pathNode)
+			}
+			n.children[c] = child
+		}
+		n = child
+	}
+
+	n.layer, n.maxLayer = layer, layer
+	if !isDir {
+		n.children = nil
+	}
+}
+
+// remove deletes the given path together with its contents
+func (n *pathNode) remove(name string) {
+	if parent := n.find(path.Dir(name)); parent != nil {
+		delete(parent.children, path.Base(name))
+	}
+}
+
+// prune deletes the contents provided by layers below the given layer
+func (n *pathNode) prune(layer int) {
+	for name, child := range n.children {
+		if child.maxLayer < layer {
+			delete(n.children, name)
+		} else {
+			child.prune(layer)
+		}
+	}
+}
+
+// hardLink is a hard link in a layer, named by name and linking to target
+type hardLink struct {
+	name, target string
+}
+
+// flattenLayers writes the file system described by the layers, applied in order, as one tar stream to w.
+// The paths provided by every layer are resolved first, applying the whiteouts of the upper layers to the
+// lower ones. The entries are then written from the layer providing them, from the lowest layer up. Hard
+// links to files deleted or replaced by upper layers carry the contents of the file instead.
+func flattenLayers(w io.Writer, layers []*layer) error {
+	root := newPathNode()
+	links := make([][]hardLink, len(layers))
+
+	for i, l := range layers {
+		err := readLayer(l, func(hdr *tar.Header, _ io.Reader) error {
+			name, base := hdr.Name, path.Base(hdr.Name)
+			switch {
+			case base == whiteoutOpaqueDir:
+				if dir := root.find(path.Dir(name)); dir != nil {
+					dir.prune(i)
+				}
+			case strings.HasPrefix(base, whiteoutMetaPrefix):
+				// Other special whiteout files are ignored
+			case strings.HasPrefix(base, whiteoutPrefix):
+				root.remove(path.Join(path.Dir(name), strings.TrimPrefix(base, whiteoutPrefix)))
+			default:
+				root.add(strings.TrimSuffix(name, "/"), i, hdr.Typeflag == tar.TypeDir)
+				if hdr.Typeflag == tar.TypeLink {
+					links[i] = append(links[i], hardLink{name, hdr.Linkname})
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The first remaining hard link to a file of the same layer that's gone takes its place
+	renames := make([]map[string]string, len(layers))
+	for i, layerLinks := range links {
+		renames[i] = make(map[string]string)
+		for _, link := range layerLinks {
+			if n := root.find(link.name); n == nil || n.layer != i {
+				continue
+			}
+
+			if n := root.find(link.target); n != nil && n.layer == i {
+				continue
+			}
+
+			if _, ok := renames[i][link.target]; !ok {
+				renames[i][link.target] = link.name
+			}
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	for i, l := range layers {
+		err := readLayer(l, func(hdr *tar.Header, r io.Reader) error {
+			name := strings.TrimSuffix(hdr.Name, "/")
+			if strings.HasPrefix(path.Base(name), whiteoutPrefix) {
+				return nil
+			}
+
+			if hdr.Typeflag == tar.TypeLink {
+				if newName, ok := renames[i][hdr.Linkname]; ok {
+					if newName == name {
+						return nil
+					}
+					hdr.Linkname = newName
+				}
+			}
+
+			if n := root.find(name); n == nil || n.layer != i {
+				newName, ok := renames[i][name]
+				if !ok {
+					return nil
+				}
+				hdr.Name = newName
+			}
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+
+			_, err := io.Copy(tw, r)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// readLayer calls fn for every entry of the layer with its normalized header and contents,
+// and verifies the digest of the layer if it's known
+func readLayer(l *layer, fn func(hdr *tar.Header, r io.Reader) error) error {
+	rc, err := l.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var in io.Reader = rc
+	var verifier digest.Verifier
+	if len(l.digest) > 0 {
+		verifier = l.digest.Verifier()
+		in = io.TeeReader(rc, verifier)
+	}
+
+	r, err := decompress(in)
+	if err != nil {
+		return fmt.Errorf("failed to read layer %s: %v", l.name, err)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read layer %s: %v", l.name, err)
+		}
+
+		if !normalizeHeader(hdr) {
+			continue
+		}
+
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+
+	if verifier != nil {
+		// Read the padding after the end of the tar stream for the digest
+		if _, err := io.Copy(ioutil.Discard, in); err != nil {
+			return err
+		}
+
+		if !verifier.Verified() {
+			return fmt.Errorf("layer %s doesn't match its digest %s", l.name, l.digest)
+		}
+	}
+
+	return nil
+}
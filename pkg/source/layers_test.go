@@ -0,0 +1,388 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	data     string
+	linkname string
+}
+
+func dirEntry(name string) tarEntry {
+	return tarEntry{name: name, typeflag: tar.TypeDir}
+}
+
+func fileEntry(name, data string) tarEntry {
+	return tarEntry{name: name, typeflag: tar.TypeReg, data: data}
+}
+
+func linkEntry(name, target string) tarEntry {
+	return tarEntry{name: name, typeflag: tar.TypeLink, linkname: target}
+}
+
+func whiteoutEntry(dir, name string) tarEntry {
+	return fileEntry(filepath.Join(dir, whiteoutPrefix+name), "")
+}
+
+// tarLayer returns the tar stream of the entries, gzip compressed if requested
+func tarLayer(t *testing.T, compress bool, entries ...tarEntry) []byte {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	gw := gzip.NewWriter(&buf)
+	if compress {
+		w = gw
+	}
+
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Mode:     0644,
+			Size:     int64(len(e.data)),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if compress {
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// testLayers returns the layers of the test image and the entries of its flattened file system
+func testLayers(t *testing.T, compress bool) ([][]byte, map[string]string) {
+	layers := [][]byte{
+		tarLayer(t, compress,
+			dirEntry("./etc/"),
+			fileEntry("./etc/hostname", "base"),
+			fileEntry("./etc/motd", "hello"),
+			dirEntry("./opt/"),
+			dirEntry("./opt/app/"),
+			fileEntry("./opt/app/old", "old"),
+			dirEntry("./var/"),
+			fileEntry("./var/data", "data"),
+			linkEntry("./var/data-link", "./var/data"),
+			fileEntry("./var/cache", "cache"),
+			linkEntry("./var/cache-link", "./var/cache"),
+		),
+		tarLayer(t, compress,
+			whiteoutEntry("etc", "motd"),
+			fileEntry("etc/hostname", "vm"),
+			dirEntry("opt/app/"),
+			fileEntry(filepath.Join("opt/app", whiteoutOpaqueDir), ""),
+			fileEntry("opt/app/new", "new"),
+			whiteoutEntry("var", "data"),
+			fileEntry("var/cache", "replaced"),
+		),
+	}
+
+	expected := map[string]string{
+		"etc/":           "",
+		"etc/hostname":   "vm",
+		"opt/":           "",
+		"opt/app/":       "",
+		"opt/app/new":    "new",
+		"var/":           "",
+		"var/data-link":  "data",
+		"var/cache":      "replaced",
+		"var/cache-link": "cache",
+	}
+
+	return layers, expected
+}
+
+func TestFlattenLayers(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		blobs, expected := testLayers(t, compress)
+
+		layers := make([]*layer, 0, len(blobs))
+		for i, blob := range blobs {
+			blob := blob
+			layers = append(layers, &layer{
+				name:   string(rune('a' + i)),
+				digest: digest.FromBytes(blob),
+				open: func() (io.ReadCloser, error) {
+					return ioutil.NopCloser(bytes.NewReader(blob)), nil
+				},
+			})
+		}
+
+		var buf bytes.Buffer
+		if err := flattenLayers(&buf, layers); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := readEntries(t, ioutil.NopCloser(&buf)); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("compress %t: expected entries %v, got %v", compress, expected, actual)
+		}
+
+		// Layers not matching their digest are rejected
+		layers[0].digest = layers[1].digest
+		if err := flattenLayers(ioutil.Discard, layers); err == nil {
+			t.Errorf("compress %t: expected an error for a layer not matching its digest", compress)
+		}
+	}
+}
+
+// writeBlob writes the blob to the OCI layout and returns its descriptor
+func writeBlob(t *testing.T, layoutDir, mediaType string, data []byte) ocispec.Descriptor {
+	d := digest.FromBytes(data)
+	if err := ioutil.WriteFile(filepath.Join(layoutDir, "blobs", "sha256", d.Encoded()), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+func writeJSONBlob(t *testing.T, layoutDir, mediaType string, v interface{}) ocispec.Descriptor {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeBlob(t, layoutDir, mediaType, data)
+}
+
+func writeJSONFile(t *testing.T, file string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOCILayoutSource(t *testing.T) {
+	layoutDir, err := ioutil.TempDir("", "ignite-oci-layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(layoutDir)
+
+	if err := os.MkdirAll(filepath.Join(layoutDir, "blobs", "sha256"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, expected := testLayers(t, true)
+	manifest := ocispec.Manifest{
+		Config: writeJSONBlob(t, layoutDir, ocispec.MediaTypeImageConfig, ocispec.Image{}),
+	}
+	manifest.SchemaVersion = 2
+
+	var size uint64
+	for _, blob := range blobs {
+		manifest.Layers = append(manifest.Layers, writeBlob(t, layoutDir, ocispec.MediaTypeImageLayerGzip, blob))
+		size += uint64(len(blob))
+	}
+
+	manifestDesc := writeJSONBlob(t, layoutDir, ocispec.MediaTypeImageManifest, manifest)
+	otherDesc := writeJSONBlob(t, layoutDir, ocispec.MediaTypeImageManifest, ocispec.Manifest{})
+
+	// The image is referred to by a nested index for multiple platforms
+	platformIndex := ocispec.Index{Manifests: []ocispec.Descriptor{manifestDesc}}
+	platformIndex.SchemaVersion = 2
+	indexDesc := writeJSONBlob(t, layoutDir, ocispec.MediaTypeImageIndex, platformIndex)
+	indexDesc.Annotations = map[string]string{ocispec.AnnotationRefName: "v1"}
+	otherDesc.Annotations = map[string]string{ocispec.AnnotationRefName: "v2"}
+
+	index := ocispec.Index{Manifests: []ocispec.Descriptor{otherDesc, indexDesc}}
+	index.SchemaVersion = 2
+	writeJSONFile(t, filepath.Join(layoutDir, "index.json"), index)
+
+	ref, err := meta.NewOCIImageRef("my-rootfs:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls := NewOCILayoutSource(layoutDir)
+	if _, err := ls.Parse(ref); err == nil {
+		t.Errorf("expected an error for a directory without the oci-layout file")
+	}
+
+	writeJSONFile(t, filepath.Join(layoutDir, ocispec.ImageLayoutFile), ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+
+	src, err := ls.Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if src.ID.String() != manifestDesc.Digest.String() {
+		t.Errorf("expected the content ID %s, got %s", manifestDesc.Digest, src.ID)
+	}
+	if src.Size.Bytes() != size {
+		t.Errorf("expected size %d, got %d", size, src.Size.Bytes())
+	}
+	if ls.Ref() != ref {
+		t.Errorf("expected ref %s, got %s", ref, ls.Ref())
+	}
+
+	rc, err := ls.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := readEntries(t, rc); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected entries %v, got %v", expected, actual)
+	}
+
+	unknown, err := meta.NewOCIImageRef("my-rootfs:v3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOCILayoutSource(layoutDir).Parse(unknown); err == nil {
+		t.Errorf("expected an error for an image not in the layout")
+	}
+
+	// Layer digests with unknown algorithms or leaving the blobs directory are rejected
+	for _, d := range []digest.Digest{"sha256:../../oci-layout", digest.Digest("unknown:" + manifestDesc.Digest.Encoded())} {
+		invalid := manifest
+		invalid.Layers = []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: d}}
+
+		index := ocispec.Index{Manifests: []ocispec.Descriptor{writeJSONBlob(t, layoutDir, ocispec.MediaTypeImageManifest, invalid)}}
+		index.SchemaVersion = 2
+		writeJSONFile(t, filepath.Join(layoutDir, "index.json"), index)
+
+		if _, err := NewOCILayoutSource(layoutDir).Parse(ref); err == nil {
+			t.Errorf("expected an error for the layer digest %q", d)
+		}
+	}
+}
+
+func TestDockerArchiveSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-docker-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	blobs, expected := testLayers(t, false)
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	configName := digest.FromBytes(config).Encoded() + ".json"
+
+	var size uint64
+	var layerNames []string
+	archive := []tarEntry{fileEntry(configName, string(config))}
+	for i, blob := range blobs {
+		name := string(rune('a'+i)) + "/layer.tar"
+		layerNames = append(layerNames, name)
+		archive = append(archive, dirEntry(string(rune('a'+i))+"/"), fileEntry(name, string(blob)))
+		size += uint64(len(blob))
+	}
+
+	// Newer versions of docker link the layers to blobs
+	archive = append(archive, tarEntry{name: "link/layer.tar", typeflag: tar.TypeSymlink, linkname: "../a/layer.tar"})
+
+	manifest, err := json.Marshal([]dockerArchiveManifest{
+		{Config: configName, RepoTags: []string{"my-rootfs:v1"}, Layers: layerNames},
+		{Config: configName, RepoTags: []string{"my-rootfs:v2"}, Layers: []string{"link/layer.tar"}},
+		{Config: "../" + configName, RepoTags: []string{"my-rootfs:v3"}, Layers: layerNames},
+		{Config: configName, RepoTags: []string{"my-rootfs:v4"}, Layers: []string{"/a/layer.tar"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive = append(archive, fileEntry("manifest.json", string(manifest)))
+
+	file := filepath.Join(dir, "image.tar")
+	if err := ioutil.WriteFile(file, tarLayer(t, false, archive...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := meta.NewOCIImageRef("docker.io/library/my-rootfs:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := NewDockerArchiveSource(file)
+	src, err := ds.Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The content ID is the image ID of docker
+	if src.ID.String() != digest.FromBytes(config).String() {
+		t.Errorf("expected the content ID %s, got %s", digest.FromBytes(config), src.ID)
+	}
+	if src.Size.Bytes() != size {
+		t.Errorf("expected size %d, got %d", size, src.Size.Bytes())
+	}
+
+	rc, err := ds.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := readEntries(t, rc); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected entries %v, got %v", expected, actual)
+	}
+
+	linked, err := meta.NewOCIImageRef("my-rootfs:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds = NewDockerArchiveSource(file)
+	if _, err := ds.Parse(linked); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err = ds.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := readEntries(t, rc); len(actual) != 11 || actual["etc/motd"] != "hello" {
+		t.Errorf("expected the entries of the first layer, got %v", actual)
+	}
+
+	// Names leaving the root of the archive are rejected
+	for _, tag := range []string{"my-rootfs:v3", "my-rootfs:v4"} {
+		invalid, err := meta.NewOCIImageRef(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewDockerArchiveSource(file).Parse(invalid); err == nil {
+			t.Errorf("expected an error for the invalid names of %s", tag)
+		}
+	}
+
+	// Compressed archives are rejected
+	compressed := filepath.Join(dir, "image.tar.gz")
+	if err := ioutil.WriteFile(compressed, tarLayer(t, true, archive...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDockerArchiveSource(compressed).Parse(ref); err == nil {
+		t.Errorf("expected an error for a compressed archive")
+	}
+}
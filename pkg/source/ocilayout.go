@@ -0,0 +1,192 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+)
+
+// OCILayoutSource imports an image from an OCI image layout directory, as written by e.g. buildah,
+// kaniko or skopeo. The layers are applied in order without a container runtime. The content ID is
+// the digest of the manifest of the image.
+type OCILayoutSource struct {
+	dir      string
+	imageRef meta.OCIImageRef
+	layers   []*layer
+}
+
+// Compile-time assert to verify interface compatibility
+var _ Source = &OCILayoutSource{}
+
+// NewOCILayoutSource returns a source reading the OCI image layout in the given directory
+func NewOCILayoutSource(dir string) *OCILayoutSource {
+	return &OCILayoutSource{dir: dir}
+}
+
+func (ls *OCILayoutSource) Ref() meta.OCIImageRef {
+	return ls.imageRef
+}
+
+// Parse selects the manifest of the image from the index of the layout. Layouts holding multiple images
+// need to name the image with the "org.opencontainers.image.ref.name" annotation, which is matched
+// against the tag of the given reference or the reference itself. Image indexes are resolved to the
+// manifest for the platform of the host.
+func (ls *OCILayoutSource) Parse(ociRef meta.OCIImageRef) (*api.OCIImageSource, error) {
+	var layout ocispec.ImageLayout
+	if err := readJSON(filepath.Join(ls.dir, ocispec.ImageLayoutFile), &layout); err != nil {
+		return nil, fmt.Errorf("%q is not an OCI image layout: %v", ls.dir, err)
+	}
+
+	if layout.Version != ocispec.ImageLayoutVersion {
+		return nil, fmt.Errorf("unsupported OCI image layout version %q", layout.Version)
+	}
+
+	var index ocispec.Index
+	if err := readJSON(filepath.Join(ls.dir, "index.json"), &index); err != nil {
+		return nil, err
+	}
+
+	desc, err := selectManifest(index.Manifests, ociRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select the image in %q: %v", ls.dir, err)
+	}
+
+	// Resolve nested indexes to the manifest for the platform of the host
+	for images.IsIndexType(desc.MediaType) {
+		if err := ls.readBlob(desc, &index); err != nil {
+			return nil, err
+		}
+
+		if desc, err = selectPlatform(index.Manifests); err != nil {
+			return nil, fmt.Errorf("failed to select the image in %q: %v", ls.dir, err)
+		}
+	}
+
+	if !images.IsManifestType(desc.MediaType) {
+		return nil, fmt.Errorf("unsupported media type %q of the manifest %s", desc.MediaType, desc.Digest)
+	}
+
+	var manifest ocispec.Manifest
+	if err := ls.readBlob(desc, &manifest); err != nil {
+		return nil, err
+	}
+
+	var size uint64
+	ls.layers = make([]*layer, 0, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		// The digest names the file of the blob, reject unknown algorithms and encodings leaving the blobs directory
+		if err := l.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid layer %q in the manifest %s: %v", l.Digest, desc.Digest, err)
+		}
+
+		blob := ls.blobPath(l.Digest)
+		ls.layers = append(ls.layers, &layer{
+			name:   l.Digest.String(),
+			digest: l.Digest,
+			open: func() (io.ReadCloser, error) {
+				return os.Open(blob)
+			},
+		})
+		size += uint64(l.Size)
+	}
+
+	id, err := meta.ParseOCIContentID(desc.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+
+	ls.imageRef = ociRef
+
+	return &api.OCIImageSource{
+		ID:   id,
+		Size: meta.NewSizeFromBytes(size),
+	}, nil
+}
+
+// Reader returns the tar stream of the layers of the image applied in order
+func (ls *OCILayoutSource) Reader() (io.ReadCloser, error) {
+	return pipeReader(func(w io.Writer) error {
+		return flattenLayers(w, ls.layers)
+	}), nil
+}
+
+// Cleanup is a no-op, the layout is read as is
+func (ls *OCILayoutSource) Cleanup() error {
+	return nil
+}
+
+func (ls *OCILayoutSource) blobPath(d digest.Digest) string {
+	return filepath.Join(ls.dir, "blobs", d.Algorithm().String(), d.Encoded())
+}
+
+// readBlob decodes the JSON blob of the descriptor after verifying its digest
+func (ls *OCILayoutSource) readBlob(desc ocispec.Descriptor, v interface{}) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(ls.blobPath(desc.Digest))
+	if err != nil {
+		return err
+	}
+
+	if desc.Digest.Algorithm().FromBytes(data) != desc.Digest {
+		return fmt.Errorf("blob %s doesn't match its digest", desc.Digest)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// selectManifest returns the only manifest, or the one annotated with the tag of the reference or the reference
+func selectManifest(manifests []ocispec.Descriptor, ociRef meta.OCIImageRef) (ocispec.Descriptor, error) {
+	if len(manifests) == 1 {
+		return manifests[0], nil
+	}
+
+	var names []string
+	for _, desc := range manifests {
+		name := desc.Annotations[ocispec.AnnotationRefName]
+		if name == ociRef.Ref().Tag() || name == ociRef.String() || name == ociRef.Normalized() {
+			return desc, nil
+		}
+
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return ocispec.Descriptor{}, fmt.Errorf("found %d images, none of them named %q, available names: [%s]",
+		len(manifests), ociRef.Ref().Tag(), strings.Join(names, ", "))
+}
+
+// selectPlatform returns the manifest of the index for the platform of the host
+func selectPlatform(manifests []ocispec.Descriptor) (ocispec.Descriptor, error) {
+	matcher := platforms.Default()
+	for _, desc := range manifests {
+		if desc.Platform == nil || matcher.Match(*desc.Platform) {
+			return desc, nil
+		}
+	}
+
+	return ocispec.Descriptor{}, fmt.Errorf("no image for platform %s", platforms.DefaultString())
+}
+
+func readJSON(file string, v interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,146 @@
+package source
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	api "github.com/weaveworks/ignite/pkg/apis/ignite"
+	meta "github.com/weaveworks/ignite/pkg/apis/meta/v1alpha1"
+	"github.com/weaveworks/ignite/pkg/constants"
+)
+
+// TarSource imports a tarball of a root filesystem, optionally gzip compressed, from a file or from stdin.
+// The content ID is the digest of the tarball as given. Stdin is buffered in a temporary file in the data
+// directory by Parse, as it can only be read once. The buffer is removed by Cleanup.
+type TarSource struct {
+	path     string
+	tempFile string
+	imageRef meta.OCIImageRef
+}
+
+// Compile-time assert to verify interface compatibility
+var _ Source = &TarSource{}
+
+// NewTarSource returns a source reading the tarball at the given path, or stdin if the path is "-"
+func NewTarSource(path string) *TarSource {
+	return &TarSource{path: path}
+}
+
+func (ts *TarSource) Ref() meta.OCIImageRef {
+	return ts.imageRef
+}
+
+// Parse computes the digest and size of the tarball, the reference names the imported image or kernel
+func (ts *TarSource) Parse(ociRef meta.OCIImageRef) (_ *api.OCIImageSource, err error) {
+	var in io.Reader
+	digester := digest.Canonical.Digester()
+	counter := &countingWriter{}
+	out := io.MultiWriter(digester.Hash(), counter)
+
+	if ts.path == "-" && len(ts.tempFile) == 0 {
+		// Buffer stdin in the data directory, images can be larger than the space available for /tmp
+		if err := os.MkdirAll(constants.DATA_DIR, constants.DATA_DIR_PERM); err != nil {
+			return nil, err
+		}
+
+		f, err := ioutil.TempFile(constants.DATA_DIR, "ignite-tar-")
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		// Remove the partial buffer if stdin can't be read
+		ts.tempFile = f.Name()
+		defer func() {
+			if err != nil {
+				ts.Cleanup()
+			}
+		}()
+
+		in, out = os.Stdin, io.MultiWriter(out, f)
+	} else {
+		f, err := os.Open(ts.file())
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		return nil, err
+	}
+
+	id, err := meta.ParseOCIContentID(digester.Digest().String())
+	if err != nil {
+		return nil, err
+	}
+
+	ts.imageRef = ociRef
+
+	return &api.OCIImageSource{
+		ID:   id,
+		Size: meta.NewSizeFromBytes(counter.n),
+	}, nil
+}
+
+// Reader returns the tar stream of the tarball, decompressed and with its entries named relative to the root
+func (ts *TarSource) Reader() (io.ReadCloser, error) {
+	f, err := os.Open(ts.file())
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarReader{
+		ReadCloser: pipeReader(func(w io.Writer) error {
+			r, err := decompress(f)
+			if err != nil {
+				return err
+			}
+
+			return normalizeTar(w, r)
+		}),
+		file: f,
+	}, nil
+}
+
+// Cleanup removes the buffered copy of stdin
+func (ts *TarSource) Cleanup() error {
+	if len(ts.tempFile) == 0 {
+		return nil
+	}
+
+	if err := os.Remove(ts.tempFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	ts.tempFile = ""
+	return nil
+}
+
+// file returns the path of the tarball, or of the buffered copy of stdin
+func (ts *TarSource) file() string {
+	if len(ts.tempFile) > 0 {
+		return ts.tempFile
+	}
+
+	return ts.path
+}
+
+// tarReader closes the tarball together with the stream read from it
+type tarReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *tarReader) Close() error {
+	err := r.ReadCloser.Close()
+	if fileErr := r.file.Close(); err == nil {
+		err = fileErr
+	}
+
+	return err
+}
@@ -7,15 +7,16 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"syscall"
 
 	"github.com/otiai10/copy"
 	log "github.com/sirupsen/logrus"
 	"github.com/weaveworks/ignite/pkg/constants"
 )
 
-// Creates the /var/lib/firecracker/{vm,image,kernel} directories
+// Creates the /var/lib/firecracker/{vm,image,kernel,network} directories
 func CreateDirectories() error {
-	for _, dir := range []string{constants.VM_DIR, constants.IMAGE_DIR, constants.KERNEL_DIR, constants.MANIFEST_DIR} {
+	for _, dir := range []string{constants.VM_DIR, constants.IMAGE_DIR, constants.KERNEL_DIR, constants.NETWORK_DIR, constants.MANIFEST_DIR} {
 		if err := os.MkdirAll(dir, constants.DATA_DIR_PERM); err != nil {
 			return fmt.Errorf("failed to create directory %q: %v", dir, err)
 		}
@@ -80,6 +81,25 @@ func IsDeviceFile(filename string) (err error) {
 	return
 }
 
+// FileSize returns the apparent size of a file and the space allocated for it on disk.
+// The allocated space of sparse files, like the overlays of VMs, is smaller than their size.
+func FileSize(filename string) (size, allocated uint64, err error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return
+	}
+
+	size = uint64(info.Size())
+	// The blocks of the stat are always 512 bytes, regardless of the block size of the filesystem
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		allocated = uint64(st.Blocks) * 512
+	} else {
+		allocated = size
+	}
+
+	return
+}
+
 // CopyFile copies both files and directories
 func CopyFile(src string, dst string) error {
 	return copy.Copy(src, dst)
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// copyBlockSize is the granularity zeroes are skipped with by CopySparse
+const copyBlockSize = 64 * 1024
+
+// CopySparse copies the contents of the file or device at src to the existing thin device or sparse file at dst,
+// skipping the blocks that only contain zeroes. Unmapped blocks of thin devices and the holes of sparse files
+// read as zeroes, so they don't need to be written.
+func CopySparse(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zeroes := make([]byte, copyBlockSize)
+	buf := make([]byte, copyBlockSize)
+
+	for offset := int64(0); ; {
+		n, err := io.ReadFull(in, buf)
+		if n > 0 && !bytes.Equal(buf[:n], zeroes[:n]) {
+			if _, err := out.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+		}
+
+		offset += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return out.Sync()
+}
+
+// DeviceSize returns the size of the file or block device in bytes
+func DeviceSize(filename string) (int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return f.Seek(0, io.SeekEnd)
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopySparse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignite-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A block of data, two blocks of zeroes and a partial block of data
+	data := bytes.Repeat([]byte{1}, copyBlockSize)
+	contents := append(append(data, make([]byte, 2*copyBlockSize)...), data[:100]...)
+
+	src := filepath.Join(dir, "src")
+	if err := ioutil.WriteFile(src, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Like a thin device, the destination reads zeroes where nothing was written
+	dst := filepath.Join(dir, "dst")
+	if err := ioutil.WriteFile(dst, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(dst, int64(len(contents))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopySparse(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, contents) {
+		t.Errorf("the contents of the copy differ")
+	}
+
+	size, err := DeviceSize(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(contents)) {
+		t.Errorf("expected size %d, got %d", len(contents), size)
+	}
+}